@@ -0,0 +1,283 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/accounts/keystore"
+	"github.com/spf13/cobra"
+)
+
+var accountFlags struct {
+	store           string
+	lightKDF        bool
+	accountType     uint32
+	password        string
+	passwordFile    string
+	newPassword     string
+	newPasswordFile string
+	out             string
+	private         bool
+}
+
+// accountCmd represents the account command
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage accounts in the node keystore",
+	Long: `Manage accounts in the node keystore. The commands operate on the
+keystore and database of the node directly, so the node must be stopped.`,
+}
+
+var accountNewCmd = &cobra.Command{
+	Use:          "new",
+	Short:        "Create a new account",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passphrase, err := readPassphrase("Passphrase: ", accountFlags.password, accountFlags.passwordFile)
+		if err != nil {
+			return err
+		}
+		ks, chainDb, err := openKeyStore(accountFlags.store, accountFlags.lightKDF)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		a, err := ks.NewAccount(passphrase, accountFlags.accountType)
+		if err != nil {
+			return err
+		}
+		fmt.Println(a.Address.String())
+		return nil
+	},
+}
+
+var accountListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the accounts in the keystore",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ks, chainDb, err := openKeyStore(accountFlags.store, accountFlags.lightKDF)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		addrs, err := ks.Accounts()
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			fmt.Println(addr)
+		}
+		return nil
+	},
+}
+
+var accountImportCmd = &cobra.Command{
+	Use:          "import <hex private key>",
+	Short:        "Import a raw hex private key as a new account",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("the hex private key is required")
+		}
+		priv, err := crypto.HexToECDSA(trimHexPrefix(args[0]))
+		if err != nil {
+			return err
+		}
+		passphrase, err := readPassphrase("Passphrase: ", accountFlags.password, accountFlags.passwordFile)
+		if err != nil {
+			return err
+		}
+		ks, chainDb, err := openKeyStore(accountFlags.store, accountFlags.lightKDF)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		a, err := ks.ImportECDSA(priv, passphrase, accountFlags.accountType)
+		if err != nil {
+			return err
+		}
+		fmt.Println(a.Address.String())
+		return nil
+	},
+}
+
+var accountExportCmd = &cobra.Command{
+	Use:          "export <address>",
+	Short:        "Export an account to an scrypt encrypted keyfile",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ks, chainDb, a, err := openAccount(args)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		passphrase, err := readPassphrase("Passphrase: ", accountFlags.password, accountFlags.passwordFile)
+		if err != nil {
+			return err
+		}
+		newPassphrase, err := readPassphrase("Keyfile passphrase: ", accountFlags.newPassword, accountFlags.newPasswordFile)
+		if err != nil {
+			return err
+		}
+		n, p := keystore.StandardScryptN, keystore.StandardScryptP
+		if accountFlags.lightKDF {
+			n, p = keystore.LightScryptN, keystore.LightScryptP
+		}
+		keyJSON, err := ks.Export(*a, passphrase, newPassphrase, n, p)
+		if err != nil {
+			return err
+		}
+		if accountFlags.out == "" {
+			fmt.Println(string(keyJSON))
+			return nil
+		}
+		return ioutil.WriteFile(accountFlags.out, keyJSON, 0600)
+	},
+}
+
+var accountUpdateCmd = &cobra.Command{
+	Use:          "update <address>",
+	Short:        "Change the passphrase of an account",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ks, chainDb, a, err := openAccount(args)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		passphrase, err := readPassphrase("Passphrase: ", accountFlags.password, accountFlags.passwordFile)
+		if err != nil {
+			return err
+		}
+		newPassphrase, err := readPassphrase("New passphrase: ", accountFlags.newPassword, accountFlags.newPasswordFile)
+		if err != nil {
+			return err
+		}
+		return ks.Update(*a, passphrase, newPassphrase)
+	},
+}
+
+// keyInfo is the output of account inspect
+type keyInfo struct {
+	Address    string `json:"address"`
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey,omitempty"`
+	Encrypted  bool   `json:"encrypted"`
+}
+
+var accountInspectCmd = &cobra.Command{
+	Use:          "inspect <keyfile>",
+	Short:        "Print the address and public key of a keyfile",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("the keyfile is required")
+		}
+		keyJSON, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		var (
+			key       *keystore.Key
+			encrypted bool
+		)
+		var probe struct {
+			Crypto json.RawMessage `json:"crypto"`
+		}
+		if err := json.Unmarshal(keyJSON, &probe); err != nil {
+			return err
+		}
+		if encrypted = len(probe.Crypto) > 0; encrypted {
+			passphrase, err := readPassphrase("Passphrase: ", accountFlags.password, accountFlags.passwordFile)
+			if err != nil {
+				return err
+			}
+			if key, err = keystore.DecryptKey(keyJSON, passphrase); err != nil {
+				return err
+			}
+		} else {
+			key = new(keystore.Key)
+			if err := json.Unmarshal(keyJSON, key); err != nil {
+				return err
+			}
+		}
+
+		info := keyInfo{
+			Address:   key.Address.String(),
+			PublicKey: hex.EncodeToString(key.PrivateKey.Public().Bytes()),
+			Encrypted: encrypted,
+		}
+		if accountFlags.private {
+			info.PrivateKey = hex.EncodeToString(key.PrivateKey.SecretBytes())
+		}
+		return printJSON(info)
+	},
+}
+
+// openAccount opens the keystore and finds the account of args[0]
+func openAccount(args []string) (*keystore.KeyStore, *db.BlockchainDB, *accounts.Account, error) {
+	if len(args) != 1 {
+		return nil, nil, nil, errors.New("the account address is required")
+	}
+	address := accounts.HexToAddress(args[0])
+	ks, chainDb, err := openKeyStore(accountFlags.store, accountFlags.lightKDF)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !ks.HasAddress(address) {
+		chainDb.Close()
+		return nil, nil, nil, fmt.Errorf("account %s not found", address)
+	}
+	return ks, chainDb, ks.Find(address), nil
+}
+
+func init() {
+	accountCmd.PersistentFlags().StringVar(&accountFlags.store, "keystore", storePlain, "keystore type, plain or scrypt")
+	accountCmd.PersistentFlags().BoolVar(&accountFlags.lightKDF, "lightkdf", false, "use less memory and CPU for scrypt")
+	accountCmd.PersistentFlags().StringVar(&accountFlags.password, "password", "", "passphrase of the account")
+	accountCmd.PersistentFlags().StringVar(&accountFlags.passwordFile, "password-file", "", "file holding the passphrase of the account")
+
+	for _, c := range []*cobra.Command{accountNewCmd, accountImportCmd} {
+		c.Flags().Uint32Var(&accountFlags.accountType, "type", accounts.AccountTypeCommon, "account type")
+	}
+	for _, c := range []*cobra.Command{accountExportCmd, accountUpdateCmd} {
+		c.Flags().StringVar(&accountFlags.newPassword, "new-password", "", "new passphrase")
+		c.Flags().StringVar(&accountFlags.newPasswordFile, "new-password-file", "", "file holding the new passphrase")
+	}
+	accountExportCmd.Flags().StringVar(&accountFlags.out, "out", "", "keyfile to write, stdout if empty")
+	accountInspectCmd.Flags().BoolVar(&accountFlags.private, "private", false, "also print the private key")
+
+	accountCmd.AddCommand(accountNewCmd, accountListCmd, accountImportCmd, accountExportCmd, accountUpdateCmd, accountInspectCmd)
+	RootCmd.AddCommand(accountCmd)
+}
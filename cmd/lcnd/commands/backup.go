@@ -0,0 +1,231 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/rpc"
+	"github.com/spf13/cobra"
+)
+
+var backupFlags struct {
+	rpcURL string
+	token  string
+	keep   int
+	maxAge time.Duration
+}
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the database of the running node",
+	Long: `Create a backup of the database of the running node through its admin
+JSON-RPC, in backup.dir of its config. The backups beyond backup.keep and
+backup.maxAge are removed afterwards.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("unexpected args %v", args)
+		}
+		var reply rpc.BackupReply
+		if err := rpc.NewClient(backupFlags.rpcURL, backupFlags.token).Call("Admin.Backup", "", &reply); err != nil {
+			return err
+		}
+		return printJSON(reply)
+	},
+}
+
+var backupListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the backups in backup.dir, oldest first",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		backups, err := db.ListBackups(cfg.BackupDir)
+		if err != nil {
+			return err
+		}
+		return printJSON(backups)
+	},
+}
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the backups beyond the retention policy",
+	Long: `Remove the backups in backup.dir beyond the retention policy, given by
+backup.keep and backup.maxAge unless overridden by flags. The newest
+backup is always kept.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		retention := cfg.BackupRetention
+		if cmd.Flags().Changed("keep") {
+			retention.Keep = backupFlags.keep
+		}
+		if cmd.Flags().Changed("max-age") {
+			retention.MaxAge = backupFlags.maxAge
+		}
+		removed, err := db.PruneBackups(cfg.BackupDir, retention)
+		for _, name := range removed {
+			fmt.Println("removed", name)
+		}
+		return err
+	},
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup>",
+	Short: "Replace the database with a backup",
+	Long: `Restore the backup, given by its name in backup.dir or its directory,
+next to the database and verify it like lcnd verify. Its genesis block
+must match the one of the database. Only then the database is replaced,
+the previous one and the consensus wal are kept aside. The node must be
+stopped.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("restore requires the backup")
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		dir := args[0]
+		if _, err := os.Stat(dir); err != nil {
+			dir = filepath.Join(cfg.BackupDir, args[0])
+		}
+
+		dbDir := filepath.Clean(cfg.DbConfig.DbPath)
+		staging := dbDir + ".restore"
+		if err := os.RemoveAll(staging); err != nil {
+			return err
+		}
+		if err := db.RestoreBackup(dir, staging); err != nil {
+			return err
+		}
+		height, err := verifyRestored(cfg.DbConfig, staging)
+		if err != nil {
+			os.RemoveAll(staging)
+			return err
+		}
+
+		suffix := ".old-" + time.Now().UTC().Format("20060102T150405Z")
+		old := dbDir + suffix
+		if err := os.Rename(dbDir, old); os.IsNotExist(err) {
+			old = ""
+		} else if err != nil {
+			return err
+		}
+		if err := os.Rename(staging, dbDir); err != nil {
+			if old == "" {
+				return err
+			}
+			if rerr := os.Rename(old, dbDir); rerr != nil {
+				return fmt.Errorf("%v, the previous database is left in %s: %v", err, old, rerr)
+			}
+			return err
+		}
+		// the consensus wal records seqNos executed after the backup
+		if err := os.Rename(cfg.ConsensusWAL, cfg.ConsensusWAL+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if old == "" {
+			fmt.Printf("restored %s at height %d\n", dir, height)
+		} else {
+			fmt.Printf("restored %s at height %d, the previous database is kept in %s\n", dir, height, old)
+		}
+		return nil
+	},
+}
+
+// verifyRestored checks the chain restored in dir and that its genesis is
+// the one of the database, returning its height
+func verifyRestored(dbConfig *db.Config, dir string) (uint32, error) {
+	restoredCfg := *dbConfig
+	restoredCfg.DbPath = dir
+	restored := db.Open(&restoredCfg)
+	defer restored.Close()
+	if value, err := restored.Get("index", []byte(heightKey)); err != nil || len(value) == 0 {
+		return 0, fmt.Errorf("backup has no chain, error %v", err)
+	}
+	if err := restored.CheckSchema(ledger.SchemaVersion()); err != nil {
+		return 0, fmt.Errorf("backup %v", err)
+	}
+
+	scratchDir, err := ioutil.TempDir("", "lcnd-restore")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(scratchDir)
+	scratchCfg := *dbConfig
+	scratchCfg.DbPath = scratchDir
+	scratch := db.Open(&scratchCfg)
+	defer scratch.Close()
+
+	restoredLedger := ledger.Open(restored)
+	report, err := restoredLedger.Verify(scratch, genesisReplicas())
+	if err != nil {
+		return 0, err
+	}
+	if !report.OK() {
+		printJSON(report)
+		return 0, fmt.Errorf("backup verified %d blocks, found %d problems and %d state differences", report.Height, len(report.Issues), len(report.Diffs))
+	}
+
+	// db.Open creates a missing database
+	if _, err := os.Stat(dbConfig.DbPath); os.IsNotExist(err) {
+		return report.Height, nil
+	} else if err != nil {
+		return 0, err
+	}
+	current := db.Open(dbConfig)
+	defer current.Close()
+	if value, err := current.Get("index", []byte(heightKey)); err == nil && len(value) > 0 {
+		genesis := ledger.Open(current).GetGenesisBlock().Hash()
+		if restoredGenesis := restoredLedger.GetGenesisBlock().Hash(); !restoredGenesis.Equal(genesis) {
+			return 0, fmt.Errorf("backup genesis %s, the database has %s", restoredGenesis, genesis)
+		}
+	}
+	return report.Height, nil
+}
+
+func init() {
+	backupCmd.Flags().StringVar(&backupFlags.rpcURL, "rpc", "http://127.0.0.1:8881", "JSON-RPC url of the node")
+	backupCmd.Flags().StringVar(&backupFlags.token, "token", "", "JSON-RPC bearer token")
+	backupPruneCmd.Flags().IntVar(&backupFlags.keep, "keep", 0, "number of newest backups kept, 0 keeps all")
+	backupPruneCmd.Flags().DurationVar(&backupFlags.maxAge, "max-age", 0, "remove backups older than this, 0 keeps all")
+
+	backupCmd.AddCommand(backupListCmd, backupPruneCmd)
+	RootCmd.AddCommand(backupCmd, restoreCmd)
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bocheninc/L0/config"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the node config file",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check [config file]",
+	Short: "Check a config file for unknown keys and invalid values",
+	Long: `Check a config file for unknown keys and invalid values. The file given
+by --config is checked if no file is given.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := cfgFile
+		if len(args) > 0 {
+			file = args[0]
+		}
+		if file == "" {
+			return errors.New("config file is required")
+		}
+		errs := config.Check(file)
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s has %d problems", file, len(errs))
+		}
+		fmt.Printf("%s is valid\n", file)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	RootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,519 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/ledger/contract"
+	"github.com/bocheninc/L0/core/ledger/state"
+	"github.com/bocheninc/L0/core/types"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// keys of the index and storage column families, see block_storage and merge
+	heightKey = "blockLastHeight"
+	seqNoKey  = "consensusLastSeqNo"
+	timeKey   = "timeKey"
+	backupKey = "backupKey"
+	// keys of the default column family, see db.Migrate
+	schemaVersionKey     = "schemaVersion"
+	migrationProgressKey = "schemaMigration"
+)
+
+var dbFlags struct {
+	prefix string
+	limit  int
+	raw    bool
+	from   uint32
+	to     uint32
+	dryRun bool
+}
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Inspect, export and import the node database",
+	Long: `Inspect, export and import the node database. The commands open the
+database of the node directly, so the node must be stopped.`,
+}
+
+var dbBlockCmd = &cobra.Command{
+	Use:          "block <height|hash>",
+	Short:        "Print a block as json",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("block height or hash is required")
+		}
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		l := ledger.NewLedger(chainDb)
+		var block *types.Block
+		if s := trimHexPrefix(args[0]); len(s) == 2*crypto.HashSize {
+			block, err = l.GetBlockByHash(crypto.HexToHash(s).Bytes())
+		} else {
+			var height uint64
+			if height, err = strconv.ParseUint(args[0], 10, 32); err != nil {
+				return fmt.Errorf("invalid block height or hash %q", args[0])
+			}
+			block, err = l.GetBlockByNumber(uint32(height))
+		}
+		if err != nil {
+			return err
+		}
+		return printJSON(newBlockView(block))
+	},
+}
+
+var dbKeysCmd = &cobra.Command{
+	Use:   "keys <column family>",
+	Short: "Iterate the keys of a column family",
+	Long: `Iterate the keys of a column family and print one json object per key.
+Balances, contract states, merge storage and indexes are decoded unless
+--raw is given.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("column family is required")
+		}
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		cf := args[0]
+		if !hasColumnFamily(chainDb, cf) {
+			return fmt.Errorf("unknown column family %s, want one of %s", cf, strings.Join(chainDb.ColumnFamilies(), ", "))
+		}
+		enc := json.NewEncoder(os.Stdout)
+		cnt := 0
+		return chainDb.Iterate(cf, []byte(dbFlags.prefix), func(key, value []byte) bool {
+			v := &keyView{Key: hex.EncodeToString(key), Value: hex.EncodeToString(value)}
+			if !dbFlags.raw {
+				v.Decoded = decodeKey(cf, key, value)
+			}
+			if err = enc.Encode(v); err != nil {
+				return false
+			}
+			cnt++
+			return dbFlags.limit <= 0 || cnt < dbFlags.limit
+		})
+	},
+}
+
+var dbStatsCmd = &cobra.Command{
+	Use:          "stats",
+	Short:        "Print chain and column family statistics",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		l := ledger.NewLedger(chainDb)
+		height, err := l.Height()
+		if err != nil {
+			return err
+		}
+		schemaVersion, err := chainDb.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		stats := &chainStats{
+			SchemaVersion: schemaVersion,
+			Height:        height,
+			Transactions:  make(map[string]int),
+			Keys:          make(map[string]int),
+		}
+		for h := uint32(0); h <= height; h++ {
+			block, err := l.GetBlockByNumber(h)
+			if err != nil {
+				return fmt.Errorf("block %d: %v", h, err)
+			}
+			if h == 0 {
+				stats.Genesis = block.Hash()
+			}
+			if h == height {
+				stats.LastBlock = block.Hash()
+				stats.LastBlockTime = time.Unix(int64(block.Header.TimeStamp), 0).UTC().Format(time.RFC3339)
+			}
+			for _, tx := range block.Transactions {
+				stats.Transactions[types.TxTypeName(tx.GetType())]++
+				stats.TotalTransactions++
+			}
+		}
+		for _, cf := range chainDb.ColumnFamilies() {
+			cnt := 0
+			if err := chainDb.Iterate(cf, nil, func(key, value []byte) bool {
+				cnt++
+				return true
+			}); err != nil {
+				return err
+			}
+			stats.Keys[cf] = cnt
+		}
+		return printJSON(stats)
+	},
+}
+
+var dbExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export the chain to a jsonl file",
+	Long: `Export the blocks of the chain to a jsonl file, one block per line with
+its height, hash and hex serialized form. "-" writes to stdout.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("export file is required")
+		}
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		l := ledger.NewLedger(chainDb)
+		height, err := l.Height()
+		if err != nil {
+			return err
+		}
+		to := height
+		if dbFlags.to > 0 && dbFlags.to < height {
+			to = dbFlags.to
+		}
+
+		var w io.Writer = os.Stdout
+		if args[0] != "-" {
+			f, err := os.OpenFile(args[0], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		for h := dbFlags.from; h <= to; h++ {
+			block, err := l.GetBlockByNumber(h)
+			if err != nil {
+				return fmt.Errorf("block %d: %v", h, err)
+			}
+			if err := enc.Encode(&exportedBlock{Height: h, Hash: block.Hash(), Block: hex.EncodeToString(block.Serialize())}); err != nil {
+				return err
+			}
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if args[0] != "-" {
+			fmt.Printf("exported blocks %d to %d\n", dbFlags.from, to)
+		}
+		return nil
+	},
+}
+
+var dbImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a chain exported by db export",
+	Long: `Import a chain exported by db export. Blocks already in the database are
+checked to be the same, new blocks are executed and appended in order.
+"-" reads from stdin.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("import file is required")
+		}
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		imported, skipped, err := importChain(ledger.NewLedger(chainDb), bufio.NewReader(r))
+		fmt.Printf("imported %d blocks, %d already present\n", imported, skipped)
+		return err
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate the database to the schema of this binary",
+	Long: `Migrate the database to the schema version of this binary. The node runs
+the pending migrations at startup as well, an interrupted migration resumes
+where it stopped. --dry-run reports the migrations without writing.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		from, err := chainDb.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		results, err := ledger.Migrate(chainDb, dbFlags.dryRun)
+		if err != nil {
+			return err
+		}
+		if results == nil {
+			results = []db.MigrationResult{}
+		}
+		return printJSON(map[string]interface{}{
+			"from":       from,
+			"to":         ledger.SchemaVersion(),
+			"dryRun":     dbFlags.dryRun,
+			"migrations": results,
+		})
+	},
+}
+
+// exportedBlock is a line of an exported chain
+type exportedBlock struct {
+	Height uint32      `json:"height"`
+	Hash   crypto.Hash `json:"hash"`
+	Block  string      `json:"block"`
+}
+
+func importChain(l *ledger.Ledger, r *bufio.Reader) (imported, skipped int, err error) {
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF && len(b) == 0 {
+			return imported, skipped, nil
+		} else if err != nil && err != io.EOF {
+			return imported, skipped, err
+		}
+
+		eb := new(exportedBlock)
+		if err := json.Unmarshal(b, eb); err != nil {
+			return imported, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+		data, err := hex.DecodeString(eb.Block)
+		if err != nil {
+			return imported, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+		block := new(types.Block)
+		if err := block.Deserialize(data); err != nil {
+			return imported, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+		if block.Height() != eb.Height || !block.Hash().Equal(eb.Hash) {
+			return imported, skipped, fmt.Errorf("line %d: block %d does not match its height or hash", line, eb.Height)
+		}
+
+		height, err := l.Height()
+		if err != nil {
+			return imported, skipped, err
+		}
+		if eb.Height <= height {
+			local, err := l.GetBlockByNumber(eb.Height)
+			if err != nil {
+				return imported, skipped, err
+			}
+			if !local.Hash().Equal(eb.Hash) {
+				return imported, skipped, fmt.Errorf("block %d is %s in the database, %s in the import", eb.Height, local.Hash(), eb.Hash)
+			}
+			skipped++
+			continue
+		}
+		if eb.Height != height+1 {
+			return imported, skipped, fmt.Errorf("block %d does not follow the database height %d", eb.Height, height)
+		}
+		last, err := l.GetLastBlockHash()
+		if err != nil {
+			return imported, skipped, err
+		}
+		if !block.PreviousHash().Equal(last) {
+			return imported, skipped, fmt.Errorf("block %d does not link to the last block %s", eb.Height, last)
+		}
+
+		if err := l.ImportBlock(block); err != nil {
+			return imported, skipped, fmt.Errorf("block %d: %v", eb.Height, err)
+		}
+		imported++
+	}
+}
+
+// blockView is the readable form of a block
+type blockView struct {
+	Hash         crypto.Hash        `json:"hash"`
+	Header       *types.BlockHeader `json:"header"`
+	Time         string             `json:"time"`
+	Transactions []*txView          `json:"transactions"`
+}
+
+func newBlockView(block *types.Block) *blockView {
+	v := &blockView{
+		Hash:         block.Hash(),
+		Header:       block.Header,
+		Time:         time.Unix(int64(block.Header.TimeStamp), 0).UTC().Format(time.RFC3339),
+		Transactions: make([]*txView, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		v.Transactions = append(v.Transactions, newTxView(tx))
+	}
+	return v
+}
+
+type chainStats struct {
+	SchemaVersion     uint32         `json:"schemaVersion"`
+	Height            uint32         `json:"height"`
+	Genesis           crypto.Hash    `json:"genesis"`
+	LastBlock         crypto.Hash    `json:"lastBlock"`
+	LastBlockTime     string         `json:"lastBlockTime"`
+	TotalTransactions int            `json:"totalTransactions"`
+	Transactions      map[string]int `json:"transactions"`
+	Keys              map[string]int `json:"keys"`
+}
+
+type keyView struct {
+	Key     string      `json:"key"`
+	Value   string      `json:"value"`
+	Decoded interface{} `json:"decoded,omitempty"`
+}
+
+func hasColumnFamily(chainDb *db.BlockchainDB, cf string) bool {
+	for _, name := range chainDb.ColumnFamilies() {
+		if name == cf {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeKey decodes the well known keys of a column family, nil if unknown
+func decodeKey(cf string, key, value []byte) interface{} {
+	switch cf {
+	case "balance":
+		if strings.HasPrefix(string(key), "bl_") && len(key) == 3+accounts.AddressLength {
+			balance := new(state.Balance)
+			if err := utils.Deserialize(value, balance); err != nil {
+				return nil
+			}
+			return map[string]interface{}{
+				"address": accounts.NewAddress(key[3:]),
+				"amount":  balance.Amount,
+				"nonce":   balance.Nonce,
+			}
+		}
+	case "scontract":
+		if strings.Contains(string(key), "|") {
+			addr, k := contract.DeSmartContractKey(string(key))
+			return map[string]interface{}{"contract": addr, "key": k, "value": string(value)}
+		}
+	case "storage":
+		switch {
+		case string(key) == timeKey:
+			return map[string]interface{}{"times": utils.BytesToUint32Arrary(value)}
+		case string(key) == backupKey, len(key) == 4:
+			txs := make(types.Transactions, 0)
+			if err := utils.Deserialize(value, &txs); err != nil {
+				return nil
+			}
+			hashes := make([]crypto.Hash, 0, len(txs))
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash())
+			}
+			if string(key) == backupKey {
+				return map[string]interface{}{"unackedMergedTxs": hashes}
+			}
+			return map[string]interface{}{"time": utils.BytesToUint32(key), "txs": hashes}
+		case len(key) == crypto.HashSize:
+			hashes := make([]crypto.Hash, 0)
+			if err := utils.Deserialize(value, &hashes); err != nil {
+				return nil
+			}
+			return map[string]interface{}{"mergedTx": crypto.NewHash(key), "txs": hashes}
+		}
+	case "index":
+		switch {
+		case string(key) == heightKey:
+			return map[string]interface{}{"height": utils.BytesToUint32(value)}
+		case string(key) == seqNoKey && len(value) == 8:
+			return map[string]interface{}{"seqNo": binary.BigEndian.Uint64(value)}
+		case len(key) == 4:
+			return map[string]interface{}{"height": utils.BytesToUint32(key), "block": crypto.NewHash(value)}
+		case len(key) == crypto.HashSize:
+			numbers, err := utils.DecodeUint32(value, 2)
+			if err != nil {
+				return nil
+			}
+			return map[string]interface{}{"tx": crypto.NewHash(key), "height": numbers[0], "index": numbers[1]}
+		}
+	case "block":
+		block := new(types.Block)
+		if err := block.Deserialize(value); err != nil {
+			return nil
+		}
+		return map[string]interface{}{"header": block.Header, "transactions": len(block.Transactions)}
+	case "default":
+		switch {
+		case string(key) == schemaVersionKey && len(value) == 4:
+			return map[string]interface{}{"schemaVersion": utils.BytesToUint32(value)}
+		case string(key) == migrationProgressKey && len(value) >= 4:
+			return map[string]interface{}{"migration": utils.BytesToUint32(value[:4]), "progress": hex.EncodeToString(value[4:])}
+		}
+	}
+	return nil
+}
+
+func init() {
+	dbKeysCmd.Flags().StringVar(&dbFlags.prefix, "prefix", "", "only keys starting with prefix")
+	dbKeysCmd.Flags().IntVar(&dbFlags.limit, "limit", 0, "maximum number of keys, 0 for all")
+	dbKeysCmd.Flags().BoolVar(&dbFlags.raw, "raw", false, "do not decode values")
+	dbExportCmd.Flags().Uint32Var(&dbFlags.from, "from", 0, "first block height")
+	dbExportCmd.Flags().Uint32Var(&dbFlags.to, "to", 0, "last block height, 0 for the chain height")
+	dbMigrateCmd.Flags().BoolVar(&dbFlags.dryRun, "dry-run", false, "report the migrations without writing")
+
+	dbCmd.AddCommand(dbBlockCmd, dbKeysCmd, dbStatsCmd, dbExportCmd, dbImportCmd, dbMigrateCmd)
+	RootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,185 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// transferCode is a contract which transfers its balance to args[1]
+const transferCode = `
+local L0 = require("L0")
+
+function L0Init()
+    return true
+end
+
+function L0Invoke(func, args)
+    L0.Transfer(args[1], tonumber(args[2]))
+    return true
+end
+`
+
+func openTestDB(t *testing.T, dir, name string) *db.BlockchainDB {
+	cfg := db.DefaultConfig()
+	cfg.DbPath = filepath.Join(dir, name)
+	return db.Open(cfg)
+}
+
+func appendTestBlock(t *testing.T, l *ledger.Ledger, txs ...*types.Transaction) {
+	height, err := l.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := l.GetLastBlockHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := types.NewBlock(last, uint32(utils.CurrentTimestamp()), height+1, 100, crypto.Hash{}, txs)
+	if err := l.AppendBlock(block, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exportTestChain(t *testing.T, l *ledger.Ledger) []byte {
+	height, err := l.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for h := uint32(1); h <= height; h++ {
+		block, err := l.GetBlockByNumber(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Encode(&exportedBlock{Height: h, Hash: block.Hash(), Block: hex.EncodeToString(block.Serialize())}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestImportChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := crypto.GenerateKey()
+	params.ChainID = []byte{0}
+	issuer := accounts.PublicKeyToAddress(*key.Public())
+	chain := coordinate.HexToChainCoordinate("00")
+	contractAddr := accounts.ChainCoordinateToAddress(coordinate.NewChainCoordinate([]byte{1}))
+	recipient := accounts.ChainCoordinateToAddress(coordinate.NewChainCoordinate([]byte{2}))
+	sign := func(tx *types.Transaction) *types.Transaction {
+		sig, _ := key.Sign(tx.SignHash().Bytes())
+		tx.WithSignature(sig)
+		return tx
+	}
+
+	srcDb := openTestDB(t, dir, "src")
+	defer srcDb.Close()
+	src := ledger.Open(srcDb)
+	appendTestBlock(t, src, sign(types.NewTransaction(chain, chain, types.TypeIssue, 1, issuer, contractAddr,
+		big.NewInt(100), big.NewInt(0), utils.CurrentTimestamp())))
+	ctx := types.NewTransaction(chain, chain, types.TypeSmartContract, 2, issuer, contractAddr,
+		big.NewInt(0), big.NewInt(0), utils.CurrentTimestamp())
+	ctx.Payload = utils.Serialize(&types.ContractSpec{
+		ContractCode:   []byte(transferCode),
+		ContractAddr:   contractAddr.Bytes(),
+		ContractParams: []string{"transfer", recipient.String(), "10"},
+	})
+	appendTestBlock(t, src, sign(ctx))
+
+	block, err := src.GetBlockByNumber(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != 2 {
+		t.Fatalf("contract block has %d txs, want the contract tx and its transfer", len(block.Transactions))
+	}
+	exported := exportTestChain(t, src)
+
+	// a tampered contract tx executes to another merkle hash
+	tampered := *block
+	tamperedCtx := *ctx
+	tamperedCtx.Payload = utils.Serialize(&types.ContractSpec{
+		ContractCode:   []byte(transferCode),
+		ContractAddr:   contractAddr.Bytes(),
+		ContractParams: []string{"transfer", recipient.String(), "20"},
+	})
+	tampered.Transactions = types.Transactions{&tamperedCtx, block.Transactions[1]}
+	first := bytes.SplitAfter(exported, []byte("\n"))[0]
+	var buf bytes.Buffer
+	buf.Write(first)
+	json.NewEncoder(&buf).Encode(&exportedBlock{Height: 2, Hash: tampered.Hash(), Block: hex.EncodeToString(tampered.Serialize())})
+
+	badDb := openTestDB(t, dir, "bad")
+	defer badDb.Close()
+	bad := ledger.Open(badDb)
+	if imported, _, err := importChain(bad, bufio.NewReader(&buf)); err == nil || imported != 1 {
+		t.Fatalf("tampered chain imported %d blocks, %v", imported, err)
+	}
+	if height, _ := bad.Height(); height != 1 {
+		t.Fatalf("tampered block written, height %d", height)
+	}
+
+	dstDb := openTestDB(t, dir, "dst")
+	defer dstDb.Close()
+	dst := ledger.Open(dstDb)
+	imported, skipped, err := importChain(dst, bufio.NewReader(bytes.NewReader(exported)))
+	if err != nil || imported != 2 || skipped != 0 {
+		t.Fatalf("imported %d, skipped %d, %v", imported, skipped, err)
+	}
+	got, err := dst.GetBlockByNumber(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Hash().Equal(block.Hash()) || len(got.Transactions) != 2 {
+		t.Fatalf("imported block %s with %d txs, want %s with 2", got.Hash(), len(got.Transactions), block.Hash())
+	}
+	if amount, _, _ := dst.GetBalance(recipient); amount.Int64() != 10 {
+		t.Fatalf("recipient balance %v, want 10", amount)
+	}
+
+	// importing again skips the present blocks
+	imported, skipped, err = importChain(dst, bufio.NewReader(bytes.NewReader(exported)))
+	if err != nil || imported != 0 || skipped != 2 {
+		t.Fatalf("reimported %d, skipped %d, %v", imported, skipped, err)
+	}
+}
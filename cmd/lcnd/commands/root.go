@@ -36,10 +36,15 @@ var RootCmd = &cobra.Command{
 	Use:   "lcnd",
 	Short: "lcnd is a layered cross-chain network",
 	Long:  `lcnd is a layered cross-chain network, a distributed ledger`,
+	// Execute prints the error itself
+	SilenceErrors: true,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
 		l := lcnd.NewLcnd(cfgFile)
+		if l == nil {
+			os.Exit(1)
+		}
 		// l.SetFlags()
 		l.Start()
 	},
@@ -0,0 +1,400 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/spf13/cobra"
+)
+
+var testnetFlags struct {
+	chains     string
+	nodes      int
+	out        string
+	host       string
+	p2pPort    int
+	rpcPort    int
+	routerPort int
+	logLevel   string
+}
+
+// testnetCmd represents the testnet command
+var testnetCmd = &cobra.Command{
+	Use:   "testnet",
+	Short: "Manage local test networks",
+}
+
+var testnetInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate the configs of a local multi-chain test network",
+	Long: `Generate the node configs, msg-net router configs, issuer accounts and
+start/stop scripts of a local multi-chain test network in one directory.
+Every chain runs --nodes lbft replicas and one msg-net router.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tn, err := newTestnet(strings.Split(testnetFlags.chains, ","), testnetFlags.nodes)
+		if err != nil {
+			return err
+		}
+		if err := tn.write(testnetFlags.out); err != nil {
+			return err
+		}
+		fmt.Printf("testnet with %d chains and %d nodes written to %s\n", len(tn.Chains), len(tn.Chains)*testnetFlags.nodes, testnetFlags.out)
+		return nil
+	},
+}
+
+type testnetNode struct {
+	Chain      string
+	Index      int
+	ID         string
+	PrivateKey string
+	PublicKey  string
+	ListenAddr string
+	Bootstrap  []string
+	RPCPort    int
+	DataDir    string
+	Config     string
+
+	url string
+}
+
+type testnetChain struct {
+	ID            string
+	N             int
+	Q             int
+	RouterID      int
+	RouterAddress string
+	RouterConfig  string
+	Discovery     []string
+	Nodes         []*testnetNode
+}
+
+type testnetIssuer struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"privateKey"`
+}
+
+type testnet struct {
+	Chains   []*testnetChain
+	Issuer   testnetIssuer
+	LogLevel string
+}
+
+func newTestnet(ids []string, nodes int) (*testnet, error) {
+	if nodes < 4 {
+		return nil, fmt.Errorf("--nodes %d is too small, lbft needs at least 4 replicas", nodes)
+	}
+	chains, err := parseHierarchy(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	tn := &testnet{
+		Issuer: testnetIssuer{
+			Address:    hex.EncodeToString(accounts.PublicKeyToAddress(*issuer.Public()).Bytes()),
+			PrivateKey: hex.EncodeToString(issuer.SecretBytes()),
+		},
+		LogLevel: testnetFlags.logLevel,
+	}
+
+	host := testnetFlags.host
+	seq := 0
+	for i, id := range chains {
+		c := &testnetChain{
+			ID:            id,
+			N:             nodes,
+			Q:             (2*nodes-1)/3 + 1,
+			RouterID:      i,
+			RouterAddress: fmt.Sprintf("%s:%d", host, testnetFlags.routerPort+i),
+			RouterConfig:  filepath.Join("routers", "router-"+id+".yaml"),
+		}
+		for j := 0; j < len(chains); j++ {
+			if j != i {
+				c.Discovery = append(c.Discovery, fmt.Sprintf("%s:%d", host, testnetFlags.routerPort+j))
+			}
+		}
+		for j := 0; j < nodes; j++ {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return nil, err
+			}
+			n := &testnetNode{
+				Chain:      id,
+				Index:      j + 1,
+				ID:         fmt.Sprintf("ID%04d", j+1),
+				PrivateKey: hex.EncodeToString(key.SecretBytes()),
+				PublicKey:  hex.EncodeToString(key.Public().Bytes()),
+				ListenAddr: fmt.Sprintf("%s:%d", host, testnetFlags.p2pPort+seq),
+				RPCPort:    testnetFlags.rpcPort + seq,
+				DataDir:    filepath.Join("datadir", id, fmt.Sprintf("%d", j+1)),
+				Config:     filepath.Join(id, fmt.Sprintf("%d.yaml", j+1)),
+			}
+			n.url = fmt.Sprintf("encode://%s@%s", n.PublicKey, n.ListenAddr)
+			c.Nodes = append(c.Nodes, n)
+			seq++
+		}
+		// every replica bootstraps from the other replicas of its chain
+		for _, n := range c.Nodes {
+			for _, m := range c.Nodes {
+				if m != n {
+					n.Bootstrap = append(n.Bootstrap, m.url)
+				}
+			}
+		}
+		tn.Chains = append(tn.Chains, c)
+	}
+	return tn, nil
+}
+
+// parseHierarchy validates the chain coordinates, every chain but the
+// root must have its parent in the list
+func parseHierarchy(ids []string) ([]string, error) {
+	var chains []string
+	seen := make(map[string]bool)
+	for _, s := range ids {
+		s = strings.ToLower(trimHexPrefix(strings.TrimSpace(s)))
+		if s == "" {
+			continue
+		}
+		if b, err := hex.DecodeString(s); err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid chain %q, want a hex chain coordinate such as 00 or 0001", s)
+		}
+		if seen[s] {
+			return nil, fmt.Errorf("duplicate chain %s", s)
+		}
+		seen[s] = true
+		chains = append(chains, s)
+	}
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("no chains given")
+	}
+
+	root := ""
+	for _, s := range chains {
+		if len(s) == 2 {
+			if root != "" {
+				return nil, fmt.Errorf("more than one root chain: %s and %s", root, s)
+			}
+			root = s
+			continue
+		}
+		if parent := s[:len(s)-2]; !seen[parent] {
+			return nil, fmt.Errorf("parent chain %s of %s is missing", parent, s)
+		}
+	}
+	if root == "" {
+		return nil, fmt.Errorf("root chain is missing")
+	}
+	return chains, nil
+}
+
+func (tn *testnet) write(out string) error {
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("%s already exists", out)
+	}
+
+	for _, c := range tn.Chains {
+		if err := writeTemplate(filepath.Join(out, c.RouterConfig), routerTemplate, 0644, c); err != nil {
+			return err
+		}
+		for _, n := range c.Nodes {
+			data := struct {
+				*testnetNode
+				Net       *testnet
+				ChainInfo *testnetChain
+			}{n, tn, c}
+			if err := writeTemplate(filepath.Join(out, n.Config), nodeTemplate, 0644, data); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeTemplate(filepath.Join(out, "start.sh"), startTemplate, 0755, tn); err != nil {
+		return err
+	}
+	if err := writeTemplate(filepath.Join(out, "stop.sh"), stopTemplate, 0755, tn); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(tn.Issuer, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(out, "issuer.json"), b, 0600)
+}
+
+func writeTemplate(file string, tmpl *template.Template, perm os.FileMode, data interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tmpl.Execute(f, data)
+}
+
+var templateFuncs = template.FuncMap{
+	"quote": func(ss []string) string {
+		q := make([]string, len(ss))
+		for i, s := range ss {
+			q[i] = fmt.Sprintf("%q", s)
+		}
+		return "[" + strings.Join(q, ", ") + "]"
+	},
+}
+
+var nodeTemplate = template.Must(template.New("node").Funcs(templateFuncs).Parse(`# chain {{.Chain}} node {{.Index}}, generated by lcnd testnet init
+net:
+  maxPeers: {{.ChainInfo.N}}
+  bootstrapNodes: {{quote .Bootstrap}}
+  listenAddr: "{{.ListenAddr}}"
+  privatekey: "{{.PrivateKey}}"
+
+  msgnet:
+    routeAddress: ["{{.ChainInfo.RouterAddress}}"]
+
+log:
+  level: "{{.Net.LogLevel}}"
+
+jrpc:
+  enabled: true
+  port: "{{.RPCPort}}"
+
+blockchain:
+  id: "{{.Chain}}"
+  datadir: "{{.DataDir}}"
+
+issueaddr:
+  addr: ["{{.Net.Issuer.Address}}"]
+
+#consensus
+consensus:
+  plugin: "lbft"
+
+  noops:
+    blockSize: 100
+    blockInterval: 10s
+
+  lbft:
+    id: "{{.ID}}"
+    "N": {{.ChainInfo.N}}
+    Q: {{.ChainInfo.Q}}
+    K: 100
+    blockSize: 10000000
+    blockTimeout: 8s
+    blockInterval: 10s
+    blockDelay: 10s
+    viewChange: 5s
+    resendViewChange: 5s
+    viewChangePeriod: 0s
+    nullRequest: 5s
+    bufferSize: 100
+    maxConcurrentNumFrom: 1
+    maxConcurrentNumTo: 1
+    # the replicas of every chain sign their votes with their node key
+    replicas:
+{{- range .Net.Chains}}{{range .Nodes}}
+      - id: "{{.ID}}"
+        publicKey: "{{.PublicKey}}"
+        chain: "{{.Chain}}"
+{{- end}}{{end}}
+`))
+
+var routerTemplate = template.Must(template.New("router").Parse(`# msg-net router of chain {{.ID}}, generated by lcnd testnet init
+logger:
+      level: info
+      formatter: text
+      out: "./logs/router-{{.ID}}"
+router:
+      id: {{.RouterID}}
+      address: {{.RouterAddress}}
+      addressAutoDetect: false
+      discovery:
+{{- range .Discovery}}
+           - {{.}}
+{{- end}}
+      timeout:
+            keepalive: 15s
+            routers: 15s
+            network:
+                  routers: 15s
+                  peers: 15s
+      reconnect:
+            interval: 10s
+            max: 5
+
+report:
+      "on": false
+`))
+
+var startTemplate = template.Must(template.New("start").Parse(`#!/bin/bash
+# Start the msg-net routers and lcnd nodes of the testnet, generated by lcnd testnet init
+cd "$(dirname "$0")"
+# MSGNET is the msg-net router binary, LCND the node binary
+LCND=${LCND:-lcnd}
+MSGNET=${MSGNET:-msg-net}
+mkdir -p logs
+{{range .Chains}}
+$MSGNET --config={{.RouterConfig}} > logs/router-{{.ID}}.out 2>&1 &
+echo $! >> pids
+{{- end}}
+sleep 1
+{{range .Chains}}{{range .Nodes}}
+$LCND --config={{.Config}} > logs/node-{{.Chain}}-{{.Index}}.out 2>&1 &
+echo $! >> pids
+{{- end}}{{end}}
+`))
+
+var stopTemplate = template.Must(template.New("stop").Parse(`#!/bin/bash
+# Stop the testnet started by start.sh, generated by lcnd testnet init
+cd "$(dirname "$0")"
+[ -f pids ] || exit 0
+kill $(cat pids) 2>/dev/null
+rm -f pids
+`))
+
+func init() {
+	testnetInitCmd.Flags().StringVar(&testnetFlags.chains, "chains", "00,0001,0002", "comma separated chain coordinates of the hierarchy")
+	testnetInitCmd.Flags().IntVar(&testnetFlags.nodes, "nodes", 4, "number of lbft replicas per chain")
+	testnetInitCmd.Flags().StringVar(&testnetFlags.out, "out", "testnet", "output directory, must not exist")
+	testnetInitCmd.Flags().StringVar(&testnetFlags.host, "host", "127.0.0.1", "host of all nodes and routers")
+	testnetInitCmd.Flags().IntVar(&testnetFlags.p2pPort, "p2p-port", 20166, "first p2p listen port")
+	testnetInitCmd.Flags().IntVar(&testnetFlags.rpcPort, "rpc-port", 8881, "first json rpc port")
+	testnetInitCmd.Flags().IntVar(&testnetFlags.routerPort, "router-port", 10001, "first msg-net router port")
+	testnetInitCmd.Flags().StringVar(&testnetFlags.logLevel, "loglevel", "info", "log level of the nodes")
+
+	testnetCmd.AddCommand(testnetInitCmd)
+	RootCmd.AddCommand(testnetCmd)
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bocheninc/L0/config"
+	"github.com/bocheninc/L0/core/consensus/lbft"
+	"github.com/spf13/viper"
+)
+
+func TestParseHierarchy(t *testing.T) {
+	chains, err := parseHierarchy([]string{"00", " 0x0001", "0002", "", "000101"})
+	if err != nil || strings.Join(chains, ",") != "00,0001,0002,000101" {
+		t.Fatalf("chains %v, %v", chains, err)
+	}
+
+	for _, ids := range [][]string{
+		nil,
+		{"00", "0g"},
+		{"00", "0001", "0001"},
+		{"00", "01"},
+		{"00", "000101"},
+		{"0001"},
+	} {
+		if chains, err := parseHierarchy(ids); err == nil {
+			t.Errorf("%v parsed as %v", ids, chains)
+		}
+	}
+}
+
+func TestNewTestnet(t *testing.T) {
+	if _, err := newTestnet([]string{"00"}, 3); err == nil {
+		t.Fatal("testnet with 3 replicas")
+	}
+
+	tn, err := newTestnet([]string{"00", "0001"}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tn.Chains) != 2 || tn.Issuer.Address == "" {
+		t.Fatalf("testnet %+v", tn)
+	}
+	addrs := make(map[string]bool)
+	for _, c := range tn.Chains {
+		if c.N != 4 || c.Q != 3 || len(c.Nodes) != 4 || len(c.Discovery) != 1 {
+			t.Fatalf("chain %s: N %d, Q %d, %d nodes, discovery %v", c.ID, c.N, c.Q, len(c.Nodes), c.Discovery)
+		}
+		for _, n := range c.Nodes {
+			if addrs[n.ListenAddr] || len(n.Bootstrap) != 3 {
+				t.Fatalf("node %s of chain %s: listen %s, bootstrap %v", n.ID, c.ID, n.ListenAddr, n.Bootstrap)
+			}
+			addrs[n.ListenAddr] = true
+		}
+	}
+}
+
+func TestTestnetReplicas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tn, err := newTestnet([]string{"00", "0001"}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "testnet")
+	if err := tn.write(out); err != nil {
+		t.Fatal(err)
+	}
+
+	// every node knows the keys of the replicas of its chain and the others
+	n := tn.Chains[1].Nodes[0]
+	file := filepath.Join(out, n.Config)
+	if errs := config.Check(file); len(errs) != 0 {
+		t.Fatalf("generated config reported %v", errs)
+	}
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	option := config.LbftOptions()
+	if len(option.Replicas) != 4 || option.Replicas[option.ID] != n.PublicKey {
+		t.Fatalf("replica %s with key %s, replicas %v", option.ID, n.PublicKey, option.Replicas)
+	}
+	root := tn.Chains[0].Nodes[0]
+	if replicas := option.ChainReplicas["00"]; len(replicas) != 4 || replicas[lbft.ReplicaID("00", root.ID)] != root.PublicKey {
+		t.Fatalf("replicas of chain 00 %v", replicas)
+	}
+}
@@ -0,0 +1,512 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/blockchain"
+	"github.com/bocheninc/L0/core/consensus"
+	"github.com/bocheninc/L0/core/consensus/lbft"
+	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/trace"
+	"github.com/bocheninc/L0/core/types"
+	"github.com/bocheninc/L0/rpc"
+	"github.com/spf13/cobra"
+)
+
+// Tx status reported by tx status
+const (
+	txStatusIncluded = "included"
+	txStatusUnknown  = blockchain.TxStatusUnknown
+)
+
+var txFlags struct {
+	rpcURL   string
+	token    string
+	chain    string
+	wait     bool
+	timeout  time.Duration
+	interval time.Duration
+
+	txType         string
+	fromChain      string
+	toChain        string
+	sender         string
+	recipient      string
+	amount         string
+	fee            string
+	nonce          uint32
+	payload        string
+	contractAddr   string
+	contractCode   string
+	contractParams []string
+	replicaOp      string
+	replicaID      string
+	replicaKey     string
+	replicaAddr    string
+
+	store        string
+	password     string
+	passwordFile string
+
+	trace bool
+}
+
+// txCmd represents the tx command
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Build, sign, send and inspect transactions",
+	Long: `Build, sign, send and inspect transactions. Transactions are passed
+between the commands as hex strings, "-" reads the hex string from stdin.`,
+}
+
+var txBuildCmd = &cobra.Command{
+	Use:          "build",
+	Short:        "Build an unsigned transaction",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadChainParams(); err != nil {
+			return err
+		}
+		tx, err := buildTx()
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.BytesToHex(tx.Serialize()))
+		return nil
+	},
+}
+
+var txSignCmd = &cobra.Command{
+	Use:          "sign <tx hex>",
+	Short:        "Sign a transaction offline with the keystore account of its sender",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tx, err := readTx(args)
+		if err != nil {
+			return err
+		}
+		ks, chainDb, err := openKeyStore(txFlags.store, false)
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		sender := tx.Data.Sender
+		if !ks.HasAddress(sender) {
+			return fmt.Errorf("account %s not found", sender)
+		}
+		passphrase, err := readPassphrase("Passphrase: ", txFlags.password, txFlags.passwordFile)
+		if err != nil {
+			return err
+		}
+		if _, err := ks.SignTx(*ks.Find(sender), tx, passphrase); err != nil {
+			return err
+		}
+		fmt.Println(utils.BytesToHex(tx.Serialize()))
+		return nil
+	},
+}
+
+var txSendCmd = &cobra.Command{
+	Use:          "send <tx hex>",
+	Short:        "Broadcast a signed transaction with Transaction.Broadcast",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tx, err := readTx(args)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Verfiy(); err != nil {
+			return fmt.Errorf("transaction is not signed by its sender: %v", err)
+		}
+		client := rpc.NewClient(txFlags.rpcURL, txFlags.token)
+		var hash crypto.Hash
+		if err := client.Call("Transaction.Broadcast", utils.BytesToHex(tx.Serialize()), &hash); err != nil {
+			return err
+		}
+		fmt.Println(hash.String())
+		if !txFlags.wait {
+			return nil
+		}
+		status, err := waitTx(client, hash)
+		if err != nil {
+			return err
+		}
+		return printJSON(status)
+	},
+}
+
+var txStatusCmd = &cobra.Command{
+	Use:          "status <tx hash>",
+	Short:        "Show if a transaction is pending, rejected or included",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("the tx hash is required")
+		}
+		hash := crypto.HexToHash(trimHexPrefix(args[0]))
+		client := rpc.NewClient(txFlags.rpcURL, txFlags.token)
+
+		var (
+			status *txStatus
+			err    error
+		)
+		if txFlags.wait {
+			status, err = waitTx(client, hash)
+		} else {
+			status, err = queryTx(client, hash)
+		}
+		if err != nil {
+			return err
+		}
+		if txFlags.trace {
+			client.Call("Ledger.TraceTx", hash.String(), &status.Trace)
+		}
+		return printJSON(status)
+	},
+}
+
+var txDecodeCmd = &cobra.Command{
+	Use:          "decode <tx hex>",
+	Short:        "Decode a transaction into readable json",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tx, err := readTx(args)
+		if err != nil {
+			return err
+		}
+		return printJSON(newTxView(tx))
+	},
+}
+
+// loadChainParams loads the chain id and issue accounts used to validate
+// coordinates, --chain overrides the chain id of the config
+func loadChainParams() error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+	if txFlags.chain != "" {
+		params.ChainID = coordinate.HexToChainCoordinate(txFlags.chain)
+	}
+	if len(params.ChainID) == 0 {
+		return errors.New("chain id is not set, use --config or --chain")
+	}
+	return nil
+}
+
+func buildTx() (*types.Transaction, error) {
+	txType, err := types.ParseTxType(txFlags.txType)
+	if err != nil {
+		return nil, err
+	}
+	fromChain, err := parseChain("from-chain", txFlags.fromChain)
+	if err != nil {
+		return nil, err
+	}
+	toChain, err := parseChain("to-chain", txFlags.toChain)
+	if err != nil {
+		return nil, err
+	}
+	if txFlags.sender == "" {
+		return nil, errors.New("--sender is required")
+	}
+	sender := accounts.HexToAddress(txFlags.sender)
+	recipient := sender
+	if txFlags.recipient != "" {
+		recipient = accounts.HexToAddress(txFlags.recipient)
+	}
+	amount, ok := new(big.Int).SetString(txFlags.amount, 10)
+	if !ok || (amount.Sign() <= 0 && txType != types.TypeSmartContract && txType != types.TypeGovernance) {
+		return nil, fmt.Errorf("invalid amount %q, must be > 0", txFlags.amount)
+	}
+	fee, ok := new(big.Int).SetString(txFlags.fee, 10)
+	if !ok || fee.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid fee %q, must be > 0", txFlags.fee)
+	}
+	nonce := txFlags.nonce
+	if nonce == 0 {
+		if nonce, err = pendingNonce(sender); err != nil {
+			return nil, fmt.Errorf("no --nonce given and the pending nonce is unavailable: %v", err)
+		}
+	}
+
+	tx := types.NewTransaction(fromChain, toChain, txType, nonce, sender, recipient, amount, fee, utils.CurrentTimestamp())
+	payload, err := buildPayload(txType)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		tx.WithPayload(payload)
+	}
+
+	if err := blockchain.CheckTransaction(tx); err != nil {
+		return nil, fmt.Errorf("invalid %s transaction from %s to %s: %v", types.TxTypeName(txType), fromChain, toChain, err)
+	}
+	return tx, nil
+}
+
+func parseChain(name, s string) (coordinate.ChainCoordinate, error) {
+	if s == "" {
+		return coordinate.NewChainCoordinate(params.ChainID), nil
+	}
+	b, err := hex.DecodeString(trimHexPrefix(s))
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid --%s %q, want a hex chain coordinate such as 00 or 0001", name, s)
+	}
+	return coordinate.NewChainCoordinate(b), nil
+}
+
+func buildPayload(txType uint32) ([]byte, error) {
+	if txType == types.TypeGovernance {
+		if txFlags.replicaID == "" {
+			return nil, errors.New("--replica-id is required for governance transactions")
+		}
+		change := &consensus.ReplicaChange{
+			Op:        txFlags.replicaOp,
+			ID:        lbft.ReplicaID(coordinate.NewChainCoordinate(params.ChainID).String(), txFlags.replicaID),
+			PublicKey: trimHexPrefix(txFlags.replicaKey),
+			Address:   txFlags.replicaAddr,
+		}
+		if err := change.Validate(); err != nil {
+			return nil, err
+		}
+		return json.Marshal(change)
+	}
+	if txType != types.TypeSmartContract {
+		if txFlags.payload == "" {
+			return nil, nil
+		}
+		return hex.DecodeString(trimHexPrefix(txFlags.payload))
+	}
+
+	if txFlags.contractAddr == "" {
+		return nil, errors.New("--contract-addr is required for smartContract transactions")
+	}
+	spec := &types.ContractSpec{
+		ContractAddr:   []byte(txFlags.contractAddr),
+		ContractParams: txFlags.contractParams,
+	}
+	if txFlags.contractCode != "" {
+		code, err := ioutil.ReadFile(txFlags.contractCode)
+		if err != nil {
+			return nil, err
+		}
+		spec.ContractCode = code
+	}
+	return utils.Serialize(spec), nil
+}
+
+// pendingNonce returns the next nonce of the sender including the txpool
+func pendingNonce(sender accounts.Address) (uint32, error) {
+	var balance struct {
+		Amount *big.Int
+		Nonce  uint32
+	}
+	client := rpc.NewClient(txFlags.rpcURL, txFlags.token)
+	if err := client.Call("Ledger.GetBalanceInTxPool", sender.String(), &balance); err != nil {
+		return 0, err
+	}
+	return balance.Nonce + 1, nil
+}
+
+func readTx(args []string) (*types.Transaction, error) {
+	if len(args) != 1 {
+		return nil, errors.New("the tx hex is required")
+	}
+	s := args[0]
+	if s == "-" {
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		s = string(b)
+	}
+	b, err := hex.DecodeString(trimHexPrefix(strings.TrimSpace(s)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hex: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.Deserialize(b); err != nil {
+		return nil, fmt.Errorf("invalid tx: %v", err)
+	}
+	return tx, nil
+}
+
+// txStatus is the output of tx status
+type txStatus struct {
+	Hash   crypto.Hash    `json:"hash"`
+	Status string         `json:"status"`
+	Reason string         `json:"reason,omitempty"`
+	Trace  []trace.Record `json:"trace,omitempty"`
+}
+
+func (s *txStatus) final() bool {
+	return s.Status == txStatusIncluded || s.Status == blockchain.TxStatusRejected
+}
+
+// queryTx asks the txpool first and then the ledger, GetTxByHash only finds
+// txs in the ledger once they left the txpool
+func queryTx(client *rpc.Client, hash crypto.Hash) (*txStatus, error) {
+	var pooled blockchain.TxPoolTx
+	if err := client.Call("TxPool.GetTx", hash.String(), &pooled); err != nil {
+		return nil, err
+	}
+	if pooled.Status != blockchain.TxStatusUnknown {
+		return &txStatus{Hash: hash, Status: pooled.Status, Reason: pooled.Reason}, nil
+	}
+	var tx types.Transaction
+	if err := client.Call("Ledger.GetTxByHash", hash.String(), &tx); err == nil {
+		return &txStatus{Hash: hash, Status: txStatusIncluded}, nil
+	}
+	return &txStatus{Hash: hash, Status: txStatusUnknown}, nil
+}
+
+// waitTx polls the tx until it is included or rejected
+func waitTx(client *rpc.Client, hash crypto.Hash) (*txStatus, error) {
+	deadline := time.Now().Add(txFlags.timeout)
+	for {
+		status, err := queryTx(client, hash)
+		if err != nil {
+			return nil, err
+		}
+		if status.final() {
+			return status, nil
+		}
+		if time.Now().After(deadline) {
+			return status, fmt.Errorf("transaction %s is still %s after %s", hash, status.Status, txFlags.timeout)
+		}
+		time.Sleep(txFlags.interval)
+	}
+}
+
+// txView is the readable form of a transaction
+type txView struct {
+	Hash       crypto.Hash              `json:"hash"`
+	SignHash   crypto.Hash              `json:"signHash"`
+	Type       string                   `json:"type"`
+	FromChain  string                   `json:"fromChain"`
+	ToChain    string                   `json:"toChain"`
+	Nonce      uint32                   `json:"nonce"`
+	Sender     accounts.Address         `json:"sender"`
+	Recipient  accounts.Address         `json:"recipient"`
+	Amount     *big.Int                 `json:"amount"`
+	Fee        *big.Int                 `json:"fee"`
+	CreateTime string                   `json:"createTime"`
+	Signature  string                   `json:"signature,omitempty"`
+	Signed     bool                     `json:"signed"`
+	Payload    string                   `json:"payload,omitempty"`
+	Contract   *contractView            `json:"contract,omitempty"`
+	Governance *consensus.ReplicaChange `json:"governance,omitempty"`
+	SignError  string                   `json:"signError,omitempty"`
+	Signer     *accounts.Address        `json:"signer,omitempty"`
+}
+
+type contractView struct {
+	Address string   `json:"address"`
+	Code    string   `json:"code,omitempty"`
+	Params  []string `json:"params,omitempty"`
+}
+
+func newTxView(tx *types.Transaction) *txView {
+	v := &txView{
+		Hash:       tx.Hash(),
+		SignHash:   tx.SignHash(),
+		Type:       types.TxTypeName(tx.GetType()),
+		FromChain:  tx.FromChain(),
+		ToChain:    tx.ToChain(),
+		Nonce:      tx.Nonce(),
+		Sender:     tx.Data.Sender,
+		Recipient:  tx.Recipient(),
+		Amount:     tx.Amount(),
+		Fee:        tx.Fee(),
+		CreateTime: time.Unix(int64(tx.CreateTime()), 0).UTC().Format(time.RFC3339),
+	}
+	if tx.Data.Signature != nil {
+		v.Signature = hex.EncodeToString(tx.Data.Signature[:])
+		if signer, err := tx.Verfiy(); err != nil {
+			v.SignError = err.Error()
+		} else {
+			v.Signed = true
+			v.Signer = &signer
+		}
+	}
+	if tx.GetType() == types.TypeSmartContract {
+		spec := new(types.ContractSpec)
+		if err := utils.Deserialize(tx.Payload, spec); err == nil {
+			v.Contract = &contractView{Address: string(spec.ContractAddr), Code: string(spec.ContractCode), Params: spec.ContractParams}
+		}
+	} else if change, err := tx.ReplicaChange(); change != nil && err == nil {
+		v.Governance = change
+	} else if len(tx.Payload) > 0 {
+		v.Payload = hex.EncodeToString(tx.Payload)
+	}
+	return v
+}
+
+func init() {
+	txCmd.PersistentFlags().StringVar(&txFlags.rpcURL, "rpc", "http://127.0.0.1:8881", "JSON-RPC url of the node")
+	txCmd.PersistentFlags().StringVar(&txFlags.token, "token", "", "JSON-RPC bearer token")
+
+	f := txBuildCmd.Flags()
+	f.StringVar(&txFlags.chain, "chain", "", "chain id of the node, overrides the config")
+	f.StringVar(&txFlags.txType, "type", "atomic", "atomic, acrossChain, merged, backfront, distribut, issue, smartContract or governance")
+	f.StringVar(&txFlags.fromChain, "from-chain", "", "hex from chain coordinate, default the chain id")
+	f.StringVar(&txFlags.toChain, "to-chain", "", "hex to chain coordinate, default the chain id")
+	f.StringVar(&txFlags.sender, "sender", "", "sender address")
+	f.StringVar(&txFlags.recipient, "recipient", "", "recipient address, default the sender")
+	f.StringVar(&txFlags.amount, "amount", "0", "amount")
+	f.StringVar(&txFlags.fee, "fee", "1", "fee")
+	f.Uint32Var(&txFlags.nonce, "nonce", 0, "nonce, 0 queries the pending nonce of the sender")
+	f.StringVar(&txFlags.payload, "payload", "", "hex payload")
+	f.StringVar(&txFlags.contractAddr, "contract-addr", "", "contract address of smartContract transactions")
+	f.StringVar(&txFlags.contractCode, "contract-code", "", "lua file deploying the contract")
+	f.StringSliceVar(&txFlags.contractParams, "contract-params", nil, "contract call params")
+	f.StringVar(&txFlags.replicaOp, "replica-op", consensus.ReplicaAdd, "replica set change of governance transactions, add or remove")
+	f.StringVar(&txFlags.replicaID, "replica-id", "", "consensus.lbft.id of the replica added or removed by governance transactions")
+	f.StringVar(&txFlags.replicaKey, "replica-key", "", "hex node id of the replica added by governance transactions")
+	f.StringVar(&txFlags.replicaAddr, "replica-addr", "", "p2p address the nodes connect the replica added by governance transactions at")
+
+	txSignCmd.Flags().StringVar(&txFlags.store, "keystore", storePlain, "keystore type, plain or scrypt")
+	txSignCmd.Flags().StringVar(&txFlags.password, "password", "", "passphrase of the sender account")
+	txSignCmd.Flags().StringVar(&txFlags.passwordFile, "password-file", "", "file holding the passphrase of the sender account")
+
+	for _, c := range []*cobra.Command{txSendCmd, txStatusCmd} {
+		c.Flags().BoolVar(&txFlags.wait, "wait", false, "poll until the transaction is included or rejected")
+		c.Flags().DurationVar(&txFlags.timeout, "timeout", time.Minute, "how long to wait")
+		c.Flags().DurationVar(&txFlags.interval, "interval", time.Second, "polling interval")
+	}
+	txStatusCmd.Flags().BoolVar(&txFlags.trace, "trace", false, "also print the stages recorded by the node")
+
+	txCmd.AddCommand(txBuildCmd, txSignCmd, txSendCmd, txStatusCmd, txDecodeCmd)
+	RootCmd.AddCommand(txCmd)
+}
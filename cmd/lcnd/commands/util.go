@@ -0,0 +1,131 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/config"
+	"github.com/bocheninc/L0/core/accounts/keystore"
+	"github.com/bocheninc/L0/core/consensus"
+	"github.com/bocheninc/L0/core/ledger"
+)
+
+// Keystore types
+const (
+	storePlain  = "plain"
+	storeScrypt = "scrypt"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// loadConfig reads the config file given by --config
+func loadConfig() (*config.Config, error) {
+	return config.New(cfgFile)
+}
+
+// genesisReplicas returns the configured replica set the ledger starts
+// with, the config must be loaded
+func genesisReplicas() *consensus.ReplicaSet {
+	option := config.LbftOptions()
+	return consensus.NewReplicaSet(option.Replicas, uint64(option.K))
+}
+
+// openDB opens the database of the node, the node must be stopped since
+// rocksdb allows a single process only. A database written by a newer
+// binary is refused
+func openDB() (*config.Config, *db.BlockchainDB, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	chainDb := db.NewDB(cfg.DbConfig)
+	if err := chainDb.CheckSchema(ledger.SchemaVersion()); err != nil {
+		chainDb.Close()
+		return nil, nil, err
+	}
+	return cfg, chainDb, nil
+}
+
+// openKeyStore opens the keystore of the node, the node must be stopped
+// since the account index is kept in its database
+func openKeyStore(store string, lightKDF bool) (*keystore.KeyStore, *db.BlockchainDB, error) {
+	cfg, chainDb, err := openDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch store {
+	case storePlain:
+		return keystore.NewPlaintextKeyStore(chainDb, cfg.KeyStoreDir), chainDb, nil
+	case storeScrypt:
+		n, p := keystore.StandardScryptN, keystore.StandardScryptP
+		if lightKDF {
+			n, p = keystore.LightScryptN, keystore.LightScryptP
+		}
+		return keystore.NewKeyStore(chainDb, cfg.KeyStoreDir, n, p), chainDb, nil
+	}
+	chainDb.Close()
+	return nil, nil, fmt.Errorf("unknown keystore %q, want %s or %s", store, storePlain, storeScrypt)
+}
+
+// readPassphrase returns the passphrase given by flag or file, or reads
+// it from stdin
+func readPassphrase(prompt, passphrase, file string) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no passphrase given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// printJSON prints v as indented json
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// trimHexPrefix removes the 0x prefix of a hex string
+func trimHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s[2:]
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/ledger"
+	"github.com/spf13/cobra"
+)
+
+var verifyFlags struct {
+	scratch string
+	keep    bool
+	out     string
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Re-verify the whole chain and recompute its state",
+	Long: `Replay all blocks from genesis into a scratch database, checking block
+links and hashes, tx merkle hashes and signatures, re-executing the txs and
+contracts and comparing the resulting balances, contract states and
+replica set with the node database. The node must be stopped.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, chainDb, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer chainDb.Close()
+
+		dir := verifyFlags.scratch
+		if dir == "" {
+			if dir, err = ioutil.TempDir("", "lcnd-verify"); err != nil {
+				return err
+			}
+		} else if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if !verifyFlags.keep {
+			defer os.RemoveAll(dir)
+		}
+		scratchCfg := *cfg.DbConfig
+		scratchCfg.DbPath = dir
+		scratch := db.Open(&scratchCfg)
+		defer scratch.Close()
+
+		report, err := ledger.NewLedger(chainDb).Verify(scratch, genesisReplicas())
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if verifyFlags.out != "" {
+			err = ioutil.WriteFile(verifyFlags.out, b, 0644)
+		} else {
+			fmt.Println(string(b))
+		}
+		if err != nil {
+			return err
+		}
+		if !report.OK() {
+			return fmt.Errorf("verified %d blocks, found %d problems and %d state differences", report.Height, len(report.Issues), len(report.Diffs))
+		}
+		fmt.Fprintf(os.Stderr, "verified %d blocks and %d txs\n", report.Height, report.Txs)
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyFlags.scratch, "scratch", "", "scratch database directory, a temporary one if empty")
+	verifyCmd.Flags().BoolVar(&verifyFlags.keep, "keep", false, "keep the scratch database")
+	verifyCmd.Flags().StringVar(&verifyFlags.out, "out", "", "file to write the report to, stdout if empty")
+
+	RootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,211 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tecbot/gorocksdb"
+)
+
+// BackupInfo describes a backup in a backup directory
+type BackupInfo struct {
+	ID        int64 `json:"id"`
+	Timestamp int64 `json:"timestamp"`
+	Size      int64 `json:"size"`
+	NumFiles  int32 `json:"numFiles"`
+}
+
+func openBackupEngine(dir string) (*gorocksdb.BackupEngine, error) {
+	opts := gorocksdb.NewDefaultOptions()
+	defer opts.Destroy()
+
+	be, err := gorocksdb.OpenBackupEngine(opts, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup engine %s, error: [%s]", dir, err)
+	}
+	return be, nil
+}
+
+func backupInfos(be *gorocksdb.BackupEngine) []BackupInfo {
+	info := be.GetInfo()
+	defer info.Destroy()
+
+	infos := make([]BackupInfo, 0, info.GetCount())
+	for i := 0; i < info.GetCount(); i++ {
+		infos = append(infos, BackupInfo{
+			ID:        info.GetBackupId(i),
+			Timestamp: info.GetTimestamp(i),
+			Size:      info.GetSize(i),
+			NumFiles:  info.GetNumFiles(i),
+		})
+	}
+	return infos
+}
+
+// Checkpoint creates a consistent backup of the running db in dir, backups
+// in the same dir share unchanged files
+func (blockchainDB *BlockchainDB) Checkpoint(dir string) (*BackupInfo, error) {
+	be, err := openBackupEngine(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer be.Close()
+
+	if err := be.CreateNewBackup(blockchainDB.DB); err != nil {
+		return nil, fmt.Errorf("failed to create backup in %s, error: [%s]", dir, err)
+	}
+	infos := backupInfos(be)
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("no backup found in %s", dir)
+	}
+	return &infos[len(infos)-1], nil
+}
+
+// Backups lists the backups in dir
+func Backups(dir string) ([]BackupInfo, error) {
+	be, err := openBackupEngine(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer be.Close()
+
+	return backupInfos(be), nil
+}
+
+// backupTimeFormat names the backup directories, sorting by name sorts by time
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// Retention limits the backups kept in a backup root, zero values don't
+// limit. The newest backup is always kept
+type Retention struct {
+	Keep   int           `json:"keep"`
+	MaxAge time.Duration `json:"maxAge"`
+}
+
+// Backup is a backup created by BlockchainDB.Backup, each one in its own
+// directory of the backup root
+type Backup struct {
+	Name string    `json:"name"`
+	Dir  string    `json:"dir"`
+	Time time.Time `json:"time"`
+}
+
+// Backup creates a backup of the running db in a new directory of root,
+// then removes the backups beyond retention and returns their names
+func (blockchainDB *BlockchainDB) Backup(root string, retention Retention) (*Backup, []string, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	name := now.Format(backupTimeFormat)
+	// the backup is created under a hidden name, an interrupted one is never listed
+	tmp, err := ioutil.TempDir(root, ".")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := blockchainDB.Checkpoint(tmp); err != nil {
+		return nil, nil, err
+	}
+	dir := filepath.Join(root, name)
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, nil, err
+	}
+
+	removed, err := PruneBackups(root, retention)
+	return &Backup{Name: name, Dir: dir, Time: now}, removed, err
+}
+
+// ListBackups returns the backups in root, oldest first
+func ListBackups(root string) ([]*Backup, error) {
+	entries, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// entries are sorted by name, that is by time
+	var backups []*Backup
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, entry.Name())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, &Backup{Name: entry.Name(), Dir: filepath.Join(root, entry.Name()), Time: t})
+	}
+	return backups, nil
+}
+
+// PruneBackups removes the backups in root beyond retention and returns their names
+func PruneBackups(root string, retention Retention) ([]string, error) {
+	backups, err := ListBackups(root)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, backup := range backups {
+		newer := len(backups) - 1 - i
+		if newer == 0 {
+			break
+		}
+		expired := retention.MaxAge > 0 && time.Since(backup.Time) > retention.MaxAge
+		if (retention.Keep > 0 && newer >= retention.Keep) || expired {
+			if err := os.RemoveAll(backup.Dir); err != nil {
+				return removed, err
+			}
+			removed = append(removed, backup.Name)
+		}
+	}
+	return removed, nil
+}
+
+// RestoreBackup restores the latest backup in dir into dbDir, which must not exist
+func RestoreBackup(dir, dbDir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbDir); err == nil {
+		return fmt.Errorf("%s already exists", dbDir)
+	}
+	be, err := openBackupEngine(dir)
+	if err != nil {
+		return err
+	}
+	defer be.Close()
+	if len(backupInfos(be)) == 0 {
+		return fmt.Errorf("no backup found in %s", dir)
+	}
+
+	opts := gorocksdb.NewRestoreOptions()
+	defer opts.Destroy()
+	if err := be.RestoreDBFromLatestBackup(dbDir, dbDir, opts); err != nil {
+		return fmt.Errorf("failed to restore backup %s, error: [%s]", dir, err)
+	}
+	return nil
+}
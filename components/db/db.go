@@ -20,9 +20,11 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
 	"github.com/bocheninc/L0/components/utils"
 
 	"github.com/tecbot/gorocksdb"
@@ -100,13 +102,22 @@ func NewDB(c *Config) *BlockchainDB {
 	once.Do(func() {
 		config = c
 
-		dbInstance = &BlockchainDB{}
-		dbInstance.open()
+		dbInstance = Open(c)
+		metrics.NewGaugeVecFunc("l0_rocksdb_property", "RocksDB integer properties by column family.",
+			[]string{"property", "cf"}, dbInstance.collectProperties)
 	})
 	return dbInstance
 }
 
-func (blockchainDB *BlockchainDB) open() {
+// Open opens a db instance that is independent of the one returned by
+// NewDB, e.g. a scratch db for replaying the chain
+func Open(c *Config) *BlockchainDB {
+	blockchainDB := &BlockchainDB{}
+	blockchainDB.open(c)
+	return blockchainDB
+}
+
+func (blockchainDB *BlockchainDB) open(config *Config) {
 	opts := gorocksdb.NewDefaultOptions()
 	defer opts.Destroy()
 
@@ -197,6 +208,93 @@ func (blockchainDB *BlockchainDB) GetByPrefix(prefix []byte, resCh chan map[stri
 	}
 }
 
+// Iterate calls fn for every key/value in the given column family that
+// starts with prefix, in key order, until fn returns false
+func (blockchainDB *BlockchainDB) Iterate(cfName string, prefix []byte, fn func(key, value []byte) bool) error {
+	blockchainDB.checkIfColumnExists(cfName)
+
+	ro := gorocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetFillCache(false)
+	it := blockchainDB.DB.NewIteratorCF(ro, blockchainDB.cfHandlers[cfName])
+	defer it.Close()
+
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		key := it.Key()
+		value := it.Value()
+		ok := fn(utils.MinimizeSilce(key.Data()), utils.MinimizeSilce(value.Data()))
+		key.Free()
+		value.Free()
+		if !ok {
+			break
+		}
+	}
+	return it.Err()
+}
+
+// Iterator walks the keys of a column family in key order
+type Iterator struct {
+	ro *gorocksdb.ReadOptions
+	it *gorocksdb.Iterator
+}
+
+// NewIterator returns an iterator positioned at the first key of the given
+// column family, it must be closed
+func (blockchainDB *BlockchainDB) NewIterator(cfName string) *Iterator {
+	blockchainDB.checkIfColumnExists(cfName)
+
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(false)
+	it := blockchainDB.DB.NewIteratorCF(ro, blockchainDB.cfHandlers[cfName])
+	it.SeekToFirst()
+	return &Iterator{ro: ro, it: it}
+}
+
+// Valid reports whether the iterator is positioned at a key
+func (iterator *Iterator) Valid() bool {
+	return iterator.it.Valid()
+}
+
+// Next moves to the next key
+func (iterator *Iterator) Next() {
+	iterator.it.Next()
+}
+
+// Key returns a copy of the current key
+func (iterator *Iterator) Key() []byte {
+	key := iterator.it.Key()
+	defer key.Free()
+	return utils.MinimizeSilce(key.Data())
+}
+
+// Value returns a copy of the current value
+func (iterator *Iterator) Value() []byte {
+	value := iterator.it.Value()
+	defer value.Free()
+	return utils.MinimizeSilce(value.Data())
+}
+
+// Err returns the error the iteration stopped with
+func (iterator *Iterator) Err() error {
+	return iterator.it.Err()
+}
+
+// Close releases the iterator
+func (iterator *Iterator) Close() {
+	iterator.it.Close()
+	iterator.ro.Destroy()
+}
+
+// ColumnFamilies returns the names of the opened column families
+func (blockchainDB *BlockchainDB) ColumnFamilies() []string {
+	var cfNames []string
+	for cfName := range blockchainDB.cfHandlers {
+		cfNames = append(cfNames, cfName)
+	}
+	sort.Strings(cfNames)
+	return cfNames
+}
+
 // Put saves the key/value in the given column family
 func (blockchainDB *BlockchainDB) Put(cfName string, key []byte, value []byte) error {
 	blockchainDB.checkIfColumnExists(cfName)
@@ -21,8 +21,10 @@ package db
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/bocheninc/L0/components/utils"
 )
@@ -120,3 +122,216 @@ func TestWriteBatch(t *testing.T) {
 	}
 
 }
+
+func TestCheckpoint(t *testing.T) {
+	db := NewDB(testConfig)
+	dir := "/tmp/rocksdb-test-backup/"
+	defer os.RemoveAll(dir)
+
+	db.Put("default", []byte("foo"), []byte("bar"))
+	first, err := db.Checkpoint(dir)
+	if err != nil {
+		t.Fatalf("faild to checkpoint, err: [%s]", err)
+	}
+	second, err := db.Checkpoint(dir)
+	if err != nil {
+		t.Fatalf("faild to checkpoint, err: [%s]", err)
+	}
+	if second.ID <= first.ID {
+		t.Fatalf("backup id %d not after %d", second.ID, first.ID)
+	}
+	infos, err := Backups(dir)
+	if err != nil || len(infos) != 2 {
+		t.Fatalf("backups %v, err: [%v]", infos, err)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	db := NewDB(testConfig)
+	root := "/tmp/rocksdb-test-backups/"
+	restored := "/tmp/rocksdb-test-restored/"
+	defer os.RemoveAll(root)
+	defer os.RemoveAll(restored)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		db.Put("default", []byte("foo"), []byte(strconv.Itoa(i)))
+		backup, removed, err := db.Backup(root, Retention{Keep: 2})
+		if err != nil {
+			t.Fatalf("faild to backup, err: [%s]", err)
+		}
+		if i < 2 && len(removed) != 0 || i == 2 && fmt.Sprint(removed) != fmt.Sprint(names[:1]) {
+			t.Fatalf("backup %d removed %v", i, removed)
+		}
+		names = append(names, backup.Name)
+	}
+	backups, err := ListBackups(root)
+	if err != nil || len(backups) != 2 || backups[0].Name != names[1] || backups[1].Name != names[2] {
+		t.Fatalf("backups %v, err: [%v]", backups, err)
+	}
+
+	if removed, err := PruneBackups(root, Retention{MaxAge: time.Nanosecond}); err != nil || fmt.Sprint(removed) != fmt.Sprint(names[1:2]) {
+		t.Fatalf("prune removed %v, err: [%v], the newest must be kept", removed, err)
+	}
+
+	if err := RestoreBackup(backups[1].Dir, restored); err != nil {
+		t.Fatalf("faild to restore, err: [%s]", err)
+	}
+	if err := RestoreBackup(backups[1].Dir, restored); err == nil {
+		t.Fatal("restore overwrote an existing dir")
+	}
+	cfg := *testConfig
+	cfg.DbPath = restored
+	rdb := Open(&cfg)
+	defer rdb.Close()
+	if value, _ := rdb.Get("default", []byte("foo")); string(value) != "2" {
+		t.Fatalf("restored value %q, want 2", value)
+	}
+}
+
+func TestIterate(t *testing.T) {
+	db := NewDB(testConfig)
+
+	db.Put("col2", []byte("a_1"), []byte("1"))
+	db.Put("col2", []byte("b_1"), []byte("2"))
+	db.Put("col2", []byte("b_2"), []byte("3"))
+	db.Put("col2", []byte("c_1"), []byte("4"))
+
+	var values []string
+	if err := db.Iterate("col2", []byte("b_"), func(key, value []byte) bool {
+		values = append(values, string(value))
+		return true
+	}); err != nil {
+		t.Fatalf("faild to iterate, err: [%s]", err)
+	}
+	if fmt.Sprint(values) != "[2 3]" {
+		t.Fatalf("iterate b_ got %v", values)
+	}
+
+	cnt := 0
+	db.Iterate("col2", nil, func(key, value []byte) bool {
+		cnt++
+		return cnt < 2
+	})
+	if cnt != 2 {
+		t.Fatalf("iterate did not stop, got %d keys", cnt)
+	}
+
+	it := db.NewIterator("col2")
+	defer it.Close()
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Err(); err != nil || fmt.Sprint(keys) != "[a_1 b_1 b_2 c_1]" {
+		t.Fatalf("iterator got %v, err %v", keys, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db := NewDB(testConfig)
+	c := *testConfig
+	c.DbPath = "/tmp/rocksdb-test-open/"
+	defer os.RemoveAll(c.DbPath)
+	scratch := Open(&c)
+	defer scratch.Close()
+
+	db.Put("col3", []byte("foo"), []byte("bar"))
+	if value, _ := scratch.Get("col3", []byte("foo")); value != nil {
+		t.Fatalf("scratch db shares data with the node db, got %s", value)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	c := *testConfig
+	c.DbPath = "/tmp/rocksdb-test-migrate/"
+	defer os.RemoveAll(c.DbPath)
+	db := Open(&c)
+	defer db.Close()
+
+	// a db without a schema version is migrated from version 0
+	for i := 0; i < 5; i++ {
+		db.Put("col2", []byte{byte(i)}, []byte("old"))
+	}
+	fail := false
+	migrations := []Migration{
+		{Version: 1, Description: "baseline", Run: func(run *MigrationRun) error { return nil }},
+		{Version: 2, Description: "rewrite col2", Run: func(run *MigrationRun) error {
+			var keys [][]byte
+			db.Iterate("col2", nil, func(key, value []byte) bool {
+				if bytes.Compare(key, run.Resume()) > 0 {
+					keys = append(keys, key)
+				}
+				return true
+			})
+			for i, key := range keys {
+				if fail && i == 2 {
+					return fmt.Errorf("interrupted")
+				}
+				if err := run.Write([]*WriteBatch{NewWriteBatch("col2", OperationPut, key, []byte("new"))}, key); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	}
+
+	results, err := db.Migrate(migrations, true)
+	if err != nil || len(results) != 2 || results[1].Changes != 5 {
+		t.Fatalf("dry run results %v, err: [%v]", results, err)
+	}
+	if version, _ := db.SchemaVersion(); version != 0 {
+		t.Fatalf("dry run set schema version %d", version)
+	}
+	if value, _ := db.Get("col2", []byte{0}); string(value) != "old" {
+		t.Fatal("dry run changed data")
+	}
+
+	fail = true
+	if _, err := db.Migrate(migrations, false); err == nil {
+		t.Fatal("interrupted migration did not fail")
+	}
+	if version, _ := db.SchemaVersion(); version != 1 {
+		t.Fatalf("schema version %d after interrupted migration, want 1", version)
+	}
+	fail = false
+	results, err = db.Migrate(migrations, false)
+	if err != nil || len(results) != 1 || !results[0].Resumed || results[0].Changes != 3 {
+		t.Fatalf("resumed results %v, err: [%v]", results, err)
+	}
+	for i := 0; i < 5; i++ {
+		if value, _ := db.Get("col2", []byte{byte(i)}); string(value) != "new" {
+			t.Fatalf("key %d not migrated, got %s", i, value)
+		}
+	}
+	if version, _ := db.SchemaVersion(); version != 2 {
+		t.Fatalf("schema version %d, want 2", version)
+	}
+	if results, err := db.Migrate(migrations, false); err != nil || len(results) != 0 {
+		t.Fatalf("migrated db ran %v, err: [%v]", results, err)
+	}
+
+	if _, err := db.Migrate(migrations[:1], false); err == nil {
+		t.Fatal("opened a db with a newer schema")
+	}
+}
+
+func TestMigrateEmpty(t *testing.T) {
+	c := *testConfig
+	c.DbPath = "/tmp/rocksdb-test-migrate-empty/"
+	defer os.RemoveAll(c.DbPath)
+	db := Open(&c)
+	defer db.Close()
+
+	results, err := db.Migrate([]Migration{
+		{Version: 3, Description: "never run on an empty db", Run: func(run *MigrationRun) error {
+			return fmt.Errorf("migration run on an empty db")
+		}},
+	}, false)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("results %v, err: [%v]", results, err)
+	}
+	if version, _ := db.SchemaVersion(); version != 3 {
+		t.Fatalf("empty db schema version %d, want 3", version)
+	}
+}
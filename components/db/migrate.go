@@ -0,0 +1,184 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/utils"
+)
+
+// the schema version and the progress of the running migration are kept
+// in the default column family
+const schemaColumnFamily = "default"
+
+var (
+	schemaVersionKey     = []byte("schemaVersion")
+	migrationProgressKey = []byte("schemaMigration")
+)
+
+// Migration changes the layout of the db from the previous version to Version
+type Migration struct {
+	Version     uint32
+	Description string
+	// Run rewrites the data through MigrationRun.Write, after an interruption
+	// it is run again and continues from MigrationRun.Resume
+	Run func(run *MigrationRun) error
+}
+
+// MigrationResult reports a migration run by Migrate
+type MigrationResult struct {
+	Version     uint32 `json:"version"`
+	Description string `json:"description"`
+	Changes     int    `json:"changes"`
+	Resumed     bool   `json:"resumed"`
+}
+
+// MigrationRun is the state of a running migration
+type MigrationRun struct {
+	DB     *BlockchainDB
+	DryRun bool
+
+	version uint32
+	resume  []byte
+	changes int
+}
+
+// Resume returns the progress saved with the last Write of an interrupted
+// run, nil if the migration starts from the beginning
+func (run *MigrationRun) Resume() []byte {
+	return run.resume
+}
+
+// Write applies the batch together with progress, which is returned by
+// Resume if the migration is interrupted afterwards. A dry run only counts
+// the changes
+func (run *MigrationRun) Write(writeBatchs []*WriteBatch, progress []byte) error {
+	run.changes += len(writeBatchs)
+	run.resume = progress
+	if run.DryRun {
+		return nil
+	}
+	value := append(utils.Uint32ToBytes(run.version), progress...)
+	writeBatchs = append(writeBatchs, NewWriteBatch(schemaColumnFamily, OperationPut, migrationProgressKey, value))
+	return run.DB.AtomicWrite(writeBatchs)
+}
+
+// SchemaVersion returns the schema version of the db, 0 if it has none
+func (blockchainDB *BlockchainDB) SchemaVersion() (uint32, error) {
+	value, err := blockchainDB.Get(schemaColumnFamily, schemaVersionKey)
+	if err != nil || len(value) == 0 {
+		return 0, err
+	}
+	return utils.BytesToUint32(value), nil
+}
+
+// CheckSchema refuses a db written with a newer schema than latest
+func (blockchainDB *BlockchainDB) CheckSchema(latest uint32) error {
+	version, err := blockchainDB.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return fmt.Errorf("db schema version %d is newer than version %d of this binary", version, latest)
+	}
+	return nil
+}
+
+// Migrate runs the migrations newer than the schema version of the db, the
+// migrations must be sorted by version. An empty db is given the version of
+// the last migration and a db with a newer version is refused
+func (blockchainDB *BlockchainDB) Migrate(migrations []Migration, dryRun bool) ([]MigrationResult, error) {
+	var latest uint32
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if err := blockchainDB.CheckSchema(latest); err != nil {
+		return nil, err
+	}
+	version, err := blockchainDB.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	empty, err := blockchainDB.empty()
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 && empty {
+		if dryRun {
+			return nil, nil
+		}
+		return nil, blockchainDB.Put(schemaColumnFamily, schemaVersionKey, utils.Uint32ToBytes(latest))
+	}
+
+	var results []MigrationResult
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		run := &MigrationRun{DB: blockchainDB, DryRun: dryRun, version: migration.Version}
+		if run.resume, err = blockchainDB.migrationProgress(migration.Version); err != nil {
+			return results, err
+		}
+		result := MigrationResult{Version: migration.Version, Description: migration.Description, Resumed: run.resume != nil}
+		log.Infof("db schema migration %d: %s, dry run %v, resumed %v", migration.Version, migration.Description, dryRun, result.Resumed)
+
+		if err := migration.Run(run); err != nil {
+			return results, fmt.Errorf("db schema migration %d error %v", migration.Version, err)
+		}
+		result.Changes = run.changes
+		results = append(results, result)
+		if dryRun {
+			continue
+		}
+		if err := blockchainDB.AtomicWrite([]*WriteBatch{
+			NewWriteBatch(schemaColumnFamily, OperationPut, schemaVersionKey, utils.Uint32ToBytes(migration.Version)),
+			NewWriteBatch(schemaColumnFamily, OperationDelete, migrationProgressKey, nil),
+		}); err != nil {
+			return results, err
+		}
+		log.Infof("db schema migration %d done, %d changes", migration.Version, result.Changes)
+	}
+	return results, nil
+}
+
+// migrationProgress returns the progress saved by the interrupted run of
+// the migration to version
+func (blockchainDB *BlockchainDB) migrationProgress(version uint32) ([]byte, error) {
+	value, err := blockchainDB.Get(schemaColumnFamily, migrationProgressKey)
+	if err != nil || len(value) < 4 || utils.BytesToUint32(value[:4]) != version {
+		return nil, err
+	}
+	return append([]byte{}, value[4:]...), nil
+}
+
+// empty reports whether no column family has a key
+func (blockchainDB *BlockchainDB) empty() (bool, error) {
+	empty := true
+	for _, cfName := range blockchainDB.ColumnFamilies() {
+		if err := blockchainDB.Iterate(cfName, nil, func(key, value []byte) bool {
+			empty = false
+			return false
+		}); err != nil || !empty {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import "strconv"
+
+// rocksDBProperties are the integer properties exported as metrics
+var rocksDBProperties = []string{
+	"rocksdb.estimate-num-keys",
+	"rocksdb.estimate-live-data-size",
+	"rocksdb.total-sst-files-size",
+	"rocksdb.cur-size-all-mem-tables",
+	"rocksdb.num-running-compactions",
+	"rocksdb.num-running-flushes",
+	"rocksdb.estimate-pending-compaction-bytes",
+}
+
+// collectProperties emits the rocksdb properties of every column family
+func (blockchainDB *BlockchainDB) collectProperties(emit func(value float64, labelValues ...string)) {
+	for cfName, cf := range blockchainDB.cfHandlers {
+		for _, property := range rocksDBProperties {
+			value, err := strconv.ParseUint(blockchainDB.DB.GetPropertyCF(property, cf), 10, 64)
+			if err != nil {
+				continue
+			}
+			emit(float64(value), property, cfName)
+		}
+	}
+}
@@ -23,14 +23,21 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Sirupsen/logrus"
 )
 
 const (
-	depth = 9
+	maxDepth = 16
 )
 
+var callerHookOnce sync.Once
+
+func addCallerHook() {
+	callerHookOnce.Do(func() { AddHook(&CallerHook{}) })
+}
+
 // CallerHook represents a caller hook of logrus
 type CallerHook struct {
 }
@@ -53,10 +60,26 @@ func (hook *CallerHook) Levels() []logrus.Level {
 	}
 }
 
+// caller returns the first frame outside logrus and this package, so that
+// both the package functions and module loggers report the right line.
 func (hook *CallerHook) caller() string {
-	if _, file, line, ok := runtime.Caller(depth); ok {
-		return strings.Join([]string{filepath.Base(file), strconv.Itoa(line)}, ":")
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame.Function) {
+			return strings.Join([]string{filepath.Base(frame.File), strconv.Itoa(frame.Line)}, ":")
+		}
+		if !more {
+			break
+		}
 	}
 	// not sure what the convention should be here
 	return ""
 }
+
+func isLoggingFrame(function string) bool {
+	return strings.Contains(function, "/Sirupsen/logrus.") ||
+		strings.Contains(function, "/components/log.")
+}
@@ -19,6 +19,7 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"os"
 
@@ -26,11 +27,20 @@ import (
 	"github.com/bocheninc/L0/components/utils"
 )
 
+// Supported formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 var (
 	defaultFormatter = &logrus.TextFormatter{
 		TimestampFormat: "2006-01-02 15:04:05",
 		FullTimestamp:   true,
 	}
+	jsonFormatter = &logrus.JSONFormatter{
+		TimestampFormat: "2006-01-02 15:04:05",
+	}
 	defaultLevel  = "debug"
 	defaultOutput = os.Stderr
 )
@@ -86,12 +96,27 @@ func New(args ...interface{}) (Logger, error) {
 	return logrus.StandardLogger(), nil
 }
 
-// SetFormatter sets the standard logger formatter.
+// SetFormatter sets the standard logger and module loggers formatter.
 func SetFormatter(formatter logrus.Formatter) {
 	logrus.SetFormatter(formatter)
+	eachModule(func(m *module) { m.logger.Formatter = formatter })
+}
+
+// SetFormat sets the formatter by name, text or json.
+func SetFormat(name string) error {
+	switch name {
+	case "", FormatText:
+		SetFormatter(defaultFormatter)
+	case FormatJSON:
+		SetFormatter(jsonFormatter)
+	default:
+		return fmt.Errorf("unknown log format %q", name)
+	}
+	return nil
 }
 
-// SetLevel sets the standard logger level.
+// SetLevel sets the standard logger level, and the level of module
+// loggers which have no level of their own.
 func SetLevel(lvl string) {
 	level, err := logrus.ParseLevel(lvl)
 	if err != nil {
@@ -99,15 +124,21 @@ func SetLevel(lvl string) {
 	}
 
 	if level >= logrus.DebugLevel {
-		AddHook(&CallerHook{})
+		addCallerHook()
 	}
 
 	logrus.SetLevel(level)
+	eachModule(func(m *module) {
+		if !m.explicit {
+			m.setLevel(level)
+		}
+	})
 }
 
-// SetOutput sets the standard logger output.
+// SetOutput sets the standard logger and module loggers output.
 func SetOutput(out io.Writer) {
 	logrus.SetOutput(out)
+	eachModule(func(m *module) { m.logger.Out = out })
 }
 
 // GetLevel returns the standard logger level.
@@ -115,9 +146,15 @@ func GetLevel() logrus.Level {
 	return logrus.GetLevel()
 }
 
-// AddHook adds a hook to the standard logger hooks.
+// AddHook adds a hook to the standard logger and module loggers hooks.
 func AddHook(hook logrus.Hook) {
 	logrus.AddHook(hook)
+	eachModule(func(m *module) { m.logger.Hooks.Add(hook) })
+}
+
+// WithField returns an entry of the standard logger with the field added.
+func WithField(key string, value interface{}) *logrus.Entry {
+	return logrus.WithField(key, value)
 }
 
 // Debug logs a message at level Debug on the standard logger.
@@ -19,7 +19,14 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogToFile(t *testing.T) {
@@ -37,3 +44,142 @@ func TestLogToFile(t *testing.T) {
 	log2.Debug("test")
 	log2.Info("info")
 }
+
+func TestModuleLevel(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(defaultOutput)
+	SetLevel("info")
+	defer SetLevel(defaultLevel)
+
+	p2p := Module("test-p2p")
+	consensus := Module("test-consensus")
+	if err := SetModuleLevel("test-consensus", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetModuleLevel("test-consensus", "verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+
+	p2p.Info("p2p info")
+	consensus.Info("consensus info")
+	consensus.WithField(FieldSeqNo, 7).Warn("consensus warn")
+
+	out := buf.String()
+	if !strings.Contains(out, "p2p info") || !strings.Contains(out, "module=test-p2p") {
+		t.Errorf("missing p2p entry: %s", out)
+	}
+	if strings.Contains(out, "consensus info") {
+		t.Errorf("consensus info should be filtered: %s", out)
+	}
+	if !strings.Contains(out, "seqNo=7") {
+		t.Errorf("missing seqNo field: %s", out)
+	}
+
+	SetLevel("error")
+	if got := ModuleLevels()["test-p2p"]; got != "error" {
+		t.Errorf("p2p level %s, want error", got)
+	}
+	if got := ModuleLevels()["test-consensus"]; got != "warning" {
+		t.Errorf("consensus level %s, want warning", got)
+	}
+
+	ResetModuleLevel("test-consensus")
+	SetLevel("info")
+	if got := ModuleLevels()["test-consensus"]; got != "info" {
+		t.Errorf("reset consensus level %s, want info", got)
+	}
+}
+
+func TestModuleLevelConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(defaultOutput)
+
+	logger := Module("test-merge")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Debugf("debug %d", i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetModuleLevel("test-merge", "warn")
+		SetModuleLevel("test-merge", "debug")
+	}
+	<-done
+	ResetModuleLevel("test-merge")
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(defaultOutput)
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	defer SetFormat(FormatText)
+	if err := SetFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	SetLevel("info")
+	defer SetLevel(defaultLevel)
+
+	Module("test-ledger").WithField(FieldTxHash, "0xabc").Info("appended")
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "appended" || entry[FieldTxHash] != "0xabc" || entry[FieldModule] != "test-ledger" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+}
+
+func TestRotateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &RotateFile{
+		Filename:    filepath.Join(dir, "lcnd.log"),
+		MaxSize:     10,
+		RotateEvery: time.Hour,
+		MaxBackups:  2,
+		now:         func() time.Time { return now },
+	}
+	defer r.Close()
+
+	write := func(s string) {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Second)
+	}
+	write("123456")
+	write("1234")
+	write("x") // over size
+	now = now.Add(time.Hour)
+	write("y")          // over the rotation interval
+	write("0123456789") // over size, drops the oldest backup
+
+	backups, err := r.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups %v, want 2", backups)
+	}
+	data, _ := ioutil.ReadFile(backups[0])
+	if string(data) != "x" {
+		t.Errorf("oldest kept backup %q", data)
+	}
+	data, _ = ioutil.ReadFile(r.Filename)
+	if string(data) != "0123456789" {
+		t.Errorf("current file %q", data)
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Modules
+const (
+	ModuleP2P       = "p2p"
+	ModuleConsensus = "consensus"
+	ModuleLedger    = "ledger"
+	ModuleMerge     = "merge"
+	ModuleVM        = "vm"
+	ModuleRPC       = "rpc"
+)
+
+// Contextual fields attached to log entries
+const (
+	FieldModule = "module"
+	FieldTxHash = "tx"
+	FieldSeqNo  = "seqNo"
+	FieldPeer   = "peer"
+)
+
+// Fields is a set of contextual fields.
+type Fields = logrus.Fields
+
+type module struct {
+	logger   *logrus.Logger
+	level    uint32 // logrus.Level, accessed atomically
+	explicit bool
+}
+
+func (m *module) getLevel() logrus.Level {
+	return logrus.Level(atomic.LoadUint32(&m.level))
+}
+
+func (m *module) setLevel(level logrus.Level) {
+	atomic.StoreUint32(&m.level, uint32(level))
+}
+
+var modules = struct {
+	sync.RWMutex
+	m map[string]*module
+}{m: make(map[string]*module)}
+
+func eachModule(fn func(m *module)) {
+	modules.RLock()
+	defer modules.RUnlock()
+	for _, m := range modules.m {
+		fn(m)
+	}
+}
+
+func getModule(name string) *module {
+	modules.Lock()
+	defer modules.Unlock()
+	m, ok := modules.m[name]
+	if !ok {
+		std := logrus.StandardLogger()
+		hooks := make(logrus.LevelHooks)
+		for level, hs := range std.Hooks {
+			hooks[level] = append(hooks[level], hs...)
+		}
+		// the logrus level is never changed, the module level filters
+		m = &module{logger: &logrus.Logger{
+			Out:       std.Out,
+			Formatter: std.Formatter,
+			Hooks:     hooks,
+			Level:     logrus.DebugLevel,
+		}, level: uint32(std.Level)}
+		modules.m[name] = m
+	}
+	return m
+}
+
+// Module returns the logger of the named module. It shares the output,
+// formatter and hooks of the standard logger but has its own level.
+func Module(name string) *Entry {
+	m := getModule(name)
+	return &Entry{module: m, entry: m.logger.WithField(FieldModule, name)}
+}
+
+// SetModuleLevel sets the level of the named module logger.
+func SetModuleLevel(name, lvl string) error {
+	level, err := logrus.ParseLevel(lvl)
+	if err != nil {
+		return err
+	}
+	if level >= logrus.DebugLevel {
+		addCallerHook()
+	}
+	m := getModule(name)
+	modules.Lock()
+	m.setLevel(level)
+	m.explicit = true
+	modules.Unlock()
+	return nil
+}
+
+// ResetModuleLevel makes the named module logger follow the standard
+// logger level again.
+func ResetModuleLevel(name string) {
+	m := getModule(name)
+	modules.Lock()
+	m.setLevel(logrus.GetLevel())
+	m.explicit = false
+	modules.Unlock()
+}
+
+// ModuleLevels returns the level of every module logger.
+func ModuleLevels() map[string]string {
+	levels := make(map[string]string)
+	modules.RLock()
+	defer modules.RUnlock()
+	for name, m := range modules.m {
+		levels[name] = m.getLevel().String()
+	}
+	return levels
+}
+
+// Entry is a module logger with contextual fields.
+type Entry struct {
+	module *module
+	entry  *logrus.Entry
+}
+
+func (e *Entry) enabled(level logrus.Level) bool {
+	return e.module.getLevel() >= level
+}
+
+// WithField returns a copy of the entry with the field added.
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return &Entry{module: e.module, entry: e.entry.WithField(key, value)}
+}
+
+// WithFields returns a copy of the entry with the fields added.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	return &Entry{module: e.module, entry: e.entry.WithFields(fields)}
+}
+
+// WithError returns a copy of the entry with the error field added.
+func (e *Entry) WithError(err error) *Entry {
+	return &Entry{module: e.module, entry: e.entry.WithError(err)}
+}
+
+// Debug logs a message at level Debug.
+func (e *Entry) Debug(args ...interface{}) {
+	if e.enabled(logrus.DebugLevel) {
+		e.entry.Debug(args...)
+	}
+}
+
+// Print logs a message at level Info.
+func (e *Entry) Print(args ...interface{}) {
+	e.Info(args...)
+}
+
+// Info logs a message at level Info.
+func (e *Entry) Info(args ...interface{}) {
+	if e.enabled(logrus.InfoLevel) {
+		e.entry.Info(args...)
+	}
+}
+
+// Warn logs a message at level Warn.
+func (e *Entry) Warn(args ...interface{}) {
+	if e.enabled(logrus.WarnLevel) {
+		e.entry.Warn(args...)
+	}
+}
+
+// Warning logs a message at level Warn.
+func (e *Entry) Warning(args ...interface{}) {
+	e.Warn(args...)
+}
+
+// Error logs a message at level Error.
+func (e *Entry) Error(args ...interface{}) {
+	if e.enabled(logrus.ErrorLevel) {
+		e.entry.Error(args...)
+	}
+}
+
+// Fatal logs a message at level Fatal and exits.
+func (e *Entry) Fatal(args ...interface{}) {
+	if e.enabled(logrus.FatalLevel) {
+		e.entry.Fatal(args...)
+	}
+	logrus.Exit(1)
+}
+
+// Panic logs a message at level Panic and panics.
+func (e *Entry) Panic(args ...interface{}) {
+	if e.enabled(logrus.PanicLevel) {
+		e.entry.Panic(args...)
+	}
+	panic(fmt.Sprint(args...))
+}
+
+// Debugf logs a message at level Debug.
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	if e.enabled(logrus.DebugLevel) {
+		e.entry.Debugf(format, args...)
+	}
+}
+
+// Printf logs a message at level Info.
+func (e *Entry) Printf(format string, args ...interface{}) {
+	e.Infof(format, args...)
+}
+
+// Infof logs a message at level Info.
+func (e *Entry) Infof(format string, args ...interface{}) {
+	if e.enabled(logrus.InfoLevel) {
+		e.entry.Infof(format, args...)
+	}
+}
+
+// Warnf logs a message at level Warn.
+func (e *Entry) Warnf(format string, args ...interface{}) {
+	if e.enabled(logrus.WarnLevel) {
+		e.entry.Warnf(format, args...)
+	}
+}
+
+// Warningf logs a message at level Warn.
+func (e *Entry) Warningf(format string, args ...interface{}) {
+	e.Warnf(format, args...)
+}
+
+// Errorf logs a message at level Error.
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	if e.enabled(logrus.ErrorLevel) {
+		e.entry.Errorf(format, args...)
+	}
+}
+
+// Fatalf logs a message at level Fatal and exits.
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	if e.enabled(logrus.FatalLevel) {
+		e.entry.Fatalf(format, args...)
+	}
+	logrus.Exit(1)
+}
+
+// Panicf logs a message at level Panic and panics.
+func (e *Entry) Panicf(format string, args ...interface{}) {
+	if e.enabled(logrus.PanicLevel) {
+		e.entry.Panicf(format, args...)
+	}
+	panic(fmt.Sprintf(format, args...))
+}
+
+// Debugln logs a message at level Debug.
+func (e *Entry) Debugln(args ...interface{}) {
+	if e.enabled(logrus.DebugLevel) {
+		e.entry.Debugln(args...)
+	}
+}
+
+// Println logs a message at level Info.
+func (e *Entry) Println(args ...interface{}) {
+	e.Infoln(args...)
+}
+
+// Infoln logs a message at level Info.
+func (e *Entry) Infoln(args ...interface{}) {
+	if e.enabled(logrus.InfoLevel) {
+		e.entry.Infoln(args...)
+	}
+}
+
+// Warnln logs a message at level Warn.
+func (e *Entry) Warnln(args ...interface{}) {
+	if e.enabled(logrus.WarnLevel) {
+		e.entry.Warnln(args...)
+	}
+}
+
+// Warningln logs a message at level Warn.
+func (e *Entry) Warningln(args ...interface{}) {
+	e.Warnln(args...)
+}
+
+// Errorln logs a message at level Error.
+func (e *Entry) Errorln(args ...interface{}) {
+	if e.enabled(logrus.ErrorLevel) {
+		e.entry.Errorln(args...)
+	}
+}
+
+// Fatalln logs a message at level Fatal and exits.
+func (e *Entry) Fatalln(args ...interface{}) {
+	if e.enabled(logrus.FatalLevel) {
+		e.entry.Fatalln(args...)
+	}
+	logrus.Exit(1)
+}
+
+// Panicln logs a message at level Panic and panics.
+func (e *Entry) Panicln(args ...interface{}) {
+	if e.enabled(logrus.PanicLevel) {
+		e.entry.Panicln(args...)
+	}
+	panic(fmt.Sprint(args...))
+}
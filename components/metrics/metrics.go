@@ -0,0 +1,577 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package metrics implements counters, gauges and histograms exported in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metric types
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefBuckets are the default histogram buckets in seconds
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry is the registry used by the package level constructors
+var DefaultRegistry = NewRegistry()
+
+type collector interface {
+	describe() *metricDesc
+	collect(emit func(suffix string, labels []string, values []string, value float64))
+}
+
+type metricDesc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *metricDesc) describe() *metricDesc { return d }
+
+// Registry holds the registered metrics
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register returns the collector registered as name, or registers the one
+// created by create, it panics if name is registered with another type
+func (r *Registry) register(name, typ string, create func() collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.collectors[name]; ok {
+		if c.describe().typ != typ {
+			panic(fmt.Sprintf("metrics: %s registered as %s", name, c.describe().typ))
+		}
+		return c
+	}
+	c := create()
+	r.collectors[name] = c
+	return c
+}
+
+// WriteTo writes all metrics in the text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make(map[string]collector, len(r.collectors))
+	for name, c := range r.collectors {
+		collectors[name] = c
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, name := range names {
+		c := collectors[name]
+		d := c.describe()
+		fmt.Fprintf(cw, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+		fmt.Fprintf(cw, "# TYPE %s %s\n", d.name, d.typ)
+		c.collect(func(suffix string, labels []string, values []string, value float64) {
+			cw.WriteString(d.name + suffix)
+			if len(labels) > 0 {
+				cw.WriteString("{")
+				for i, label := range labels {
+					if i > 0 {
+						cw.WriteString(",")
+					}
+					cw.WriteString(label + `="` + escapeLabel(values[i]) + `"`)
+				}
+				cw.WriteString("}")
+			}
+			cw.WriteString(" " + formatFloat(value) + "\n")
+		})
+	}
+	err := cw.w.Flush()
+	return cw.n, err
+}
+
+// Handler returns the http handler exporting the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// Handler returns the http handler exporting the default registry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+type countWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func (cw *countWriter) WriteString(s string) {
+	n, _ := cw.w.WriteString(s)
+	cw.n += int64(n)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// value is a float64 updated atomically
+type value struct {
+	bits uint64
+}
+
+func (v *value) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&v.bits))
+}
+
+func (v *value) store(f float64) {
+	atomic.StoreUint64(&v.bits, math.Float64bits(f))
+}
+
+func (v *value) add(f float64) {
+	for {
+		old := atomic.LoadUint64(&v.bits)
+		if atomic.CompareAndSwapUint64(&v.bits, old, math.Float64bits(math.Float64frombits(old)+f)) {
+			return
+		}
+	}
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	v value
+}
+
+// Inc increments the counter by 1
+func (c *Counter) Inc() { c.v.add(1) }
+
+// Add increments the counter by v, which must not be negative
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.v.add(v)
+}
+
+// Value returns the current value
+func (c *Counter) Value() float64 { return c.v.load() }
+
+// Gauge is a value which can go up and down
+type Gauge struct {
+	v value
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) { g.v.store(v) }
+
+// Add adds v to the gauge
+func (g *Gauge) Add(v float64) { g.v.add(v) }
+
+// Inc increments the gauge by 1
+func (g *Gauge) Inc() { g.v.add(1) }
+
+// Dec decrements the gauge by 1
+func (g *Gauge) Dec() { g.v.add(-1) }
+
+// Value returns the current value
+func (g *Gauge) Value() float64 { return g.v.load() }
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe adds an observation
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince observes the seconds elapsed since t
+func (h *Histogram) ObserveSince(t time.Time) {
+	h.Observe(time.Since(t).Seconds())
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) collect(labels, values []string, emit func(string, []string, []string, float64)) {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.mu.Unlock()
+
+	bucketLabels := append(append([]string(nil), labels...), "le")
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += counts[i]
+		emit("_bucket", bucketLabels, append(append([]string(nil), values...), formatFloat(bound)), float64(cumulative))
+	}
+	emit("_bucket", bucketLabels, append(append([]string(nil), values...), "+Inf"), float64(count))
+	emit("_sum", labels, values, sum)
+	emit("_count", labels, values, float64(count))
+}
+
+type counterMetric struct {
+	metricDesc
+	*Counter
+}
+
+func (m *counterMetric) collect(emit func(string, []string, []string, float64)) {
+	emit("", nil, nil, m.Value())
+}
+
+type gaugeMetric struct {
+	metricDesc
+	*Gauge
+}
+
+func (m *gaugeMetric) collect(emit func(string, []string, []string, float64)) {
+	emit("", nil, nil, m.Value())
+}
+
+type histogramMetric struct {
+	metricDesc
+	*Histogram
+}
+
+func (m *histogramMetric) collect(emit func(string, []string, []string, float64)) {
+	m.Histogram.collect(nil, nil, emit)
+}
+
+// GaugeFunc is a gauge whose value is read at collection
+type GaugeFunc struct {
+	metricDesc
+	mu sync.Mutex
+	fn func() float64
+}
+
+// Set replaces the function returning the value
+func (g *GaugeFunc) Set(fn func() float64) {
+	g.mu.Lock()
+	g.fn = fn
+	g.mu.Unlock()
+}
+
+func (g *GaugeFunc) collect(emit func(string, []string, []string, float64)) {
+	g.mu.Lock()
+	fn := g.fn
+	g.mu.Unlock()
+	if fn != nil {
+		emit("", nil, nil, fn())
+	}
+}
+
+// GaugeVecFunc is a labeled gauge whose values are read at collection
+type GaugeVecFunc struct {
+	metricDesc
+	mu sync.Mutex
+	fn func(emit func(value float64, labelValues ...string))
+}
+
+// Set replaces the function emitting the values
+func (g *GaugeVecFunc) Set(fn func(emit func(value float64, labelValues ...string))) {
+	g.mu.Lock()
+	g.fn = fn
+	g.mu.Unlock()
+}
+
+func (g *GaugeVecFunc) collect(emit func(string, []string, []string, float64)) {
+	g.mu.Lock()
+	fn := g.fn
+	g.mu.Unlock()
+	if fn != nil {
+		fn(func(value float64, labelValues ...string) {
+			if len(labelValues) == len(g.labels) {
+				emit("", g.labels, labelValues, value)
+			}
+		})
+	}
+}
+
+// vec holds the children of a labeled metric
+type vec struct {
+	metricDesc
+	mu       sync.Mutex
+	children map[string]interface{}
+	values   map[string][]string
+	create   func() interface{}
+}
+
+func newVec(d metricDesc, create func() interface{}) *vec {
+	return &vec{metricDesc: d, children: make(map[string]interface{}), values: make(map[string][]string), create: create}
+}
+
+func (v *vec) with(labelValues []string) interface{} {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	child, ok := v.children[key]
+	if !ok {
+		child = v.create()
+		v.children[key] = child
+		v.values[key] = append([]string(nil), labelValues...)
+	}
+	return child
+}
+
+func (v *vec) each(fn func(values []string, child interface{})) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]interface{}, len(keys))
+	values := make([][]string, len(keys))
+	for i, key := range keys {
+		children[i], values[i] = v.children[key], v.values[key]
+	}
+	v.mu.Unlock()
+
+	for i := range keys {
+		fn(values[i], children[i])
+	}
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	*vec
+}
+
+// With returns the counter of the label values
+func (c *CounterVec) With(labelValues ...string) *Counter {
+	return c.with(labelValues).(*Counter)
+}
+
+func (c *CounterVec) collect(emit func(string, []string, []string, float64)) {
+	c.each(func(values []string, child interface{}) {
+		emit("", c.labels, values, child.(*Counter).Value())
+	})
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	*vec
+}
+
+// With returns the gauge of the label values
+func (g *GaugeVec) With(labelValues ...string) *Gauge {
+	return g.with(labelValues).(*Gauge)
+}
+
+func (g *GaugeVec) collect(emit func(string, []string, []string, float64)) {
+	g.each(func(values []string, child interface{}) {
+		emit("", g.labels, values, child.(*Gauge).Value())
+	})
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	*vec
+}
+
+// With returns the histogram of the label values
+func (h *HistogramVec) With(labelValues ...string) *Histogram {
+	return h.with(labelValues).(*Histogram)
+}
+
+func (h *HistogramVec) collect(emit func(string, []string, []string, float64)) {
+	h.each(func(values []string, child interface{}) {
+		child.(*Histogram).collect(h.labels, values, emit)
+	})
+}
+
+// NewCounter registers a counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.register(name, typeCounter, func() collector {
+		return &counterMetric{metricDesc{name: name, help: help, typ: typeCounter}, new(Counter)}
+	}).(*counterMetric).Counter
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.register(name, typeGauge, func() collector {
+		return &gaugeMetric{metricDesc{name: name, help: help, typ: typeGauge}, new(Gauge)}
+	}).(*gaugeMetric).Gauge
+}
+
+// NewHistogram registers a histogram, DefBuckets are used if buckets is empty
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.register(name, typeHistogram, func() collector {
+		return &histogramMetric{metricDesc{name: name, help: help, typ: typeHistogram}, newHistogram(buckets)}
+	}).(*histogramMetric).Histogram
+}
+
+// NewGaugeFunc registers a gauge reading its value from fn, registering
+// the name again replaces fn
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := r.register(name, typeGauge, func() collector {
+		return &GaugeFunc{metricDesc: metricDesc{name: name, help: help, typ: typeGauge}}
+	}).(*GaugeFunc)
+	g.Set(fn)
+	return g
+}
+
+// NewGaugeVecFunc registers a labeled gauge whose values are emitted by fn,
+// registering the name again replaces fn
+func (r *Registry) NewGaugeVecFunc(name, help string, labels []string, fn func(emit func(value float64, labelValues ...string))) *GaugeVecFunc {
+	g := r.register(name, typeGauge, func() collector {
+		return &GaugeVecFunc{metricDesc: metricDesc{name: name, help: help, typ: typeGauge, labels: labels}}
+	}).(*GaugeVecFunc)
+	g.Set(fn)
+	return g
+}
+
+// NewCounterVec registers a counter partitioned by labels
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return r.register(name, typeCounter, func() collector {
+		return &CounterVec{newVec(metricDesc{name: name, help: help, typ: typeCounter, labels: labels},
+			func() interface{} { return new(Counter) })}
+	}).(*CounterVec)
+}
+
+// NewGaugeVec registers a gauge partitioned by labels
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return r.register(name, typeGauge, func() collector {
+		return &GaugeVec{newVec(metricDesc{name: name, help: help, typ: typeGauge, labels: labels},
+			func() interface{} { return new(Gauge) })}
+	}).(*GaugeVec)
+}
+
+// NewHistogramVec registers a histogram partitioned by labels
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return r.register(name, typeHistogram, func() collector {
+		return &HistogramVec{newVec(metricDesc{name: name, help: help, typ: typeHistogram, labels: labels},
+			func() interface{} { return newHistogram(buckets) })}
+	}).(*HistogramVec)
+}
+
+// NewCounter registers a counter in the default registry
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+// NewGauge registers a gauge in the default registry
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+// NewHistogram registers a histogram in the default registry
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets)
+}
+
+// NewGaugeFunc registers a gauge function in the default registry
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return DefaultRegistry.NewGaugeFunc(name, help, fn)
+}
+
+// NewGaugeVecFunc registers a labeled gauge function in the default registry
+func NewGaugeVecFunc(name, help string, labels []string, fn func(emit func(value float64, labelValues ...string))) *GaugeVecFunc {
+	return DefaultRegistry.NewGaugeVecFunc(name, help, labels, fn)
+}
+
+// NewCounterVec registers a labeled counter in the default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+// NewGaugeVec registers a labeled gauge in the default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labels...)
+}
+
+// NewHistogramVec registers a labeled histogram in the default registry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labels...)
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "A counter.").Add(3)
+	r.NewGauge("test_gauge", "A gauge.").Set(-1.5)
+	r.NewCounterVec("test_labeled_total", "A labeled counter.", "reason").With(`bad "nonce"`).Inc()
+	h := r.NewHistogram("test_seconds", "A histogram.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+	r.NewGaugeFunc("test_func", "A gauge func.", func() float64 { return 7 })
+	r.NewGaugeVecFunc("test_vec_func", "A gauge vec func.", []string{"cf"}, func(emit func(float64, ...string)) {
+		emit(1, "block")
+	})
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE test_total counter\ntest_total 3\n",
+		"test_gauge -1.5\n",
+		`test_labeled_total{reason="bad \"nonce\""} 1` + "\n",
+		`test_seconds_bucket{le="0.1"} 1` + "\n",
+		`test_seconds_bucket{le="1"} 2` + "\n",
+		`test_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_seconds_sum 2.55\ntest_seconds_count 3\n",
+		"test_func 7\n",
+		`test_vec_func{cf="block"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	r := NewRegistry()
+	c1 := r.NewCounter("test_total", "")
+	c2 := r.NewCounter("test_total", "")
+	c1.Inc()
+	if c1 != c2 || c2.Value() != 1 {
+		t.Error("registering a counter twice should return the same counter")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering another type should panic")
+		}
+	}()
+	r.NewGauge("test_total", "")
+}
@@ -19,7 +19,10 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/db"
@@ -39,7 +42,12 @@ const (
 	defaultKeyStoreDirname  = "keystore"
 	defaultNodeDirname      = "node"
 	defaultNodeKeyFilename  = "nodekey"
+	defaultBackupDirname    = "backups"
+	defaultConsensusWAL     = "consensus/lbft.wal"
+	defaultBackupKeep       = 7
 	defaultMaxPeers         = 8
+	defaultLogLevel         = "debug"
+	defaultShutdownTimeout  = 10 * time.Second
 )
 
 var (
@@ -48,8 +56,11 @@ var (
 		DbConfig:    db.DefaultConfig(),
 		MergeConfig: merge.DefaultConfig(),
 
-		LogLevel: "debug",
-		LogFile:  defaultLogFilename,
+		LogLevel:  defaultLogLevel,
+		LogFile:   defaultLogFilename,
+		LogFormat: log.FormatText,
+
+		ShutdownTimeout: defaultShutdownTimeout,
 	}
 
 	privkey *crypto.PrivateKey
@@ -75,8 +86,13 @@ type Config struct {
 	MergeConfig *merge.Config
 
 	// log
-	LogLevel string
-	LogFile  string
+	LogLevel       string
+	LogFile        string
+	LogFormat      string
+	LogMaxSize     int
+	LogRotateEvery time.Duration
+	LogMaxBackups  int
+	LogModules     map[string]string
 
 	// db
 	DbConfig    *db.Config
@@ -84,6 +100,22 @@ type Config struct {
 
 	// profile
 	CPUFile string
+
+	// txpool
+	TxPoolSize int
+
+	// ShutdownTimeout bounds the stop of each component
+	ShutdownTimeout time.Duration
+
+	// backup
+	BackupDir       string
+	BackupRetention db.Retention
+
+	// ConsensusWAL is the write-ahead log of lbft
+	ConsensusWAL string
+
+	// Reloadable are the settings applied again when the config file changes
+	Reloadable *Reloadable
 }
 
 // New returns a config according the config file
@@ -100,30 +132,31 @@ func loadConfig(cfgFile string) (conf *Config, err error) {
 	cfg = defaultConfig
 
 	if cfgFile != "" {
-		if utils.FileExist(cfgFile) {
-			viper.SetConfigFile(cfgFile)
+		if !utils.FileExist(cfgFile) {
+			return nil, fmt.Errorf("config file %s not found", cfgFile)
 		}
-		if err := viper.ReadInConfig(); err != nil {
-			log.Debugf("no config file, run as default config! viper.ReadInConfig error %s", err)
-		} else {
-			appDataDir = cfg.read()
+		if err := readConfigFile(cfgFile); err != nil {
+			return nil, err
 		}
+		cfg.ConfigFile = cfgFile
+		appDataDir = cfg.read()
 	}
 
 	if appDataDir == "" {
 		appDataDir = utils.AppDataDir()
-		cfgFile = filepath.Join(appDataDir, defaultConfigFilename)
-		if utils.FileExist(cfgFile) {
-			viper.SetConfigFile(cfgFile)
-			if err := viper.ReadInConfig(); err != nil {
-				log.Debug("no config file, run as default config!")
-			} else {
-				if dir := cfg.read(); dir != "" {
-					if ok, _ := utils.IsDirExist(dir); ok {
-						appDataDir = dir
-					}
+		file := filepath.Join(appDataDir, defaultConfigFilename)
+		if utils.FileExist(file) {
+			if err := readConfigFile(file); err != nil {
+				return nil, err
+			}
+			cfg.ConfigFile = file
+			if dir := cfg.read(); dir != "" {
+				if ok, _ := utils.IsDirExist(dir); ok {
+					appDataDir = dir
 				}
 			}
+		} else if cfgFile == "" {
+			log.Debug("no config file, run as default config!")
 		}
 	}
 
@@ -140,12 +173,44 @@ func loadConfig(cfgFile string) (conf *Config, err error) {
 	cfg.DbConfig = DBConfig(cfg.DataDir)
 	cfg.NetDbConfig = DBConfig(cfg.NodeDir)
 	cfg.NetConfig = NetConfig(cfg.NodeDir)
-	cfg.MergeConfig = MergeConfig(cfg.NodeDir)
+	if cfg.MergeConfig, err = MergeConfig(cfg.NodeDir); err != nil {
+		return nil, err
+	}
 	cfg.readLogConfig()
+	cfg.TxPoolSize = getInt("txpool.maxSize", cfg.TxPoolSize)
+	cfg.ShutdownTimeout = getDuration("shutdown.timeout", cfg.ShutdownTimeout)
+	cfg.BackupDir = getString("backup.dir", filepath.Join(appDataDir, defaultBackupDirname))
+	cfg.BackupRetention = db.Retention{
+		Keep:   getInt("backup.keep", defaultBackupKeep),
+		MaxAge: getDuration("backup.maxAge", 0),
+	}
+	cfg.ConsensusWAL = getString("consensus.lbft.wal", filepath.Join(appDataDir, defaultConsensusWAL))
+	cfg.Reloadable = readReloadable()
 
 	return cfg, nil
 }
 
+// readConfigFile reads cfgFile into viper, rejecting unknown keys and
+// invalid values
+func readConfigFile(cfgFile string) error {
+	viper.SetConfigFile(cfgFile)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("read config file %s: %v", cfgFile, err)
+	}
+	if errs := validate(viper.GetViper()); len(errs) > 0 {
+		return invalidConfig(cfgFile, errs)
+	}
+	return nil
+}
+
+func invalidConfig(cfgFile string, errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("invalid config file %s:\n  %s", cfgFile, strings.Join(msgs, "\n  "))
+}
+
 func (cfg *Config) read() string {
 	var (
 		dataDir string
@@ -181,4 +246,11 @@ func (cfg *Config) readLogConfig() {
 	if logFile = filepath.Join(cfg.LogDir, defaultLogFilename); logFile != "" {
 		cfg.LogFile = logFile
 	}
+	cfg.LogFormat = getString("log.format", cfg.LogFormat)
+	cfg.LogMaxSize = getInt("log.maxSize", cfg.LogMaxSize)
+	cfg.LogRotateEvery = getDuration("log.rotateEvery", cfg.LogRotateEvery)
+	cfg.LogMaxBackups = getInt("log.maxBackups", cfg.LogMaxBackups)
+	if modules := viper.GetStringMapString("log.modules"); len(modules) > 0 {
+		cfg.LogModules = modules
+	}
 }
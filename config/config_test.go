@@ -20,10 +20,131 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/bocheninc/L0/core/consensus/lbft"
+	"github.com/bocheninc/L0/core/consensus/nbft"
 )
 
 func TestConfig(t *testing.T) {
 	cfg, err := loadConfig("ss.yaml")
 	fmt.Println(cfg, err)
 }
+
+func writeConfig(t *testing.T, file, content string) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "lcnd.yaml")
+
+	writeConfig(t, file, "log:\n  level: info\nmerge:\n  mergeDuration: 5s\n")
+	if errs := Check(file); len(errs) != 0 {
+		t.Fatalf("valid config reported %v", errs)
+	}
+
+	writeConfig(t, file, "log:\n  levle: info\nmerge:\n  mergeDuration: soon\n")
+	errs := Check(file)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	all := strings.Join(msgs, "\n")
+	if !strings.Contains(all, "log.levle") || !strings.Contains(all, "log.level") {
+		t.Errorf("missing unknown key suggestion in %q", all)
+	}
+	if !strings.Contains(all, "merge.mergeDuration") {
+		t.Errorf("missing duration error in %q", all)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "lcnd.yaml")
+	writeConfig(t, file, "log:\n  level: info\n")
+
+	changes := make(chan *Reloadable, 4)
+	w, err := Watch(file, func(r *Reloadable) { changes <- r })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeConfig(t, file, "log:\n  level: nonsense\n")
+	select {
+	case r := <-changes:
+		t.Fatalf("invalid config reloaded with log level %s", r.LogLevel)
+	case <-time.After(time.Second):
+	}
+
+	writeConfig(t, file, "log:\n  level: warn\n")
+	select {
+	case r := <-changes:
+		if r.LogLevel != "warn" {
+			t.Errorf("log level %s, expected warn", r.LogLevel)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("config change not observed")
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "lcnd.yaml")
+	publicKey := "04" + strings.Repeat("ab", 64)
+	replicas := "    id: ID0001\n    replicas:\n      - id: ID0001\n        publicKey: " + publicKey + "\n" +
+		"      - id: ID0002\n        publicKey: " + publicKey + "\n        chain: \"01\"\n"
+	writeConfig(t, file, "blockchain:\n  id: \"00\"\nconsensus:\n  lbft:\n"+replicas+"  nbft:\n"+replicas)
+	if err := readConfigFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	lbftOption := LbftOptions()
+	if lbftOption.ID != lbft.ReplicaID("00", "ID0001") {
+		t.Fatalf("lbft replica id %s, expected the one of ID0001", lbftOption.ID)
+	}
+	if key, ok := lbftOption.Replicas[lbftOption.ID]; !ok || key != publicKey {
+		t.Errorf("lbft replica %s not in %v", lbftOption.ID, lbftOption.Replicas)
+	}
+	if len(lbftOption.Replicas) != 1 || lbftOption.ChainReplicas["01"][lbft.ReplicaID("01", "ID0002")] != publicKey {
+		t.Errorf("lbft replicas %v, replicas of other chains %v", lbftOption.Replicas, lbftOption.ChainReplicas)
+	}
+	nbftOption := NbftOptions()
+	if nbftOption.ID != nbft.ReplicaID("00", "ID0001") {
+		t.Fatalf("nbft replica id %s, expected the one of ID0001", nbftOption.ID)
+	}
+	if key, ok := nbftOption.Replicas[nbftOption.ID]; !ok || key != publicKey {
+		t.Errorf("nbft replica %s not in %v", nbftOption.ID, nbftOption.Replicas)
+	}
+	if len(nbftOption.Replicas) != 1 || nbftOption.ChainReplicas["01"][nbft.ReplicaID("01", "ID0002")] != publicKey {
+		t.Errorf("nbft replicas %v, replicas of other chains %v", nbftOption.Replicas, nbftOption.ChainReplicas)
+	}
+
+	writeConfig(t, file, "consensus:\n  nbft:\n    replicas:\n      ID0001: "+publicKey+"\n")
+	if errs := Check(file); len(errs) != 1 || !strings.Contains(errs[0].Error(), "a list of replicas") {
+		t.Errorf("map of replicas reported %v", errs)
+	}
+}
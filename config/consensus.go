@@ -19,12 +19,14 @@
 package config
 
 import (
-	"github.com/bocheninc/L0/components/crypto"
-	"github.com/bocheninc/L0/components/utils"
+	"fmt"
+
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/consensus/consenter"
 	"github.com/bocheninc/L0/core/consensus/lbft"
 	"github.com/bocheninc/L0/core/consensus/nbft"
 	"github.com/bocheninc/L0/core/consensus/noops"
+	"github.com/spf13/viper"
 )
 
 func ConsenterOptions() *consenter.Options {
@@ -46,27 +48,29 @@ func NoopsOptions() *noops.Options {
 func NbftOptions() *nbft.Options {
 	option := nbft.NewDefaultOptions()
 	option.Chain = getString("blockchain.id", option.Chain)
-	option.ID = utils.BytesToHex(crypto.Ripemd160(crypto.Ripemd160([]byte(getString("consensus.nbft.id", option.ID) + option.Chain))))
+	option.ID = nbft.ReplicaID(option.Chain, getString("consensus.nbft.id", option.ID))
 	option.N = getInt("consensus.nbft.N", option.N)
 	option.Q = getInt("consensus.nbft.Q", option.Q)
 	option.BlockSize = getInt("consensus.nbft.blockSize", option.BlockSize)
 	option.BlockInterval = getDuration("consensus.nbft.blockInterval", option.BlockInterval)
 	option.BlockTimeout = getDuration("consensus.nbft.blockTimeout", option.BlockTimeout)
 	option.BlockDelay = getDuration("consensus.nbft.blockDelay", option.BlockDelay)
+	option.Replicas, option.ChainReplicas = getReplicas("consensus.nbft.replicas", option.Chain, nbft.ReplicaID)
 	return option
 }
 
 func LbftOptions() *lbft.Options {
 	option := lbft.NewDefaultOptions()
 	option.Chain = getString("blockchain.id", option.Chain)
-	option.ID = option.Chain + ":" + utils.BytesToHex(crypto.Ripemd160([]byte(getString("consensus.lbft.id", option.ID)+option.Chain)))
+	option.ID = lbft.ReplicaID(option.Chain, getString("consensus.lbft.id", option.ID))
 	option.N = getInt("consensus.lbft.N", option.N)
 	option.Q = getInt("consensus.lbft.Q", option.Q)
 	option.K = getInt("consensus.lbft.K", option.K)
+	option.Retain = getInt("consensus.lbft.retain", option.Retain)
 	option.BlockSize = getInt("consensus.lbft.blockSize", option.BlockSize)
 	option.BlockInterval = getDuration("consensus.lbft.blockInterval", option.BlockInterval)
 	option.BlockTimeout = getDuration("consensus.lbft.blockTimeout", option.BlockTimeout)
-	option.BlockDelay = getDuration("consensus.bft.blockDelay", option.BlockDelay)
+	option.BlockDelay = getDuration("consensus.lbft.blockDelay", option.BlockDelay)
 	option.ViewChange = getDuration("consensus.lbft.viewChange", option.ViewChange)
 	option.ResendViewChange = getDuration("consensus.lbft.resendViewChange", option.ViewChange)
 	option.ViewChangePeriod = getDuration("consensus.lbft.viewChangePeriod", option.ViewChangePeriod)
@@ -74,5 +78,43 @@ func LbftOptions() *lbft.Options {
 	option.BufferSize = getInt("consensus.lbft.bufferSize", option.BufferSize)
 	option.MaxConcurrentNumFrom = getInt("consensus.lbft.maxConcurrentNumFrom", option.MaxConcurrentNumFrom)
 	option.MaxConcurrentNumTo = getInt("consensus.lbft.maxConcurrentNumTo", option.MaxConcurrentNumTo)
+	option.Replicas, option.ChainReplicas = getReplicas("consensus.lbft.replicas", option.Chain, lbft.ReplicaID)
 	return option
 }
+
+// replicaOption is a configured replica of chain, our chain if empty.
+// Replicas are listed rather than keyed by id, viper lower-cases map keys
+// and the id must keep its case
+type replicaOption struct {
+	ID        string
+	PublicKey string
+	Chain     string
+}
+
+// getReplicas returns the replica set of chain and the ones of the other
+// chains
+func getReplicas(key, chain string, replicaID func(chain, id string) string) (consensus.Replicas, map[string]consensus.Replicas) {
+	var options []replicaOption
+	if err := viper.UnmarshalKey(key, &options); err != nil {
+		panic(fmt.Errorf("%s config error %v", key, err))
+	}
+	var replicas consensus.Replicas
+	var chainReplicas map[string]consensus.Replicas
+	for _, replica := range options {
+		if replica.Chain == "" || replica.Chain == chain {
+			if replicas == nil {
+				replicas = consensus.Replicas{}
+			}
+			replicas[replicaID(chain, replica.ID)] = replica.PublicKey
+			continue
+		}
+		if chainReplicas == nil {
+			chainReplicas = map[string]consensus.Replicas{}
+		}
+		if chainReplicas[replica.Chain] == nil {
+			chainReplicas[replica.Chain] = consensus.Replicas{}
+		}
+		chainReplicas[replica.Chain][replicaID(replica.Chain, replica.ID)] = replica.PublicKey
+	}
+	return replicas, chainReplicas
+}
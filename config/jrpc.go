@@ -19,6 +19,8 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/bocheninc/L0/rpc"
 	"github.com/spf13/viper"
 )
@@ -29,5 +31,12 @@ func JrpcConfig() *rpc.Option {
 	option.Port = getString("jrpc.port", option.Port)
 	option.User = getString("jrpc.user", option.User)
 	option.PassWord = getString("jrpc.password", option.PassWord)
+	option.TLSCert = getString("jrpc.tls.cert", option.TLSCert)
+	option.TLSKey = getString("jrpc.tls.key", option.TLSKey)
+	option.Methods = getStringSlice("jrpc.methods", option.Methods)
+	option.MinPeers = getInt("jrpc.readiness.minPeers", option.MinPeers)
+	if err := viper.UnmarshalKey("jrpc.tokens", &option.Tokens); err != nil {
+		panic(fmt.Errorf("jrpc.tokens config error %v", err))
+	}
 	return option
 }
@@ -28,7 +28,7 @@ import (
 )
 
 //MergeConfig returns merge configuration
-func MergeConfig(nodeDir string) *merge.Config {
+func MergeConfig(nodeDir string) (*merge.Config, error) {
 	var (
 		config        = merge.DefaultConfig()
 		privkey       *crypto.PrivateKey
@@ -37,28 +37,40 @@ func MergeConfig(nodeDir string) *merge.Config {
 		err           error
 	)
 
-	if !utils.FileExist(nodeKeyFile) {
-		// no configuration and node, generate a new key and store it
-		privkey, _ = crypto.GenerateKey()
-		privkey.SaveECDSA(nodeKeyFile)
-	} else {
-		privkey, err = crypto.LoadECDSA(nodeKeyFile)
-		if err != nil {
+	nodeSigner, err := NodeSigner()
+	if err != nil {
+		return nil, err
+	}
+
+	// the node key is held by the external signer if configured
+	if nodeSigner == nil {
+		if !utils.FileExist(nodeKeyFile) {
+			// no configuration and node, generate a new key and store it
 			privkey, _ = crypto.GenerateKey()
 			privkey.SaveECDSA(nodeKeyFile)
+		} else {
+			privkey, err = crypto.LoadECDSA(nodeKeyFile)
+			if err != nil {
+				privkey, _ = crypto.GenerateKey()
+				privkey.SaveECDSA(nodeKeyFile)
+			}
 		}
-	}
 
-	if hexPrivateKey = viper.GetString("net.privateKey"); hexPrivateKey != "" {
-		privkey, _ = crypto.HexToECDSA(hexPrivateKey)
-		privkey.SaveECDSA(nodeKeyFile)
+		if hexPrivateKey = viper.GetString("net.privateKey"); hexPrivateKey != "" {
+			privkey, _ = crypto.HexToECDSA(hexPrivateKey)
+			privkey.SaveECDSA(nodeKeyFile)
+		}
 	}
 
 	//config.MaxPeers = getInt("net.maxPeers", config.MaxPeers)
 	config.ChainID = getString("blockchain.id", "CHAINID-NOT_SET")
 	config.MaxPeers = getInt("consensus.nbft.N", config.MaxPeers)
-	config.PeerID = utils.BytesToHex(privkey.Public().Bytes())
+	if nodeSigner != nil {
+		config.PeerID = utils.BytesToHex(nodeSigner.PublicKey().Bytes())
+	} else {
+		config.PeerID = utils.BytesToHex(privkey.Public().Bytes())
+	}
 	config.MergeDuration = getDuration("merge.mergeDuration", config.MergeDuration)
 
-	return config
+	return config, nil
 }
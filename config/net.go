@@ -37,21 +37,28 @@ func NetConfig(nodeDir string) *p2p.Config {
 		err           error
 	)
 
-	if !utils.FileExist(nodeKeyFile) {
-		// no configuration and node, generate a new key and store it
-		privkey, _ = crypto.GenerateKey()
-		privkey.SaveECDSA(nodeKeyFile)
-	} else {
-		privkey, err = crypto.LoadECDSA(nodeKeyFile)
-		if err != nil {
+	if config.Signer, err = NodeSigner(); err != nil {
+		panic(err)
+	}
+
+	// the node key is held by the external signer if configured
+	if config.Signer == nil {
+		if !utils.FileExist(nodeKeyFile) {
+			// no configuration and node, generate a new key and store it
 			privkey, _ = crypto.GenerateKey()
 			privkey.SaveECDSA(nodeKeyFile)
+		} else {
+			privkey, err = crypto.LoadECDSA(nodeKeyFile)
+			if err != nil {
+				privkey, _ = crypto.GenerateKey()
+				privkey.SaveECDSA(nodeKeyFile)
+			}
 		}
-	}
 
-	if hexPrivateKey = viper.GetString("net.privateKey"); hexPrivateKey != "" {
-		privkey, _ = crypto.HexToECDSA(hexPrivateKey)
-		privkey.SaveECDSA(nodeKeyFile)
+		if hexPrivateKey = viper.GetString("net.privateKey"); hexPrivateKey != "" {
+			privkey, _ = crypto.HexToECDSA(hexPrivateKey)
+			privkey.SaveECDSA(nodeKeyFile)
+		}
 	}
 
 	config.Address = getString("net.listenAddr", config.Address)
@@ -0,0 +1,117 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/core/merge"
+	"github.com/bocheninc/L0/rpc"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadDelay collects the events of a single save of the config file
+const reloadDelay = 200 * time.Millisecond
+
+// Reloadable are the settings that are safe to change at runtime
+type Reloadable struct {
+	LogLevel       string
+	LogModules     map[string]string
+	RPCTokens      []rpc.Token
+	RPCMethods     []string
+	BootstrapNodes []string
+	TxPoolSize     int
+	MergeDuration  time.Duration
+}
+
+func readReloadable() *Reloadable {
+	option := JrpcConfig()
+	return &Reloadable{
+		LogLevel:       getString("log.level", defaultLogLevel),
+		LogModules:     viper.GetStringMapString("log.modules"),
+		RPCTokens:      option.Tokens,
+		RPCMethods:     option.Methods,
+		BootstrapNodes: getStringSlice("net.bootstrapNodes", nil),
+		TxPoolSize:     getInt("txpool.maxSize", 0),
+		MergeDuration:  getDuration("merge.mergeDuration", merge.DefaultConfig().MergeDuration),
+	}
+}
+
+// Watcher watches the config file for changes
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	quit    chan struct{}
+}
+
+// Watch calls onChange with the reloadable settings whenever the config
+// file changes, changes to an invalid config file are logged and ignored
+func Watch(cfgFile string, onChange func(*Reloadable)) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the directory to pick up editors replacing the file
+	cfgFile = filepath.Clean(cfgFile)
+	if err := watcher.Add(filepath.Dir(cfgFile)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &Watcher{watcher: watcher, quit: make(chan struct{})}
+	go w.loop(cfgFile, onChange)
+	return w, nil
+}
+
+func (w *Watcher) loop(cfgFile string, onChange func(*Reloadable)) {
+	var reload <-chan time.Time
+	for {
+		select {
+		case event := <-w.watcher.Events:
+			if filepath.Clean(event.Name) == cfgFile && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				reload = time.After(reloadDelay)
+			}
+		case err := <-w.watcher.Errors:
+			log.Errorf("watch config file %s error %v", cfgFile, err)
+		case <-reload:
+			reload = nil
+			// validate before touching the settings in use
+			if errs := Check(cfgFile); len(errs) > 0 {
+				log.Errorf("config reload rejected: %v", invalidConfig(cfgFile, errs))
+				continue
+			}
+			if err := readConfigFile(cfgFile); err != nil {
+				log.Errorf("config reload rejected: %v", err)
+				continue
+			}
+			log.Infof("config file %s reloaded", cfgFile)
+			onChange(readReloadable())
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	close(w.quit)
+	return w.watcher.Close()
+}
@@ -0,0 +1,385 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/core/consensus"
+	"github.com/bocheninc/L0/core/p2p"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindBool
+	kindDuration
+	kindStrings
+	// kindStringMap holds arbitrary keys with string values
+	kindStringMap
+	// kindTokens is the list of rpc tokens
+	kindTokens
+	// kindReplicas is the list of consensus replicas
+	kindReplicas
+)
+
+var kindNames = map[valueKind]string{
+	kindString:    "a string",
+	kindInt:       "an integer",
+	kindBool:      "a boolean",
+	kindDuration:  "a duration such as 10s",
+	kindStrings:   "a list of strings",
+	kindStringMap: "a map of strings",
+	kindTokens:    "a list of tokens",
+	kindReplicas:  "a list of replicas",
+}
+
+// schema lists every key read from the config file
+var schema = map[string]valueKind{
+	"blockchain.id":                       kindString,
+	"blockchain.datadir":                  kindString,
+	"blockchain.cpuprofile":               kindString,
+	"blockchain.validator":                kindBool,
+	"issueaddr.addr":                      kindStrings,
+	"txpool.maxSize":                      kindInt,
+	"shutdown.timeout":                    kindDuration,
+	"backup.dir":                          kindString,
+	"backup.keep":                         kindInt,
+	"backup.maxAge":                       kindDuration,
+	"merge.mergeDuration":                 kindDuration,
+	"db.columnfamilies":                   kindStrings,
+	"db.keepLogFileNumber":                kindInt,
+	"db.maxLogFileSize":                   kindInt,
+	"db.loglevel":                         kindString,
+	"log.level":                           kindString,
+	"log.format":                          kindString,
+	"log.maxSize":                         kindInt,
+	"log.rotateEvery":                     kindDuration,
+	"log.maxBackups":                      kindInt,
+	"log.modules":                         kindStringMap,
+	"net.privateKey":                      kindString,
+	"net.listenAddr":                      kindString,
+	"net.bootstrapNodes":                  kindStrings,
+	"net.maxPeers":                        kindInt,
+	"net.minPeers":                        kindInt,
+	"net.reconnectTimes":                  kindInt,
+	"net.connectTimeInterval":             kindInt,
+	"net.keepAliveInterval":               kindInt,
+	"net.keepAliveTimes":                  kindInt,
+	"net.msgnet.routeAddress":             kindStrings,
+	"net.signer.endpoint":                 kindString,
+	"net.signer.address":                  kindString,
+	"signer.endpoint":                     kindString,
+	"signer.approval":                     kindString,
+	"jrpc.enabled":                        kindBool,
+	"jrpc.port":                           kindString,
+	"jrpc.user":                           kindString,
+	"jrpc.password":                       kindString,
+	"jrpc.tls.cert":                       kindString,
+	"jrpc.tls.key":                        kindString,
+	"jrpc.methods":                        kindStrings,
+	"jrpc.tokens":                         kindTokens,
+	"jrpc.readiness.minPeers":             kindInt,
+	"consensus.plugin":                    kindString,
+	"consensus.noops.blockSize":           kindInt,
+	"consensus.noops.blockInterval":       kindDuration,
+	"consensus.lbft.id":                   kindString,
+	"consensus.lbft.N":                    kindInt,
+	"consensus.lbft.Q":                    kindInt,
+	"consensus.lbft.K":                    kindInt,
+	"consensus.lbft.retain":               kindInt,
+	"consensus.lbft.blockSize":            kindInt,
+	"consensus.lbft.blockTimeout":         kindDuration,
+	"consensus.lbft.blockInterval":        kindDuration,
+	"consensus.lbft.blockDelay":           kindDuration,
+	"consensus.lbft.viewChange":           kindDuration,
+	"consensus.lbft.resendViewChange":     kindDuration,
+	"consensus.lbft.viewChangePeriod":     kindDuration,
+	"consensus.lbft.nullRequest":          kindDuration,
+	"consensus.lbft.bufferSize":           kindInt,
+	"consensus.lbft.maxConcurrentNumFrom": kindInt,
+	"consensus.lbft.maxConcurrentNumTo":   kindInt,
+	"consensus.lbft.wal":                  kindString,
+	"consensus.lbft.replicas":             kindReplicas,
+	"consensus.nbft.id":                   kindString,
+	"consensus.nbft.replicas":             kindReplicas,
+	"consensus.nbft.N":                    kindInt,
+	"consensus.nbft.Q":                    kindInt,
+	"consensus.nbft.blockSize":            kindInt,
+	"consensus.nbft.blockTimeout":         kindDuration,
+	"consensus.nbft.blockInterval":        kindDuration,
+	"consensus.nbft.blockDelay":           kindDuration,
+}
+
+// tokenFields are the fields of a jrpc token
+var tokenFields = []string{"name", "token", "scopes", "methods"}
+
+// replicaFields are the fields of a consensus replica
+var replicaFields = []string{"id", "publickey", "chain"}
+
+// schemaKeys maps the lower case keys used by viper to the schema keys
+var schemaKeys = func() map[string]string {
+	m := make(map[string]string, len(schema))
+	for key := range schema {
+		m[strings.ToLower(key)] = key
+	}
+	return m
+}()
+
+// Check reads the config file and returns all its problems
+func Check(cfgFile string) []error {
+	v := viper.New()
+	v.SetConfigFile(cfgFile)
+	if err := v.ReadInConfig(); err != nil {
+		return []error{fmt.Errorf("read %s: %v", cfgFile, err)}
+	}
+	return validate(v)
+}
+
+// validate checks that v has known keys only, holding values of the
+// right kind
+func validate(v *viper.Viper) []error {
+	var errs []error
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		key, ok := schemaKeys[k]
+		if !ok {
+			if parent := parentKey(k); parent != "" {
+				if schema[parent] != kindStringMap {
+					errs = append(errs, fmt.Errorf("%s must be %s, got %s", parent, kindNames[schema[parent]], kindNames[kindStringMap]))
+				} else if _, err := cast.ToStringE(v.Get(k)); err != nil {
+					errs = append(errs, fmt.Errorf("%s must be %s", parent, kindNames[kindStringMap]))
+				}
+				continue
+			}
+			if suggestion := suggestKey(k); suggestion != "" {
+				errs = append(errs, fmt.Errorf("unknown key %s, did you mean %s?", k, suggestion))
+			} else {
+				errs = append(errs, fmt.Errorf("unknown key %s", k))
+			}
+			continue
+		}
+		if err := checkValue(key, schema[key], v.Get(k)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// parentKey returns the key holding k, empty if none
+func parentKey(k string) string {
+	for i := strings.LastIndex(k, "."); i > 0; i = strings.LastIndex(k[:i], ".") {
+		if key, ok := schemaKeys[k[:i]]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
+func checkValue(key string, kind valueKind, value interface{}) error {
+	invalid := fmt.Errorf("%s must be %s, got %v", key, kindNames[kind], value)
+	switch kind {
+	case kindString:
+		s, err := cast.ToStringE(value)
+		if err != nil {
+			return invalid
+		}
+		return checkString(key, s)
+	case kindInt:
+		if _, err := cast.ToIntE(value); err != nil {
+			return invalid
+		}
+	case kindBool:
+		if _, err := cast.ToBoolE(value); err != nil {
+			return invalid
+		}
+	case kindDuration:
+		if _, err := time.ParseDuration(cast.ToString(value)); err != nil {
+			return invalid
+		}
+	case kindStrings:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+		ss, err := cast.ToStringSliceE(value)
+		if err != nil {
+			return invalid
+		}
+		for _, s := range ss {
+			if err := checkString(key, s); err != nil {
+				return err
+			}
+		}
+	case kindStringMap:
+		m, err := cast.ToStringMapStringE(value)
+		if err != nil {
+			return invalid
+		}
+		if key == "log.modules" {
+			for module, level := range m {
+				if _, err := logrus.ParseLevel(level); err != nil {
+					return fmt.Errorf("log.modules.%s: %v", module, err)
+				}
+			}
+		}
+	case kindTokens:
+		tokens, ok := value.([]interface{})
+		if !ok {
+			return invalid
+		}
+		for i, token := range tokens {
+			fields, err := cast.ToStringMapE(token)
+			if err != nil {
+				return fmt.Errorf("%s[%d] must be a map", key, i)
+			}
+			for field := range fields {
+				if !contains(tokenFields, strings.ToLower(field)) {
+					return fmt.Errorf("unknown key %s[%d].%s, want one of %s", key, i, field, strings.Join(tokenFields, ", "))
+				}
+			}
+			if cast.ToString(fields["token"]) == "" {
+				return fmt.Errorf("%s[%d].token is required", key, i)
+			}
+		}
+	case kindReplicas:
+		replicas, ok := value.([]interface{})
+		if !ok {
+			return invalid
+		}
+		for i, replica := range replicas {
+			fields, err := cast.ToStringMapStringE(replica)
+			if err != nil {
+				return fmt.Errorf("%s[%d] must be a map of strings", key, i)
+			}
+			change := &consensus.ReplicaChange{Op: consensus.ReplicaAdd}
+			for field, value := range fields {
+				switch strings.ToLower(field) {
+				case "id":
+					change.ID = value
+				case "publickey":
+					change.PublicKey = value
+				case "chain":
+					if err := checkString("blockchain.id", value); err != nil {
+						return fmt.Errorf("%s[%d].chain must be a hex chain coordinate such as 00 or 0001, got %q", key, i, value)
+					}
+				default:
+					return fmt.Errorf("unknown key %s[%d].%s, want one of %s", key, i, field, strings.Join(replicaFields, ", "))
+				}
+			}
+			if err := change.Validate(); err != nil {
+				return fmt.Errorf("%s[%d]: %v", key, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkString checks the values with a format of their own
+func checkString(key, s string) error {
+	switch key {
+	case "blockchain.id":
+		if b, err := hex.DecodeString(s); err != nil || len(b) == 0 {
+			return fmt.Errorf("%s must be a hex chain coordinate such as 00 or 0001, got %q", key, s)
+		}
+	case "log.level", "db.loglevel":
+		if _, err := logrus.ParseLevel(s); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	case "log.format":
+		if s != log.FormatText && s != log.FormatJSON {
+			return fmt.Errorf("%s must be %s or %s, got %q", key, log.FormatText, log.FormatJSON, s)
+		}
+	case "net.privateKey":
+		if _, err := crypto.HexToECDSA(s); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	case "net.bootstrapNodes":
+		if peer, err := p2p.ParsePeer(s); err != nil || len(peer.ID) == 0 {
+			return fmt.Errorf("%s: invalid peer %q, want encode://<node id>@<host>:<port>", key, s)
+		}
+	case "consensus.plugin":
+		if !contains([]string{"noops", "lbft", "nbft"}, strings.ToLower(s)) {
+			return fmt.Errorf("%s must be noops, lbft or nbft, got %q", key, s)
+		}
+	}
+	return nil
+}
+
+// suggestKey returns the schema key closest to the unknown key k
+func suggestKey(k string) string {
+	lowers := make([]string, 0, len(schemaKeys))
+	for lower := range schemaKeys {
+		lowers = append(lowers, lower)
+	}
+	sort.Strings(lowers)
+
+	best, bestDistance := "", len(k)/3+1
+	for _, lower := range lowers {
+		if d := editDistance(k, lower); d < bestDistance {
+			best, bestDistance = schemaKeys[lower], d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cur[j] = prev[j-1]
+			if a[i-1] != b[j-1] {
+				cur[j]++
+			}
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
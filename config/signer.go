@@ -0,0 +1,80 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/accounts/signer"
+	"github.com/spf13/viper"
+)
+
+var nodeSigner *signer.KeySigner
+
+// NodeSigner returns the node key signer configured by net.signer, it returns
+// nil if the node key is kept in the local nodekey file
+func NodeSigner() (*signer.KeySigner, error) {
+	endpoint := viper.GetString("net.signer.endpoint")
+	if endpoint == "" {
+		return nil, nil
+	}
+	if nodeSigner != nil {
+		return nodeSigner, nil
+	}
+
+	s, err := signer.New(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	addr := accounts.Address{}
+	if hexAddr := viper.GetString("net.signer.address"); hexAddr != "" {
+		addr = accounts.HexToAddress(hexAddr)
+	}
+	if nodeSigner, err = signer.Bind(s, addr); err != nil {
+		return nil, fmt.Errorf("bind node signer %s error %v", endpoint, err)
+	}
+	return nodeSigner, nil
+}
+
+// AccountSigner returns the account signer configured by signer.endpoint,
+// requests are approved according signer.approval (auto, console or deny)
+func AccountSigner() (signer.Signer, error) {
+	endpoint := viper.GetString("signer.endpoint")
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	s, err := signer.New(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	switch approval := getString("signer.approval", "auto"); approval {
+	case "auto":
+		return s, nil
+	case "console":
+		return signer.NewApprovalSigner(s, signer.NewConsoleUI(os.Stdin, os.Stdout)), nil
+	case "deny":
+		return signer.NewApprovalSigner(s, signer.AutoUI(false)), nil
+	default:
+		return nil, fmt.Errorf("unknown signer approval %s", approval)
+	}
+}
@@ -19,27 +19,33 @@
 package keystore
 
 import (
+	"crypto/ecdsa"
 	crand "crypto/rand"
 	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/db"
 
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/accounts/signer"
 	"github.com/bocheninc/L0/core/types"
 )
 
 var (
 	ErrNoMatch = errors.New("no key for given address or file")
 	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
+	ErrLocked  = errors.New("account is locked")
+	ErrExists  = errors.New("account already exists")
 )
 
 var columnFamily = "account"
 var KeyStoreScheme = "keystore"
+var SignerScheme = "signer"
 var ksInstance *KeyStore
 var ksPInstance *KeyStore
 var once sync.Once
@@ -49,6 +55,15 @@ type KeyStore struct {
 	storage keyStore
 	db      *db.BlockchainDB
 	ksDir   string
+	signer  signer.Signer
+
+	mu       sync.RWMutex
+	unlocked map[accounts.Address]*unlocked
+}
+
+type unlocked struct {
+	*Key
+	abort chan struct{}
 }
 
 // NewKeyStore new a KeyStore instance
@@ -58,7 +73,7 @@ func NewKeyStore(db *db.BlockchainDB, keydir string, scryptN, scryptP int) *KeyS
 		if err != nil {
 			panic(err)
 		}
-		ksInstance = &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP}}
+		ksInstance = &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP}, unlocked: make(map[accounts.Address]*unlocked)}
 		ksInstance.db = db
 		ksInstance.ksDir = keydir
 	})
@@ -72,18 +87,23 @@ func NewPlaintextKeyStore(db *db.BlockchainDB, keydir string) *KeyStore {
 		if err != nil {
 			panic(err)
 		}
-		ksPInstance = &KeyStore{storage: &keyStorePlain{keydir}}
+		ksPInstance = &KeyStore{storage: &keyStorePlain{keydir}, unlocked: make(map[accounts.Address]*unlocked)}
 		ksPInstance.db = db
 		ksPInstance.ksDir = keydir
 	})
 	return ksPInstance
 }
 
+// SetSigner sets the external signer, accounts held by it are signed without passphrase
+func (ks *KeyStore) SetSigner(s signer.Signer) {
+	ks.signer = s
+}
+
 // HasAddress returns if current node has the specified addr
 func (ks *KeyStore) HasAddress(addr accounts.Address) bool {
 	a, _ := ks.db.Get(columnFamily, addr.Bytes())
 	if len(a) == 0 {
-		return false
+		return ks.signerAccount(addr) != nil
 	}
 	return true
 }
@@ -92,6 +112,9 @@ func (ks *KeyStore) Find(addr accounts.Address) *accounts.Account {
 	var account accounts.Account
 	a, _ := ks.db.Get(columnFamily, addr.Bytes())
 	if len(a) == 0 {
+		if sa := ks.signerAccount(addr); sa != nil {
+			return sa
+		}
 		return &account
 	}
 	account.Deserialize(a)
@@ -133,6 +156,36 @@ func (ks *KeyStore) NewAccount(passphrase string, accountType uint32) (accounts.
 	return a, nil
 }
 
+// ImportECDSA stores the private key as a new account
+func (ks *KeyStore) ImportECDSA(priv *crypto.PrivateKey, passphrase string, accountType uint32) (accounts.Account, error) {
+	key := newKeyFromECDSA((*ecdsa.PrivateKey)(priv))
+	if ks.HasAddress(key.Address) {
+		return accounts.Account{}, ErrExists
+	}
+	a := accounts.Account{
+		PublicKey:   key.PrivateKey.Public(),
+		URL:         accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))},
+		Address:     key.Address,
+		AccountType: accountType,
+	}
+	if err := ks.storage.StoreKey(a.URL.Path, key, passphrase); err != nil {
+		return accounts.Account{}, err
+	}
+	if err := ks.db.Put(columnFamily, a.Address.Bytes(), a.Serialize()); err != nil {
+		return accounts.Account{}, err
+	}
+	return a, nil
+}
+
+// Export returns the key of the account encrypted with newPassphrase
+func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string, scryptN, scryptP int) ([]byte, error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptKey(key, newPassphrase, scryptN, scryptP)
+}
+
 // Delete removes the speciified account
 func (ks *KeyStore) Delete(a accounts.Account, passphrase string) error {
 	a, key, err := ks.getDecryptedKey(a, passphrase)
@@ -147,6 +200,7 @@ func (ks *KeyStore) Delete(a accounts.Account, passphrase string) error {
 		return err
 	}
 	err = ks.db.Delete(columnFamily, a.Address.Bytes())
+	ks.Lock(a.Address)
 	return err
 }
 
@@ -161,10 +215,15 @@ func (ks *KeyStore) Update(a accounts.Account, passphrase, newPassphrase string)
 
 // SignTx sign the specified transaction
 func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, pass string) (*types.Transaction, error) {
+	if ks.signerAccount(a.Address) != nil {
+		return ks.signTxWithSigner(a, tx)
+	}
+
 	_, key, err := ks.getDecryptedKey(a, pass)
 	if err != nil {
 		return nil, err
 	}
+	defer crypto.ZeroKey(key.PrivateKey)
 
 	priv := key.PrivateKey
 	sig, err1 := priv.Sign(tx.Hash().Bytes())
@@ -175,6 +234,120 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, pass strin
 	return tx, nil
 }
 
+// SignTxUnlocked signs the transaction with an unlocked account or an account held by the external signer
+func (ks *KeyStore) SignTxUnlocked(a accounts.Account, tx *types.Transaction) (*types.Transaction, error) {
+	if ks.signerAccount(a.Address) != nil {
+		return ks.signTxWithSigner(a, tx)
+	}
+
+	// the key is zeroed by expire under the write lock
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	u, ok := ks.unlocked[a.Address]
+	if !ok {
+		return nil, ErrLocked
+	}
+	sig, err := u.PrivateKey.Sign(tx.Hash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	tx.WithSignature(sig)
+	return tx, nil
+}
+
+func (ks *KeyStore) signTxWithSigner(a accounts.Account, tx *types.Transaction) (*types.Transaction, error) {
+	sig, err := ks.signer.SignHash(a.Address, tx.Hash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	tx.WithSignature(sig)
+	return tx, nil
+}
+
+// Unlock unlocks the given account indefinitely.
+func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
+	return ks.TimedUnlock(a, passphrase, 0)
+}
+
+// Lock removes the private key with the given address from memory.
+func (ks *KeyStore) Lock(addr accounts.Address) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if u, found := ks.unlocked[addr]; found {
+		if u.abort != nil {
+			// Terminate the expire goroutine of a timed unlock.
+			close(u.abort)
+		}
+		crypto.ZeroKey(u.PrivateKey)
+		delete(ks.unlocked, addr)
+	}
+	return nil
+}
+
+// TimedUnlock unlocks the given account with the passphrase. The account
+// stays unlocked for the duration of timeout. A timeout of 0 unlocks the account
+// until the program exits. The account must match a unique key file.
+//
+// If the account address is already unlocked for a duration, TimedUnlock extends or
+// shortens the active unlock timeout. If the address was previously unlocked
+// indefinitely the timeout is not altered.
+func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout time.Duration) error {
+	a, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	u, found := ks.unlocked[a.Address]
+	if found {
+		if u.abort == nil {
+			// The address was unlocked indefinitely, so unlocking
+			// it with a timeout would be confusing.
+			crypto.ZeroKey(key.PrivateKey)
+			return nil
+		}
+		// Terminate the expire goroutine and replace it below.
+		close(u.abort)
+	}
+	if timeout > 0 {
+		u = &unlocked{Key: key, abort: make(chan struct{})}
+		go ks.expire(a.Address, u, timeout)
+	} else {
+		u = &unlocked{Key: key}
+	}
+	ks.unlocked[a.Address] = u
+	return nil
+}
+
+// IsUnlocked returns if the account is unlocked
+func (ks *KeyStore) IsUnlocked(addr accounts.Address) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	_, ok := ks.unlocked[addr]
+	return ok
+}
+
+func (ks *KeyStore) expire(addr accounts.Address, u *unlocked, timeout time.Duration) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-u.abort:
+		// just quit
+	case <-t.C:
+		ks.mu.Lock()
+		// only drop if it's still the same key instance that dropLater
+		// was launched with. we can check that using pointer equality
+		// because the map stores a new pointer every time the key is
+		// unlocked.
+		if ks.unlocked[addr] == u {
+			crypto.ZeroKey(u.PrivateKey)
+			delete(ks.unlocked, addr)
+		}
+		ks.mu.Unlock()
+	}
+}
+
 // SignHashWithPassphrase signs hash if the private key matching the given address
 // can be decrypted with the given passphrase. The produced signature is in the
 // [R || S || V] format where V is 0 or 1.
@@ -191,6 +364,22 @@ func (ks *KeyStore) SignHashWithPassphrase(a accounts.Account, passphrase string
 	return sig.Bytes(), nil
 }
 
+// signerAccount returns the account if its key is held by the external signer
+func (ks *KeyStore) signerAccount(addr accounts.Address) *accounts.Account {
+	if ks.signer == nil {
+		return nil
+	}
+	pub, err := ks.signer.PublicKey(addr)
+	if err != nil {
+		return nil
+	}
+	return &accounts.Account{
+		URL:       accounts.URL{Scheme: SignerScheme, Path: addr.String()},
+		PublicKey: pub,
+		Address:   addr,
+	}
+}
+
 func (ks *KeyStore) getDecryptedKey(a accounts.Account, auth string) (accounts.Account, *Key, error) {
 	addr := accounts.PublicKeyToAddress(*a.PublicKey)
 	key, err := ks.storage.GetKey(addr, a.URL.Path, auth)
@@ -22,14 +22,20 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/db"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/accounts/signer"
+	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/types"
 )
 
 var testSigData = make([]byte, 32)
@@ -103,6 +109,44 @@ func TestAccountSerialize(t *testing.T) {
 	}
 }
 
+func TestImportExport(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ks.ImportECDSA(priv, "foo", accounts.AccountTypeCommon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Address != accounts.PublicKeyToAddress(*priv.Public()) {
+		t.Errorf("imported address %s", a.Address)
+	}
+	if _, err := ks.ImportECDSA(priv, "foo", accounts.AccountTypeCommon); err != ErrExists {
+		t.Errorf("import twice error %v, want %v", err, ErrExists)
+	}
+
+	if _, err := ks.Export(a, "wrong", "bar", veryLightScryptN, veryLightScryptP); err == nil {
+		t.Error("export with wrong passphrase should fail")
+	}
+	keyJSON, err := ks.Export(a, "foo", "bar", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(keyJSON, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Address != a.Address || !bytes.Equal(key.PrivateKey.SecretBytes(), priv.SecretBytes()) {
+		t.Errorf("exported key mismatch")
+	}
+	if err := ks.Delete(a, "foo"); err != nil {
+		t.Error(err)
+	}
+}
+
 func tmpKeyStore(t *testing.T, encrypted bool) (string, *KeyStore) {
 	d, err := ioutil.TempDir("", "eth-keystore-test")
 	if err != nil {
@@ -121,3 +165,102 @@ func tmpKeyStore(t *testing.T, encrypted bool) (string, *KeyStore) {
 	}
 	return d, NewPlaintextKeyStore(db, d)
 }
+
+func TestSignTxWithSigner(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	si, err := signer.NewStandIn(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer si.Close()
+	rs, err := signer.NewRemoteSigner(si.Endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close()
+
+	ks.SetSigner(rs)
+	defer ks.SetSigner(nil)
+
+	addr := si.Accounts[0]
+	if !ks.HasAddress(addr) {
+		t.Fatalf("HasAddress(%s) should've returned true for signer account", addr)
+	}
+	tx := types.NewTransaction(coordinate.NewChainCoordinate([]byte{0}), coordinate.NewChainCoordinate([]byte{0}), types.TypeAtomic, 1, addr, accounts.Address{}, big.NewInt(1), big.NewInt(1), utils.CurrentTimestamp())
+	if _, err := ks.SignTx(*ks.Find(addr), tx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if sender, err := tx.Verfiy(); err != nil || sender != addr {
+		t.Fatalf("tx sender %s, want %s, error %v", sender, addr, err)
+	}
+}
+
+func TestTimedUnlock(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "foo"
+	a1, err := ks.NewAccount(pass, accounts.AccountTypeCommon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := types.NewTransaction(coordinate.NewChainCoordinate([]byte{0}), coordinate.NewChainCoordinate([]byte{0}), types.TypeAtomic, 1, a1.Address, accounts.Address{}, big.NewInt(1), big.NewInt(1), utils.CurrentTimestamp())
+	if _, err := ks.SignTxUnlocked(a1, tx); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked before unlocking, got ", err)
+	}
+	if err := ks.TimedUnlock(a1, "bar", 100*time.Millisecond); err == nil {
+		t.Fatal("TimedUnlock should've failed with wrong passphrase")
+	}
+
+	// Signing with passphrase works
+	if err = ks.TimedUnlock(a1, pass, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing without passphrase works because account is temp unlocked
+	if _, err := ks.SignTxUnlocked(a1, tx); err != nil {
+		t.Fatal("Signing shouldn't return an error after unlocking, got ", err)
+	}
+	if sender, err := tx.Verfiy(); err != nil || sender != a1.Address {
+		t.Fatalf("tx sender %s, want %s, error %v", sender, a1.Address, err)
+	}
+	// SignTx still requires the passphrase of an unlocked account
+	if _, err := ks.SignTx(a1, tx, "bar"); err != ErrDecrypt {
+		t.Fatal("SignTx should've failed with a wrong passphrase, got ", err)
+	}
+
+	// Signing fails again after automatic locking
+	time.Sleep(250 * time.Millisecond)
+	if _, err := ks.SignTxUnlocked(a1, tx); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked timeout expired, got ", err)
+	}
+
+	// Lock drops an indefinitely unlocked account
+	if err = ks.Unlock(a1, pass); err != nil {
+		t.Fatal(err)
+	}
+	if !ks.IsUnlocked(a1.Address) {
+		t.Fatal("account should be unlocked")
+	}
+	ks.Lock(a1.Address)
+	if ks.IsUnlocked(a1.Address) {
+		t.Fatal("account should be locked")
+	}
+
+	// Lock stops the expire goroutine of a timed unlock
+	if err = ks.TimedUnlock(a1, pass, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ks.mu.RLock()
+	abort := ks.unlocked[a1.Address].abort
+	ks.mu.RUnlock()
+	ks.Lock(a1.Address)
+	select {
+	case <-abort:
+	default:
+		t.Fatal("expire goroutine not aborted")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+)
+
+// SignRequest describes a sign request waiting for approval
+type SignRequest struct {
+	Address accounts.Address
+	Hash    utils.Bytes
+}
+
+// UI approves or rejects sign requests
+type UI interface {
+	ApproveSignHash(req *SignRequest) (bool, error)
+}
+
+// AutoUI approves every request when true and rejects every request when false
+type AutoUI bool
+
+// ApproveSignHash implements UI
+func (ui AutoUI) ApproveSignHash(req *SignRequest) (bool, error) {
+	return bool(ui), nil
+}
+
+// ConsoleUI asks the operator to confirm every request
+type ConsoleUI struct {
+	mu  sync.Mutex
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewConsoleUI returns an ui reading answers from in and writing prompts to out
+func NewConsoleUI(in io.Reader, out io.Writer) *ConsoleUI {
+	return &ConsoleUI{in: bufio.NewReader(in), out: out}
+}
+
+// ApproveSignHash implements UI
+func (ui *ConsoleUI) ApproveSignHash(req *SignRequest) (bool, error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	fmt.Fprintf(ui.out, "Sign hash %s with account %s? [y/N] ", utils.BytesToHex(req.Hash), req.Address)
+	answer, err := ui.in.ReadString('\n')
+	if err != nil && answer == "" {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// ApprovalSigner forwards sign requests to the inner signer after they are approved by the ui
+type ApprovalSigner struct {
+	Signer
+	ui UI
+}
+
+// NewApprovalSigner returns a signer asking ui before every signature
+func NewApprovalSigner(s Signer, ui UI) *ApprovalSigner {
+	return &ApprovalSigner{Signer: s, ui: ui}
+}
+
+// SignHash signs the hash if the request is approved
+func (as *ApprovalSigner) SignHash(addr accounts.Address, hash []byte) (*crypto.Signature, error) {
+	ok, err := as.ui.ApproveSignHash(&SignRequest{Address: addr, Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		log.Warnf("sign request for %s hash %s denied", addr, utils.BytesToHex(hash))
+		return nil, ErrRequestDenied
+	}
+	return as.Signer.SignHash(addr, hash)
+}
@@ -0,0 +1,307 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+)
+
+const serviceName = "Signer"
+
+// SignHashArgs are the arguments of Signer.SignHash
+type SignHashArgs struct {
+	Address accounts.Address
+	Hash    utils.Bytes
+}
+
+// Service exposes a signer as json-rpc service
+type Service struct {
+	signer Signer
+}
+
+// Accounts returns the addresses of the signer
+func (s *Service) Accounts(ignore string, reply *[]accounts.Address) error {
+	addrs, err := s.signer.Accounts()
+	if err != nil {
+		return err
+	}
+	*reply = addrs
+	return nil
+}
+
+// PublicKey returns the public key bytes of the address
+func (s *Service) PublicKey(addr accounts.Address, reply *utils.Bytes) error {
+	pub, err := s.signer.PublicKey(addr)
+	if err != nil {
+		return err
+	}
+	*reply = pub.Bytes()
+	return nil
+}
+
+// SignHash signs the hash with the key of the address
+func (s *Service) SignHash(args *SignHashArgs, reply *crypto.Signature) error {
+	sig, err := s.signer.SignHash(args.Address, args.Hash)
+	if err != nil {
+		return err
+	}
+	*reply = *sig
+	return nil
+}
+
+// Daemon serves a signer over a unix socket or http, http requests must
+// carry the token of the endpoint as bearer token
+type Daemon struct {
+	server   *rpc.Server
+	mu       sync.Mutex
+	listener net.Listener
+	token    string
+}
+
+// NewDaemon returns a signer daemon
+func NewDaemon(s Signer) *Daemon {
+	server := rpc.NewServer()
+	server.RegisterName(serviceName, &Service{signer: s})
+	return &Daemon{server: server}
+}
+
+// Listen listens on the endpoint, unix://<socket> or http://<token>@<host:port>
+func (d *Daemon) Listen(endpoint string) error {
+	var (
+		listener net.Listener
+		err      error
+	)
+	switch {
+	case strings.HasPrefix(endpoint, "unix://"):
+		path := strings.TrimPrefix(endpoint, "unix://")
+		os.Remove(path)
+		if listener, err = net.Listen("unix", path); err != nil {
+			return err
+		}
+		os.Chmod(path, 0600)
+		go d.serveConns(listener)
+	case strings.HasPrefix(endpoint, "http://"):
+		u, token, err := parseHTTPEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+		if listener, err = net.Listen("tcp", u.Host); err != nil {
+			return err
+		}
+		d.token = token
+		go http.Serve(listener, d)
+		endpoint = u.String()
+	default:
+		return fmt.Errorf("signer: unsupported endpoint %s", endpoint)
+	}
+
+	d.mu.Lock()
+	d.listener = listener
+	d.mu.Unlock()
+	log.Infof("signer daemon listening on %s", endpoint)
+	return nil
+}
+
+// Close stops the daemon
+func (d *Daemon) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.listener == nil {
+		return nil
+	}
+	err := d.listener.Close()
+	d.listener = nil
+	return err
+}
+
+func (d *Daemon) serveConns(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+type httpConn struct {
+	in  io.Reader
+	out io.Writer
+}
+
+func (c *httpConn) Read(p []byte) (n int, err error)  { return c.in.Read(p) }
+func (c *httpConn) Write(d []byte) (n int, err error) { return c.out.Write(d) }
+func (c *httpConn) Close() error                      { return nil }
+
+// ServeHTTP serves one json-rpc request per http post
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") || subtle.ConstantTimeCompare([]byte(auth[len("Bearer "):]), []byte(d.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-type", "application/json")
+	if err := d.server.ServeRequest(jsonrpc.NewServerCodec(&httpConn{in: r.Body, out: w})); err != nil {
+		log.Errorf("signer daemon serve request error %v", err)
+	}
+}
+
+// parseHTTPEndpoint splits http://<token>@<host:port> into the url without
+// the token and the token
+func parseHTTPEndpoint(endpoint string) (*url.URL, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, "", fmt.Errorf("signer: http endpoint %s://%s has no token", u.Scheme, u.Host)
+	}
+	token := u.User.Username()
+	u.User = nil
+	return u, token, nil
+}
+
+// RemoteSigner talks to a signer daemon
+type RemoteSigner struct {
+	endpoint string
+	token    string
+	client   *rpc.Client
+	seq      uint64
+}
+
+// NewRemoteSigner returns a signer connected to the daemon at the endpoint
+func NewRemoteSigner(endpoint string) (*RemoteSigner, error) {
+	if strings.HasPrefix(endpoint, "unix://") {
+		conn, err := net.Dial("unix", strings.TrimPrefix(endpoint, "unix://"))
+		if err != nil {
+			return nil, err
+		}
+		return &RemoteSigner{endpoint: endpoint, client: jsonrpc.NewClient(conn)}, nil
+	}
+	u, token, err := parseHTTPEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteSigner{endpoint: u.String(), token: token}, nil
+}
+
+// Close closes the connection to the daemon
+func (rs *RemoteSigner) Close() error {
+	if rs.client != nil {
+		return rs.client.Close()
+	}
+	return nil
+}
+
+// Accounts returns the addresses of the remote signer
+func (rs *RemoteSigner) Accounts() ([]accounts.Address, error) {
+	var addrs []accounts.Address
+	err := rs.call("Accounts", "", &addrs)
+	return addrs, err
+}
+
+// PublicKey returns the public key of the address
+func (rs *RemoteSigner) PublicKey(addr accounts.Address) (*crypto.PublicKey, error) {
+	var pub utils.Bytes
+	if err := rs.call("PublicKey", addr, &pub); err != nil {
+		return nil, err
+	}
+	pk := crypto.ToECDSAPub(pub)
+	if pk == nil || pk.X == nil {
+		return nil, errors.New("signer: invalid public key")
+	}
+	return pk, nil
+}
+
+// SignHash asks the remote signer to sign the hash
+func (rs *RemoteSigner) SignHash(addr accounts.Address, hash []byte) (*crypto.Signature, error) {
+	sig := new(crypto.Signature)
+	if err := rs.call("SignHash", &SignHashArgs{Address: addr, Hash: hash}, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (rs *RemoteSigner) call(method string, args interface{}, reply interface{}) error {
+	method = serviceName + "." + method
+	if rs.client != nil {
+		return rs.client.Call(method, args, reply)
+	}
+
+	req, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": []interface{}{args},
+		"id":     atomic.AddUint64(&rs.seq, 1),
+	})
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, rs.endpoint, bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+rs.token)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("signer: %s", resp.Status)
+	}
+
+	var res struct {
+		Result *json.RawMessage `json:"result"`
+		Error  interface{}      `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%v", res.Error)
+	}
+	if res.Result == nil {
+		return errors.New("signer: empty result")
+	}
+	return json.Unmarshal(*res.Result, reply)
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/accounts"
+)
+
+var (
+	// ErrUnknownAccount is returned when the signer holds no key for the address
+	ErrUnknownAccount = errors.New("signer: unknown account")
+	// ErrRequestDenied is returned when a sign request is rejected by the approval ui
+	ErrRequestDenied = errors.New("signer: request denied")
+)
+
+// Signer signs hashes with keys that may live outside the node process
+type Signer interface {
+	// Accounts returns the addresses the signer holds keys for
+	Accounts() ([]accounts.Address, error)
+	// PublicKey returns the public key of the address
+	PublicKey(addr accounts.Address) (*crypto.PublicKey, error)
+	// SignHash signs the 32 bytes hash with the key of the address
+	SignHash(addr accounts.Address, hash []byte) (*crypto.Signature, error)
+}
+
+// New returns a signer according the endpoint, supported schemes are
+// file://<keyfile>, unix://<socket> and http://<token>@<host:port>
+func New(endpoint string) (Signer, error) {
+	switch {
+	case strings.HasPrefix(endpoint, "file://"):
+		return NewLocalSigner(strings.TrimPrefix(endpoint, "file://"))
+	case strings.HasPrefix(endpoint, "unix://"),
+		strings.HasPrefix(endpoint, "http://"):
+		return NewRemoteSigner(endpoint)
+	}
+	return nil, fmt.Errorf("signer: unsupported endpoint %s", endpoint)
+}
+
+// LocalSigner signs with private keys loaded into process memory
+type LocalSigner struct {
+	mu   sync.RWMutex
+	keys map[accounts.Address]*crypto.PrivateKey
+}
+
+// NewLocalSigner returns a signer holding the keys of the given key files
+func NewLocalSigner(files ...string) (*LocalSigner, error) {
+	ls := NewLocalSignerFromKeys()
+	for _, file := range files {
+		priv, err := crypto.LoadECDSA(file)
+		if err != nil {
+			return nil, fmt.Errorf("signer: load key file %s error %v", file, err)
+		}
+		ls.Add(priv)
+	}
+	return ls, nil
+}
+
+// NewLocalSignerFromKeys returns a signer holding the given keys
+func NewLocalSignerFromKeys(keys ...*crypto.PrivateKey) *LocalSigner {
+	ls := &LocalSigner{keys: make(map[accounts.Address]*crypto.PrivateKey)}
+	for _, priv := range keys {
+		ls.Add(priv)
+	}
+	return ls
+}
+
+// Add adds the key to the signer and returns its address
+func (ls *LocalSigner) Add(priv *crypto.PrivateKey) accounts.Address {
+	addr := accounts.PublicKeyToAddress(*priv.Public())
+	ls.mu.Lock()
+	ls.keys[addr] = priv
+	ls.mu.Unlock()
+	return addr
+}
+
+// Accounts returns the addresses of the loaded keys
+func (ls *LocalSigner) Accounts() ([]accounts.Address, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	var addrs []accounts.Address
+	for addr := range ls.keys {
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// PublicKey returns the public key of the address
+func (ls *LocalSigner) PublicKey(addr accounts.Address) (*crypto.PublicKey, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	priv, ok := ls.keys[addr]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	return priv.Public(), nil
+}
+
+// SignHash signs the hash with the key of the address
+func (ls *LocalSigner) SignHash(addr accounts.Address, hash []byte) (*crypto.Signature, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	priv, ok := ls.keys[addr]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	return priv.Sign(hash)
+}
+
+// KeySigner binds a signer to a single address, such as the node key
+type KeySigner struct {
+	signer    Signer
+	address   accounts.Address
+	publicKey *crypto.PublicKey
+}
+
+// Bind returns a KeySigner for the address, the address may be empty only
+// if the signer holds a single account
+func Bind(s Signer, addr accounts.Address) (*KeySigner, error) {
+	if addr == (accounts.Address{}) {
+		addrs, err := s.Accounts()
+		if err != nil {
+			return nil, err
+		}
+		switch len(addrs) {
+		case 0:
+			return nil, ErrUnknownAccount
+		case 1:
+			addr = addrs[0]
+		default:
+			return nil, fmt.Errorf("signer: %d accounts, the address to bind is required", len(addrs))
+		}
+	}
+	pub, err := s.PublicKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySigner{signer: s, address: addr, publicKey: pub}, nil
+}
+
+// Address returns the bound address
+func (ks *KeySigner) Address() accounts.Address {
+	return ks.address
+}
+
+// PublicKey returns the public key of the bound address
+func (ks *KeySigner) PublicKey() *crypto.PublicKey {
+	return ks.publicKey
+}
+
+// SignHash signs the hash with the bound key
+func (ks *KeySigner) SignHash(hash []byte) (*crypto.Signature, error) {
+	return ks.signer.SignHash(ks.address, hash)
+}
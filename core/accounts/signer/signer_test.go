@@ -0,0 +1,162 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/accounts"
+)
+
+var testHash = crypto.Sha256([]byte("signer test"))
+
+func checkSignature(t *testing.T, s Signer, addr accounts.Address) {
+	sig, err := s.SignHash(addr, testHash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := sig.RecoverPublicKey(testHash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accounts.PublicKeyToAddress(*pub) != addr {
+		t.Fatalf("signature recovered %s, want %s", accounts.PublicKeyToAddress(*pub), addr)
+	}
+}
+
+func TestLocalSigner(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "l0-signer-test")
+	defer os.RemoveAll(dir)
+
+	priv, _ := crypto.GenerateKey()
+	file := filepath.Join(dir, "nodekey")
+	priv.SaveECDSA(file)
+
+	s, err := New("file://" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := accounts.PublicKeyToAddress(*priv.Public())
+	checkSignature(t, s, addr)
+
+	if _, err := s.SignHash(accounts.Address{}, testHash[:]); err != ErrUnknownAccount {
+		t.Fatalf("expected ErrUnknownAccount, got %v", err)
+	}
+}
+
+func TestRemoteSignerUnix(t *testing.T) {
+	si, err := NewStandIn(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer si.Close()
+
+	s, err := New(si.Endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.(*RemoteSigner).Close()
+
+	addrs, err := s.Accounts()
+	if err != nil || len(addrs) != 2 {
+		t.Fatalf("accounts %v, error %v", addrs, err)
+	}
+	for _, addr := range si.Accounts {
+		checkSignature(t, s, addr)
+	}
+
+	if _, err := Bind(s, accounts.Address{}); err == nil {
+		t.Fatal("bound without an address to a signer of several accounts")
+	}
+	ks, err := Bind(s, si.Accounts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accounts.PublicKeyToAddress(*ks.PublicKey()) != si.Accounts[1] {
+		t.Fatal("bound public key mismatch")
+	}
+	if _, err := s.SignHash(accounts.Address{}, testHash[:]); err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+}
+
+func TestRemoteSignerHTTP(t *testing.T) {
+	priv, _ := crypto.GenerateKey()
+	ls := NewLocalSignerFromKeys(priv)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	d := NewDaemon(ls)
+	if err := d.Listen("http://" + addr); err == nil {
+		t.Fatal("http daemon listening without a token")
+	}
+	if err := d.Listen("http://secret@" + addr); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	s, err := New("http://secret@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSignature(t, s, accounts.PublicKeyToAddress(*priv.Public()))
+
+	s, err = New("http://wrong@" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Accounts(); err == nil {
+		t.Fatal("request with a wrong token served")
+	}
+}
+
+func TestApprovalSigner(t *testing.T) {
+	priv, _ := crypto.GenerateKey()
+	addr := accounts.PublicKeyToAddress(*priv.Public())
+	ls := NewLocalSignerFromKeys(priv)
+
+	checkSignature(t, NewApprovalSigner(ls, AutoUI(true)), addr)
+	if _, err := NewApprovalSigner(ls, AutoUI(false)).SignHash(addr, testHash[:]); err != ErrRequestDenied {
+		t.Fatalf("expected ErrRequestDenied, got %v", err)
+	}
+
+	out := new(bytes.Buffer)
+	ui := NewConsoleUI(strings.NewReader("y\nn\n"), out)
+	as := NewApprovalSigner(ls, ui)
+	checkSignature(t, as, addr)
+	if _, err := as.SignHash(addr, testHash[:]); err != ErrRequestDenied {
+		t.Fatalf("expected ErrRequestDenied, got %v", err)
+	}
+	if !strings.Contains(out.String(), fmt.Sprintf("account %s", addr)) {
+		t.Fatalf("unexpected prompt %q", out.String())
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/accounts"
+)
+
+// StandIn is a local signer daemon with freshly generated keys, it lets the
+// remote signing flow be exercised offline without a real signer deployment
+type StandIn struct {
+	*Daemon
+	Endpoint string
+	Accounts []accounts.Address
+
+	dir string
+}
+
+// NewStandIn starts a daemon on a temporary unix socket holding n new keys
+func NewStandIn(n int) (*StandIn, error) {
+	dir, err := ioutil.TempDir("", "l0-signer")
+	if err != nil {
+		return nil, err
+	}
+
+	ls := NewLocalSignerFromKeys()
+	si := &StandIn{dir: dir, Endpoint: "unix://" + filepath.Join(dir, "signer.ipc")}
+	for i := 0; i < n; i++ {
+		priv, err := crypto.GenerateKey()
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		si.Accounts = append(si.Accounts, ls.Add(priv))
+	}
+
+	si.Daemon = NewDaemon(ls)
+	if err := si.Listen(si.Endpoint); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return si, nil
+}
+
+// Close stops the daemon and removes the socket
+func (si *StandIn) Close() error {
+	err := si.Daemon.Close()
+	os.RemoveAll(si.dir)
+	return err
+}
@@ -19,7 +19,9 @@
 package blockchain
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"math/big"
 
@@ -28,7 +30,9 @@ import (
 	"github.com/bocheninc/L0/core/accounts"
 	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/notify"
 	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 )
 
@@ -37,7 +41,17 @@ type NetworkStack interface {
 	Relay(inv types.IInventory)
 }
 
-var validTxPoolSize = 100000
+var validTxPoolSize int64 = 100000
+
+// SetTxPoolSize sets the maximum number of transactions in the txpool
+func SetTxPoolSize(size int) {
+	atomic.StoreInt64(&validTxPoolSize, int64(size))
+}
+
+// TxPoolSize returns the maximum number of transactions in the txpool
+func TxPoolSize() int {
+	return int(atomic.LoadInt64(&validTxPoolSize))
+}
 
 // Blockchain is blockchain instance
 type Blockchain struct {
@@ -53,12 +67,14 @@ type Blockchain struct {
 	// network stack
 	pm NetworkStack
 
-	quitCh chan bool
+	quitCh chan struct{}
 	txCh   chan *types.Transaction
 	blkCh  chan *types.Block
 
 	// 0 respresents sync block, 1 respresents sync done
 	synced uint32
+	// 1 represents no more transactions are accepted
+	intakeStopped uint32
 }
 
 // load loads local blockchain data
@@ -87,7 +103,7 @@ func NewBlockchain(ledger *ledger.Ledger) *Blockchain {
 		mu:           sync.Mutex{},
 		wg:           sync.WaitGroup{},
 		ledger:       ledger,
-		quitCh:       make(chan bool),
+		quitCh:       make(chan struct{}),
 		txCh:         make(chan *types.Transaction, 10000),
 		blkCh:        make(chan *types.Block, 10),
 		currentBlock: new(types.Block),
@@ -164,49 +180,106 @@ func (bc *Blockchain) Start() {
 
 // StartConsensusService starts consensus service
 func (bc *Blockchain) StartConsensusService() {
+	bc.wg.Add(1)
 	go func() {
+		defer bc.wg.Done()
 		for {
 			select {
 			case commitedTxs := <-bc.consenter.CommittedTxsChannel():
-				var (
-					// atmoicTxs, acrossChainTxs types.Transactions
-					txs types.Transactions
-				)
-
-				log.Debugf("Get CommitedTxs Number: %d", len(commitedTxs.Transactions))
-				for _, tx := range commitedTxs.Transactions {
-					txs = append(txs, tx.(*types.Transaction))
-				}
-				if txs != nil && len(txs) > 0 {
-					blk := bc.GenerateBlock(txs, uint32(commitedTxs.Time))
-					// bc.pm.Relay(blk)
-					bc.ProcessBlock(blk)
+				bc.processCommittedTxs(commitedTxs)
+			case <-bc.quitCh:
+				// drain the batches committed before the consenter stopped
+				for {
+					select {
+					case commitedTxs := <-bc.consenter.CommittedTxsChannel():
+						bc.processCommittedTxs(commitedTxs)
+					default:
+						return
+					}
 				}
 			}
 		}
 	}()
 }
 
+func (bc *Blockchain) processCommittedTxs(commitedTxs *consensus.CommittedTxs) {
+	var (
+		// atmoicTxs, acrossChainTxs types.Transactions
+		txs types.Transactions
+	)
+
+	log.Debugf("Get CommitedTxs Number: %d", len(commitedTxs.Transactions))
+	detail := fmt.Sprintf("seqNos %v", commitedTxs.SeqNos)
+	for _, tx := range commitedTxs.Transactions {
+		txs = append(txs, tx.(*types.Transaction))
+		trace.OK(tx.(*types.Transaction).Hash(), trace.Committed, detail)
+	}
+	var seqNo uint64
+	if n := len(commitedTxs.SeqNos); n > 0 {
+		seqNo = commitedTxs.SeqNos[n-1]
+	}
+	if txs != nil && len(txs) > 0 {
+		blk := bc.GenerateBlock(txs, uint32(commitedTxs.Time))
+		// bc.pm.Relay(blk)
+		bc.processBlock(blk, seqNo)
+	} else if seqNo > 0 {
+		if err := bc.ledger.SetLastSeqNo(seqNo); err != nil {
+			log.Errorf("failed to record seqNo %d, %v", seqNo, err)
+		}
+	}
+}
+
+// Stop writes the blocks of the batches already committed by the stopped
+// consenter and stops the consensus service
+func (bc *Blockchain) Stop() {
+	close(bc.quitCh)
+	bc.wg.Wait()
+	log.Debug("BlockChain Service stop")
+}
+
+// StopTxIntake rejects the transactions processed from now on
+func (bc *Blockchain) StopTxIntake() {
+	atomic.StoreUint32(&bc.intakeStopped, 1)
+}
+
 // ProcessTransaction processes new transaction from the network
 func (bc *Blockchain) ProcessTransaction(tx *types.Transaction) bool {
 	// step 1: validate and mark transaction
 	// step 2: add transaction to txPool
 	// if atomic.LoadUint32(&bc.synced) == 0 {
-	if bc.txValidator.TxsLenInTxPool() < validTxPoolSize {
+	if atomic.LoadUint32(&bc.intakeStopped) == 1 {
+		bc.txValidator.rejected.add(tx.Hash(), rejectShutdown, "node is shutting down")
+		trace.Fail(tx.Hash(), trace.TxPool, "node is shutting down")
+	} else if bc.txValidator.TxsLenInTxPool() < TxPoolSize() {
 		if ok := bc.txValidator.VerifyTxInTxPool(tx); ok {
 			return true
 		}
+	} else {
+		bc.txValidator.rejected.add(tx.Hash(), rejectPoolFull, "txpool is full")
+		trace.Fail(tx.Hash(), trace.TxPool, "txpool is full")
 	}
 	return false
 }
 
 // ProcessBlock processes new block from the network
 func (bc *Blockchain) ProcessBlock(blk *types.Block) bool {
+	return bc.processBlock(blk, 0)
+}
+
+// processBlock appends the block, seqNo is the consensus seqNo of its last
+// batch, 0 for a block not written by the consensus
+func (bc *Blockchain) processBlock(blk *types.Block, seqNo uint64) bool {
 	log.Debugf("block previoushash %s, currentblockhash %s", blk.PreviousHash(), bc.CurrentBlockHash())
 	if blk.PreviousHash() == bc.CurrentBlockHash() {
+		// the hash is cached, don't take it before AppendBlock sets the merkle root
+		if seqNo > 0 {
+			bc.ledger.AppendCommittedBlock(blk, seqNo)
+		} else {
+			bc.ledger.AppendBlock(blk, true)
+		}
 		log.Infof("New Block  %s, height: %d Transaction Number: %d", blk.Hash(), blk.Height(), len(blk.Transactions))
-		bc.ledger.AppendBlock(blk, true)
 		bc.currentBlock = blk
+		notify.Publish(notify.NewBlock, blk)
 		return true
 	}
 	return false
@@ -251,3 +324,23 @@ func (bc *Blockchain) StartReceiveTx() {
 func (bc *Blockchain) StopReceiveTx() {
 	bc.txValidator.stopValidator()
 }
+
+// GetTxPoolStatus returns the number of pending txs per account and type
+func (bc *Blockchain) GetTxPoolStatus() *TxPoolStatus {
+	return bc.txValidator.Status()
+}
+
+// GetPendingTxsBySender returns the pending txs sent by addr
+func (bc *Blockchain) GetPendingTxsBySender(addr accounts.Address) types.Transactions {
+	return bc.txValidator.PendingTxsBySender(addr)
+}
+
+// LookupTxInTxPool returns the state of the tx in the txpool with its rejection reason
+func (bc *Blockchain) LookupTxInTxPool(txHash crypto.Hash) *TxPoolTx {
+	return bc.txValidator.LookupTx(txHash)
+}
+
+// DropTxInTxPool removes the tx and the following txs of its sender from the txpool
+func (bc *Blockchain) DropTxInTxPool(txHash crypto.Hash) ([]crypto.Hash, error) {
+	return bc.txValidator.DropTx(txHash)
+}
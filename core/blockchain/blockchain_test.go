@@ -0,0 +1,165 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus/noops"
+	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/types"
+)
+
+type nopNetworkStack struct{}
+
+func (nopNetworkStack) Relay(inv types.IInventory) {}
+
+// testNode is a blockchain with noops consensus on its own db
+type testNode struct {
+	db     *db.BlockchainDB
+	ledger *ledger.Ledger
+	bc     *Blockchain
+	noops  *noops.Noops
+}
+
+func startTestNode(dir string) *testNode {
+	cfg := db.DefaultConfig()
+	cfg.DbPath = dir
+	n := &testNode{db: db.Open(cfg)}
+	n.ledger = ledger.Open(n.db)
+	n.bc = NewBlockchain(n.ledger)
+	options := noops.NewDefaultOptions()
+	options.BlockSize = 10
+	options.BlockInterval = 50 * time.Millisecond
+	n.noops = noops.NewNoops(options, n.bc)
+	n.bc.SetBlockchainConsenter(n.noops)
+	n.bc.SetNetworkStack(nopNetworkStack{})
+	n.bc.Start()
+	go n.noops.Start()
+	return n
+}
+
+// stop stops the node in the order of lcnd shutdown
+func (n *testNode) stop() {
+	n.bc.StopTxIntake()
+	n.noops.Stop()
+	n.bc.Stop()
+	n.db.Close()
+}
+
+func (n *testNode) height(t *testing.T) uint32 {
+	height, err := n.ledger.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return height
+}
+
+// load sends issue txs to the node until stop is closed
+func load(n *testNode, key *crypto.PrivateKey, nonce uint32, stop, done chan struct{}) {
+	defer close(done)
+	issuer := accounts.PublicKeyToAddress(*key.Public())
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		nonce++
+		tx := types.NewTransaction(coordinate.HexToChainCoordinate("00"), coordinate.HexToChainCoordinate("00"),
+			types.TypeIssue, nonce, issuer, accounts.ChainCoordinateToAddress(coordinate.NewChainCoordinate([]byte{byte(nonce)})),
+			big.NewInt(int64(nonce)), big.NewInt(0), utils.CurrentTimestamp())
+		sig, _ := key.Sign(tx.SignHash().Bytes())
+		tx.WithSignature(sig)
+		n.bc.ProcessTransaction(tx)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRestartUnderLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blockchain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := crypto.GenerateKey()
+	params.ChainID = []byte{0}
+	params.Validator = false
+	params.PublicAddress = []string{utils.BytesToHex(accounts.PublicKeyToAddress(*key.Public()).Bytes())}
+
+	issuer := accounts.PublicKeyToAddress(*key.Public())
+	var height uint32
+	for round := 0; round < 2; round++ {
+		n := startTestNode(filepath.Join(dir, "chaindata"))
+		if h := n.height(t); h != height {
+			t.Fatalf("round %d: height %d after restart, want %d", round, h, height)
+		}
+		// txs rejected at shutdown don't advance the nonce
+		_, nonce, err := n.ledger.GetBalance(issuer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stop, done := make(chan struct{}), make(chan struct{})
+		go load(n, key, nonce, stop, done)
+		for deadline := time.Now().Add(5 * time.Second); n.height(t) < height+3; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("round %d: height %d, no blocks written", round, n.height(t))
+			}
+		}
+
+		// stop in the middle of the load
+		n.stop()
+		close(stop)
+		<-done
+		if n.bc.ProcessTransaction(types.NewTransaction(nil, nil, types.TypeIssue, 0, accounts.Address{}, accounts.Address{}, big.NewInt(1), big.NewInt(0), 0)) {
+			t.Errorf("round %d: tx accepted after shutdown", round)
+		}
+		height = n.bc.CurrentHeight()
+	}
+
+	n := startTestNode(filepath.Join(dir, "chaindata"))
+	defer n.stop()
+	if h := n.height(t); h != height {
+		t.Fatalf("height %d after restart, want %d", h, height)
+	}
+	cfg := db.DefaultConfig()
+	cfg.DbPath = filepath.Join(dir, "scratch")
+	scratch := db.Open(cfg)
+	defer scratch.Close()
+	report, err := n.ledger.Verify(scratch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("chain written before shutdown does not verify: issues %v, diffs %v", report.Issues, report.Diffs)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/bocheninc/L0/core/types"
+)
+
+var (
+	// feeHistoryBlocks is the number of recent blocks used to suggest fee
+	feeHistoryBlocks uint32 = 20
+	// defaultFee is suggested when there is no fee history
+	defaultFee = big.NewInt(1)
+)
+
+// SuggestFee returns the median fee of the transactions in recent blocks
+func (bc *Blockchain) SuggestFee() *big.Int {
+	var fees []*big.Int
+
+	height := bc.CurrentHeight()
+	for n := uint32(0); n < feeHistoryBlocks && n < height; n++ {
+		block, err := bc.ledger.GetBlockByNumber(height - n)
+		if err != nil || block == nil {
+			break
+		}
+		for _, tx := range block.Transactions {
+			if tx.GetType() == types.TypeMerged || tx.Fee() == nil || tx.Fee().Sign() <= 0 {
+				continue
+			}
+			fees = append(fees, tx.Fee())
+		}
+	}
+
+	return medianFee(fees)
+}
+
+func medianFee(fees []*big.Int) *big.Int {
+	if len(fees) == 0 {
+		return new(big.Int).Set(defaultFee)
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i].Cmp(fees[j]) < 0 })
+	return new(big.Int).Set(fees[len(fees)/2])
+}
@@ -19,7 +19,11 @@
 package blockchain
 
 import (
+	"fmt"
+
+	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/core/consensus"
+	"github.com/bocheninc/L0/core/notify"
 	"github.com/bocheninc/L0/core/types"
 )
 
@@ -48,9 +52,53 @@ func (bc *Blockchain) VerifyTxsInConsensus(txs []consensus.ITransaction, primary
 	return rtxs
 }
 
-// GetLastSeqNo To do
+// GetLastSeqNo returns the consensus seqNo of the last committed batch written
 func (bc *Blockchain) GetLastSeqNo() uint64 {
-	return 0
+	return bc.ledger.LastSeqNo()
+}
+
+// ReplicaSet returns the replica set changed by the governance txs written
+func (bc *Blockchain) ReplicaSet() (*consensus.ReplicaSet, error) {
+	return bc.ledger.ReplicaSet()
+}
+
+// CommittedBlocks returns at most max blocks above height written by the
+// consensus with the seqNo of their last batch
+func (bc *Blockchain) CommittedBlocks(height uint32, max int) []*consensus.CommittedBlock {
+	var blocks []*consensus.CommittedBlock
+	for h := height + 1; h <= bc.CurrentHeight() && len(blocks) < max; h++ {
+		seqNo := bc.ledger.BlockSeqNo(h)
+		if seqNo == 0 {
+			break
+		}
+		blk, err := bc.ledger.GetBlockByNumber(h)
+		if err != nil {
+			log.Errorf("GetBlockByNumber error %v", err)
+			break
+		}
+		blocks = append(blocks, &consensus.CommittedBlock{Height: h, SeqNo: seqNo, Block: blk.Serialize()})
+	}
+	return blocks
+}
+
+// AppendCommittedBlock appends the next block fetched from the replicas,
+// its txs are executed again and must have the merkle hash of the block
+func (bc *Blockchain) AppendCommittedBlock(data []byte, seqNo uint64) error {
+	blk := &types.Block{}
+	if err := blk.Deserialize(data); err != nil {
+		return err
+	}
+	if blk.Height() != bc.CurrentHeight()+1 || blk.PreviousHash() != bc.CurrentBlockHash() {
+		return fmt.Errorf("block at height %d doesn't follow block %s at height %d", blk.Height(), bc.CurrentBlockHash(), bc.CurrentHeight())
+	}
+	if err := bc.ledger.ImportCommittedBlock(blk, seqNo); err != nil {
+		return err
+	}
+	bc.txValidator.RemoveTxInVerify(blk.Transactions)
+	log.Infof("New Block  %s, height: %d Transaction Number: %d, fetched", blk.Hash(), blk.Height(), len(blk.Transactions))
+	bc.currentBlock = blk
+	notify.Publish(notify.NewBlock, blk)
+	return nil
 }
 
 func (bc *Blockchain) IterTransaction(function func(consensus.ITransaction) bool) {
@@ -0,0 +1,297 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// maxRejectedTxs is the number of rejected txs whose reason is kept
+const maxRejectedTxs = 4096
+
+// status of a tx in the txpool
+const (
+	TxStatusPending   = "pending"
+	TxStatusConsensus = "consensus"
+	TxStatusRejected  = "rejected"
+	TxStatusUnknown   = "unknown"
+)
+
+// reasons of rejected txs, used as metrics label
+const (
+	rejectSignature = "signature"
+	rejectInvalid   = "invalid"
+	rejectNonce     = "nonce"
+	rejectBalance   = "balance"
+	rejectPoolFull  = "pool_full"
+	rejectDropped   = "dropped"
+	rejectShutdown  = "shutdown"
+)
+
+var txPoolRejects = metrics.NewCounterVec("l0_txpool_rejected_total", "Number of txs rejected by the txpool by reason.", "reason")
+
+// rejectError is a tx rejection with the reason for metrics
+type rejectError struct {
+	reason string
+	msg    string
+}
+
+func (e *rejectError) Error() string { return e.msg }
+
+func rejectReason(err error) string {
+	if e, ok := err.(*rejectError); ok {
+		return e.reason
+	}
+	return rejectInvalid
+}
+
+// ErrTxInConsensus is returned when dropping a tx which is already handed to consensus
+var ErrTxInConsensus = errors.New("transaction is being processed by consensus")
+
+// TxPoolStatus is the summary of the txpool
+type TxPoolStatus struct {
+	Pending int `json:"pending"`
+	// Consensus is the number of pending txs handed to consensus
+	Consensus int            `json:"consensus"`
+	Rejected  int            `json:"rejected"`
+	Accounts  map[string]int `json:"accounts"`
+	Types     map[string]int `json:"types"`
+}
+
+// TxPoolTx is the state of a tx in the txpool
+type TxPoolTx struct {
+	Hash        crypto.Hash        `json:"hash"`
+	Status      string             `json:"status"`
+	Reason      string             `json:"reason,omitempty"`
+	Transaction *types.Transaction `json:"transaction,omitempty"`
+}
+
+type rejectedTx struct {
+	hash   crypto.Hash
+	reason string
+}
+
+// rejectedTxs keeps the reasons of the latest rejected txs
+type rejectedTxs struct {
+	sync.Mutex
+	capacity int
+	order    *list.List
+	reasons  map[crypto.Hash]*list.Element
+}
+
+func newRejectedTxs(capacity int) *rejectedTxs {
+	return &rejectedTxs{
+		capacity: capacity,
+		order:    list.New(),
+		reasons:  make(map[crypto.Hash]*list.Element),
+	}
+}
+
+func (rt *rejectedTxs) add(hash crypto.Hash, kind, reason string) {
+	txPoolRejects.With(kind).Inc()
+
+	rt.Lock()
+	defer rt.Unlock()
+
+	if ele, ok := rt.reasons[hash]; ok {
+		ele.Value.(*rejectedTx).reason = reason
+		rt.order.MoveToBack(ele)
+		return
+	}
+	rt.reasons[hash] = rt.order.PushBack(&rejectedTx{hash: hash, reason: reason})
+	for rt.order.Len() > rt.capacity {
+		front := rt.order.Front()
+		rt.order.Remove(front)
+		delete(rt.reasons, front.Value.(*rejectedTx).hash)
+	}
+}
+
+func (rt *rejectedTxs) get(hash crypto.Hash) (string, bool) {
+	rt.Lock()
+	defer rt.Unlock()
+
+	if ele, ok := rt.reasons[hash]; ok {
+		return ele.Value.(*rejectedTx).reason, true
+	}
+	return "", false
+}
+
+func (rt *rejectedTxs) len() int {
+	rt.Lock()
+	defer rt.Unlock()
+	return rt.order.Len()
+}
+
+// pendingTxs returns the txs of the account in nonce order
+func (va *validatorAccount) pendingTxs() types.Transactions {
+	va.RLock()
+	defer va.RUnlock()
+
+	txs := make(types.Transactions, 0, va.txs.Len())
+	for ele := va.txs.Front(); ele != nil; ele = ele.Next() {
+		txs = append(txs, ele.Value.(*types.Transaction))
+	}
+	return txs
+}
+
+// dropTransaction removes the tx and the following txs of the account, which
+// can't be executed without it, the amount and nonce are restored
+func (va *validatorAccount) dropTransaction(txHash crypto.Hash, inConsensus func(crypto.Hash) bool) (types.Transactions, error) {
+	va.Lock()
+	defer va.Unlock()
+
+	ele, ok := va.txMap[txHash]
+	if !ok {
+		return nil, nil
+	}
+	for e := ele; e != nil; e = e.Next() {
+		if inConsensus(e.Value.(*types.Transaction).Hash()) {
+			return nil, ErrTxInConsensus
+		}
+	}
+
+	var (
+		dropped types.Transactions
+		next    *list.Element
+		nonce   = va.nonce
+	)
+	for e := ele; e != nil; e = next {
+		next = e.Next()
+		tx := e.Value.(*types.Transaction)
+		va.txs.Remove(e)
+		delete(va.txMap, tx.Hash())
+		va.amount.Add(va.amount, tx.Amount())
+		if tx.GetType() != types.TypeMerged && tx.Nonce() < nonce {
+			nonce = tx.Nonce()
+		}
+		dropped = append(dropped, tx)
+	}
+	va.nonce = nonce
+	return dropped, nil
+}
+
+// Status returns the number of pending txs per account and type
+func (vr *Validator) Status() *TxPoolStatus {
+	status := &TxPoolStatus{
+		Accounts: make(map[string]int),
+		Types:    make(map[string]int),
+		Rejected: vr.rejected.len(),
+	}
+	// iterate the pool directly, IterElementInTxPool marks txs as handed to consensus
+	vr.txPool.IterElement(func(element IElement) bool {
+		tx := element.(*types.Transaction)
+		status.Pending++
+		if vr.txsCacheFilter.hasTxInCacheFilter(tx.Hash()) {
+			status.Consensus++
+		}
+		status.Accounts[tx.Sender().String()]++
+		status.Types[types.TxTypeName(tx.GetType())]++
+		return false
+	})
+	return status
+}
+
+func (vr *Validator) account(address accounts.Address) (*validatorAccount, bool) {
+	vr.Lock()
+	defer vr.Unlock()
+	account, ok := vr.accounts[address.String()]
+	return account, ok
+}
+
+// PendingTxsBySender returns the pending txs sent by address
+func (vr *Validator) PendingTxsBySender(address accounts.Address) types.Transactions {
+	if vr.isValid {
+		if account, ok := vr.account(address); ok {
+			return account.pendingTxs()
+		}
+		return types.Transactions{}
+	}
+
+	txs := types.Transactions{}
+	vr.txPool.IterElement(func(element IElement) bool {
+		if tx := element.(*types.Transaction); tx.Sender() == address {
+			txs = append(txs, tx)
+		}
+		return false
+	})
+	return txs
+}
+
+// LookupTx returns the state of the tx in the txpool
+func (vr *Validator) LookupTx(txHash crypto.Hash) *TxPoolTx {
+	if tx, ok := vr.getTransactionByHash(txHash); ok {
+		status := TxStatusPending
+		if vr.txsCacheFilter.hasTxInCacheFilter(txHash) {
+			status = TxStatusConsensus
+		}
+		return &TxPoolTx{Hash: txHash, Status: status, Transaction: tx}
+	}
+	if reason, ok := vr.rejected.get(txHash); ok {
+		return &TxPoolTx{Hash: txHash, Status: TxStatusRejected, Reason: reason}
+	}
+	return &TxPoolTx{Hash: txHash, Status: TxStatusUnknown}
+}
+
+// DropTx removes the tx from the txpool together with the txs of the same
+// sender queued behind it, it returns the hashes of the dropped txs
+func (vr *Validator) DropTx(txHash crypto.Hash) ([]crypto.Hash, error) {
+	tx, ok := vr.getTransactionByHash(txHash)
+	if !ok {
+		return nil, errors.New("transaction not in txpool")
+	}
+
+	dropped := types.Transactions{tx}
+	if vr.isValid {
+		account, ok := vr.account(tx.Sender())
+		if ok {
+			txs, err := account.dropTransaction(txHash, vr.txsCacheFilter.hasTxInCacheFilter)
+			if err != nil {
+				return nil, err
+			}
+			if len(txs) > 0 {
+				dropped = txs
+			}
+		}
+	} else if vr.txsCacheFilter.hasTxInCacheFilter(txHash) {
+		return nil, ErrTxInConsensus
+	}
+
+	elements := make([]IElement, 0, len(dropped))
+	hashes := make([]crypto.Hash, 0, len(dropped))
+	for _, dtx := range dropped {
+		elements = append(elements, dtx)
+		hashes = append(hashes, dtx.Hash())
+		if dtx.Hash() == txHash {
+			vr.rejected.add(dtx.Hash(), rejectDropped, "dropped by admin")
+		} else {
+			vr.rejected.add(dtx.Hash(), rejectDropped, "dropped by admin with "+txHash.String())
+		}
+	}
+	vr.txPool.Removes(elements)
+	log.Infof("dropped %d txs from txpool, tx_hash: %v", len(hashes), txHash.String())
+	return hashes, nil
+}
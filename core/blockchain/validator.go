@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"container/list"
 	"errors"
+	"fmt"
 	"math/big"
 	"strings"
 	"sync"
@@ -29,11 +30,14 @@ import (
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
 	"github.com/bocheninc/L0/core/coordinate"
 	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/notify"
 	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 )
 
@@ -59,6 +63,7 @@ type Validator struct {
 	accounts       map[string]*validatorAccount
 	txsCacheFilter *validatorFilter
 	delTxsChan     chan types.Transactions
+	rejected       *rejectedTxs
 }
 
 func newValidatorFilter() *validatorFilter {
@@ -114,20 +119,22 @@ func (va *validatorAccount) addAmount(tx *types.Transaction) {
 	log.Info("RemoveTxInVerify va.amount: ", va.amount)
 }
 
-func (va *validatorAccount) addTransaction(tx *types.Transaction) bool {
+func (va *validatorAccount) addTransaction(tx *types.Transaction) error {
 	va.Lock()
 	defer va.Unlock()
 
 	addr := tx.Sender()
-	isOK := true
+	var err error
 	amount := (&big.Int{}).Sub(va.amount, tx.Amount())
 	nonce := va.nonce
 
 	switch tx.GetType() {
 	case types.TypeMerged:
 	case types.TypeIssue:
+		fallthrough
+	case types.TypeGovernance:
 		if nonce != tx.Nonce() {
-			isOK = false
+			err = &rejectError{reason: rejectNonce, msg: fmt.Sprintf("nonce mismatch, expected %d got %d", nonce, tx.Nonce())}
 		}
 	case types.TypeAcrossChain:
 		fallthrough
@@ -136,16 +143,18 @@ func (va *validatorAccount) addTransaction(tx *types.Transaction) bool {
 	case types.TypeBackfront:
 		fallthrough
 	case types.TypeAtomic:
-		if nonce != tx.Nonce() || amount.Sign() < 0 {
-			isOK = false
+		if nonce != tx.Nonce() {
+			err = &rejectError{reason: rejectNonce, msg: fmt.Sprintf("nonce mismatch, expected %d got %d", nonce, tx.Nonce())}
+		} else if amount.Sign() < 0 {
+			err = &rejectError{reason: rejectBalance, msg: fmt.Sprintf("insufficient balance %v for amount %v", va.amount, tx.Amount())}
 		}
 	case types.TypeSmartContract:
 		//TODO
 	default:
-		log.Errorf("add: unknow tx's type, tx_hash: %v, tx_type: %v", tx.Hash().String(), tx.GetType())
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("add: unknow tx's type, tx_hash: %v, tx_type: %v", tx.Hash().String(), tx.GetType())
 	}
 
-	if isOK {
+	if err == nil {
 		ele := va.txs.PushBack(tx)
 		va.txMap[tx.Hash()] = ele
 		va.amount.Set(amount)
@@ -153,14 +162,14 @@ func (va *validatorAccount) addTransaction(tx *types.Transaction) bool {
 			va.nonce++
 		}
 
-		log.Debugf("add: new tx, tx_hash: %v, tx_sender: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v, va.amount: %v, va.nonce: %v",
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("add: new tx, tx_hash: %v, tx_sender: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v, va.amount: %v, va.nonce: %v",
 			tx.Hash().String(), addr.String(), tx.GetType(), tx.Amount(), tx.Nonce(), va.amount, va.nonce)
-		return true
+		return nil
 	}
 
-	log.Debugf("can't add: new tx, tx_hash: %v, tx_sender: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v, va.amount: %v, va.nonce: %v",
+	log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("can't add: new tx, tx_hash: %v, tx_sender: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v, va.amount: %v, va.nonce: %v",
 		tx.Hash().String(), addr.String(), tx.GetType(), tx.Amount(), tx.Nonce(), va.amount, va.nonce)
-	return false
+	return err
 }
 
 func (va *validatorAccount) removeTransaction(tx *types.Transaction) bool {
@@ -175,7 +184,7 @@ func (va *validatorAccount) removeTransaction(tx *types.Transaction) bool {
 			va.txs.Remove(ele)
 			return true
 		} else {
-			log.Errorf("trx order different between consensus and leger, txs_list_first: %v, cur_tx: %v",
+			log.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("trx order different between consensus and leger, txs_list_first: %v, cur_tx: %v",
 				data.Hash().String(), tx.Hash().String())
 			// TODO  Delete all the transactions before this transaction
 			// TODO And update amount of sender
@@ -228,7 +237,7 @@ func (va *validatorAccount) checkTransaction(tx *types.Transaction) (bool, error
 			//	"tx_hash: %v, tx_nonce: %v, tx_amount", otx.Hash().String(), otx.Nonce(), otx.Amount(),
 			//	tx.Hash().String(), tx.Nonce(), tx.Amount())
 
-			log.Debugf("innoment checkTranaction, otx_hash: %v, otx: %v "+
+			log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("innoment checkTranaction, otx_hash: %v, otx: %v "+
 				"tx_hash: %v, otx: %v", otx.Hash().String(), otx,
 				tx.Hash().String(), tx)
 			return false, nil
@@ -238,14 +247,13 @@ func (va *validatorAccount) checkTransaction(tx *types.Transaction) (bool, error
 		va.txs.Remove(ele)
 		va.txMap[tx.Hash()] = ele
 		delete(va.txMap, otx.Hash())
-		log.Debugf("checkTransaction changeTx, tx_hash: %v", tx.Hash().String())
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("checkTransaction changeTx, tx_hash: %v", tx.Hash().String())
 	}
 
 	return false, nil
 }
 
-func (vr *Validator) checkIssueTransaction(tx *types.Transaction) bool {
-	address := tx.Sender()
+func isIssueAccount(address accounts.Address) bool {
 	addressHex := utils.BytesToHex(address.Bytes())
 	for _, addr := range params.PublicAddress {
 		if strings.Compare(addressHex, addr) == 0 {
@@ -257,46 +265,48 @@ func (vr *Validator) checkIssueTransaction(tx *types.Transaction) bool {
 }
 
 func (vr *Validator) checkTransaction(tx *types.Transaction) bool {
-	isOK := true
+	if err := CheckTransaction(tx); err != nil {
+		vr.rejected.add(tx.Hash(), rejectInvalid, err.Error())
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("add: fail[%v], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
+			err, tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
+		return false
+	}
+
+	return true
+}
 
+// CheckTransaction checks the chain coordinates of the transaction according its type,
+// the same rules are applied when a transaction enters the txpool
+func CheckTransaction(tx *types.Transaction) error {
 	if !(strings.Compare(tx.FromChain(), params.ChainID.String()) == 0 || (strings.Compare(tx.ToChain(), params.ChainID.String()) == 0)) {
-		log.Errorf("invalid transaction, fromCahin or toChain == params.ChainID")
-		return false
+		return errors.New("invalid transaction, fromChain or toChain should be params.ChainID")
 	}
 
 	switch tx.GetType() {
 	case types.TypeAtomic:
 		//TODO fromChain==toChain
 		if strings.Compare(tx.FromChain(), tx.ToChain()) != 0 {
-			log.Errorf("add: fail[should fromchain == tochain], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
-				tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
-			isOK = false
+			return errors.New("should fromchain == tochain")
 		}
 	case types.TypeAcrossChain:
 		//TODO the len of fromchain == the len of tochain
 		if !(len(tx.FromChain()) == len(tx.ToChain()) && strings.Compare(tx.FromChain(), tx.ToChain()) != 0) {
-			log.Errorf("add: fail[should(chain same floor, and different)], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
-				tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
-			isOK = false
+			return errors.New("should(chain same floor, and different)")
 		}
 	case types.TypeDistribut:
 		//TODO |fromChain - toChain| = 1 and sender_addr == receive_addr
 		address := tx.Sender()
 		fromChain := coordinate.HexToChainCoordinate(tx.FromChain())
-		toChainParent := coordinate.HexToChainCoordinate(tx.ToChain()).ParentCoorinate()
-		if !bytes.Equal(fromChain, toChainParent) || strings.Compare(address.String(), tx.Recipient().String()) != 0 {
-			log.Errorf("add: fail[should(|fromChain - toChain| = 1 and sender_addr == receive_addr)], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
-				tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
-			isOK = false
+		toChain := coordinate.HexToChainCoordinate(tx.ToChain())
+		if len(toChain) == 0 || !bytes.Equal(fromChain, toChain.ParentCoorinate()) || strings.Compare(address.String(), tx.Recipient().String()) != 0 {
+			return errors.New("should(|fromChain - toChain| = 1 and sender_addr == receive_addr)")
 		}
 	case types.TypeBackfront:
 		address := tx.Sender()
-		fromChainParent := coordinate.HexToChainCoordinate(tx.FromChain()).ParentCoorinate()
+		fromChain := coordinate.HexToChainCoordinate(tx.FromChain())
 		toChain := coordinate.HexToChainCoordinate(tx.ToChain())
-		if !bytes.Equal(fromChainParent, toChain) || strings.Compare(address.String(), tx.Recipient().String()) != 0 {
-			log.Errorf("add: fail[should(|fromChain - toChain| = 1 and sender_addr == receive_addr)], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
-				tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
-			isOK = false
+		if len(fromChain) == 0 || !bytes.Equal(fromChain.ParentCoorinate(), toChain) || strings.Compare(address.String(), tx.Recipient().String()) != 0 {
+			return errors.New("should(|fromChain - toChain| = 1 and sender_addr == receive_addr)")
 		}
 	case types.TypeMerged:
 	//TODO nothing to do
@@ -306,19 +316,25 @@ func (vr *Validator) checkTransaction(tx *types.Transaction) bool {
 		toChain := coordinate.HexToChainCoordinate(tx.FromChain())
 
 		if !(len(fromChain) == len(toChain) && strings.Compare(fromChain.String(), "00") == 0) {
-			log.Errorf("add: fail[should(the first floor)], Tx-hash: %v, tx_type: %v, tx_fchain: %v, tx_tchain: %v",
-				tx.Hash().String(), tx.GetType(), tx.FromChain(), tx.ToChain())
-			isOK = false
+			return errors.New("should(the first floor)")
 		}
 
-		if ok := vr.checkIssueTransaction(tx); !ok {
-			log.Errorf("add: valid issue tx public key fail, tx: %v", tx.Hash().String())
-			isOK = false
+		if !isIssueAccount(tx.Sender()) {
+			return errors.New("valid issue tx public key fail")
+		}
+	case types.TypeGovernance:
+		if strings.Compare(tx.FromChain(), tx.ToChain()) != 0 || !tx.Sender().Equal(tx.Recipient()) || (tx.Amount() != nil && tx.Amount().Sign() != 0) {
+			return errors.New("should(fromChain == toChain, sender_addr == receive_addr and amount 0)")
+		}
+		if !isIssueAccount(tx.Sender()) {
+			return errors.New("governance tx should be sent by an issue account")
+		}
+		if _, err := tx.ReplicaChange(); err != nil {
+			return err
 		}
-
 	}
 
-	return isOK
+	return nil
 }
 
 func NewValidator(ledger *ledger.Ledger) *Validator {
@@ -329,7 +345,11 @@ func NewValidator(ledger *ledger.Ledger) *Validator {
 		accounts:       make(map[string]*validatorAccount),
 		txsCacheFilter: newValidatorFilter(),
 		delTxsChan:     make(chan types.Transactions, 100),
+		rejected:       newRejectedTxs(maxRejectedTxs),
 	}
+	metrics.NewGaugeFunc("l0_txpool_size", "Number of txs in the txpool.", func() float64 {
+		return float64(validator.txPool.Len())
+	})
 	go validator.Loop()
 	return validator
 }
@@ -455,17 +475,22 @@ func (vr *Validator) VerifyTxInTxPool(tx *types.Transaction) bool {
 		ok := vr.checkTransaction(tx)
 		if ok {
 			vr.txPool.Add(tx)
-			log.Debugf("added new tx, tx_hash: %v", tx.Hash().String())
+			notify.Publish(notify.NewPendingTx, tx)
+			trace.OK(tx.Hash(), trace.TxPool, "")
+			log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("added new tx, tx_hash: %v", tx.Hash().String())
 			return true
 		}
 
-		log.Debugf("can't add new tx, tx_hash: %v", tx.Hash().String())
+		trace.Fail(tx.Hash(), trace.TxPool, "invalid transaction")
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("can't add new tx, tx_hash: %v", tx.Hash().String())
 		return false
 	}
 
 	address, err := tx.Verfiy()
 	if err != nil {
-		log.Debugf("varify fail, tx_hash: ", tx.Hash().String())
+		vr.rejected.add(tx.Hash(), rejectSignature, "invalid signature: "+err.Error())
+		trace.Fail(tx.Hash(), trace.TxPool, "invalid signature: "+err.Error())
+		log.WithField(log.FieldTxHash, tx.Hash().String()).Debugf("varify fail, tx_hash: %v", tx.Hash().String())
 		return false
 	}
 
@@ -473,12 +498,24 @@ func (vr *Validator) VerifyTxInTxPool(tx *types.Transaction) bool {
 	if ok {
 		senderAccount := vr.getSenderAccount(address)
 		vr.Lock()
-		if ok = senderAccount.addTransaction(tx); ok {
+		if err = senderAccount.addTransaction(tx); err == nil {
 			vr.txPool.Add(tx)
+		} else {
+			ok = false
+			vr.rejected.add(tx.Hash(), rejectReason(err), err.Error())
 		}
 		vr.Unlock()
 	}
 
+	if ok {
+		notify.Publish(notify.NewPendingTx, tx)
+		trace.OK(tx.Hash(), trace.TxPool, "")
+	} else if reason, rejected := vr.rejected.get(tx.Hash()); rejected {
+		trace.Fail(tx.Hash(), trace.TxPool, reason)
+	} else {
+		trace.Fail(tx.Hash(), trace.TxPool, "invalid transaction")
+	}
+
 	return ok
 }
 
@@ -493,7 +530,7 @@ func (vr *Validator) VerifyTxsInConsensus(txs types.Transactions, role bool) typ
 			continue
 		} else if ok, err := vr.checkExceptionTransaction(tx); ok {
 			if err != nil {
-				log.Errorf("VerifyTxsInConsensus invalid transaction, tx_hash: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v",
+				log.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("VerifyTxsInConsensus invalid transaction, tx_hash: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v",
 					tx.Hash().String(), tx.GetType(), tx.Amount(), tx.Nonce())
 				return make(types.Transactions, 0)
 			}
@@ -501,7 +538,7 @@ func (vr *Validator) VerifyTxsInConsensus(txs types.Transactions, role bool) typ
 			ok := vr.VerifyTxInTxPool(tx)
 			if !ok {
 				if ok = vr.hasTransaction(tx); !ok {
-					log.Errorf("VerifyTxsInConsensus can't add transaction, tx_hash: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v",
+					log.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("VerifyTxsInConsensus can't add transaction, tx_hash: %v, tx_type: %v, tx_amount: %v, tx_nonce: %v",
 						tx.Hash().String(), tx.GetType(), tx.Amount(), tx.Nonce())
 					return make(types.Transactions, 0)
 				}
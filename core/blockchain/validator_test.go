@@ -0,0 +1,125 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package blockchain
+
+import (
+	"container/list"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/types"
+)
+
+func TestCheckTransaction(t *testing.T) {
+	params.ChainID = []byte{0, 1}
+	sender := accounts.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50311")
+	recipient := accounts.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50310")
+
+	tests := []struct {
+		txType    uint32
+		from, to  string
+		recipient accounts.Address
+		ok        bool
+	}{
+		{types.TypeAtomic, "0001", "0001", recipient, true},
+		{types.TypeAtomic, "0001", "0002", recipient, false},
+		{types.TypeAcrossChain, "0001", "0002", recipient, true},
+		{types.TypeAcrossChain, "0001", "0001", recipient, false},
+		{types.TypeDistribut, "00", "0001", sender, true},
+		{types.TypeDistribut, "00", "0001", recipient, false},
+		{types.TypeBackfront, "0001", "00", sender, true},
+		{types.TypeBackfront, "0001", "0002", sender, false},
+		{types.TypeAtomic, "0002", "0002", recipient, false},
+	}
+
+	for i, test := range tests {
+		tx := types.NewTransaction(coordinate.HexToChainCoordinate(test.from), coordinate.HexToChainCoordinate(test.to),
+			test.txType, 1, sender, test.recipient, big.NewInt(1), big.NewInt(1), utils.CurrentTimestamp())
+		if err := CheckTransaction(tx); (err == nil) != test.ok {
+			t.Errorf("test %d: %s %s->%s, error %v, want ok %v", i, types.TxTypeName(test.txType), test.from, test.to, err, test.ok)
+		}
+	}
+}
+
+func TestMedianFee(t *testing.T) {
+	if fee := medianFee(nil); fee.Cmp(defaultFee) != 0 {
+		t.Errorf("median fee of empty history %v, want %v", fee, defaultFee)
+	}
+	fees := []*big.Int{big.NewInt(5), big.NewInt(1), big.NewInt(3)}
+	if fee := medianFee(fees); fee.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("median fee %v, want 3", fee)
+	}
+}
+
+func TestRejectedTxs(t *testing.T) {
+	rt := newRejectedTxs(2)
+	hashes := []crypto.Hash{crypto.Sha256([]byte("a")), crypto.Sha256([]byte("b")), crypto.Sha256([]byte("c"))}
+	for i, hash := range hashes {
+		rt.add(hash, rejectInvalid, fmt.Sprintf("reason %d", i))
+	}
+	if _, ok := rt.get(hashes[0]); ok {
+		t.Error("oldest rejected tx should be evicted")
+	}
+	if reason, ok := rt.get(hashes[2]); !ok || reason != "reason 2" {
+		t.Errorf("rejected reason %q, want %q", reason, "reason 2")
+	}
+	if rt.len() != 2 {
+		t.Errorf("rejected len %d, want 2", rt.len())
+	}
+}
+
+func TestDropTransaction(t *testing.T) {
+	sender := accounts.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50311")
+	recipient := accounts.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50310")
+	va := &validatorAccount{amount: big.NewInt(10), nonce: 1, txs: list.New(), txMap: make(map[crypto.Hash]*list.Element)}
+
+	var txs types.Transactions
+	for nonce := uint32(1); nonce <= 3; nonce++ {
+		tx := types.NewTransaction(coordinate.HexToChainCoordinate("00"), coordinate.HexToChainCoordinate("00"),
+			types.TypeAtomic, nonce, sender, recipient, big.NewInt(2), big.NewInt(1), utils.CurrentTimestamp())
+		if err := va.addTransaction(tx); err != nil {
+			t.Fatalf("add tx %d error %v", nonce, err)
+		}
+		txs = append(txs, tx)
+	}
+	if err := va.addTransaction(txs[0]); err == nil {
+		t.Error("tx with used nonce should be rejected")
+	}
+
+	notInConsensus := func(crypto.Hash) bool { return false }
+	if _, err := va.dropTransaction(txs[1].Hash(), func(crypto.Hash) bool { return true }); err != ErrTxInConsensus {
+		t.Errorf("drop tx in consensus error %v, want %v", err, ErrTxInConsensus)
+	}
+	dropped, err := va.dropTransaction(txs[1].Hash(), notInConsensus)
+	if err != nil || len(dropped) != 2 {
+		t.Fatalf("dropped %d txs error %v, want 2", len(dropped), err)
+	}
+	if va.nonce != 2 || va.amount.Cmp(big.NewInt(8)) != 0 {
+		t.Errorf("account nonce %d amount %v, want 2 and 8", va.nonce, va.amount)
+	}
+	if pending := va.pendingTxs(); len(pending) != 1 || pending[0].Hash() != txs[0].Hash() {
+		t.Errorf("pending txs %v, want first tx only", pending)
+	}
+}
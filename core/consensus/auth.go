@@ -0,0 +1,131 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+)
+
+// ISigner Interface for signing consensus messages with the replica key
+type ISigner interface {
+	SignHash(hash []byte) (*crypto.Signature, error)
+}
+
+// Sign signs the consensus message data with the replica key
+func Sign(signer ISigner, data []byte) ([]byte, error) {
+	h := crypto.Sha256(data)
+	sig, err := signer.SignHash(h[:])
+	if err != nil {
+		return nil, err
+	}
+	return sig.Bytes(), nil
+}
+
+// Verify checks the consensus message data is signed by the key of the replica
+func (rs Replicas) Verify(replicaID string, data, signature []byte) error {
+	publicKey, ok := rs[replicaID]
+	if !ok {
+		return fmt.Errorf("replica %s isn't in the replica set", replicaID)
+	}
+	if len(signature) != crypto.SignatureSize {
+		return fmt.Errorf("replica %s sent an unsigned message", replicaID)
+	}
+	key, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key of replica %s, %v", replicaID, err)
+	}
+
+	sig := &crypto.Signature{}
+	copy(sig[:], signature)
+	h := crypto.Sha256(data)
+	pub, err := sig.RecoverPublicKey(h[:])
+	if err != nil {
+		return fmt.Errorf("invalid signature of replica %s, %v", replicaID, err)
+	}
+	if !bytes.Equal(pub.Bytes(), key) {
+		return fmt.Errorf("message of replica %s isn't signed by its key", replicaID)
+	}
+	return nil
+}
+
+// Evidence Two signed messages of a replica voting different digests for the same slot
+type Evidence struct {
+	ReplicaID string
+	Slot      string
+	Digests   [2]string
+	Messages  [2][]byte
+}
+
+func (e *Evidence) String() string {
+	return fmt.Sprintf("replica %s voted %s and %s for %s", e.ReplicaID, e.Digests[0], e.Digests[1], e.Slot)
+}
+
+type vote struct {
+	digest  string
+	message []byte
+}
+
+// Equivocations Detects replicas voting different digests for the same slot,
+// the latest size votes are kept
+type Equivocations struct {
+	sync.Mutex
+	size  int
+	votes map[string]*vote
+	keys  []string
+}
+
+// NewEquivocations Create equivocation detector keeping size votes
+func NewEquivocations(size int) *Equivocations {
+	return &Equivocations{
+		size:  size,
+		votes: make(map[string]*vote),
+	}
+}
+
+// Check records the digest voted by the replica for the slot, it returns the
+// evidence if the replica voted a different digest before
+func (e *Equivocations) Check(replicaID, slot, digest string, message []byte) *Evidence {
+	e.Lock()
+	defer e.Unlock()
+	key := replicaID + "/" + slot
+	if v, ok := e.votes[key]; ok {
+		if v.digest == digest {
+			return nil
+		}
+		return &Evidence{
+			ReplicaID: replicaID,
+			Slot:      slot,
+			Digests:   [2]string{v.digest, digest},
+			Messages:  [2][]byte{v.message, message},
+		}
+	}
+
+	e.votes[key] = &vote{digest: digest, message: message}
+	e.keys = append(e.keys, key)
+	if len(e.keys) > e.size {
+		delete(e.votes, e.keys[0])
+		e.keys = e.keys[1:]
+	}
+	return nil
+}
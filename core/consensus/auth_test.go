@@ -0,0 +1,78 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"testing"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+)
+
+type testSigner struct {
+	*crypto.PrivateKey
+}
+
+func (s testSigner) SignHash(hash []byte) (*crypto.Signature, error) {
+	return s.Sign(hash)
+}
+
+func TestSignVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	rs := Replicas{"r1": utils.BytesToHex(key.Public().Bytes()), "r2": utils.BytesToHex(other.Public().Bytes())}
+
+	data := []byte("prepare")
+	sig, err := Sign(testSigner{key}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Verify("r1", data, sig); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		id   string
+		data []byte
+		sig  []byte
+	}{
+		{"r2", data, sig},
+		{"r3", data, sig},
+		{"r1", []byte("commit"), sig},
+		{"r1", data, nil},
+	} {
+		if err := rs.Verify(c.id, c.data, c.sig); err == nil {
+			t.Errorf("message %s of %s verified", c.data, c.id)
+		}
+	}
+}
+
+func TestEquivocations(t *testing.T) {
+	e := NewEquivocations(2)
+	if e.Check("r1", "1", "a", nil) != nil || e.Check("r1", "1", "a", nil) != nil || e.Check("r2", "1", "b", nil) != nil {
+		t.Fatal("evidence of consistent votes")
+	}
+	if evidence := e.Check("r1", "1", "b", []byte("b")); evidence == nil || evidence.Digests != [2]string{"a", "b"} {
+		t.Fatalf("evidence %v", evidence)
+	}
+	// the oldest vote is dropped
+	e.Check("r3", "1", "c", nil)
+	if e.Check("r1", "1", "b", nil) != nil {
+		t.Fatal("dropped vote kept")
+	}
+}
@@ -48,6 +48,19 @@ type IBroadcast interface {
 	Payload() []byte
 }
 
+// IUnicast is a broadcast for a single replica of the chain, it is
+// broadcast to the chain if the peer of the replica isn't known
+type IUnicast interface {
+	IBroadcast
+	Replica() string
+}
+
+// ISender is implemented by the consenters which send unicasts, it returns
+// the replica which sent the payload and expects a unicast answer
+type ISender interface {
+	Sender(payload []byte) string
+}
+
 // Consenter Interface for plugin consenser
 type Consenter interface {
 	Start()
@@ -65,3 +78,50 @@ type IStack interface {
 	GetLastSeqNo() uint64
 	ITxPool
 }
+
+// CommittedBlock is a block written by the consensus, SeqNo is the seqNo
+// of its last batch
+type CommittedBlock struct {
+	Height uint32
+	SeqNo  uint64
+	Block  []byte
+}
+
+// IBlockStack is implemented by the stacks which transfer the committed
+// blocks to the replicas fallen behind the batches kept by the others
+type IBlockStack interface {
+	CurrentHeight() uint32
+	// CommittedBlocks returns at most max committed blocks above height
+	CommittedBlocks(height uint32, max int) []*CommittedBlock
+	// AppendCommittedBlock verifies and appends the next block, seqNo is
+	// written with it
+	AppendCommittedBlock(block []byte, seqNo uint64) error
+}
+
+// State Snapshot of the consenter state
+type State struct {
+	Plugin  string `json:"plugin"`
+	ID      string `json:"id"`
+	Running bool   `json:"running"`
+	// View is the current primary of lbft, empty during view change
+	View      string `json:"view,omitempty"`
+	LastView  string `json:"lastView,omitempty"`
+	IsPrimary bool   `json:"isPrimary"`
+	SeqNo     uint64 `json:"seqNo"`
+	ExecSeqNo uint64 `json:"execSeqNo"`
+	LastSeqNo uint64 `json:"lastSeqNo"`
+	Instances int    `json:"instances"`
+	// Replicas is the replica set, empty if it isn't configured
+	Replicas []string `json:"replicas,omitempty"`
+	Quorum   int      `json:"quorum,omitempty"`
+	// FetchFrom is the first seqNo fetched from the other replicas, 0
+	// unless the replica fell behind
+	FetchFrom uint64 `json:"fetchFrom,omitempty"`
+	// Stalled reports the replicas don't answer the fetch any more
+	Stalled bool `json:"stalled,omitempty"`
+}
+
+// IState Interface for consenter reporting its state
+type IState interface {
+	State() *State
+}
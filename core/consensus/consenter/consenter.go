@@ -28,6 +28,8 @@ import (
 	"github.com/bocheninc/L0/core/consensus/noops"
 )
 
+var logger = log.Module(log.ModuleConsensus)
+
 // NewConsenter Create consenter of plugin
 func NewConsenter(option *Options, stack consensus.IStack) (consenter consensus.Consenter) {
 	plugin := strings.ToLower(option.Plugin)
@@ -37,12 +39,12 @@ func NewConsenter(option *Options, stack consensus.IStack) (consenter consensus.
 		consenter = nbft.NewNbft(option.Nbft, stack)
 	} else {
 		if plugin != "noops" {
-			log.Warnf("Unspport consenter of plugin %s, use default plugin noops", plugin)
+			logger.Warnf("Unspport consenter of plugin %s, use default plugin noops", plugin)
 			plugin = "noops"
 		}
 		consenter = noops.NewNoops(option.Noops, stack)
 	}
-	log.Infof("Consenter %s : %s", plugin, consenter)
+	logger.Infof("Consenter %s : %s", plugin, consenter)
 	go consenter.Start()
 	return consenter
 }
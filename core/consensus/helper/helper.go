@@ -27,6 +27,8 @@ func NewStack() *Stack {
 
 // Stack Implenment consensus.IStack
 type Stack struct {
+	// LastSeqNo is returned by GetLastSeqNo
+	LastSeqNo uint64
 }
 
 // NewTransaction Implenment consensus.IStack
@@ -36,7 +38,7 @@ func (stack *Stack) NewTransaction() consensus.ITransaction {
 
 // GetLastSeqNo Implenment consensus.IStack
 func (stack *Stack) GetLastSeqNo() uint64 {
-	return 0
+	return stack.LastSeqNo
 }
 
 // VerifyTxsInConsensus Implenment consensus.IStack
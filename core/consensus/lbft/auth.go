@@ -0,0 +1,112 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lbft
+
+import (
+	"fmt"
+
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+// sign returns a copy of the message signed with the replica key, the
+// message is returned as is if no signer is configured
+func (lbft *Lbft) sign(msg *Message) *Message {
+	if lbft.options.Signer == nil {
+		return msg
+	}
+	signed := &Message{Payload: msg.Payload}
+	sig, err := consensus.Sign(lbft.options.Signer, signed.Serialize())
+	if err != nil {
+		logger.Errorf("Replica %s failed to sign %s, %v", lbft.options.ID, msg.info(), err)
+		return signed
+	}
+	signed.Signature = sig
+	return signed
+}
+
+// verify checks the message is signed by a replica of the replica set of its
+// chain, any message is accepted if no replica set is configured. The votes
+// of other chains are rejected unless their replica set is configured, request
+// batches carry no sender and are not verified
+func (lbft *Lbft) verify(msg *Message) error {
+	lbft.rwReplicas.RLock()
+	replicas := lbft.replicas
+	lbft.rwReplicas.RUnlock()
+	if len(replicas) == 0 {
+		return nil
+	}
+	if _, ok := msg.Payload.(*Message_RequestBatch); ok {
+		return nil
+	}
+	chain, replicaID := msg.sender()
+	if replicaID == "" {
+		return fmt.Errorf("no sender replica")
+	}
+	if chain != lbft.options.Chain {
+		if replicas = lbft.options.ChainReplicas[chain]; len(replicas) == 0 {
+			return fmt.Errorf("no replica set of chain %s is configured", chain)
+		}
+	}
+	return replicas.Verify(replicaID, (&Message{Payload: msg.Payload}).Serialize(), msg.Signature)
+}
+
+// Sender returns the replica of our chain which sent a fetch committed
+// message, the committed batches are sent to it only
+func (lbft *Lbft) Sender(payload []byte) string {
+	msg := &Message{}
+	if err := msg.Deserialize(payload); err != nil {
+		return ""
+	}
+	fc := msg.GetFetchCommitted()
+	if fc == nil || fc.Chain != lbft.options.Chain || lbft.verify(msg) != nil {
+		return ""
+	}
+	return fc.ReplicaID
+}
+
+// checkEquivocation logs the evidence if the replica of our chain voted
+// another digest for the same seqNo and primary before
+func (lbft *Lbft) checkEquivocation(msg *Message, payload []byte) {
+	var (
+		phase, chain, replicaID, primaryID, digest string
+		seqNo                                      uint64
+	)
+	switch tp := msg.Payload.(type) {
+	case *Message_PrePrepare:
+		preprep := tp.PrePrepare
+		phase, chain, replicaID, primaryID, seqNo, digest = "prePrepare", preprep.Chain, preprep.ReplicaID, preprep.PrimaryID, preprep.SeqNo, hash(preprep.Requests)
+	case *Message_Prepare:
+		prepare := tp.Prepare
+		phase, chain, replicaID, primaryID, seqNo, digest = "prepare", prepare.Chain, prepare.ReplicaID, prepare.PrimaryID, prepare.SeqNo, prepare.Digest
+	case *Message_Commit:
+		commit := tp.Commit
+		phase, chain, replicaID, primaryID, seqNo, digest = "commit", commit.Chain, commit.ReplicaID, commit.PrimaryID, commit.SeqNo, commit.Digest
+	default:
+		return
+	}
+	if chain != lbft.options.Chain {
+		return
+	}
+
+	slot := fmt.Sprintf("%s of seqNo %d with primary %s", phase, seqNo, primaryID)
+	if evidence := lbft.equivocations.Check(replicaID, slot, digest, payload); evidence != nil {
+		equivocated.Inc()
+		logger.Errorf("Replica %s detected equivocation : %s, evidence %x and %x", lbft.options.ID, evidence, evidence.Messages[0], evidence.Messages[1])
+	}
+}
@@ -19,10 +19,13 @@
 package lbft
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils/vote"
+	"github.com/bocheninc/L0/core/trace"
 )
 
 func newLbftCore(name string, lbft *Lbft) *lbftCore {
@@ -79,7 +82,7 @@ func (instance *lbftCore) recvMessage(msg *Message) {
 
 func (instance *lbftCore) start() {
 	if instance.isRunnig {
-		log.Warnf("Replica %s core consenter %s alreay started", instance.lbft.options.ID, instance.name)
+		instance.logEntry().Warnf("Replica %s core consenter %s alreay started", instance.lbft.options.ID, instance.name)
 		return
 	}
 	instance.isRunnig = true
@@ -92,7 +95,7 @@ func (instance *lbftCore) start() {
 			case <-timeoutTimer.C:
 				if !instance.isPassCommit {
 					if instance.seqNo > instance.lbft.lastSeqNum() {
-						log.Debugf("Replica %s send view change for consensus %s : timeout (%d > %d)", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.lastSeqNum())
+						instance.logEntry().Debugf("Replica %s send view change for consensus %s : timeout (%d > %d)", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.lastSeqNum())
 						if instance.lbft.options.AutoVote {
 							instance.lbft.sendViewChange(nil)
 						}
@@ -103,15 +106,15 @@ func (instance *lbftCore) start() {
 				instance.exit = nil
 				if !instance.isPassCommit {
 					if instance.seqNo > instance.lbft.verifySeqNum() {
-						log.Errorf("Replica %s failed to verify for consensus %s :  wrong verifySeqNo (%d <= %d),  previous verify failed ", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.verifySeqNum())
+						instance.logEntry().Errorf("Replica %s failed to verify for consensus %s :  wrong verifySeqNo (%d <= %d),  previous verify failed ", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.verifySeqNum())
 					}
 					if instance.seqNo > instance.lbft.lastSeqNum() {
-						log.Warnf("Replica %s is failed for consensus %s (%d<=%d)", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.lastSeqNum())
+						instance.logEntry().Warnf("Replica %s is failed for consensus %s (%d<=%d)", instance.lbft.options.ID, instance.name, instance.seqNo, instance.lbft.lastSeqNum())
 					}
 				}
 				//instance.deltaTime[4] = time.Since(instance.startTime)
 				//log.Infof("lbft_core_cost_time(%s)  deltatime(%s,%s,%s,%s) txs(%d)", instance.name, instance.deltaTime[1], instance.deltaTime[2], instance.deltaTime[3], instance.deltaTime[4], len(instance.requestBatch.Requests))
-				log.Debugf("Replica %s core consenter %s stopped", instance.lbft.options.ID, instance.name)
+				instance.logEntry().Debugf("Replica %s core consenter %s stopped", instance.lbft.options.ID, instance.name)
 				return
 			case msg := <-instance.msgChan:
 				switch tp := msg.Payload.(type) {
@@ -122,18 +125,18 @@ func (instance *lbftCore) start() {
 				case *Message_Commit:
 					instance.handleCommit(msg.GetCommit())
 				default:
-					log.Warnf("unsupport core consensus message type %v ", tp)
+					instance.logEntry().Warnf("unsupport core consensus message type %v ", tp)
 				}
 			}
 		}
 	}()
 	instance.startTime = time.Now()
-	log.Debugf("Replica %s core consenter %s started", instance.lbft.options.ID, instance.name)
+	instance.logEntry().Debugf("Replica %s core consenter %s started", instance.lbft.options.ID, instance.name)
 }
 
 func (instance *lbftCore) stop() {
 	if !instance.isRunnig {
-		log.Warnf("Replica %s core consenter %s alreay stopped", instance.lbft.options.ID, instance.name)
+		instance.logEntry().Warnf("Replica %s core consenter %s alreay stopped", instance.lbft.options.ID, instance.name)
 		return
 	}
 	instance.isRunnig = false
@@ -158,14 +161,14 @@ func (instance *lbftCore) handleRequestBatch(seqNo uint64, requestBatch *Request
 	}
 	var verfiy bool
 	instance.lbft.prePrepareAsync.wait(instance.seqNo, func() {
-		log.Debugf("Replica %s handle requestBatch for consensus %s : seqNo %d (async preprepare)", instance.lbft.options.ID, instance.name, instance.seqNo)
+		instance.logEntry().Debugf("Replica %s handle requestBatch for consensus %s : seqNo %d (async preprepare)", instance.lbft.options.ID, instance.name, instance.seqNo)
 		instance.waitForVerify()
 		if requestBatch.Id != EMPTYBLOCK && instance.fromChain == instance.lbft.options.Chain {
 			//instance.lbft.stack.Removes(instance.lbft.toTxs(requestBatch))
 			id := requestBatch.Id
 			t := time.Now()
 			txs := instance.lbft.stack.VerifyTxsInConsensus(instance.lbft.toTxs(requestBatch), true)
-			log.Debugf("Replica %s VerifyTxsInConsensus elapsed %s for consensus %s(%d)", instance.lbft.options.ID, time.Now().Sub(t), instance.name, instance.seqNo)
+			instance.logEntry().Debugf("Replica %s VerifyTxsInConsensus elapsed %s for consensus %s(%d)", instance.lbft.options.ID, time.Now().Sub(t), instance.name, instance.seqNo)
 			requestBatch = instance.lbft.toRequestBatch(txs)
 			requestBatch.Id = id
 
@@ -179,7 +182,7 @@ func (instance *lbftCore) handleRequestBatch(seqNo uint64, requestBatch *Request
 			// }(requestBatch)
 
 			if instance.fromChain != instance.toChain {
-				log.Infof("Replica %s broadcast requestBatch message to %s  for consensus %s (%d transactions)", instance.lbft.options.ID, instance.toChain, instance.name, len(requestBatch.Requests))
+				instance.logEntry().Infof("Replica %s broadcast requestBatch message to %s  for consensus %s (%d transactions)", instance.lbft.options.ID, instance.toChain, instance.name, len(requestBatch.Requests))
 				instance.lbft.broadcast(instance.toChain, &Message{Payload: &Message_RequestBatch{RequestBatch: requestBatch}})
 			}
 		}
@@ -189,10 +192,10 @@ func (instance *lbftCore) handleRequestBatch(seqNo uint64, requestBatch *Request
 	})
 
 	if !verfiy {
-		log.Errorf("Replica %s for consensus %s failed to verify %d", instance.lbft.options.ID, instance.name, instance.seqNo)
+		instance.logEntry().Errorf("Replica %s for consensus %s failed to verify %d", instance.lbft.options.ID, instance.name, instance.seqNo)
 	}
 
-	log.Infof("Replica %s received requestBatch message for consensus %s (%d transactions) (seqNo %d)", instance.lbft.options.ID, instance.name, len(requestBatch.Requests), instance.seqNo)
+	instance.logEntry().Infof("Replica %s received requestBatch message for consensus %s (%d transactions) (seqNo %d)", instance.lbft.options.ID, instance.name, len(requestBatch.Requests), instance.seqNo)
 
 	prePrepare := &PrePrepare{
 		Name:      instance.name,
@@ -204,7 +207,7 @@ func (instance *lbftCore) handleRequestBatch(seqNo uint64, requestBatch *Request
 		// Quorum:    uint64(instance.lbft.intersectionQuorum()),
 		Requests: requestBatch,
 	}
-	log.Infof("Replica %s send prePrepare message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(requestBatch.Requests))
+	instance.logEntry().Infof("Replica %s send prePrepare message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(requestBatch.Requests))
 	instance.handlePrePrepare(prePrepare)
 	instance.lbft.broadcast(instance.lbft.options.Chain, &Message{Payload: &Message_PrePrepare{PrePrepare: prePrepare}})
 
@@ -215,7 +218,7 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 		return
 	}
 	if instance.isPassPrePrepare {
-		log.Errorf("Replica %s received prePrepare message from %s for consensus %s : alreay exist ", instance.lbft.options.ID, preprep.ReplicaID, instance.name)
+		instance.logEntry().Errorf("Replica %s received prePrepare message from %s for consensus %s : alreay exist ", instance.lbft.options.ID, preprep.ReplicaID, instance.name)
 		return
 	}
 
@@ -235,18 +238,18 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 
 	if !instance.lbft.isPrimary() {
 		if !instance.lbft.isValid(requestBatch, fromChain == instance.lbft.options.Chain) {
-			log.Errorf("Replica %s received requestBatch message  for consensus %s (%d transactions): illegal requestBatch", instance.lbft.options.ID, instance.name, len(requestBatch.Requests))
+			instance.logEntry().Errorf("Replica %s received requestBatch message  for consensus %s (%d transactions): illegal requestBatch", instance.lbft.options.ID, instance.name, len(requestBatch.Requests))
 			return
 		}
 		var verify bool
 		instance.lbft.prePrepareAsync.wait(instance.seqNo, func() {
-			log.Debugf("Replica %s handle preprepare for consensus %s : seqNo %d (async preprepare)", instance.lbft.options.ID, instance.name, instance.seqNo)
+			instance.logEntry().Debugf("Replica %s handle preprepare for consensus %s : seqNo %d (async preprepare)", instance.lbft.options.ID, instance.name, instance.seqNo)
 			instance.waitForVerify()
 			if requestBatch.Id != EMPTYBLOCK && instance.lbft.options.Chain == fromChain && instance.seqNo > instance.lbft.seqNum() {
 				//instance.lbft.stack.Removes(instance.lbft.toTxs(requestBatch))
 				t := time.Now()
 				txs := instance.lbft.stack.VerifyTxsInConsensus(instance.lbft.toTxs(requestBatch), false)
-				log.Debugf("Replica %s VerifyTxsInConsensus elapsed %s for consensus %s(%d)", instance.lbft.options.ID, time.Now().Sub(t), instance.name, instance.seqNo)
+				instance.logEntry().Debugf("Replica %s VerifyTxsInConsensus elapsed %s for consensus %s(%d)", instance.lbft.options.ID, time.Now().Sub(t), instance.name, instance.seqNo)
 				trequestBatch := instance.lbft.toRequestBatch(txs)
 				trequestBatch.Id = requestBatch.Id
 				trequestBatch.Time = requestBatch.Time
@@ -260,7 +263,7 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 				// }(requestBatch)
 
 				if hash(requestBatch) != hash(trequestBatch) {
-					log.Errorf("Replica %s received prePrepare message from %s for consensus %s : different digest (%d==%d)", instance.lbft.options.ID, preprep.ReplicaID, instance.name, len(requestBatch.Requests), len(trequestBatch.Requests))
+					instance.logEntry().Errorf("Replica %s received prePrepare message from %s for consensus %s : different digest (%d==%d)", instance.lbft.options.ID, preprep.ReplicaID, instance.name, len(requestBatch.Requests), len(trequestBatch.Requests))
 					return
 				}
 			}
@@ -268,19 +271,24 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 			verify = true
 		})
 		if !verify {
-			log.Errorf("Replica %s for consensus %s failed to verify %d", instance.lbft.options.ID, instance.name, instance.seqNo)
+			instance.logEntry().Errorf("Replica %s for consensus %s failed to verify %d", instance.lbft.options.ID, instance.name, instance.seqNo)
 			return
 		}
 	} else if requestBatch.Id != EMPTYBLOCK {
 		if instance.toChain == instance.fromChain {
 			instance.lbft.resetEmptyBlockTimer()
 		} else {
-			log.Debugf("Replica %s start cross chain empty block", instance.lbft.options.ID)
+			instance.logEntry().Debugf("Replica %s start cross chain empty block", instance.lbft.options.ID)
 			instance.lbft.softResetEmptyBlockTimer()
 		}
 	}
 
-	log.Infof("Replica %s received prePrepare message from %s for consensus %s (%d transactions)", instance.lbft.options.ID, preprep.ReplicaID, instance.name, len(requestBatch.Requests))
+	if digest, ok := instance.lbft.wal.voted(instance.lbft.primaryID, instance.seqNo); ok && digest != hash(requestBatch) {
+		instance.logEntry().Errorf("Replica %s received prePrepare message from %s for consensus %s : already voted another digest for seqNo %d", instance.lbft.options.ID, preprep.ReplicaID, instance.name, instance.seqNo)
+		return
+	}
+
+	instance.logEntry().Infof("Replica %s received prePrepare message from %s for consensus %s (%d transactions)", instance.lbft.options.ID, preprep.ReplicaID, instance.name, len(requestBatch.Requests))
 
 	instance.requestBatch = requestBatch
 	// instance.fromChain = fromChain
@@ -288,7 +296,9 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 	//instance.seqNo = preprep.SeqNo
 	instance.digest = hash(instance.requestBatch)
 	instance.isPassPrePrepare = true
+	instance.traceRequestBatch()
 	instance.deltaTime[1] = time.Since(instance.startTime)
+	roundSeconds.With("prePrepare").Observe(instance.deltaTime[1].Seconds())
 	prepare := &Prepare{
 		Name:      instance.name,
 		PrimaryID: instance.lbft.primaryID,
@@ -298,7 +308,15 @@ func (instance *lbftCore) handlePrePrepare(preprep *PrePrepare) {
 		Digest:    instance.digest,
 		Quorum:    uint64(instance.lbft.intersectionQuorum()),
 	}
-	log.Infof("Replica %s send prepare message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
+	if err := instance.lbft.wal.recordPrePrepare(preprep); err != nil {
+		instance.logEntry().Errorf("Replica %s failed to record prePrepare for consensus %s, %v", instance.lbft.options.ID, instance.name, err)
+		return
+	}
+	if err := instance.lbft.wal.recordPrepare(prepare); err != nil {
+		instance.logEntry().Errorf("Replica %s failed to record prepare for consensus %s, %v", instance.lbft.options.ID, instance.name, err)
+		return
+	}
+	instance.logEntry().Infof("Replica %s send prepare message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
 	instance.handlePrepare(prepare)
 	instance.broadcast(&Message{Payload: &Message_Prepare{Prepare: prepare}})
 }
@@ -309,25 +327,26 @@ func (instance *lbftCore) handlePrepare(prepare *Prepare) {
 	}
 	if instance.isPassPrePrepare {
 		if prepare.Chain != instance.fromChain && prepare.Chain != instance.toChain {
-			log.Errorf("Replica %s received prepare message from %s for consensus %s: illegal prepare", instance.lbft.options.ID, prepare.ReplicaID, instance.name)
+			instance.logEntry().Errorf("Replica %s received prepare message from %s for consensus %s: illegal prepare", instance.lbft.options.ID, prepare.ReplicaID, instance.name)
 			return
 		}
 
 		if prepare.Chain == instance.lbft.options.Chain && prepare.SeqNo != instance.seqNo {
-			log.Errorf("Replica %s received prepare message from %s for consensus %s : different seqNo (%d == %d) ", instance.lbft.options.ID, prepare.ReplicaID, instance.name, instance.seqNo, prepare.SeqNo)
+			instance.logEntry().Errorf("Replica %s received prepare message from %s for consensus %s : different seqNo (%d == %d) ", instance.lbft.options.ID, prepare.ReplicaID, instance.name, instance.seqNo, prepare.SeqNo)
 			return
 		}
 
 		if prepare.Digest != instance.digest {
-			log.Errorf("Replica %s received prepare message from %s for consensus %s : different digest ", instance.lbft.options.ID, prepare.ReplicaID, instance.name)
+			instance.logEntry().Errorf("Replica %s received prepare message from %s for consensus %s : different digest ", instance.lbft.options.ID, prepare.ReplicaID, instance.name)
 			return
 		}
 	}
 
 	instance.prepareVote.Add(prepare.ReplicaID, prepare)
-	log.Infof("Replica %s received prepare message from %s for consensus %s, voted %d", instance.lbft.options.ID, prepare.ReplicaID, prepare.Name, instance.prepareVote.Size())
+	instance.logEntry().Infof("Replica %s received prepare message from %s for consensus %s, voted %d", instance.lbft.options.ID, prepare.ReplicaID, prepare.Name, instance.prepareVote.Size())
 	if instance.isPassPrepare == false && instance.maybePreparePass() {
 		instance.deltaTime[2] = time.Since(instance.startTime)
+		roundSeconds.With("prepare").Observe(instance.deltaTime[2].Seconds())
 
 		commit := &Commit{
 			Name:      instance.name,
@@ -338,7 +357,11 @@ func (instance *lbftCore) handlePrepare(prepare *Prepare) {
 			Digest:    instance.digest,
 			Quorum:    uint64(instance.lbft.intersectionQuorum()),
 		}
-		log.Infof("Replica %s send commit message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
+		if err := instance.lbft.wal.recordCommit(commit); err != nil {
+			instance.logEntry().Errorf("Replica %s failed to record commit for consensus %s, %v", instance.lbft.options.ID, instance.name, err)
+			return
+		}
+		instance.logEntry().Infof("Replica %s send commit message for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
 		instance.handleCommit(commit)
 		instance.broadcast(&Message{Payload: &Message_Commit{Commit: commit}})
 	}
@@ -350,28 +373,29 @@ func (instance *lbftCore) handleCommit(commit *Commit) {
 	}
 	if instance.isPassPrePrepare {
 		if commit.Chain != instance.fromChain && commit.Chain != instance.toChain {
-			log.Errorf("Replica %s received commit message from %s for consensus %s: illegal commit", instance.lbft.options.ID, commit.ReplicaID, instance.name)
+			instance.logEntry().Errorf("Replica %s received commit message from %s for consensus %s: illegal commit", instance.lbft.options.ID, commit.ReplicaID, instance.name)
 			return
 		}
 
 		if commit.Chain == instance.lbft.options.Chain && commit.SeqNo != instance.seqNo {
-			log.Errorf("Replica %s received prepare message from %s for consensus %s : different seqNo (%d == %d) ", instance.lbft.options.ID, commit.ReplicaID, instance.name, instance.seqNo, commit.SeqNo)
+			instance.logEntry().Errorf("Replica %s received prepare message from %s for consensus %s : different seqNo (%d == %d) ", instance.lbft.options.ID, commit.ReplicaID, instance.name, instance.seqNo, commit.SeqNo)
 			return
 		}
 
 		if commit.Digest != instance.digest {
-			log.Errorf("Replica %s received prepare message from %s for consensus %s : different digest ", instance.lbft.options.ID, commit.ReplicaID, instance.name)
+			instance.logEntry().Errorf("Replica %s received prepare message from %s for consensus %s : different digest ", instance.lbft.options.ID, commit.ReplicaID, instance.name)
 			return
 		}
 	}
 
 	instance.commitVote.Add(commit.ReplicaID, commit)
-	log.Infof("Replica %s received commit message from %s for consensus %s, voted %d", instance.lbft.options.ID, commit.ReplicaID, commit.Name, instance.commitVote.Size())
+	instance.logEntry().Infof("Replica %s received commit message from %s for consensus %s, voted %d", instance.lbft.options.ID, commit.ReplicaID, commit.Name, instance.commitVote.Size())
 
 	if instance.isPassCommit == false && instance.maybeCommitPass() {
 		instance.deltaTime[3] = time.Since(instance.startTime)
+		roundSeconds.With("commit").Observe(instance.deltaTime[3].Seconds())
 		instance.lbft.commitAsync.wait(instance.seqNo, func() {
-			log.Infof("Replica %s succeed to commit for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
+			instance.logEntry().Infof("Replica %s succeed to commit for consensus %s (%d transactions)", instance.lbft.options.ID, instance.name, len(instance.requestBatch.Requests))
 			ctt := &Committed{
 				Name:         instance.name,
 				Chain:        instance.lbft.options.Chain,
@@ -524,3 +548,17 @@ func (instance *lbftCore) waitForVerify() {
 		}
 	}
 }
+
+func (instance *lbftCore) logEntry() *log.Entry {
+	return logger.WithFields(log.Fields{"consensus": instance.name, log.FieldSeqNo: instance.seqNo})
+}
+
+func (instance *lbftCore) traceRequestBatch() {
+	if instance.requestBatch.Id == EMPTYBLOCK {
+		return
+	}
+	detail := fmt.Sprintf("consensus %s, primary %s", instance.name, instance.lbft.primaryID)
+	for _, req := range instance.requestBatch.Requests {
+		trace.Add(crypto.DoubleSha256(req.Transaction), trace.Record{Stage: trace.RequestBatch, OK: true, SeqNo: instance.seqNo, Detail: detail})
+	}
+}
@@ -29,10 +29,13 @@ import (
 	"sort"
 
 	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
 	"github.com/bocheninc/L0/components/utils/vote"
 	"github.com/bocheninc/L0/core/consensus"
 )
 
+var logger = log.Module(log.ModuleConsensus)
+
 //MINQUORUM  Define min quorum
 const MINQUORUM = 3
 
@@ -51,31 +54,44 @@ func NewLbft(options *Options, stack consensus.IStack) *Lbft {
 		lbftCores:             make(map[string]*lbftCore),
 		voteViewChange:        vote.NewVote(),
 		voteCommitted:         make(map[string]*vote.Vote),
+		voteCommittedBlock:    make(map[uint32]*vote.Vote),
+		fetchedBlocks:         make(map[uint32]*CommittedBlock),
+		fetchedBlockChan:      make(chan struct{}, 1),
+		equivocations:         consensus.NewEquivocations(3 * options.N * (options.K + 1)),
 
 		committedRequestBatchChan: make(chan *committedRequestBatch, options.BufferSize),
 		recvConsensusMsgChan:      make(chan *Message, options.BufferSize),
 		committedTxsChan:          make(chan *consensus.CommittedTxs, options.BufferSize),
 		broadcastChan:             make(chan consensus.IBroadcast, options.BufferSize),
+		replicaChangeChan:         make(chan *consensus.ReplicaChange, options.BufferSize),
 		pool:                      &sync.Pool{New: func() interface{} { return stack.NewTransaction() }},
 	}
 
 	if lbft.options.BlockTimeout >= lbft.options.BlockInterval {
-		log.Warn("lbft.blockTimeout should is smaller lbft.blockInterval")
+		logger.Warn("lbft.blockTimeout should is smaller lbft.blockInterval")
 		lbft.options.BlockTimeout = 4 * lbft.options.BlockInterval / 5
 	}
 
 	if lbft.options.ViewChangePeriod > 0*time.Second && lbft.options.ViewChangePeriod <= lbft.options.BlockInterval {
-		log.Warn("lbft.ViewChangePeriod should is greater lbft.blockInterval")
+		logger.Warn("lbft.ViewChangePeriod should is greater lbft.blockInterval")
 		lbft.options.ViewChangePeriod = 1000 * lbft.options.BlockInterval
 	}
 
+	if len(lbft.options.Replicas) > 0 {
+		lbft.replicas = lbft.options.Replicas
+		lbft.options.N = len(lbft.options.Replicas)
+		lbft.options.Q = quorum(lbft.options.N)
+	} else {
+		logger.Warnf("Replica %s has no replica set configured, consensus messages aren't authenticated", lbft.options.ID)
+	}
+
 	if lbft.options.N < 4 {
-		log.Panicf("lbft.N should is greater 3, %d", lbft.options.N)
+		logger.Panicf("lbft.N should is greater 3, %d", lbft.options.N)
 	}
 
 	if 3*lbft.options.Q+1 < lbft.options.N*2 {
 		q := (lbft.options.N*2-1)/3 + 1
-		log.Warn("lbft.Q should is greater %d", q)
+		logger.Warnf("lbft.Q should is greater %d", q)
 		lbft.options.Q = q
 	}
 
@@ -91,6 +107,11 @@ func NewLbft(options *Options, stack consensus.IStack) *Lbft {
 	lbft.viewChangePeriodTimer.Stop()
 	lbft.nullRequestTimer = time.NewTimer(lbft.options.NullRequest)
 	lbft.nullRequestTimer.Stop()
+	metrics.NewGaugeFunc("l0_lbft_instances", "Number of lbft consensus instances.", func() float64 {
+		lbft.rwlbftCores.RLock()
+		defer lbft.rwlbftCores.RUnlock()
+		return float64(len(lbft.lbftCores))
+	})
 	return lbft
 }
 
@@ -113,12 +134,19 @@ type Lbft struct {
 	stack                   consensus.IStack
 	committedRequestBatch   map[uint64]*RequestBatch
 	rwCommittedRequestBatch sync.RWMutex
+	fetchFrom               uint64
+	fetchTime               time.Time
+	fetchAttempts           int
+	fetchedBlocks           map[uint32]*CommittedBlock
+	fetchedBlockChan        chan struct{}
+	writtenSeqNo            uint64
 	lbftCores               map[string]*lbftCore
 	rwlbftCores             sync.RWMutex
 	lbftCoreChan            chan string
 	lbftCoreCommittedChan   chan *Committed
 	voteViewChange          *vote.Vote
 	voteCommitted           map[string]*vote.Vote
+	voteCommittedBlock      map[uint32]*vote.Vote
 
 	blockTimer            *time.Timer
 	viewChangeTimer       *time.Timer
@@ -137,6 +165,12 @@ type Lbft struct {
 	exit                 chan struct{}
 	waitGroup            sync.WaitGroup
 	pool                 *sync.Pool
+	wal                  *wal
+
+	replicas          consensus.Replicas
+	replicaChangeChan chan *consensus.ReplicaChange
+	rwReplicas        sync.RWMutex
+	equivocations     *consensus.Equivocations
 }
 
 func (lbft *Lbft) String() string {
@@ -193,9 +227,18 @@ func (lbft *Lbft) incrVerifySeqNum() uint64 {
 //Start Start consenter serverice
 func (lbft *Lbft) Start() {
 	if lbft.exit != nil {
-		log.Warnf("Replica %s consenter alreay started", lbft.options.ID)
+		logger.Warnf("Replica %s consenter alreay started", lbft.options.ID)
 		return
 	}
+	var prePrepares []*PrePrepare
+	if lbft.options.WAL != "" {
+		w, err := openWAL(lbft.options.WAL, int(lbft.retain()))
+		if err != nil {
+			logger.Panicf("Replica %s failed to open consensus wal %s, %v", lbft.options.ID, lbft.options.WAL, err)
+		}
+		lbft.wal = w
+		prePrepares = lbft.restore(w.snapshot())
+	}
 	lbft.exit = make(chan struct{})
 	go func() {
 		lbft.waitGroup.Add(1)
@@ -212,29 +255,44 @@ func (lbft *Lbft) Start() {
 		defer lbft.waitGroup.Done()
 		lbft.handleConsensusMsg()
 	}()
-	log.Debugf("Replica %s consenter started", lbft.options.ID)
+	logger.Debugf("Replica %s consenter started", lbft.options.ID)
 	lbft.resetBlockTimer()
+	for _, prePrepare := range prePrepares {
+		lbft.recvConsensusMsgChan <- &Message{Payload: &Message_PrePrepare{PrePrepare: prePrepare}}
+	}
+	if lbft.wal != nil {
+		go lbft.checkpoint()
+	}
 }
 
 //Stop Stop consenter serverice
 func (lbft *Lbft) Stop() {
 	if lbft.exit == nil {
-		log.Warnf("Replica %s consenter alreay stopped", lbft.options.ID)
+		logger.Warnf("Replica %s consenter alreay stopped", lbft.options.ID)
 		return
 	}
 	close(lbft.exit)
 	lbft.waitGroup.Wait()
 	lbft.exit = nil
-	log.Debugf("Replica %s consenter stopped", lbft.options.ID)
+	if err := lbft.wal.close(); err != nil {
+		logger.Errorf("Replica %s failed to close consensus wal, %v", lbft.options.ID, err)
+	}
+	logger.Debugf("Replica %s consenter stopped", lbft.options.ID)
 }
 
 //RecvConsensus Receive consensus data for consenter
 func (lbft *Lbft) RecvConsensus(payload []byte) {
 	msg := &Message{}
 	if err := msg.Deserialize(payload); err != nil {
-		log.Errorf("Replica %s receive consensus message : unkown %v", lbft.options.ID, err)
+		logger.Errorf("Replica %s receive consensus message : unkown %v", lbft.options.ID, err)
 		return
 	}
+	if err := lbft.verify(msg); err != nil {
+		rejected.Inc()
+		logger.Warnf("Replica %s receive consensus message %s : reject %v", lbft.options.ID, msg.info(), err)
+		return
+	}
+	lbft.checkEquivocation(msg, payload)
 	//log.Debugf("Replica %s receive broadcast consensus message %s(%s)", lbft.options.ID, msg.info(), hash(msg))
 	lbft.recvConsensusMsgChan <- msg
 }
@@ -254,7 +312,14 @@ func (lbft *Lbft) CommittedTxsChannel() <-chan *consensus.CommittedTxs {
 	return lbft.committedTxsChan
 }
 
+// ReplicaChangeChannel Implement consensus.IReplicaNotifier
+func (lbft *Lbft) ReplicaChangeChannel() <-chan *consensus.ReplicaChange {
+	return lbft.replicaChangeChan
+}
+
 func (lbft *Lbft) intersectionQuorum() int {
+	lbft.rwReplicas.RLock()
+	defer lbft.rwReplicas.RUnlock()
 	return lbft.options.Q
 }
 
@@ -269,7 +334,13 @@ func (lbft *Lbft) handleCommittedRequestBatch() {
 			lbft.removeInstance(name)
 		case committed := <-lbft.lbftCoreCommittedChan:
 			lbft.addCommittedReqeustBatch(committed.SeqNo, committed.RequestBatch)
+		case <-lbft.fetchedBlockChan:
+			lbft.appendFetchedBlocks()
 		case ctt := <-lbft.committedRequestBatchChan:
+			if ctt.seqNo <= lbft.writtenSeqNo {
+				// written by a fetched block
+				break
+			}
 			if ctt.requestBatch.Id == EMPTYBLOCK {
 				if id != EMPTYBLOCK || has {
 					lbft.committedBlock = append(lbft.committedBlock, ctt)
@@ -325,9 +396,16 @@ func (lbft *Lbft) writeBlock() {
 			}
 		}
 	}
-	log.Infof("Replica %s write block %v (%d transactions) ", lbft.options.ID, seqNos, len(txs))
+	logger.Infof("Replica %s write block %v (%d transactions) ", lbft.options.ID, seqNos, len(txs))
+	lbft.writtenSeqNo = seqNos[len(seqNos)-1]
 	lbft.committedTxsChan <- &consensus.CommittedTxs{Time: nano, Transactions: txs, SeqNos: seqNos}
 	lbft.committedBlock = nil
+	// the blockchain drains the batches on a graceful stop, after a crash
+	// restore executes again the ones it didn't write
+	if err := lbft.wal.recordExec(seqNos[len(seqNos)-1]); err != nil {
+		logger.WithField(log.FieldSeqNo, seqNos[len(seqNos)-1]).Errorf("Replica %s failed to record exec seqNo %d, %v", lbft.options.ID, seqNos[len(seqNos)-1], err)
+	}
+	lbft.syncReplicas(seqNos[len(seqNos)-1])
 }
 
 func (lbft *Lbft) handleTransaction() {
@@ -336,18 +414,18 @@ func (lbft *Lbft) handleTransaction() {
 		case <-lbft.exit:
 			return
 		case <-lbft.viewChangeTimer.C:
-			log.Debugf("Replica %s view change timeout", lbft.options.ID)
+			logger.Debugf("Replica %s view change timeout", lbft.options.ID)
 			lbft.voteViewChange.Clear()
 		case <-lbft.resendViewChangeTimer.C:
 			lbft.votedCnt++
-			if lbft.votedCnt > lbft.options.K*lbft.options.N {
+			if lbft.votedCnt > lbft.options.K*lbft.replicaNum() {
 				lbft.voteViewChange.IterVoter(func(voter string, ticket vote.ITicket) {
 					tvc := ticket.(*ViewChange)
-					log.Infof("Replica %s received view change message from %s for voter %s , lastSeqNo %d", lbft.options.ID, tvc.ReplicaID, tvc.PrimaryID, tvc.H)
+					logger.Infof("Replica %s received view change message from %s for voter %s , lastSeqNo %d", lbft.options.ID, tvc.ReplicaID, tvc.PrimaryID, tvc.H)
 				})
-				log.Panicf("Replica %s failed to vote new Primary, diff lastSeqNo  %d", lbft.options.ID, lbft.lastSeqNum())
+				logger.Panicf("Replica %s failed to vote new Primary, diff lastSeqNo  %d", lbft.options.ID, lbft.lastSeqNum())
 			}
-			log.Debugf("Replica %s resend view change %s", lbft.options.ID, time.Now())
+			logger.Debugf("Replica %s resend view change %s", lbft.options.ID, time.Now())
 			var vc *ViewChange
 			lbft.voteViewChange.IterVoter(func(voter string, ticket vote.ITicket) {
 				tvc := ticket.(*ViewChange)
@@ -365,7 +443,7 @@ func (lbft *Lbft) handleTransaction() {
 			time.Sleep(time.Second - t1.Sub(t2))
 			lbft.sendViewChange(vc)
 		case <-lbft.viewChangePeriodTimer.C:
-			log.Debugf("Replica %s view change period", lbft.options.ID)
+			logger.Debugf("Replica %s view change period", lbft.options.ID)
 			lbft.sendViewChange(nil)
 		case <-lbft.nullRequestTimer.C:
 			lbft.nullRequestHandler()
@@ -375,8 +453,11 @@ func (lbft *Lbft) handleTransaction() {
 				lbft.handleRequestBatch(requestBath)
 			}
 			lbft.emptyBlockTimerStart = false
-			log.Debugf("Replica %s stop empty block", lbft.options.ID)
+			logger.Debugf("Replica %s stop empty block", lbft.options.ID)
 		case <-lbft.blockTimer.C:
+			if lbft.isFetching() {
+				go lbft.checkpoint()
+			}
 			lbft.maybeSendViewChange()
 			lbft.submitRequestBatches()
 			lbft.resetBlockTimer()
@@ -399,7 +480,7 @@ func (lbft *Lbft) submitRequestBatches() {
 	lbft.stack.IterTransaction(func(tx consensus.ITransaction) bool {
 		if tx == nil && len(reqs) > 0 {
 			requestBath := &RequestBatch{Time: nano, Requests: reqs, Id: id}
-			log.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
+			logger.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
 			cnt = 0
 			nano = 0
 			toChain = ""
@@ -418,7 +499,7 @@ func (lbft *Lbft) submitRequestBatches() {
 		}
 		if toChain != "" && req.ToChain != toChain {
 			requestBath := &RequestBatch{Time: nano, Requests: reqs, Id: id}
-			log.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
+			logger.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
 			cnt = 0
 			nano = 0
 			toChain = ""
@@ -436,7 +517,7 @@ func (lbft *Lbft) submitRequestBatches() {
 		cnt++
 		if cnt == lbft.options.BlockSize {
 			requestBath := &RequestBatch{Time: nano, Requests: reqs, Id: id}
-			log.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
+			logger.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
 			cnt = 0
 			nano = 0
 			toChain = ""
@@ -451,14 +532,14 @@ func (lbft *Lbft) submitRequestBatches() {
 	if len(reqs) > 0 {
 		requestBath := &RequestBatch{Time: nano, Requests: reqs, Id: id}
 		requestBatchList = append(requestBatchList, requestBath)
-		log.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
+		logger.Debugf("Replica %s generate requestBatch %s : timestamp %d, transations %d", lbft.options.ID, hash(requestBath), requestBath.Time, len(requestBath.Requests))
 	}
 
 	for _, requestBatch := range requestBatchList {
 		if lbft.isValid(requestBatch, true) {
 			lbft.handleRequestBatch(requestBatch)
 		} else {
-			log.Warnf("Replica %s received requestBatch message for consensus %s : ignore illegal requestBatch (%s == %s)", lbft.options.ID, requestBatch.key(), requestBatch.fromChain(), lbft.options.Chain)
+			logger.Warnf("Replica %s received requestBatch message for consensus %s : ignore illegal requestBatch (%s == %s)", lbft.options.ID, requestBatch.key(), requestBatch.fromChain(), lbft.options.Chain)
 		}
 	}
 }
@@ -485,7 +566,7 @@ func (lbft *Lbft) resetEmptyBlockTimer() {
 	t2 := t1.Truncate(lbft.options.BlockInterval)
 	lbft.emptyBlockTimer.Reset(2*lbft.options.BlockInterval - t1.Sub(t2))
 	lbft.emptyBlockTimerStart = true
-	log.Debugf("Replica %s start empty block", lbft.options.ID)
+	logger.Debugf("Replica %s start empty block", lbft.options.ID)
 }
 
 func (lbft *Lbft) softResetEmptyBlockTimer() {
@@ -497,7 +578,7 @@ func (lbft *Lbft) softResetEmptyBlockTimer() {
 	t2 := t1.Truncate(lbft.options.BlockInterval)
 	lbft.emptyBlockTimer.Reset(2*lbft.options.BlockInterval - t1.Sub(t2))
 	lbft.emptyBlockTimerStart = true
-	log.Debugf("Replica %s start empty block", lbft.options.ID)
+	logger.Debugf("Replica %s start empty block", lbft.options.ID)
 }
 
 func (lbft *Lbft) hasPrimary() bool {
@@ -508,7 +589,7 @@ func (lbft *Lbft) maybeSendViewChange() {
 	if lbft.hasPrimary() {
 		return
 	}
-	log.Debugf("Primary %s has no PrimaryID, send view change", lbft.options.ID)
+	logger.Debugf("Primary %s has no PrimaryID, send view change", lbft.options.ID)
 	lbft.sendViewChange(nil)
 }
 
@@ -522,14 +603,18 @@ func (lbft *Lbft) handleConsensusMsg() {
 		case <-lbft.exit:
 			return
 		case msg := <-lbft.recvConsensusMsgChan:
+			if chain, replicaID := msg.sender(); chain == lbft.options.Chain && !lbft.isReplica(replicaID) {
+				logger.Warnf("Replica %s received %s : ignore not in the replica set", lbft.options.ID, msg.info())
+				continue
+			}
 			switch tp := msg.Payload.(type) {
 			case *Message_RequestBatch:
 				if requestBatch := msg.GetRequestBatch(); requestBatch != nil {
 					if !lbft.isValid(requestBatch, false) {
-						log.Errorf("Replica %s received requestBatch message for consensus %s : ignore illegal requestBatch (%s == %s) ", lbft.options.ID, requestBatch.key(), requestBatch.toChain(), lbft.options.Chain)
+						logger.Errorf("Replica %s received requestBatch message for consensus %s : ignore illegal requestBatch (%s == %s) ", lbft.options.ID, requestBatch.key(), requestBatch.toChain(), lbft.options.Chain)
 					} else if lbft.isPrimary() {
 						if lbft.concurrentCntTo > lbft.options.MaxConcurrentNumTo {
-							log.Warnf("Replica %s received requestBatch message for consensus %s :  max concurrent %d ", lbft.options.ID, requestBatch.key(), lbft.options.MaxConcurrentNumTo)
+							logger.Warnf("Replica %s received requestBatch message for consensus %s :  max concurrent %d ", lbft.options.ID, requestBatch.key(), lbft.options.MaxConcurrentNumTo)
 						} else {
 							lbft.concurrentCntTo++
 							lbft.handleRequestBatch(requestBatch)
@@ -539,13 +624,13 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_PrePrepare:
 				if preprepare := msg.GetPrePrepare(); preprepare != nil {
 					if !lbft.hasPrimary() {
-						log.Errorf("Replica %s received prePrepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.PrimaryID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, preprepare.SeqNo).Errorf("Replica %s received prePrepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.PrimaryID, lbft.primaryID)
 					} else if preprepare.Chain != lbft.options.Chain || preprepare.ReplicaID != preprepare.PrimaryID {
-						log.Errorf("Replica %s received prePrepare message from %s for consensus %s : ignore illegal preprepare (%s==%s) ", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.Chain, lbft.options.Chain)
+						logger.WithField(log.FieldSeqNo, preprepare.SeqNo).Errorf("Replica %s received prePrepare message from %s for consensus %s : ignore illegal preprepare (%s==%s) ", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.Chain, lbft.options.Chain)
 					} else if preprepare.ReplicaID != lbft.primaryID {
-						log.Errorf("Replica %s received prePrepare message from %s for consensus %s :  ignore not from primayID (%s==%s)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.ReplicaID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, preprepare.SeqNo).Errorf("Replica %s received prePrepare message from %s for consensus %s :  ignore not from primayID (%s==%s)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.ReplicaID, lbft.primaryID)
 					} else if preprepare.SeqNo <= lbft.lastSeqNum() {
-						log.Debugf("Replica %s received prePrepare message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.SeqNo, lbft.lastSeqNum())
+						logger.WithField(log.FieldSeqNo, preprepare.SeqNo).Debugf("Replica %s received prePrepare message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, preprepare.ReplicaID, preprepare.Name, preprepare.SeqNo, lbft.lastSeqNum())
 					} else {
 						lbft.handleLbftCoreMsg(preprepare.Name, msg)
 					}
@@ -553,11 +638,11 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_Prepare:
 				if prepare := msg.GetPrepare(); prepare != nil {
 					if !lbft.hasPrimary() {
-						log.Errorf("Replica %s received prepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.PrimaryID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, prepare.SeqNo).Errorf("Replica %s received prepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.PrimaryID, lbft.primaryID)
 					} else if prepare.Chain == lbft.options.Chain && prepare.PrimaryID != lbft.primaryID {
-						log.Errorf("Replica %s received prepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.PrimaryID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, prepare.SeqNo).Errorf("Replica %s received prepare message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.PrimaryID, lbft.primaryID)
 					} else if prepare.Chain == lbft.options.Chain && prepare.SeqNo <= lbft.lastSeqNum() {
-						log.Debugf("Replica %s received prepare message from %s for consensus %s : ingore delay sepNo (%d > %d)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.SeqNo, lbft.lastSeqNum())
+						logger.WithField(log.FieldSeqNo, prepare.SeqNo).Debugf("Replica %s received prepare message from %s for consensus %s : ingore delay sepNo (%d > %d)", lbft.options.ID, prepare.ReplicaID, prepare.Name, prepare.SeqNo, lbft.lastSeqNum())
 					} else {
 						lbft.handleLbftCoreMsg(prepare.Name, msg)
 					}
@@ -565,11 +650,11 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_Commit:
 				if commit := msg.GetCommit(); commit != nil {
 					if !lbft.hasPrimary() {
-						log.Errorf("Replica %s received commit message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.PrimaryID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, commit.SeqNo).Errorf("Replica %s received commit message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.PrimaryID, lbft.primaryID)
 					} else if commit.Chain == lbft.options.Chain && commit.PrimaryID != lbft.primaryID {
-						log.Errorf("Replica %s received commit message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.PrimaryID, lbft.primaryID)
+						logger.WithField(log.FieldSeqNo, commit.SeqNo).Errorf("Replica %s received commit message from %s for consensus %s : ignore diff primayID (%s==%s)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.PrimaryID, lbft.primaryID)
 					} else if commit.Chain == lbft.options.Chain && commit.SeqNo <= lbft.lastSeqNum() {
-						log.Debugf("Replica %s received commit message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.SeqNo, lbft.lastSeqNum())
+						logger.WithField(log.FieldSeqNo, commit.SeqNo).Debugf("Replica %s received commit message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, commit.ReplicaID, commit.Name, commit.SeqNo, lbft.lastSeqNum())
 					} else {
 						lbft.handleLbftCoreMsg(commit.Name, msg)
 					}
@@ -577,9 +662,9 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_Committed:
 				if committed := msg.GetCommitted(); committed != nil {
 					if committed.Chain != lbft.options.Chain {
-						log.Errorf("Replica %s received committed message from %s for consensus %s : ignore diff chain (%s==%s) ", lbft.options.ID, committed.ReplicaID, committed.Name, committed.Chain, lbft.options.Chain)
+						logger.WithField(log.FieldSeqNo, committed.SeqNo).Errorf("Replica %s received committed message from %s for consensus %s : ignore diff chain (%s==%s) ", lbft.options.ID, committed.ReplicaID, committed.Name, committed.Chain, lbft.options.Chain)
 					} else if committed.SeqNo <= lbft.execSeqNum() {
-						log.Debugf("Replica %s received committed message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, committed.ReplicaID, committed.Name, committed.SeqNo, lbft.execSeqNum())
+						logger.WithField(log.FieldSeqNo, committed.SeqNo).Debugf("Replica %s received committed message from %s for consensus %s : ignore delay seqNo (%d > %d)", lbft.options.ID, committed.ReplicaID, committed.Name, committed.SeqNo, lbft.execSeqNum())
 					} else {
 						lbft.recvCommitted(committed)
 					}
@@ -587,28 +672,23 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_FetchCommitted:
 				if committed := msg.GetFetchCommitted(); committed != nil {
 					if committed.Chain != lbft.options.Chain {
-						log.Errorf("Replica %s received fetch committed message from %s : ignore diff chain  (%s==%s)", lbft.options.ID, committed.ReplicaID, committed.Chain, lbft.options.Chain)
+						logger.Errorf("Replica %s received fetch committed message from %s : ignore diff chain  (%s==%s)", lbft.options.ID, committed.ReplicaID, committed.Chain, lbft.options.Chain)
 					} else {
-						if requestBatch := lbft.getCommittedReqeustBatch(committed.SeqNo); requestBatch != nil {
-							key := requestBatch.key()
-							log.Infof("Replica %s received fetch committed message from %s : broadcast committed for %s ", lbft.options.ID, committed.ReplicaID, key)
-							ctt := &Committed{
-								Name:         key,
-								Chain:        lbft.options.Chain,
-								ReplicaID:    lbft.options.ID,
-								SeqNo:        committed.SeqNo,
-								RequestBatch: requestBatch,
-							}
-							lbft.broadcast(lbft.options.Chain, &Message{Payload: &Message_Committed{Committed: ctt}})
-						} else {
-							log.Warnf("Replica %s received fetch committed message from %s : ignore missing seqno ", lbft.options.ID, committed.ReplicaID)
-						}
+						lbft.recvFetchCommitted(committed)
+					}
+				}
+			case *Message_CommittedBlock:
+				if cb := msg.GetCommittedBlock(); cb != nil {
+					if cb.Chain != lbft.options.Chain {
+						logger.Errorf("Replica %s received committed block message from %s : ignore diff chain  (%s==%s)", lbft.options.ID, cb.ReplicaID, cb.Chain, lbft.options.Chain)
+					} else {
+						lbft.recvCommittedBlock(cb)
 					}
 				}
 			case *Message_Viewchange:
 				if vc := msg.GetViewchange(); vc != nil {
 					if vc.Chain != lbft.options.Chain {
-						log.Errorf("Replica %s received view change from %s : ignore diff chain (%s==%s) ", lbft.options.ID, vc.ReplicaID, vc.Chain, lbft.options.Chain)
+						logger.Errorf("Replica %s received view change from %s : ignore diff chain (%s==%s) ", lbft.options.ID, vc.ReplicaID, vc.Chain, lbft.options.Chain)
 						return
 					}
 					lbft.recvViewChange(vc)
@@ -616,24 +696,26 @@ func (lbft *Lbft) handleConsensusMsg() {
 			case *Message_NullReqest:
 				if np := msg.GetNullReqest(); np != nil {
 					if np.Chain != lbft.options.Chain {
-						log.Errorf("Replica %s received null request from %s : ignore diff chain (%s==%s) ", lbft.options.ID, np.ReplicaID, np.Chain, lbft.options.Chain)
+						logger.Errorf("Replica %s received null request from %s : ignore diff chain (%s==%s) ", lbft.options.ID, np.ReplicaID, np.Chain, lbft.options.Chain)
 						return
 					}
-					log.Debugf("Replica %s received null request from %s", lbft.options.ID, np.ReplicaID)
+					logger.Debugf("Replica %s received null request from %s", lbft.options.ID, np.ReplicaID)
 					if lbft.primaryID != np.PrimaryID && np.PrimaryID == np.ReplicaID {
-						log.Infof("Replica %s view change : vote new PrimaryID %s (%s), null request", lbft.options.ID, np.PrimaryID, lbft.primaryID)
+						logger.Infof("Replica %s view change : vote new PrimaryID %s (%s), null request", lbft.options.ID, np.PrimaryID, lbft.primaryID)
 						lbft.primaryID = np.PrimaryID
+						viewChanges.Inc()
 						lbft.lastSeqNo = np.H
 						lbft.seqNo = np.H
 						lbft.updateExecSeqNo(np.H)
 						lbft.verifySeqNo = np.H
 						lbft.prePrepareAsync = newAsyncSeqNo(np.H)
 						lbft.commitAsync = newAsyncSeqNo(np.H)
+						lbft.recordView(np.H)
 					}
 					lbft.nullRequestTimerStart()
 				}
 			default:
-				log.Warnf("unsupport consensus message type %v ", tp)
+				logger.Warnf("unsupport consensus message type %v ", tp)
 			}
 		}
 	}
@@ -644,7 +726,7 @@ func (lbft *Lbft) nullRequestHandler() {
 		return
 	}
 	if lbft.isPrimary() {
-		log.Debugf("Primary %s null request timer expired, sending null request", lbft.options.ID)
+		logger.Debugf("Primary %s null request timer expired, sending null request", lbft.options.ID)
 		nullRequest := &NullRequest{
 			ReplicaID: lbft.options.ID,
 			Chain:     lbft.options.Chain,
@@ -654,7 +736,7 @@ func (lbft *Lbft) nullRequestHandler() {
 		lbft.broadcast(lbft.options.Chain, &Message{Payload: &Message_NullReqest{NullReqest: nullRequest}})
 		lbft.nullRequestTimerStart()
 	} else {
-		log.Debugf("Replica %s null request timer expired, sending view change", lbft.options.ID)
+		logger.Debugf("Replica %s null request timer expired, sending view change", lbft.options.ID)
 		lbft.sendViewChange(nil)
 	}
 }
@@ -697,7 +779,7 @@ func (lbft *Lbft) sendViewChange(vc *ViewChange) {
 
 func (lbft *Lbft) recvViewChange(vc *ViewChange) {
 	if vc.Chain != lbft.options.Chain {
-		log.Warningf("Replica %s received view change message form other chain (%s-%s)", lbft.options.ID, lbft.options.Chain, vc.Chain)
+		logger.Warningf("Replica %s received view change message form other chain (%s-%s)", lbft.options.ID, lbft.options.Chain, vc.Chain)
 		return
 	}
 
@@ -719,13 +801,13 @@ func (lbft *Lbft) recvViewChange(vc *ViewChange) {
 		lbft.voteViewChange.Add(vc.ReplicaID, vc)
 	}
 	cnt := lbft.voteViewChange.Size()
-	log.Infof("Replica %s received view change message from %s for voter %s , vote size %d", lbft.options.ID, vc.ReplicaID, vc.PrimaryID, cnt)
+	logger.Infof("Replica %s received view change message from %s for voter %s , vote size %d", lbft.options.ID, vc.ReplicaID, vc.PrimaryID, cnt)
 	if cnt == 1 {
 		lbft.viewChangeTimer.Reset(lbft.options.ViewChange)
 	} else if cnt == lbft.intersectionQuorum() {
 		lbft.lastPrimaryID = lbft.primaryID
 		lbft.primaryID = ""
-		log.Infof("Replica %s start to vote new PrimaryID, view change", lbft.options.ID)
+		logger.Infof("Replica %s start to vote new PrimaryID, view change", lbft.options.ID)
 		lbft.viewChangeTimer.Stop()
 		lbft.resetViewChangePeriodTimer()
 		lbft.nullRequestTimerStart()
@@ -743,6 +825,7 @@ func (lbft *Lbft) recvViewChange(vc *ViewChange) {
 
 func (lbft *Lbft) newView(vc *ViewChange) {
 	lbft.primaryID = vc.PrimaryID
+	viewChanges.Inc()
 	if lbft.isPrimary() {
 		lbft.priority = time.Now().UnixNano()
 		lbft.blockTimer.Stop()
@@ -750,7 +833,7 @@ func (lbft *Lbft) newView(vc *ViewChange) {
 	lbft.lastSeqNo = vc.H
 	lbft.seqNo = vc.H
 	lbft.votedCnt = 0
-	log.Infof("Replica %s view change : vote new PrimaryID %s", lbft.options.ID, vc.PrimaryID)
+	logger.Infof("Replica %s view change : vote new PrimaryID %s", lbft.options.ID, vc.PrimaryID)
 	lbft.verifySeqNo = vc.H
 	lbft.updateExecSeqNo(vc.H)
 	lbft.iterInstance(func(key string, instance *lbftCore) {
@@ -758,7 +841,7 @@ func (lbft *Lbft) newView(vc *ViewChange) {
 			delete(lbft.lbftCores, key)
 			instance.stop()
 		} else {
-			log.Debugf("Replica %s alreay commmit for consensus %s, view change", lbft.options.ID, instance.name)
+			instance.logEntry().Debugf("Replica %s alreay commmit for consensus %s, view change", lbft.options.ID, instance.name)
 		}
 	})
 	for len(lbft.lbftCoreChan) > 0 {
@@ -767,6 +850,7 @@ func (lbft *Lbft) newView(vc *ViewChange) {
 	}
 	lbft.prePrepareAsync = newAsyncSeqNo(vc.H)
 	lbft.commitAsync = newAsyncSeqNo(vc.H)
+	lbft.recordView(vc.H)
 	lbft.resetViewChangePeriodTimer()
 	lbft.nullRequestTimerStart()
 	lbft.concurrentCntTo = 0
@@ -780,9 +864,155 @@ func (lbft *Lbft) newView(vc *ViewChange) {
 	}
 }
 
+// recvFetchCommitted sends the committed requestBatches from SeqNo to
+// ToSeqNo still kept, at most K of them. The committed blocks above Height
+// are sent instead once the requestBatch of SeqNo isn't kept any more
+func (lbft *Lbft) recvFetchCommitted(fc *FetchCommitted) {
+	if fc.SeqNo <= lbft.execSeqNum() && !lbft.hasCommittedReqeustBatch(fc.SeqNo) {
+		if stack, ok := lbft.stack.(consensus.IBlockStack); ok {
+			lbft.sendCommittedBlocks(stack, fc)
+			return
+		}
+	}
+	to := fc.ToSeqNo
+	if to < fc.SeqNo {
+		to = fc.SeqNo
+	}
+	if max := fc.SeqNo + uint64(lbft.options.K) - 1; to > max {
+		to = max
+	}
+	cnt := 0
+	for seqNo := fc.SeqNo; seqNo <= to; seqNo++ {
+		requestBatch := lbft.getCommittedReqeustBatch(seqNo)
+		if requestBatch == nil {
+			continue
+		}
+		ctt := &Committed{
+			Name:         requestBatch.key(),
+			Chain:        lbft.options.Chain,
+			ReplicaID:    lbft.options.ID,
+			SeqNo:        seqNo,
+			RequestBatch: requestBatch,
+		}
+		lbft.send(fc.ReplicaID, &Message{Payload: &Message_Committed{Committed: ctt}})
+		cnt++
+	}
+	if cnt == 0 {
+		logger.WithField(log.FieldSeqNo, fc.SeqNo).Warnf("Replica %s received fetch committed message from %s : ignore missing seqNo %d-%d", lbft.options.ID, fc.ReplicaID, fc.SeqNo, to)
+		return
+	}
+	logger.WithField(log.FieldSeqNo, fc.SeqNo).Infof("Replica %s received fetch committed message from %s : send %d committed of seqNo %d-%d", lbft.options.ID, fc.ReplicaID, cnt, fc.SeqNo, to)
+}
+
+// sendCommittedBlocks sends at most K committed blocks above the height of
+// the fetch
+func (lbft *Lbft) sendCommittedBlocks(stack consensus.IBlockStack, fc *FetchCommitted) {
+	blocks := stack.CommittedBlocks(fc.Height, lbft.options.K)
+	if len(blocks) == 0 {
+		logger.Warnf("Replica %s received fetch committed message from %s : ignore missing blocks above height %d", lbft.options.ID, fc.ReplicaID, fc.Height)
+		return
+	}
+	for _, block := range blocks {
+		cb := &CommittedBlock{
+			Chain:     lbft.options.Chain,
+			ReplicaID: lbft.options.ID,
+			SeqNo:     block.SeqNo,
+			Height:    block.Height,
+			Block:     block.Block,
+		}
+		lbft.send(fc.ReplicaID, &Message{Payload: &Message_CommittedBlock{CommittedBlock: cb}})
+	}
+	logger.Infof("Replica %s received fetch committed message from %s : send %d committed blocks above height %d", lbft.options.ID, fc.ReplicaID, len(blocks), fc.Height)
+}
+
+// recvCommittedBlock keeps the committed block once a quorum sent the same
+// one for its height, the blocks kept are appended in height order
+func (lbft *Lbft) recvCommittedBlock(cb *CommittedBlock) {
+	if cb.SeqNo <= lbft.execSeqNum() {
+		logger.WithField(log.FieldSeqNo, cb.SeqNo).Debugf("Replica %s received committed block message from %s for height %d, delay", lbft.options.ID, cb.ReplicaID, cb.Height)
+		for height, v := range lbft.voteCommittedBlock {
+			if _, ticket := v.Voter(); ticket.(*CommittedBlock).SeqNo <= lbft.execSeqNum() {
+				delete(lbft.voteCommittedBlock, height)
+			}
+		}
+		return
+	}
+
+	v, ok := lbft.voteCommittedBlock[cb.Height]
+	if !ok {
+		v = vote.NewVote()
+		lbft.voteCommittedBlock[cb.Height] = v
+	}
+	v.Add(cb.ReplicaID, cb)
+	logger.WithField(log.FieldSeqNo, cb.SeqNo).Infof("Replica %s received committed block message from %s for height %d, vote %d", lbft.options.ID, cb.ReplicaID, cb.Height, v.Size())
+	if quorum := v.VoterByTicket(cb); quorum >= lbft.intersectionQuorum() {
+		delete(lbft.voteCommittedBlock, cb.Height)
+		lbft.rwCommittedRequestBatch.Lock()
+		lbft.fetchedBlocks[cb.Height] = cb
+		lbft.rwCommittedRequestBatch.Unlock()
+		lbft.notifyFetchedBlocks()
+	}
+}
+
+func (lbft *Lbft) notifyFetchedBlocks() {
+	select {
+	case lbft.fetchedBlockChan <- struct{}{}:
+	default:
+	}
+}
+
+// appendFetchedBlocks appends the fetched blocks following the height of
+// the stack and moves execSeqNo to the seqNo written with them. The blocks
+// are appended once the blockchain wrote the batches handed to it.
+func (lbft *Lbft) appendFetchedBlocks() {
+	stack, ok := lbft.stack.(consensus.IBlockStack)
+	if !ok || lbft.stack.GetLastSeqNo() < lbft.writtenSeqNo {
+		return
+	}
+	for {
+		height := stack.CurrentHeight()
+		lbft.rwCommittedRequestBatch.Lock()
+		for h := range lbft.fetchedBlocks {
+			if h <= height {
+				delete(lbft.fetchedBlocks, h)
+			}
+		}
+		cb := lbft.fetchedBlocks[height+1]
+		delete(lbft.fetchedBlocks, height+1)
+		lbft.rwCommittedRequestBatch.Unlock()
+		if cb == nil {
+			break
+		}
+		if cb.SeqNo <= lbft.writtenSeqNo {
+			logger.WithField(log.FieldSeqNo, cb.SeqNo).Errorf("Replica %s ignore fetched block at height %d, seqNo %d was written", lbft.options.ID, cb.Height, lbft.writtenSeqNo)
+			continue
+		}
+		if err := stack.AppendCommittedBlock(cb.Block, cb.SeqNo); err != nil {
+			logger.WithField(log.FieldSeqNo, cb.SeqNo).Errorf("Replica %s failed to append fetched block at height %d, %v", lbft.options.ID, cb.Height, err)
+			break
+		}
+		logger.WithField(log.FieldSeqNo, cb.SeqNo).Infof("Replica %s append fetched block at height %d", lbft.options.ID, cb.Height)
+		// the batches not written yet are in the block
+		lbft.committedBlock = nil
+		lbft.writtenSeqNo = cb.SeqNo
+		lbft.rwCommittedRequestBatch.Lock()
+		if cb.SeqNo > lbft.execSeqNum() {
+			atomic.StoreUint64(&lbft.execSeqNo, cb.SeqNo)
+		}
+		lbft.rwCommittedRequestBatch.Unlock()
+		lbft.updateLastSeqNo(cb.SeqNo)
+		lbft.updateVerifySeqNo(cb.SeqNo)
+		if err := lbft.wal.recordExec(cb.SeqNo); err != nil {
+			logger.WithField(log.FieldSeqNo, cb.SeqNo).Errorf("Replica %s failed to record exec seqNo %d, %v", lbft.options.ID, cb.SeqNo, err)
+		}
+		lbft.syncReplicas(cb.SeqNo)
+	}
+	go lbft.checkpoint()
+}
+
 func (lbft *Lbft) recvCommitted(ct *Committed) {
 	if ct.SeqNo <= lbft.execSeqNum() || lbft.hasCommittedReqeustBatch(ct.SeqNo) {
-		log.Debugf("Replica %s received committed message from %s for consensus %s, delay", lbft.options.ID, ct.ReplicaID, ct.Name)
+		logger.WithField(log.FieldSeqNo, ct.SeqNo).Debugf("Replica %s received committed message from %s for consensus %s, delay", lbft.options.ID, ct.ReplicaID, ct.Name)
 		delete(lbft.voteCommitted, ct.Name)
 		for k, v := range lbft.voteCommitted {
 			_, ticket := v.Voter()
@@ -800,7 +1030,7 @@ func (lbft *Lbft) recvCommitted(ct *Committed) {
 		lbft.voteCommitted[ct.Name] = v
 	}
 	v.Add(ct.ReplicaID, ct)
-	log.Infof("Replica %s received committed message from %s for consensus %s, vote %d", lbft.options.ID, ct.ReplicaID, ct.Name, v.Size())
+	logger.WithField(log.FieldSeqNo, ct.SeqNo).Infof("Replica %s received committed message from %s for consensus %s, vote %d", lbft.options.ID, ct.ReplicaID, ct.Name, v.Size())
 	if quorum := v.VoterByTicket(ct); quorum >= lbft.intersectionQuorum() {
 		lbft.addCommittedReqeustBatch(ct.SeqNo, ct.RequestBatch)
 		delete(lbft.voteCommitted, ct.Name)
@@ -813,7 +1043,10 @@ func (lbft *Lbft) addCommittedReqeustBatch(seqNo uint64, requestBatch *RequestBa
 	if _, ok := lbft.committedRequestBatch[seqNo]; ok {
 		return
 	}
-	log.Infof("Replica %s add committed requestBatch %d (%s)", lbft.options.ID, seqNo, hash(requestBatch))
+	logger.WithField(log.FieldSeqNo, seqNo).Infof("Replica %s add committed requestBatch %d (%s)", lbft.options.ID, seqNo, hash(requestBatch))
+	if err := lbft.wal.recordCommitted(&Committed{Name: requestBatch.key(), Chain: lbft.options.Chain, SeqNo: seqNo, RequestBatch: requestBatch}); err != nil {
+		logger.WithField(log.FieldSeqNo, seqNo).Errorf("Replica %s failed to record committed requestBatch %d, %v", lbft.options.ID, seqNo, err)
+	}
 	lbft.committedRequestBatch[seqNo] = requestBatch
 	lbft.updateLastSeqNo(seqNo)
 	lbft.updateVerifySeqNo(seqNo)
@@ -858,36 +1091,95 @@ func (lbft *Lbft) checkpoint() {
 	}
 	sort.Sort(keys)
 	checkpoint := lbft.execSeqNum() + 1
+	retain := lbft.retain()
 	for _, seqNo := range keys {
 		reqBatch := lbft.committedRequestBatch[seqNo]
 		if seqNo < checkpoint {
-			if n := seqNo - uint64(lbft.options.K); n >= keys[0] {
+			if n := seqNo - retain; n >= keys[0] {
 				delete(lbft.committedRequestBatch, n)
 			}
 		} else if seqNo == checkpoint {
 			height := lbft.incrExecSeqNum()
-			log.Debugf("Replica %s write requestBatch %d (%s, %d transactions) ", lbft.options.ID, seqNo, hash(reqBatch), len(reqBatch.Requests))
+			logger.WithField(log.FieldSeqNo, seqNo).Debugf("Replica %s write requestBatch %d (%s, %d transactions) ", lbft.options.ID, seqNo, hash(reqBatch), len(reqBatch.Requests))
 			lbft.committedRequestBatchChan <- &committedRequestBatch{requestBatch: reqBatch, seqNo: height}
-			delete(lbft.committedRequestBatch, seqNo-uint64(lbft.options.K))
+			delete(lbft.committedRequestBatch, seqNo-retain)
 			checkpoint = lbft.execSeqNum() + 1
-		} else if seqNo-checkpoint > uint64(lbft.options.K) {
-			log.Warnf("Replica %s fetch committed %d ", lbft.options.ID, checkpoint)
-			lbft.broadcast(lbft.options.Chain, &Message{Payload: &Message_FetchCommitted{FetchCommitted: &FetchCommitted{
-				ReplicaID: lbft.options.ID,
-				Chain:     lbft.options.Chain,
-				SeqNo:     checkpoint,
-			}}})
-			break
 		}
 	}
 
-	if cnt := len(keys); cnt > 0 && keys[cnt-1] > checkpoint+uint64(lbft.options.K) {
-		log.Infof("Replica %s need seqNo %d ", lbft.options.ID, lbft.execSeqNum()+1)
-		for seqNo, reqBatch := range lbft.committedRequestBatch {
-			log.Infof("Replica %s seqNo %d : %s", lbft.options.ID, seqNo, reqBatch.key())
+	if last := keys[len(keys)-1]; last > checkpoint+uint64(lbft.options.K) {
+		to := last - 1
+		if max := checkpoint + uint64(lbft.options.K) - 1; to > max {
+			to = max
 		}
-		log.Panicf("Replica %s fallen behind over %d", lbft.options.ID, lbft.options.K)
+		lbft.fetchCommitted(checkpoint, to)
+	} else if lbft.fetchFrom != 0 {
+		logger.WithField(log.FieldSeqNo, checkpoint-1).Infof("Replica %s caught up at seqNo %d by state transfer", lbft.options.ID, checkpoint-1)
+		lbft.fetchFrom = 0
+		lbft.fetchAttempts = 0
+	}
+}
+
+// retain returns the number of executed requestBatches kept to serve the
+// state transfer of lagging replicas
+func (lbft *Lbft) retain() uint64 {
+	if lbft.options.Retain > lbft.options.K {
+		return uint64(lbft.options.Retain)
 	}
+	return uint64(lbft.options.K)
+}
+
+// stalledFetches is the number of fetches of the same seqNo after which
+// the state transfer is reported stalled
+const stalledFetches = 5
+
+// fetchCommitted asks the replicas for the committed requestBatches from
+// seqNo to toSeqNo, which are applied once a quorum sent the same batch.
+// The request is repeated every block timeout until it is answered
+func (lbft *Lbft) fetchCommitted(seqNo, toSeqNo uint64) {
+	if seqNo == lbft.fetchFrom && time.Since(lbft.fetchTime) < lbft.options.BlockTimeout {
+		return
+	}
+	logger.WithField(log.FieldSeqNo, seqNo).Warnf("Replica %s fallen behind over %d, fetch committed %d-%d", lbft.options.ID, lbft.options.K, seqNo, toSeqNo)
+	if seqNo == lbft.fetchFrom {
+		lbft.fetchAttempts++
+	} else {
+		lbft.fetchAttempts = 1
+	}
+	if lbft.fetchAttempts == stalledFetches {
+		logger.WithField(log.FieldSeqNo, seqNo).Errorf("Replica %s state transfer stalled at seqNo %d, the replicas no longer keep it, restore the database from a backup", lbft.options.ID, seqNo)
+	}
+	lbft.fetchFrom = seqNo
+	lbft.fetchTime = time.Now()
+	stateTransfers.Inc()
+	fc := &FetchCommitted{
+		ReplicaID: lbft.options.ID,
+		Chain:     lbft.options.Chain,
+		SeqNo:     seqNo,
+		ToSeqNo:   toSeqNo,
+	}
+	if stack, ok := lbft.stack.(consensus.IBlockStack); ok {
+		fc.Height = stack.CurrentHeight()
+	}
+	if len(lbft.fetchedBlocks) > 0 {
+		// the blocks wait for the blockchain writing the batches
+		lbft.notifyFetchedBlocks()
+	}
+	lbft.broadcast(lbft.options.Chain, &Message{Payload: &Message_FetchCommitted{FetchCommitted: fc}})
+}
+
+// isFetching reports whether a state transfer is in progress
+func (lbft *Lbft) isFetching() bool {
+	lbft.rwCommittedRequestBatch.RLock()
+	defer lbft.rwCommittedRequestBatch.RUnlock()
+	return lbft.fetchFrom != 0
+}
+
+// fetching returns the first seqNo fetched and whether the fetch stalled
+func (lbft *Lbft) fetching() (uint64, bool) {
+	lbft.rwCommittedRequestBatch.RLock()
+	defer lbft.rwCommittedRequestBatch.RUnlock()
+	return lbft.fetchFrom, lbft.fetchAttempts >= stalledFetches
 }
 
 func (lbft *Lbft) removeCommittedReqeustBatch(seqNo uint64) {
@@ -986,7 +1278,16 @@ func (lbft *Lbft) broadcast(to string, msg *Message) {
 	//log.Debugf("Replica %s send broadcast consensus message %s(%s) from %s to %s", lbft.options.ID, msg.info(), hash(msg), lbft.options.Chain, to)
 	lbft.broadcastChan <- &Broadcast{
 		to:  to,
-		msg: msg,
+		msg: lbft.sign(msg),
+	}
+}
+
+// send sends the message to a replica of our chain
+func (lbft *Lbft) send(replicaID string, msg *Message) {
+	lbft.broadcastChan <- &Broadcast{
+		to:      lbft.options.Chain,
+		replica: replicaID,
+		msg:     lbft.sign(msg),
 	}
 }
 
@@ -1041,3 +1342,31 @@ type committedRequestBatch struct {
 	seqNo        uint64
 	requestBatch *RequestBatch
 }
+
+//State returns the snapshot of lbft state
+func (lbft *Lbft) State() *consensus.State {
+	lbft.rwlbftCores.RLock()
+	instances := len(lbft.lbftCores)
+	lbft.rwlbftCores.RUnlock()
+	lbft.rwReplicas.RLock()
+	replicas := lbft.replicas.IDs()
+	lbft.rwReplicas.RUnlock()
+	fetchFrom, stalled := lbft.fetching()
+
+	return &consensus.State{
+		Plugin:    "lbft",
+		ID:        lbft.options.ID,
+		Running:   lbft.exit != nil,
+		View:      lbft.primaryID,
+		LastView:  lbft.lastPrimaryID,
+		IsPrimary: lbft.isPrimary(),
+		SeqNo:     lbft.seqNum(),
+		ExecSeqNo: lbft.execSeqNum(),
+		LastSeqNo: lbft.lastSeqNum(),
+		Instances: instances,
+		Replicas:  replicas,
+		Quorum:    lbft.intersectionQuorum(),
+		FetchFrom: fetchFrom,
+		Stalled:   stalled,
+	}
+}
@@ -19,9 +19,15 @@
 package lbft
 
 import (
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/consensus/helper"
 )
 
@@ -34,3 +40,328 @@ func TestLbft(t *testing.T) {
 	_ = lbft
 
 }
+
+func nextBroadcast(t *testing.T, lbft *Lbft) *Message {
+	select {
+	case b := <-lbft.broadcastChan:
+		return b.(*Broadcast).msg
+	case <-time.After(time.Second):
+		t.Fatal("no broadcast")
+	}
+	return nil
+}
+
+func TestStateTransfer(t *testing.T) {
+	options := NewDefaultOptions()
+	options.K = 2
+	options.Retain = 4
+	lbft := NewLbft(options, helper.NewStack())
+	batch := func(seqNo uint64) *RequestBatch {
+		return &RequestBatch{Time: uint32(seqNo), Id: EMPTYBLOCK}
+	}
+
+	// a replica more than K behind fetches K batches at a time instead of panicking
+	lbft.committedRequestBatch[10] = batch(10)
+	lbft.checkpoint()
+	fc := nextBroadcast(t, lbft).GetFetchCommitted()
+	if fc == nil || fc.SeqNo != 1 || fc.ToSeqNo != 2 {
+		t.Fatalf("fetch committed %v, want 1-2", fc)
+	}
+	lbft.checkpoint()
+	if len(lbft.broadcastChan) != 0 {
+		t.Fatal("fetch committed repeated before the block timeout")
+	}
+
+	// an unanswered fetch is reported stalled
+	for i := 1; i < stalledFetches; i++ {
+		if lbft.State().Stalled {
+			t.Fatalf("stalled after %d fetches", i)
+		}
+		lbft.fetchTime = time.Time{}
+		lbft.checkpoint()
+		nextBroadcast(t, lbft)
+	}
+	if state := lbft.State(); !state.Stalled || state.FetchFrom != 1 {
+		t.Fatalf("state %+v, want stalled at 1", state)
+	}
+
+	// the batches are applied once a quorum of replicas sent the same one
+	for _, seqNo := range []uint64{1, 2} {
+		for i, replica := range []string{"r1", "r2", "r3"} {
+			ct := &Committed{Name: batch(seqNo).key(), Chain: options.Chain, ReplicaID: replica, SeqNo: seqNo, RequestBatch: batch(seqNo)}
+			if i == 1 && seqNo == 2 {
+				ct.RequestBatch = batch(20)
+			}
+			lbft.recvCommitted(ct)
+		}
+	}
+	if !lbft.hasCommittedReqeustBatch(1) || lbft.hasCommittedReqeustBatch(2) {
+		t.Fatal("committed applied without a quorum")
+	}
+	if !lbft.State().Stalled {
+		t.Fatal("stall cleared before catching up")
+	}
+	lbft.recvCommitted(&Committed{Name: batch(2).key(), Chain: options.Chain, ReplicaID: "r4", SeqNo: 2, RequestBatch: batch(2)})
+	for i := 0; i < 10 && lbft.execSeqNum() != 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if lbft.execSeqNum() != 2 {
+		t.Fatalf("execSeqNo %d, want 2", lbft.execSeqNum())
+	}
+	for fc = nextBroadcast(t, lbft).GetFetchCommitted(); fc == nil || fc.SeqNo != 3; fc = nextBroadcast(t, lbft).GetFetchCommitted() {
+	}
+	if fc.ToSeqNo != 4 {
+		t.Fatalf("fetch committed %v, want 3-4", fc)
+	}
+
+	// a replica serves the batches it still keeps
+	for len(lbft.broadcastChan) > 0 {
+		<-lbft.broadcastChan
+	}
+	if lbft.State().Stalled {
+		t.Fatal("stalled after catching up")
+	}
+	lbft.recvFetchCommitted(&FetchCommitted{Chain: options.Chain, ReplicaID: "r1", SeqNo: 1, ToSeqNo: 100})
+	for _, seqNo := range []uint64{1, 2} {
+		b := (<-lbft.broadcastChan).(*Broadcast)
+		if ct := b.msg.GetCommitted(); ct == nil || ct.SeqNo != seqNo || ct.Name != batch(seqNo).key() || b.Replica() != "r1" {
+			t.Fatalf("committed %v to %q, want seqNo %d to r1", ct, b.Replica(), seqNo)
+		}
+	}
+	if len(lbft.broadcastChan) != 0 {
+		t.Fatal("served more than K batches")
+	}
+}
+
+// testBlockStack keeps the committed blocks of heights 1 to len(blocks)
+type testBlockStack struct {
+	*helper.Stack
+	blocks []*consensus.CommittedBlock
+}
+
+func (stack *testBlockStack) CurrentHeight() uint32 {
+	return uint32(len(stack.blocks))
+}
+
+func (stack *testBlockStack) CommittedBlocks(height uint32, max int) []*consensus.CommittedBlock {
+	var blocks []*consensus.CommittedBlock
+	for h := int(height); h < len(stack.blocks) && len(blocks) < max; h++ {
+		blocks = append(blocks, stack.blocks[h])
+	}
+	return blocks
+}
+
+func (stack *testBlockStack) AppendCommittedBlock(block []byte, seqNo uint64) error {
+	stack.blocks = append(stack.blocks, &consensus.CommittedBlock{Height: uint32(len(stack.blocks) + 1), SeqNo: seqNo, Block: block})
+	stack.LastSeqNo = seqNo
+	return nil
+}
+
+func TestBlockTransfer(t *testing.T) {
+	options := NewDefaultOptions()
+	options.K = 2
+	block := func(height uint32, seqNo uint64) *consensus.CommittedBlock {
+		return &consensus.CommittedBlock{Height: height, SeqNo: seqNo, Block: []byte(fmt.Sprintf("block %d", height))}
+	}
+
+	// a replica which no longer keeps the batch sends K blocks above the height
+	server := NewLbft(options, &testBlockStack{Stack: helper.NewStack(), blocks: []*consensus.CommittedBlock{block(1, 3), block(2, 5), block(3, 8), block(4, 9)}})
+	server.execSeqNo = 9
+	server.recvFetchCommitted(&FetchCommitted{Chain: options.Chain, ReplicaID: "r1", SeqNo: 4, ToSeqNo: 5, Height: 1})
+	for _, want := range []*consensus.CommittedBlock{block(2, 5), block(3, 8)} {
+		b := (<-server.broadcastChan).(*Broadcast)
+		if cb := b.msg.GetCommittedBlock(); cb == nil || cb.Height != want.Height || cb.SeqNo != want.SeqNo || b.Replica() != "r1" {
+			t.Fatalf("committed block %v to %q, want height %d to r1", cb, b.Replica(), want.Height)
+		}
+	}
+	if len(server.broadcastChan) != 0 {
+		t.Fatal("served more than K blocks")
+	}
+
+	// the fetch carries the height of the lagging replica
+	stack := &testBlockStack{Stack: helper.NewStack(), blocks: []*consensus.CommittedBlock{block(1, 3)}}
+	stack.LastSeqNo = 3
+	lbft := NewLbft(options, stack)
+	lbft.execSeqNo = 3
+	lbft.committedRequestBatch[20] = &RequestBatch{Id: EMPTYBLOCK}
+	lbft.checkpoint()
+	if fc := nextBroadcast(t, lbft).GetFetchCommitted(); fc == nil || fc.SeqNo != 4 || fc.Height != 1 {
+		t.Fatalf("fetch committed %v, want seqNo 4 above height 1", fc)
+	}
+
+	// the blocks are appended in height order once a quorum sent the same one
+	vote := func(cb *consensus.CommittedBlock, replicas ...string) {
+		for _, replica := range replicas {
+			lbft.recvCommittedBlock(&CommittedBlock{Chain: options.Chain, ReplicaID: replica, SeqNo: cb.SeqNo, Height: cb.Height, Block: cb.Block})
+		}
+	}
+	vote(block(3, 8), "r1", "r2", "r3")
+	vote(block(2, 5), "r1", "r2")
+	vote(&consensus.CommittedBlock{Height: 2, SeqNo: 5, Block: []byte("forged")}, "r4")
+	lbft.appendFetchedBlocks()
+	if stack.CurrentHeight() != 1 {
+		t.Fatalf("height %d, block appended without a quorum", stack.CurrentHeight())
+	}
+	vote(block(2, 5), "r3")
+	// the batches handed to the blockchain are written first
+	lbft.writtenSeqNo = 4
+	lbft.appendFetchedBlocks()
+	if stack.CurrentHeight() != 1 {
+		t.Fatal("block appended before the blockchain wrote the batches")
+	}
+	lbft.writtenSeqNo = 3
+	lbft.appendFetchedBlocks()
+	if stack.CurrentHeight() != 3 || string(stack.blocks[1].Block) != "block 2" {
+		t.Fatalf("height %d, want the fetched blocks appended", stack.CurrentHeight())
+	}
+	if lbft.execSeqNum() != 8 || lbft.writtenSeqNo != 8 {
+		t.Fatalf("execSeqNo %d, written %d, want 8", lbft.execSeqNum(), lbft.writtenSeqNo)
+	}
+}
+
+// testReplicaStack has the replica set of the ledger
+type testReplicaStack struct {
+	*helper.Stack
+	lastSeqNo uint64
+	set       *consensus.ReplicaSet
+}
+
+func (stack *testReplicaStack) GetLastSeqNo() uint64 {
+	return atomic.LoadUint64(&stack.lastSeqNo)
+}
+
+func (stack *testReplicaStack) ReplicaSet() (*consensus.ReplicaSet, error) {
+	return stack.set, nil
+}
+
+func TestReplicaChanges(t *testing.T) {
+	key := "04" + strings.Repeat("ab", 64)
+	genesis := consensus.Replicas{"r1": key, "r2": key, "r3": key, "r4": key}
+	options := NewDefaultOptions()
+	// N and Q follow the replica set, not the configured values
+	options.N = 7
+	options.Q = 5
+	options.Replicas = genesis
+	stack := &testReplicaStack{Stack: helper.NewStack(), set: consensus.NewReplicaSet(genesis, 2)}
+	lbft := NewLbft(options, stack)
+	if lbft.isReplica("r5") || !lbft.isReplica("r1") {
+		t.Fatal("replica set not enforced")
+	}
+	if lbft.replicaNum() != 4 || lbft.intersectionQuorum() != 3 {
+		t.Fatalf("N %d, Q %d with 4 replicas", lbft.replicaNum(), lbft.intersectionQuorum())
+	}
+
+	// the ledger executed the governance txs at seqNo 5
+	stack.set.Schedule(&consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: "r5", PublicKey: key, Address: "127.0.0.1:20170"}, 5)
+	stack.set.Schedule(&consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: "r6", PublicKey: key}, 5)
+	stack.lastSeqNo = 5
+	// the changes take effect Delay seqNos later
+	lbft.syncReplicas(6)
+	if lbft.isReplica("r5") || lbft.intersectionQuorum() != 3 {
+		t.Fatal("replica change applied before its seqNo")
+	}
+	lbft.syncReplicas(7)
+	if !lbft.isReplica("r6") || lbft.replicaNum() != 6 || lbft.intersectionQuorum() != 4 {
+		t.Fatalf("replicas %v, N %d, Q %d", lbft.replicas.IDs(), lbft.replicaNum(), lbft.intersectionQuorum())
+	}
+	if state := lbft.State(); len(state.Replicas) != 6 || state.Quorum != 4 {
+		t.Fatalf("state %v", state)
+	}
+	for _, want := range []string{"r5", "r6"} {
+		if change := <-lbft.ReplicaChangeChannel(); change.Op != consensus.ReplicaAdd || change.ID != want {
+			t.Fatalf("notified %v, want %s added", change, want)
+		} else if want == "r5" && change.Address != "127.0.0.1:20170" {
+			t.Fatalf("r5 added at %q", change.Address)
+		}
+	}
+
+	// the switch waits for the blockchain to write the txs taking effect
+	stack.set.Schedule(&consensus.ReplicaChange{Op: consensus.ReplicaRemove, ID: "r1"}, 9)
+	stack.lastSeqNo = 8
+	done := make(chan struct{})
+	go func() {
+		lbft.syncReplicas(11)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("switched before the blockchain wrote seqNo 9")
+	case <-time.After(5 * replicaSyncInterval):
+	}
+	atomic.StoreUint64(&stack.lastSeqNo, 9)
+	<-done
+	if lbft.isReplica("r1") || lbft.replicaNum() != 5 {
+		t.Fatalf("replicas %v", lbft.replicas.IDs())
+	}
+	if change := <-lbft.ReplicaChangeChannel(); change.Op != consensus.ReplicaRemove || change.ID != "r1" || change.PublicKey != key {
+		t.Fatalf("notified %v, want r1 removed", change)
+	}
+}
+
+type testSigner struct {
+	*crypto.PrivateKey
+}
+
+func (s testSigner) SignHash(hash []byte) (*crypto.Signature, error) {
+	return s.Sign(hash)
+}
+
+func TestSignedConsensus(t *testing.T) {
+	options := NewDefaultOptions()
+	options.Replicas = consensus.Replicas{}
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		options.Replicas[fmt.Sprintf("r%d", i)] = utils.BytesToHex(key.Public().Bytes())
+		if i == 0 {
+			options.Signer = testSigner{key}
+		}
+	}
+	lbft := NewLbft(options, helper.NewStack())
+	prepare := func(replicaID, digest string) *Message {
+		return &Message{Payload: &Message_Prepare{Prepare: &Prepare{Name: "n", Chain: options.Chain, ReplicaID: replicaID, PrimaryID: "r0", SeqNo: 1, Digest: digest}}}
+	}
+
+	// broadcasts are signed with the replica key
+	lbft.broadcast(options.Chain, prepare("r0", "a"))
+	lbft.RecvConsensus(nextBroadcast(t, lbft).Serialize())
+	if len(lbft.recvConsensusMsgChan) != 1 {
+		t.Fatal("signed message rejected")
+	}
+
+	// unsigned, signed with another key and from outside the replica set
+	for _, msg := range []*Message{prepare("r1", "a"), lbft.sign(prepare("r1", "a")), lbft.sign(prepare("r5", "a"))} {
+		lbft.RecvConsensus(msg.Serialize())
+	}
+	if len(lbft.recvConsensusMsgChan) != 1 {
+		t.Fatal("invalid message accepted")
+	}
+
+	// an equivocation is logged as evidence, the message is still handled
+	lbft.RecvConsensus(lbft.sign(prepare("r0", "b")).Serialize())
+	if len(lbft.recvConsensusMsgChan) != 2 {
+		t.Fatal("equivocating message dropped")
+	}
+
+	// the votes of another chain are verified against its replica set
+	foreign := prepare("f0", "a")
+	foreign.GetPrepare().Chain = "1"
+	lbft.RecvConsensus(lbft.sign(foreign).Serialize())
+	if len(lbft.recvConsensusMsgChan) != 2 {
+		t.Fatal("vote of a chain without replica set accepted")
+	}
+	lbft.options.ChainReplicas = map[string]consensus.Replicas{"1": {"f0": options.Replicas["r0"]}}
+	lbft.RecvConsensus(lbft.sign(foreign).Serialize())
+	foreign.GetPrepare().ReplicaID = "f1"
+	lbft.RecvConsensus(lbft.sign(foreign).Serialize())
+	if len(lbft.recvConsensusMsgChan) != 3 {
+		t.Fatal("votes of another chain not verified against its replica set")
+	}
+
+	// votes without a sender are not exempt from the signature check
+	foreign.GetPrepare().ReplicaID = ""
+	lbft.RecvConsensus(foreign.Serialize())
+	lbft.RecvConsensus(lbft.sign(prepare("", "a")).Serialize())
+	if len(lbft.recvConsensusMsgChan) != 3 {
+		t.Fatal("vote without replica id accepted")
+	}
+}
@@ -28,8 +28,9 @@ import (
 
 //Broadcast Define consensus data for broadcast
 type Broadcast struct {
-	to  string
-	msg *Message
+	to      string
+	replica string
+	msg     *Message
 }
 
 //To Get target for broadcast
@@ -37,6 +38,11 @@ func (broadcast *Broadcast) To() string {
 	return broadcast.to
 }
 
+//Replica Get the replica of a unicast, empty for a broadcast
+func (broadcast *Broadcast) Replica() string {
+	return broadcast.replica
+}
+
 //Payload Get consensus data for broadcast
 func (broadcast *Broadcast) Payload() []byte {
 	return broadcast.msg.Serialize()
@@ -160,6 +166,15 @@ func (msg *Committed) Serialize() []byte {
 	return serialize(m)
 }
 
+//Serialize Serialize
+func (msg *CommittedBlock) Serialize() []byte {
+	payload := serialize(msg)
+	m := &CommittedBlock{}
+	deserialize(payload, m)
+	m.ReplicaID = ""
+	return serialize(m)
+}
+
 //Serialize Serialize
 func (msg *ViewChange) Serialize() []byte {
 	payload := serialize(msg)
@@ -171,6 +186,29 @@ func (msg *ViewChange) Serialize() []byte {
 	return serialize(m)
 }
 
+// sender chain and replica of the message, empty for requestBatch
+func (msg *Message) sender() (chain, replicaID string) {
+	switch payload := msg.Payload.(type) {
+	case *Message_PrePrepare:
+		return payload.PrePrepare.GetChain(), payload.PrePrepare.GetReplicaID()
+	case *Message_Prepare:
+		return payload.Prepare.GetChain(), payload.Prepare.GetReplicaID()
+	case *Message_Commit:
+		return payload.Commit.GetChain(), payload.Commit.GetReplicaID()
+	case *Message_Committed:
+		return payload.Committed.GetChain(), payload.Committed.GetReplicaID()
+	case *Message_FetchCommitted:
+		return payload.FetchCommitted.GetChain(), payload.FetchCommitted.GetReplicaID()
+	case *Message_Viewchange:
+		return payload.Viewchange.GetChain(), payload.Viewchange.GetReplicaID()
+	case *Message_NullReqest:
+		return payload.NullReqest.GetChain(), payload.NullReqest.GetReplicaID()
+	case *Message_CommittedBlock:
+		return payload.CommittedBlock.GetChain(), payload.CommittedBlock.GetReplicaID()
+	}
+	return "", ""
+}
+
 func (msg *Message) info() string {
 	if requestBatch := msg.GetRequestBatch(); requestBatch != nil {
 		return "requestBatch"
@@ -184,6 +222,8 @@ func (msg *Message) info() string {
 		return fmt.Sprintf("committed from %s (%s)", committed.ReplicaID, committed.Name)
 	} else if fecthcommitted := msg.GetFetchCommitted(); fecthcommitted != nil {
 		return fmt.Sprintf("fecthcommitted from %s (%d)", fecthcommitted.ReplicaID, fecthcommitted.SeqNo)
+	} else if cb := msg.GetCommittedBlock(); cb != nil {
+		return fmt.Sprintf("committedblock from %s (%d)", cb.ReplicaID, cb.Height)
 	} else if viewchange := msg.GetViewchange(); viewchange != nil {
 		return fmt.Sprintf("viewchange from %s", viewchange.ReplicaID)
 	} else if nullrequest := msg.GetNullReqest(); nullrequest != nil {
@@ -37,6 +37,7 @@ It has these top-level messages:
 	ViewChange
 	NullRequest
 	Message
+	CommittedBlock
 */
 package lbft
 
@@ -395,6 +396,8 @@ type FetchCommitted struct {
 	Chain     string `protobuf:"bytes,1,opt,name=chain" json:"chain,omitempty"`
 	ReplicaID string `protobuf:"bytes,2,opt,name=replicaID" json:"replicaID,omitempty"`
 	SeqNo     uint64 `protobuf:"varint,3,opt,name=seqNo" json:"seqNo,omitempty"`
+	ToSeqNo   uint64 `protobuf:"varint,4,opt,name=toSeqNo" json:"toSeqNo,omitempty"`
+	Height    uint32 `protobuf:"varint,5,opt,name=height" json:"height,omitempty"`
 }
 
 func (m *FetchCommitted) Reset()                    { *m = FetchCommitted{} }
@@ -423,6 +426,20 @@ func (m *FetchCommitted) GetSeqNo() uint64 {
 	return 0
 }
 
+func (m *FetchCommitted) GetToSeqNo() uint64 {
+	if m != nil {
+		return m.ToSeqNo
+	}
+	return 0
+}
+
+func (m *FetchCommitted) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
 type ViewChange struct {
 	ReplicaID string `protobuf:"bytes,1,opt,name=replicaID" json:"replicaID,omitempty"`
 	Chain     string `protobuf:"bytes,2,opt,name=chain" json:"chain,omitempty"`
@@ -521,7 +538,9 @@ type Message struct {
 	//	*Message_FetchCommitted
 	//	*Message_Viewchange
 	//	*Message_NullReqest
-	Payload isMessage_Payload `protobuf_oneof:"payload"`
+	//	*Message_CommittedBlock
+	Payload   isMessage_Payload `protobuf_oneof:"payload"`
+	Signature []byte            `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
 }
 
 func (m *Message) Reset()                    { *m = Message{} }
@@ -557,6 +576,9 @@ type Message_Viewchange struct {
 type Message_NullReqest struct {
 	NullReqest *NullRequest `protobuf:"bytes,8,opt,name=nullReqest,oneof"`
 }
+type Message_CommittedBlock struct {
+	CommittedBlock *CommittedBlock `protobuf:"bytes,10,opt,name=committedBlock,oneof"`
+}
 
 func (*Message_RequestBatch) isMessage_Payload()   {}
 func (*Message_PrePrepare) isMessage_Payload()     {}
@@ -566,6 +588,7 @@ func (*Message_Committed) isMessage_Payload()      {}
 func (*Message_FetchCommitted) isMessage_Payload() {}
 func (*Message_Viewchange) isMessage_Payload()     {}
 func (*Message_NullReqest) isMessage_Payload()     {}
+func (*Message_CommittedBlock) isMessage_Payload() {}
 
 func (m *Message) GetPayload() isMessage_Payload {
 	if m != nil {
@@ -630,6 +653,20 @@ func (m *Message) GetNullReqest() *NullRequest {
 	return nil
 }
 
+func (m *Message) GetCommittedBlock() *CommittedBlock {
+	if x, ok := m.GetPayload().(*Message_CommittedBlock); ok {
+		return x.CommittedBlock
+	}
+	return nil
+}
+
+func (m *Message) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Message_OneofMarshaler, _Message_OneofUnmarshaler, _Message_OneofSizer, []interface{}{
@@ -641,6 +678,7 @@ func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error
 		(*Message_FetchCommitted)(nil),
 		(*Message_Viewchange)(nil),
 		(*Message_NullReqest)(nil),
+		(*Message_CommittedBlock)(nil),
 	}
 }
 
@@ -688,6 +726,11 @@ func _Message_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.NullReqest); err != nil {
 			return err
 		}
+	case *Message_CommittedBlock:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.CommittedBlock); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Message.Payload has unexpected type %T", x)
@@ -762,6 +805,14 @@ func _Message_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer
 		err := b.DecodeMessage(msg)
 		m.Payload = &Message_NullReqest{msg}
 		return true, err
+	case 10: // payload.committedBlock
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CommittedBlock)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_CommittedBlock{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -811,6 +862,11 @@ func _Message_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(8<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Message_CommittedBlock:
+		s := proto.Size(x.CommittedBlock)
+		n += proto.SizeVarint(10<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -818,6 +874,54 @@ func _Message_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
+type CommittedBlock struct {
+	Chain     string `protobuf:"bytes,1,opt,name=chain" json:"chain,omitempty"`
+	ReplicaID string `protobuf:"bytes,2,opt,name=replicaID" json:"replicaID,omitempty"`
+	SeqNo     uint64 `protobuf:"varint,3,opt,name=seqNo" json:"seqNo,omitempty"`
+	Height    uint32 `protobuf:"varint,4,opt,name=height" json:"height,omitempty"`
+	Block     []byte `protobuf:"bytes,5,opt,name=block,proto3" json:"block,omitempty"`
+}
+
+func (m *CommittedBlock) Reset()                    { *m = CommittedBlock{} }
+func (m *CommittedBlock) String() string            { return proto.CompactTextString(m) }
+func (*CommittedBlock) ProtoMessage()               {}
+func (*CommittedBlock) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *CommittedBlock) GetChain() string {
+	if m != nil {
+		return m.Chain
+	}
+	return ""
+}
+
+func (m *CommittedBlock) GetReplicaID() string {
+	if m != nil {
+		return m.ReplicaID
+	}
+	return ""
+}
+
+func (m *CommittedBlock) GetSeqNo() uint64 {
+	if m != nil {
+		return m.SeqNo
+	}
+	return 0
+}
+
+func (m *CommittedBlock) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *CommittedBlock) GetBlock() []byte {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Request)(nil), "lbft.Request")
 	proto.RegisterType((*RequestBatch)(nil), "lbft.RequestBatch")
@@ -829,48 +933,54 @@ func init() {
 	proto.RegisterType((*ViewChange)(nil), "lbft.ViewChange")
 	proto.RegisterType((*NullRequest)(nil), "lbft.NullRequest")
 	proto.RegisterType((*Message)(nil), "lbft.Message")
+	proto.RegisterType((*CommittedBlock)(nil), "lbft.CommittedBlock")
 }
 
 func init() { proto.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 607 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x55, 0xbd, 0x6e, 0xdb, 0x3c,
-	0x14, 0x35, 0x25, 0xc5, 0x8a, 0xae, 0xec, 0x7c, 0x5f, 0x89, 0xa0, 0x10, 0x8a, 0x0e, 0x82, 0x86,
-	0xc2, 0x59, 0x5c, 0x40, 0x01, 0x8a, 0x4e, 0x1d, 0x92, 0xa0, 0x70, 0x86, 0x06, 0x01, 0x87, 0x0e,
-	0x05, 0x3a, 0x30, 0x12, 0x13, 0x11, 0xb0, 0x7e, 0x4c, 0xd1, 0x0d, 0xfc, 0x06, 0x5d, 0xfa, 0x2c,
-	0x5d, 0xda, 0xb9, 0xaf, 0xd3, 0xc7, 0x28, 0x44, 0xd2, 0xfa, 0x31, 0xec, 0xa5, 0x4b, 0x91, 0x4d,
-	0x97, 0x3c, 0x87, 0xf7, 0x1c, 0xde, 0xcb, 0x2b, 0x98, 0xe6, 0xac, 0xae, 0xe9, 0x03, 0x9b, 0x57,
-	0xa2, 0x94, 0x25, 0x76, 0x96, 0x77, 0xf7, 0x32, 0xfa, 0x86, 0xc0, 0x25, 0x6c, 0xb5, 0x66, 0xb5,
-	0xc4, 0x18, 0x1c, 0xc9, 0x73, 0x16, 0xa0, 0x10, 0xcd, 0xa6, 0x44, 0x7d, 0xe3, 0x10, 0x7c, 0x29,
-	0x68, 0x51, 0xd3, 0x44, 0xf2, 0xb2, 0x08, 0xac, 0x10, 0xcd, 0x26, 0xa4, 0xbf, 0x84, 0x5f, 0x82,
-	0x77, 0x2f, 0xca, 0xfc, 0x32, 0xa3, 0xbc, 0x08, 0xec, 0x10, 0xcd, 0x3c, 0xd2, 0x2d, 0xe0, 0x00,
-	0x5c, 0x59, 0xea, 0x3d, 0x47, 0xed, 0x6d, 0x43, 0x7c, 0x0a, 0x47, 0x45, 0x59, 0x24, 0x2c, 0x38,
-	0x52, 0xe9, 0x74, 0x10, 0x7d, 0x86, 0x89, 0x91, 0x73, 0x41, 0x65, 0x92, 0xed, 0xd5, 0x74, 0x06,
-	0xc7, 0x42, 0x63, 0xea, 0xc0, 0x0a, 0xed, 0x99, 0x1f, 0x4f, 0xe7, 0x8d, 0x99, 0xb9, 0x61, 0x92,
-	0x76, 0x1b, 0x9f, 0x80, 0xc5, 0x53, 0xa5, 0xca, 0x26, 0x16, 0x4f, 0xa3, 0xdf, 0x08, 0xe0, 0x56,
-	0xb0, 0x5b, 0xc1, 0x2a, 0x2a, 0x58, 0x73, 0x7a, 0x41, 0xcd, 0xe9, 0x1e, 0x51, 0xdf, 0x8d, 0x9f,
-	0x4a, 0xf0, 0x9c, 0x8a, 0xcd, 0xf5, 0x95, 0xf2, 0xeb, 0x91, 0x6e, 0xa1, 0x51, 0x9d, 0xf4, 0x9c,
-	0xea, 0xa0, 0xe1, 0x08, 0x56, 0x2d, 0x79, 0x42, 0xaf, 0xaf, 0x8c, 0xcf, 0x6e, 0xa1, 0xe1, 0xd4,
-	0x6c, 0x75, 0x53, 0x2a, 0xa7, 0x0e, 0xd1, 0x01, 0x7e, 0x0e, 0xe3, 0x94, 0x3f, 0xb0, 0x5a, 0x06,
-	0x63, 0x45, 0x30, 0x51, 0xb3, 0xbe, 0x5a, 0x97, 0x62, 0x9d, 0x07, 0xae, 0x82, 0x9b, 0x08, 0xcf,
-	0x7b, 0xae, 0x8f, 0x43, 0x34, 0xf3, 0x63, 0x3c, 0x70, 0xad, 0xee, 0xab, 0xb3, 0x1e, 0xfd, 0x44,
-	0xe0, 0x3e, 0x41, 0x9f, 0xd1, 0x0f, 0x04, 0xe3, 0xcb, 0x32, 0xcf, 0xb9, 0x7c, 0x52, 0xb2, 0x7f,
-	0x21, 0xf0, 0xb4, 0x6c, 0xc9, 0xd2, 0x7f, 0xaa, 0xfc, 0x0d, 0x4c, 0x44, 0xaf, 0x25, 0x94, 0xfe,
-	0xfd, 0xcd, 0x32, 0xc0, 0x45, 0x9f, 0xe0, 0xe4, 0x3d, 0x93, 0x49, 0xd6, 0xb9, 0x68, 0x35, 0xa1,
-	0x83, 0x9a, 0xac, 0x83, 0x9a, 0xec, 0x9e, 0xa6, 0xe8, 0x2b, 0x02, 0xf8, 0xc8, 0xd9, 0xe3, 0x65,
-	0x46, 0x8b, 0x07, 0x36, 0x3c, 0x02, 0xed, 0x39, 0x42, 0xa7, 0xb5, 0xfa, 0x69, 0x5f, 0xc0, 0x71,
-	0x25, 0x78, 0x29, 0xb8, 0xdc, 0x98, 0x07, 0xdd, 0xc6, 0xc3, 0xab, 0x75, 0x76, 0xaf, 0x76, 0x02,
-	0x28, 0x33, 0x57, 0x84, 0xb2, 0x28, 0x07, 0xff, 0x66, 0xbd, 0x5c, 0x6e, 0x87, 0xde, 0xdf, 0x48,
-	0x19, 0xa4, 0xb3, 0xf7, 0xa6, 0x73, 0xb6, 0xe9, 0xbe, 0xdb, 0xe0, 0x7e, 0xd0, 0x83, 0x17, 0xbf,
-	0xdd, 0xa9, 0x0c, 0x3a, 0x54, 0x99, 0xc5, 0x68, 0x58, 0x1b, 0x1c, 0x03, 0x54, 0xed, 0xd8, 0x52,
-	0x62, 0xfc, 0xf8, 0x7f, 0xcd, 0xeb, 0xc6, 0xd9, 0x62, 0x44, 0x7a, 0x28, 0x7c, 0x06, 0x6e, 0x65,
-	0x08, 0xb6, 0x22, 0x4c, 0x5b, 0x82, 0x41, 0x6f, 0xf7, 0xf1, 0x2b, 0x18, 0x27, 0xaa, 0xea, 0x4a,
-	0xb7, 0x1f, 0x4f, 0x34, 0x52, 0x77, 0xc2, 0x62, 0x44, 0xcc, 0x2e, 0x7e, 0x0d, 0x5e, 0xb2, 0xed,
-	0x0e, 0x75, 0xa3, 0x7e, 0xfc, 0x5f, 0x1f, 0x2a, 0x59, 0xba, 0x18, 0x91, 0x0e, 0x83, 0xdf, 0xc1,
-	0xc9, 0xfd, 0xa0, 0xa7, 0x4c, 0x37, 0x9e, 0x6a, 0xd6, 0xb0, 0xdf, 0x16, 0x23, 0xb2, 0x83, 0x6e,
-	0x7c, 0x7f, 0xe1, 0xec, 0x31, 0x51, 0x6d, 0xa3, 0x5e, 0x5c, 0xeb, 0xbb, 0x6b, 0xa7, 0xc6, 0x77,
-	0x87, 0xc2, 0xe7, 0x00, 0x85, 0x2e, 0x70, 0xf3, 0x7a, 0xf5, 0xa8, 0x7c, 0xa6, 0x39, 0xbd, 0xc2,
-	0x37, 0xa4, 0x0e, 0x76, 0xe1, 0x81, 0x5b, 0xd1, 0xcd, 0xb2, 0xa4, 0xe9, 0xdd, 0x58, 0xfd, 0x1f,
-	0xcf, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x5d, 0x38, 0x59, 0xf8, 0x30, 0x07, 0x00, 0x00,
+	// 674 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x56, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xce, 0x26, 0x6e, 0x5c, 0x8f, 0xd3, 0x00, 0xab, 0x0a, 0x59, 0x88, 0x83, 0xe5, 0x03, 0x4a,
+	0x2f, 0x41, 0x4a, 0x25, 0xc4, 0x89, 0x43, 0x53, 0xa1, 0xf4, 0x40, 0x55, 0x2d, 0x12, 0x37, 0x0e,
+	0x5b, 0x67, 0x1b, 0xaf, 0x88, 0x7f, 0xba, 0xde, 0x50, 0xf5, 0x0d, 0x38, 0xd0, 0xb7, 0x81, 0x33,
+	0xaf, 0xc3, 0x63, 0xa0, 0xfd, 0x71, 0xd6, 0xae, 0x9a, 0x0b, 0x42, 0x42, 0xbd, 0x79, 0x66, 0xbe,
+	0xd9, 0xf9, 0xbe, 0x9d, 0xd9, 0x49, 0xe0, 0x20, 0x67, 0x75, 0x4d, 0x57, 0x6c, 0x5a, 0x89, 0x52,
+	0x96, 0xd8, 0x5b, 0x5f, 0x5e, 0xc9, 0xe4, 0x0e, 0x81, 0x4f, 0xd8, 0xf5, 0x86, 0xd5, 0x12, 0x63,
+	0xf0, 0x24, 0xcf, 0x59, 0x84, 0x62, 0x34, 0x39, 0x20, 0xfa, 0x1b, 0xc7, 0x10, 0x4a, 0x41, 0x8b,
+	0x9a, 0xa6, 0x92, 0x97, 0x45, 0xd4, 0x8f, 0xd1, 0x64, 0x44, 0xda, 0x2e, 0xfc, 0x12, 0x82, 0x2b,
+	0x51, 0xe6, 0xf3, 0x8c, 0xf2, 0x22, 0x1a, 0xc4, 0x68, 0x12, 0x10, 0xe7, 0xc0, 0x11, 0xf8, 0xb2,
+	0x34, 0x31, 0x4f, 0xc7, 0x1a, 0x13, 0x1f, 0xc2, 0x5e, 0x51, 0x16, 0x29, 0x8b, 0xf6, 0x74, 0x39,
+	0x63, 0x24, 0x9f, 0x61, 0x64, 0xe9, 0x9c, 0x50, 0x99, 0x66, 0x0f, 0x72, 0x3a, 0x82, 0x7d, 0x61,
+	0x30, 0x75, 0xd4, 0x8f, 0x07, 0x93, 0x70, 0x76, 0x30, 0x55, 0x62, 0xa6, 0x36, 0x93, 0x6c, 0xc3,
+	0x78, 0x0c, 0x7d, 0xbe, 0xd4, 0xac, 0x06, 0xa4, 0xcf, 0x97, 0xc9, 0x6f, 0x04, 0x70, 0x21, 0xd8,
+	0x85, 0x60, 0x15, 0x15, 0x4c, 0x9d, 0x5e, 0x50, 0x7b, 0x7a, 0x40, 0xf4, 0xb7, 0xd2, 0x53, 0x09,
+	0x9e, 0x53, 0x71, 0x7b, 0x76, 0xaa, 0xf5, 0x06, 0xc4, 0x39, 0x14, 0xeb, 0xb4, 0xa5, 0xd4, 0x18,
+	0x2a, 0x47, 0xb0, 0x6a, 0xcd, 0x53, 0x7a, 0x76, 0x6a, 0x75, 0x3a, 0x87, 0xca, 0xa9, 0xd9, 0xf5,
+	0x79, 0xa9, 0x95, 0x7a, 0xc4, 0x18, 0xf8, 0x39, 0x0c, 0x97, 0x7c, 0xc5, 0x6a, 0x19, 0x0d, 0x75,
+	0x82, 0xb5, 0x94, 0xff, 0x7a, 0x53, 0x8a, 0x4d, 0x1e, 0xf9, 0x1a, 0x6e, 0x2d, 0x3c, 0x6d, 0xa9,
+	0xde, 0x8f, 0xd1, 0x24, 0x9c, 0xe1, 0x8e, 0x6a, 0x7d, 0x5f, 0x4e, 0x7a, 0xf2, 0x13, 0x81, 0xff,
+	0x08, 0x75, 0x26, 0x3f, 0x10, 0x0c, 0xe7, 0x65, 0x9e, 0x73, 0xf9, 0xa8, 0x68, 0xff, 0x42, 0x10,
+	0x18, 0xda, 0x92, 0x2d, 0xff, 0x2b, 0xf3, 0x37, 0x30, 0x12, 0xad, 0x91, 0x88, 0x86, 0x3b, 0x87,
+	0xa5, 0x83, 0x4b, 0xbe, 0x23, 0x18, 0xbf, 0x67, 0x32, 0xcd, 0x9c, 0x8c, 0x2d, 0x29, 0xb4, 0x93,
+	0x54, 0x7f, 0x27, 0xa9, 0x41, 0x9b, 0x94, 0xde, 0x03, 0x1f, 0xb5, 0xdf, 0xd3, 0xfe, 0xc6, 0x54,
+	0x17, 0x9a, 0x31, 0xbe, 0xca, 0xa4, 0x5d, 0x04, 0xd6, 0x4a, 0xbe, 0x21, 0x80, 0x4f, 0x9c, 0xdd,
+	0xcc, 0x33, 0x5a, 0xac, 0x58, 0xb7, 0x28, 0x7a, 0xa0, 0xa8, 0x21, 0xda, 0x6f, 0x13, 0x7d, 0x01,
+	0xfb, 0x95, 0xe0, 0xa5, 0xe0, 0xf2, 0xd6, 0xee, 0x80, 0xad, 0xdd, 0xed, 0x86, 0x77, 0xbf, 0x1b,
+	0x23, 0x40, 0x99, 0xbd, 0x55, 0x94, 0x25, 0x39, 0x84, 0xe7, 0x9b, 0xf5, 0xba, 0xd9, 0x93, 0x7f,
+	0x43, 0xa5, 0x53, 0x6e, 0xf0, 0x60, 0x39, 0xaf, 0x29, 0x77, 0xe7, 0x81, 0xff, 0xc1, 0xec, 0x6a,
+	0xfc, 0xf6, 0x5e, 0x33, 0xd1, 0xae, 0x66, 0x2e, 0x7a, 0xdd, 0x76, 0xe2, 0x19, 0x40, 0xb5, 0xdd,
+	0x74, 0x9a, 0x4c, 0x38, 0x7b, 0x6a, 0xf2, 0xdc, 0x06, 0x5c, 0xf4, 0x48, 0x0b, 0x85, 0x8f, 0xc0,
+	0xaf, 0x6c, 0xc2, 0x20, 0x46, 0x6e, 0xb1, 0x3a, 0x74, 0x13, 0xc7, 0xaf, 0x60, 0x98, 0xea, 0x39,
+	0xd1, 0xbc, 0xc3, 0xd9, 0xc8, 0x20, 0xcd, 0xec, 0x2c, 0x7a, 0xc4, 0x46, 0xf1, 0x6b, 0x08, 0xd2,
+	0x66, 0x9e, 0xf4, 0x8d, 0x86, 0xb3, 0x27, 0x6d, 0xa8, 0x64, 0xcb, 0x45, 0x8f, 0x38, 0x0c, 0x7e,
+	0x07, 0xe3, 0xab, 0xce, 0x14, 0xda, 0x01, 0x3e, 0x34, 0x59, 0xdd, 0x09, 0x5d, 0xf4, 0xc8, 0x3d,
+	0xb4, 0xd2, 0xfd, 0x95, 0xb3, 0x9b, 0x54, 0x8f, 0x4d, 0xe4, 0xb7, 0x75, 0xbb, 0x71, 0x52, 0xba,
+	0x1d, 0x0a, 0x1f, 0x03, 0x14, 0xa6, 0xc1, 0xea, 0xc1, 0x9b, 0xed, 0xfa, 0xcc, 0xe4, 0xb4, 0x1a,
+	0xaf, 0x92, 0x1c, 0x4c, 0xb5, 0xb4, 0xe6, 0xab, 0x82, 0xca, 0x8d, 0x60, 0x51, 0xa0, 0x7f, 0x18,
+	0x9d, 0x43, 0xc9, 0xd8, 0x6a, 0x3a, 0x59, 0x97, 0xe9, 0x97, 0x08, 0xda, 0x32, 0xe6, 0x9d, 0x98,
+	0x92, 0xd1, 0x45, 0x9f, 0x04, 0xe0, 0x57, 0xf4, 0x76, 0x5d, 0xd2, 0xa5, 0x7a, 0x09, 0xe3, 0x2e,
+	0xfe, 0x1f, 0x3e, 0x4c, 0xf7, 0xfc, 0xbc, 0xf6, 0xf3, 0x53, 0xe8, 0x4b, 0x4d, 0x7b, 0x4f, 0x2b,
+	0x33, 0xc6, 0xe5, 0x50, 0xff, 0x77, 0x38, 0xfe, 0x33, 0x00, 0x47, 0x70, 0xf2, 0xaf, 0x4c, 0x08,
+	0x00, 0x00,
 }
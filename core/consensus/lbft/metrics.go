@@ -0,0 +1,29 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lbft
+
+import "github.com/bocheninc/L0/components/metrics"
+
+var (
+	viewChanges    = metrics.NewCounter("l0_lbft_view_changes_total", "Number of lbft view changes.")
+	roundSeconds   = metrics.NewHistogramVec("l0_lbft_round_seconds", "Time from the start of a consensus instance to passing each phase.", nil, "phase")
+	stateTransfers = metrics.NewCounter("l0_lbft_state_transfers_total", "Number of committed requestBatch ranges fetched by a lagging replica.")
+	rejected       = metrics.NewCounter("l0_lbft_rejected_messages_total", "Number of consensus messages rejected for an invalid sender or signature.")
+	equivocated    = metrics.NewCounter("l0_lbft_equivocations_total", "Number of conflicting votes signed by a replica for the same seqNo.")
+)
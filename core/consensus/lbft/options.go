@@ -18,7 +18,11 @@
 
 package lbft
 
-import "time"
+import (
+	"time"
+
+	"github.com/bocheninc/L0/core/consensus"
+)
 
 //NewDefaultOptions Create nbft options with default value
 func NewDefaultOptions() *Options {
@@ -28,6 +32,7 @@ func NewDefaultOptions() *Options {
 	options.N = 4
 	options.Q = 3
 	options.K = 20
+	options.Retain = 200
 	options.BlockSize = 2000
 	options.BlockTimeout = 8 * time.Second
 	options.BlockInterval = 10 * time.Second
@@ -51,6 +56,7 @@ type Options struct {
 	N                    int
 	Q                    int
 	K                    int
+	Retain               int // executed requestBatches kept to serve lagging replicas, at least K
 	BlockSize            int
 	BlockTimeout         time.Duration
 	BlockInterval        time.Duration
@@ -62,4 +68,8 @@ type Options struct {
 	BufferSize           int
 	MaxConcurrentNumFrom int
 	MaxConcurrentNumTo   int
+	WAL                  string                        // path of the consensus write-ahead log, empty disables it
+	Replicas             consensus.Replicas            // replica set changed by governance txs, empty allows any replica
+	ChainReplicas        map[string]consensus.Replicas // replica sets of the other chains, verifying their cross-chain votes
+	Signer               consensus.ISigner             `json:"-"` // replica key signing consensus messages, nil sends them unsigned
 }
@@ -0,0 +1,134 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lbft
+
+import (
+	"time"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+// ReplicaID returns the replica id of the configured id on chain
+func ReplicaID(chain, id string) string {
+	return chain + ":" + utils.BytesToHex(crypto.Ripemd160([]byte(id+chain)))
+}
+
+// quorum returns the least quorum of n replicas
+func quorum(n int) int {
+	return (n*2-1)/3 + 1
+}
+
+// isReplica reports whether id belongs to the replica set, any id does if
+// no replica set is configured
+func (lbft *Lbft) isReplica(id string) bool {
+	lbft.rwReplicas.RLock()
+	defer lbft.rwReplicas.RUnlock()
+	if len(lbft.replicas) == 0 {
+		return true
+	}
+	_, ok := lbft.replicas[id]
+	return ok
+}
+
+// replicaNum returns N
+func (lbft *Lbft) replicaNum() int {
+	lbft.rwReplicas.RLock()
+	defer lbft.rwReplicas.RUnlock()
+	return lbft.options.N
+}
+
+// replicaSyncInterval is how often the blockchain is polled until it wrote
+// the governance txs taking effect
+const replicaSyncInterval = 10 * time.Millisecond
+
+// syncReplicas switches to the replica set the ledger has in effect once
+// seqNo is executed. A change takes effect Delay seqNos after its block, so
+// it waits for the blockchain to write the blocks up to seqNo-Delay, which
+// is the same on every replica and across restarts
+func (lbft *Lbft) syncReplicas(seqNo uint64) {
+	stack, ok := lbft.stack.(consensus.IReplicaStack)
+	if !ok {
+		return
+	}
+	for {
+		written := lbft.stack.GetLastSeqNo()
+		set, err := stack.ReplicaSet()
+		if err != nil {
+			logger.WithField(log.FieldSeqNo, seqNo).Errorf("Replica %s failed to read the replica set, %v", lbft.options.ID, err)
+			return
+		}
+		if set == nil {
+			return
+		}
+		if written+set.Delay >= seqNo {
+			lbft.setReplicas(set.At(seqNo), set.Addresses)
+			return
+		}
+		select {
+		case <-lbft.exit:
+			return
+		case <-time.After(replicaSyncInterval):
+		}
+	}
+}
+
+// setReplicas replaces the replica set, recomputes N and Q and notifies
+// the replicas added and removed
+func (lbft *Lbft) setReplicas(replicas consensus.Replicas, addresses map[string]string) {
+	lbft.rwReplicas.Lock()
+	previous := lbft.replicas
+	var changes []*consensus.ReplicaChange
+	for _, id := range previous.IDs() {
+		if _, ok := replicas[id]; !ok {
+			changes = append(changes, &consensus.ReplicaChange{Op: consensus.ReplicaRemove, ID: id, PublicKey: previous[id]})
+		}
+	}
+	for _, id := range replicas.IDs() {
+		if _, ok := previous[id]; !ok {
+			changes = append(changes, &consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: id, PublicKey: replicas[id], Address: addresses[id]})
+		}
+	}
+	if len(changes) == 0 {
+		lbft.rwReplicas.Unlock()
+		return
+	}
+	lbft.replicas = replicas
+	lbft.options.N = len(replicas)
+	lbft.options.Q = quorum(lbft.options.N)
+	lbft.rwReplicas.Unlock()
+
+	for _, change := range changes {
+		logger.Infof("Replica %s replica change %s %s : N %d, Q %d", lbft.options.ID, change.Op, change.ID, len(replicas), quorum(len(replicas)))
+		if change.ID == lbft.options.ID {
+			if change.Op == consensus.ReplicaAdd {
+				logger.Infof("Replica %s joined the replica set", lbft.options.ID)
+			} else {
+				logger.Warnf("Replica %s left the replica set, its votes are ignored", lbft.options.ID)
+			}
+		}
+		select {
+		case lbft.replicaChangeChan <- change:
+		default:
+			logger.Warnf("Replica %s drop the notification of replica change %s %s", lbft.options.ID, change.Op, change.ID)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lbft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	proto "github.com/golang/protobuf/proto"
+)
+
+// wal record types
+const (
+	walView byte = iota + 1
+	walPrePrepare
+	walPrepare
+	walCommit
+	walCommitted
+	walExec
+)
+
+// walCompactRecords is the number of records appended before the wal is
+// rewritten with the records still needed
+const walCompactRecords = 1024
+
+// walHeaderSize is the length and the checksum of a record
+const walHeaderSize = 8
+
+var errWALClosed = errors.New("consensus wal closed")
+
+// walState is the consensus state kept by the wal
+type walState struct {
+	view        *ViewChange
+	exec        uint64
+	committed   map[uint64]*Committed
+	prePrepares map[uint64]*PrePrepare
+	prepares    map[uint64]*Prepare
+	commits     map[uint64]*Commit
+}
+
+// wal is the write-ahead log of the lbft decisions, the current view and
+// the last executed seqNo. Each record is synced before the decision is
+// sent, so a restarted replica neither votes twice for a seqNo nor loses
+// the committed batches it has not executed yet
+type wal struct {
+	path    string
+	k       uint64
+	file    *os.File
+	state   walState
+	appends int
+	sync.Mutex
+}
+
+// openWAL opens the wal at path and replays it, a torn record at the end
+// left by a crash is truncated
+func openWAL(path string, k int) (*wal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	w := &wal{path: path, k: uint64(k)}
+	w.reset()
+	size, err := w.replay()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.file = file
+	return w, nil
+}
+
+func (w *wal) reset() {
+	w.state = walState{
+		committed:   make(map[uint64]*Committed),
+		prePrepares: make(map[uint64]*PrePrepare),
+		prepares:    make(map[uint64]*Prepare),
+		commits:     make(map[uint64]*Commit),
+	}
+}
+
+// replay applies the records of the file and returns the size of its valid
+// part
+func (w *wal) replay() (int64, error) {
+	file, err := os.Open(w.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var size int64
+	header := make([]byte, walHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length == 0 || length > 1<<30 {
+			break
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil || crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+		if err := w.apply(data[0], data[1:]); err != nil {
+			break
+		}
+		size += int64(walHeaderSize + length)
+	}
+	if info, err := file.Stat(); err == nil && info.Size() > size {
+		logger.Warnf("consensus wal %s : truncate %d bytes of torn records", w.path, info.Size()-size)
+	}
+	return size, nil
+}
+
+// apply decodes the record and updates the state
+func (w *wal) apply(tp byte, payload []byte) error {
+	switch tp {
+	case walView:
+		view := &ViewChange{}
+		if err := proto.Unmarshal(payload, view); err != nil {
+			return err
+		}
+		w.state.view = view
+	case walPrePrepare:
+		prePrepare := &PrePrepare{}
+		if err := proto.Unmarshal(payload, prePrepare); err != nil {
+			return err
+		}
+		w.state.prePrepares[prePrepare.SeqNo] = prePrepare
+	case walPrepare:
+		prepare := &Prepare{}
+		if err := proto.Unmarshal(payload, prepare); err != nil {
+			return err
+		}
+		w.state.prepares[prepare.SeqNo] = prepare
+	case walCommit:
+		commit := &Commit{}
+		if err := proto.Unmarshal(payload, commit); err != nil {
+			return err
+		}
+		w.state.commits[commit.SeqNo] = commit
+	case walCommitted:
+		committed := &Committed{}
+		if err := proto.Unmarshal(payload, committed); err != nil {
+			return err
+		}
+		w.state.committed[committed.SeqNo] = committed
+	case walExec:
+		if len(payload) != 8 {
+			return fmt.Errorf("invalid exec record")
+		}
+		w.setExec(binary.BigEndian.Uint64(payload))
+	default:
+		return fmt.Errorf("unknown record type %d", tp)
+	}
+	return nil
+}
+
+// setExec records the last executed seqNo and drops the decisions no longer
+// needed, the last K committed batches are kept for fetching replicas
+func (w *wal) setExec(seqNo uint64) {
+	w.state.exec = seqNo
+	for n := range w.state.committed {
+		if n+w.k < seqNo {
+			delete(w.state.committed, n)
+		}
+	}
+	for n := range w.state.prePrepares {
+		if n <= seqNo {
+			delete(w.state.prePrepares, n)
+		}
+	}
+	for n := range w.state.prepares {
+		if n <= seqNo {
+			delete(w.state.prepares, n)
+		}
+	}
+	for n := range w.state.commits {
+		if n <= seqNo {
+			delete(w.state.commits, n)
+		}
+	}
+}
+
+func encodeWALRecord(tp byte, payload []byte) []byte {
+	data := make([]byte, walHeaderSize+1+len(payload))
+	binary.BigEndian.PutUint32(data[:4], uint32(1+len(payload)))
+	data[walHeaderSize] = tp
+	copy(data[walHeaderSize+1:], payload)
+	binary.BigEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(data[walHeaderSize:]))
+	return data
+}
+
+// append writes and syncs the record, then applies it to the state
+func (w *wal) append(tp byte, payload []byte) error {
+	if w == nil {
+		return nil
+	}
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return errWALClosed
+	}
+	if _, err := w.file.Write(encodeWALRecord(tp, payload)); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	if err := w.apply(tp, payload); err != nil {
+		return err
+	}
+	w.appends++
+	if tp == walExec && w.appends >= walCompactRecords {
+		return w.compact()
+	}
+	return nil
+}
+
+func (w *wal) recordView(primaryID string, h uint64) error {
+	return w.append(walView, serialize(&ViewChange{PrimaryID: primaryID, H: h}))
+}
+
+func (w *wal) recordPrePrepare(prePrepare *PrePrepare) error {
+	return w.append(walPrePrepare, serialize(prePrepare))
+}
+
+func (w *wal) recordPrepare(prepare *Prepare) error {
+	return w.append(walPrepare, serialize(prepare))
+}
+
+func (w *wal) recordCommit(commit *Commit) error {
+	return w.append(walCommit, serialize(commit))
+}
+
+func (w *wal) recordCommitted(committed *Committed) error {
+	return w.append(walCommitted, serialize(committed))
+}
+
+func (w *wal) recordExec(seqNo uint64) error {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, seqNo)
+	return w.append(walExec, payload)
+}
+
+// voted returns the digest this replica prepared or committed for seqNo in
+// the view of primaryID
+func (w *wal) voted(primaryID string, seqNo uint64) (string, bool) {
+	if w == nil {
+		return "", false
+	}
+	w.Lock()
+	defer w.Unlock()
+	if commit, ok := w.state.commits[seqNo]; ok && commit.PrimaryID == primaryID {
+		return commit.Digest, true
+	}
+	if prepare, ok := w.state.prepares[seqNo]; ok && prepare.PrimaryID == primaryID {
+		return prepare.Digest, true
+	}
+	return "", false
+}
+
+// compact rewrites the wal with the records of the current state
+func (w *wal) compact() error {
+	tmp := w.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(file)
+	write := func(tp byte, payload []byte) {
+		bw.Write(encodeWALRecord(tp, payload))
+	}
+	if w.state.view != nil {
+		write(walView, serialize(w.state.view))
+	}
+	exec := make([]byte, 8)
+	binary.BigEndian.PutUint64(exec, w.state.exec)
+	write(walExec, exec)
+	for _, seqNo := range sortedSeqNos(w.state.committed) {
+		write(walCommitted, serialize(w.state.committed[seqNo]))
+	}
+	for _, seqNo := range sortedSeqNos(w.state.prePrepares) {
+		write(walPrePrepare, serialize(w.state.prePrepares[seqNo]))
+	}
+	for _, seqNo := range sortedSeqNos(w.state.prepares) {
+		write(walPrepare, serialize(w.state.prepares[seqNo]))
+	}
+	for _, seqNo := range sortedSeqNos(w.state.commits) {
+		write(walCommit, serialize(w.state.commits[seqNo]))
+	}
+	if err := bw.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		file.Close()
+		return err
+	}
+	w.file.Close()
+	w.file = file
+	w.appends = 0
+	return nil
+}
+
+// snapshot returns a copy of the state
+func (w *wal) snapshot() walState {
+	w.Lock()
+	defer w.Unlock()
+	state := walState{
+		view:        w.state.view,
+		exec:        w.state.exec,
+		committed:   make(map[uint64]*Committed, len(w.state.committed)),
+		prePrepares: make(map[uint64]*PrePrepare, len(w.state.prePrepares)),
+	}
+	for seqNo, committed := range w.state.committed {
+		state.committed[seqNo] = committed
+	}
+	for seqNo, prePrepare := range w.state.prePrepares {
+		state.prePrepares[seqNo] = prePrepare
+	}
+	return state
+}
+
+func (w *wal) close() error {
+	if w == nil {
+		return nil
+	}
+	w.Lock()
+	defer w.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// sortedSeqNos returns the keys of a map keyed by seqNo in order
+func sortedSeqNos(m interface{}) Uint64Slice {
+	keys := Uint64Slice{}
+	switch m := m.(type) {
+	case map[uint64]*Committed:
+		for seqNo := range m {
+			keys = append(keys, seqNo)
+		}
+	case map[uint64]*PrePrepare:
+		for seqNo := range m {
+			keys = append(keys, seqNo)
+		}
+	case map[uint64]*Prepare:
+		for seqNo := range m {
+			keys = append(keys, seqNo)
+		}
+	case map[uint64]*Commit:
+		for seqNo := range m {
+			keys = append(keys, seqNo)
+		}
+	}
+	sort.Sort(keys)
+	return keys
+}
+
+// restore sets the seqNos, the view and the committed batches recorded by
+// the wal, and returns the prePrepares of the view still in flight
+func (lbft *Lbft) restore(state walState) []*PrePrepare {
+	lbft.rwCommittedRequestBatch.Lock()
+	for seqNo, committed := range state.committed {
+		lbft.committedRequestBatch[seqNo] = committed.RequestBatch
+	}
+	lbft.rwCommittedRequestBatch.Unlock()
+	exec := state.exec
+	written := lbft.stack.GetLastSeqNo()
+	if written < exec {
+		// the batches handed to the blockchain but not written before a
+		// crash are executed again from the committed batches
+		logger.Warnf("Replica %s restored consensus wal : execSeqNo %d, blockchain wrote up to %d", lbft.options.ID, exec, written)
+		exec = written
+	} else if written > exec {
+		// blocks fetched from the replicas were written after the record
+		exec = written
+	}
+	lbft.writtenSeqNo = written
+	if exec > lbft.execSeqNum() {
+		atomic.StoreUint64(&lbft.execSeqNo, exec)
+	}
+
+	last := state.exec
+	for seqNo := range state.committed {
+		if seqNo > last {
+			last = seqNo
+		}
+	}
+	if state.view == nil {
+		lbft.updateLastSeqNo(last)
+		lbft.updateVerifySeqNo(last)
+		logger.Infof("Replica %s restored consensus wal : execSeqNo %d, lastSeqNo %d", lbft.options.ID, exec, last)
+		return nil
+	}
+
+	if state.view.H > last {
+		last = state.view.H
+	}
+	lbft.primaryID = state.view.PrimaryID
+	lbft.lastSeqNo = last
+	lbft.seqNo = last
+	lbft.verifySeqNo = last
+	lbft.prePrepareAsync = newAsyncSeqNo(last)
+	lbft.commitAsync = newAsyncSeqNo(last)
+
+	var prePrepares []*PrePrepare
+	for _, seqNo := range sortedSeqNos(state.prePrepares) {
+		prePrepare := state.prePrepares[seqNo]
+		if seqNo <= last || prePrepare.PrimaryID != lbft.primaryID {
+			continue
+		}
+		if lbft.isPrimary() {
+			lbft.seqNo = seqNo
+		}
+		prePrepares = append(prePrepares, prePrepare)
+	}
+	lbft.nullRequestTimerStart()
+	logger.Infof("Replica %s restored consensus wal : primaryID %s, execSeqNo %d, lastSeqNo %d, %d instances in flight", lbft.options.ID, lbft.primaryID, exec, last, len(prePrepares))
+	return prePrepares
+}
+
+// recordView records the view of the current primary starting after h
+func (lbft *Lbft) recordView(h uint64) {
+	if err := lbft.wal.recordView(lbft.primaryID, h); err != nil {
+		logger.Errorf("Replica %s failed to record view %s, %v", lbft.options.ID, lbft.primaryID, err)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lbft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bocheninc/L0/core/consensus/helper"
+)
+
+func testWAL(t *testing.T, k int) (*wal, string) {
+	dir, err := ioutil.TempDir("", "lbft-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "consensus", "lbft.wal")
+	w, err := openWAL(path, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, path
+}
+
+func TestWALReplay(t *testing.T) {
+	w, path := testWAL(t, 2)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+	batch := &RequestBatch{Time: 1, Id: EMPTYBLOCK}
+	w.recordView("primary", 2)
+	w.recordCommitted(&Committed{Name: "c3", SeqNo: 3, RequestBatch: batch})
+	w.recordPrePrepare(&PrePrepare{Name: "p4", PrimaryID: "primary", SeqNo: 4, Requests: batch})
+	w.recordPrepare(&Prepare{Name: "p4", PrimaryID: "primary", SeqNo: 4, Digest: "d4"})
+	w.recordCommit(&Commit{Name: "p4", PrimaryID: "primary", SeqNo: 4, Digest: "d4"})
+	w.recordExec(2)
+	w.close()
+	if err := w.recordExec(3); err != errWALClosed {
+		t.Fatalf("append to closed wal, err: [%v]", err)
+	}
+
+	info, _ := os.Stat(path)
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.Write(encodeWALRecord(walExec, []byte{0, 0, 0, 0, 0, 0, 0, 9})[:10])
+	f.Close()
+
+	w, err := openWAL(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	if truncated, _ := os.Stat(path); truncated.Size() != info.Size() {
+		t.Fatalf("torn record not truncated, size %d, want %d", truncated.Size(), info.Size())
+	}
+	state := w.snapshot()
+	if state.view == nil || state.view.PrimaryID != "primary" || state.view.H != 2 || state.exec != 2 {
+		t.Fatalf("view %v, exec %d", state.view, state.exec)
+	}
+	if state.committed[3] == nil || state.prePrepares[4] == nil {
+		t.Fatalf("committed %v, prePrepares %v", state.committed, state.prePrepares)
+	}
+	if digest, ok := w.voted("primary", 4); !ok || digest != "d4" {
+		t.Fatalf("voted %s %v, want d4", digest, ok)
+	}
+	if _, ok := w.voted("other", 4); ok {
+		t.Fatal("voted in the view of another primary")
+	}
+
+	w.recordExec(4)
+	if _, ok := w.voted("primary", 4); ok {
+		t.Fatal("executed seqNo still voted")
+	}
+}
+
+func TestWALCompact(t *testing.T) {
+	w, path := testWAL(t, 2)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+	w.recordView("primary", 0)
+	for seqNo := uint64(1); seqNo <= 10; seqNo++ {
+		w.recordCommitted(&Committed{SeqNo: seqNo, RequestBatch: &RequestBatch{Time: uint32(seqNo), Id: EMPTYBLOCK}})
+		w.recordExec(seqNo)
+	}
+	w.recordPrepare(&Prepare{PrimaryID: "primary", SeqNo: 11, Digest: "d11"})
+	w.Lock()
+	err := w.compact()
+	w.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.recordExec(10)
+	w.close()
+
+	w, err = openWAL(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	state := w.snapshot()
+	if state.exec != 10 || len(state.committed) != 3 || state.committed[8] == nil {
+		t.Fatalf("exec %d, committed %v", state.exec, state.committed)
+	}
+	if digest, ok := w.voted("primary", 11); !ok || digest != "d11" {
+		t.Fatalf("voted %s %v, want d11", digest, ok)
+	}
+}
+
+func TestLbftRestore(t *testing.T) {
+	w, path := testWAL(t, 20)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+	w.recordView("primary", 5)
+	w.recordCommitted(&Committed{SeqNo: 7, RequestBatch: &RequestBatch{Time: 7, Id: EMPTYBLOCK}})
+	w.recordExec(6)
+	w.close()
+
+	options := NewDefaultOptions()
+	options.WAL = path
+	stack := helper.NewStack()
+	stack.LastSeqNo = 6
+	lbft := NewLbft(options, stack)
+	lbft.Start()
+	defer lbft.Stop()
+
+	if lbft.primaryID != "primary" || lbft.lastSeqNum() != 7 || lbft.seqNum() != 7 {
+		t.Fatalf("primaryID %s, lastSeqNo %d, seqNo %d", lbft.primaryID, lbft.lastSeqNum(), lbft.seqNum())
+	}
+	for i := 0; i < 10 && lbft.execSeqNum() != 7; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if lbft.execSeqNum() != 7 {
+		t.Fatalf("execSeqNo %d, want the restored batch 7 executed", lbft.execSeqNum())
+	}
+}
+
+func TestLbftRestoreUnwritten(t *testing.T) {
+	w, path := testWAL(t, 20)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+	for seqNo := uint64(5); seqNo <= 7; seqNo++ {
+		w.recordCommitted(&Committed{SeqNo: seqNo, RequestBatch: &RequestBatch{Time: uint32(seqNo), Id: EMPTYBLOCK}})
+		w.recordExec(seqNo)
+	}
+	w.close()
+
+	// the blockchain wrote the batches up to 5 before the crash
+	stack := helper.NewStack()
+	stack.LastSeqNo = 5
+	lbft := NewLbft(NewDefaultOptions(), stack)
+	w, err := openWAL(path, int(lbft.retain()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	lbft.wal = w
+	lbft.restore(w.snapshot())
+	if lbft.execSeqNum() != 5 {
+		t.Fatalf("execSeqNo %d, want the written seqNo 5", lbft.execSeqNum())
+	}
+
+	lbft.checkpoint()
+	for _, seqNo := range []uint64{6, 7} {
+		select {
+		case ctt := <-lbft.committedRequestBatchChan:
+			if ctt.seqNo != seqNo || ctt.requestBatch.Time != uint32(seqNo) {
+				t.Fatalf("executed batch %d of time %d, want %d", ctt.seqNo, ctt.requestBatch.Time, seqNo)
+			}
+		default:
+			t.Fatalf("batch %d not executed again", seqNo)
+		}
+	}
+}
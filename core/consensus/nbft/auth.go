@@ -0,0 +1,118 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package nbft
+
+import (
+	"fmt"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+// maxVotes the latest votes kept to detect equivocation
+const maxVotes = 10000
+
+// ReplicaID returns the replica id of the configured id on chain
+func ReplicaID(chain, id string) string {
+	return utils.BytesToHex(crypto.Ripemd160(crypto.Ripemd160([]byte(id + chain))))
+}
+
+// signed returns the message of the payload signed with the replica key,
+// it is unsigned if no signer is configured
+func signed(options *Options, payload isNbftMessage_Payload) *NbftMessage {
+	msg := &NbftMessage{Payload: payload}
+	if options.Signer == nil {
+		return msg
+	}
+	sig, err := consensus.Sign(options.Signer, msg.Serialize())
+	if err != nil {
+		logger.Errorf("Replica %s failed to sign consensus message, %v", options.ID, err)
+		return msg
+	}
+	msg.Signature = sig
+	return msg
+}
+
+// sender chain and replica of the message, empty for request and committed
+func (msg *NbftMessage) sender() (chain, replicaID string) {
+	switch payload := msg.Payload.(type) {
+	case *NbftMessage_Preprepare:
+		return payload.Preprepare.GetChain(), payload.Preprepare.GetReplicaID()
+	case *NbftMessage_Prepare:
+		return payload.Prepare.GetChain(), payload.Prepare.GetReplicaID()
+	case *NbftMessage_Commit:
+		return payload.Commit.GetChain(), payload.Commit.GetReplicaID()
+	case *NbftMessage_FetchCommitted:
+		return "", payload.FetchCommitted.GetReplicaID()
+	case *NbftMessage_ReturnCommitted:
+		return "", payload.ReturnCommitted.GetReplicaID()
+	}
+	return "", ""
+}
+
+// verify checks the message is signed by a replica of the replica set of its
+// chain, any message is accepted if no replica set is configured. The votes
+// of other chains are rejected unless their replica set is configured.
+// Committed requests are fetched from and returned by replicas of our chain,
+// requests and committed messages carry no sender and are not verified
+func (nbft *Nbft) verify(msg *NbftMessage) error {
+	if len(nbft.options.Replicas) == 0 {
+		return nil
+	}
+	switch msg.Payload.(type) {
+	case *NbftMessage_Request, *NbftMessage_Committed:
+		return nil
+	}
+	chain, replicaID := msg.sender()
+	if replicaID == "" {
+		return fmt.Errorf("no sender replica")
+	}
+	replicas := nbft.options.Replicas
+	if chain != "" && chain != nbft.options.Chain {
+		if replicas = nbft.options.ChainReplicas[chain]; len(replicas) == 0 {
+			return fmt.Errorf("no replica set of chain %s is configured", chain)
+		}
+	}
+	return replicas.Verify(replicaID, (&NbftMessage{Payload: msg.Payload}).Serialize(), msg.Signature)
+}
+
+// checkEquivocation logs the evidence if the replica of our chain voted
+// another digest for the same consensus before
+func (nbft *Nbft) checkEquivocation(msg *NbftMessage, payload []byte) {
+	var phase, chain, replicaID, name, digest string
+	switch tp := msg.Payload.(type) {
+	case *NbftMessage_Preprepare:
+		phase, chain, replicaID, name, digest = "prePrepare", tp.Preprepare.Chain, tp.Preprepare.ReplicaID, tp.Preprepare.Name, tp.Preprepare.Digest
+	case *NbftMessage_Prepare:
+		phase, chain, replicaID, name, digest = "prepare", tp.Prepare.Chain, tp.Prepare.ReplicaID, tp.Prepare.Name, tp.Prepare.Digest
+	case *NbftMessage_Commit:
+		phase, chain, replicaID, name, digest = "commit", tp.Commit.Chain, tp.Commit.ReplicaID, tp.Commit.Name, tp.Commit.Digest
+	default:
+		return
+	}
+	if chain != nbft.options.Chain {
+		return
+	}
+
+	slot := fmt.Sprintf("%s of consensus %s", phase, name)
+	if evidence := nbft.equivocations.Check(replicaID, slot, digest, payload); evidence != nil {
+		logger.Errorf("Replica %s detected equivocation : %s, evidence %x and %x", nbft.options.ID, evidence, evidence.Messages[0], evidence.Messages[1])
+	}
+}
@@ -334,7 +334,8 @@ type NbftMessage struct {
 	//	*NbftMessage_Committed
 	//	*NbftMessage_FetchCommitted
 	//	*NbftMessage_ReturnCommitted
-	Payload isNbftMessage_Payload `protobuf_oneof:"payload"`
+	Payload   isNbftMessage_Payload `protobuf_oneof:"payload"`
+	Signature []byte                `protobuf:"bytes,8,opt,name=signature,proto3" json:"signature,omitempty"`
 }
 
 func (m *NbftMessage) Reset()                    { *m = NbftMessage{} }
@@ -432,6 +433,13 @@ func (m *NbftMessage) GetReturnCommitted() *ReturnCommitted {
 	return nil
 }
 
+func (m *NbftMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*NbftMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _NbftMessage_OneofMarshaler, _NbftMessage_OneofUnmarshaler, _NbftMessage_OneofSizer, []interface{}{
@@ -615,35 +623,35 @@ func init() {
 func init() { proto.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 467 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0xb5, 0xe3, 0xc4, 0xc6, 0xe3, 0xb6, 0xa9, 0x46, 0x05, 0xed, 0x81, 0x83, 0xe5, 0x03, 0x4a,
-	0x0f, 0x04, 0x29, 0xdc, 0x11, 0x50, 0x84, 0xcc, 0x01, 0x54, 0xed, 0x0f, 0x40, 0xda, 0x38, 0x93,
-	0xd6, 0xa2, 0xfe, 0xe8, 0x7a, 0x23, 0xd4, 0x1b, 0xfc, 0x3a, 0xfe, 0x15, 0x42, 0xde, 0x5d, 0xc7,
-	0x76, 0x84, 0xaa, 0xde, 0x72, 0xdb, 0x99, 0x79, 0xf3, 0xe6, 0xbd, 0x99, 0x38, 0x70, 0x5a, 0x50,
-	0xd3, 0x88, 0x1b, 0x5a, 0xd6, 0xb2, 0x52, 0x15, 0x4e, 0xcb, 0xf5, 0x56, 0x25, 0x3f, 0x21, 0xe0,
-	0x74, 0xbf, 0xa3, 0x46, 0x21, 0xc2, 0x54, 0xe5, 0x05, 0x31, 0x37, 0x76, 0x17, 0x1e, 0xd7, 0x6f,
-	0x8c, 0x21, 0x52, 0x52, 0x94, 0x8d, 0xc8, 0x54, 0x5e, 0x95, 0x6c, 0x12, 0xbb, 0x8b, 0x13, 0x3e,
-	0x4c, 0xe1, 0x4b, 0x08, 0xb7, 0xb2, 0x2a, 0xae, 0x6e, 0x45, 0x5e, 0x32, 0x2f, 0x76, 0x17, 0x21,
-	0xef, 0x13, 0xc8, 0x20, 0x50, 0x95, 0xa9, 0x4d, 0x75, 0xad, 0x0b, 0x93, 0x3f, 0x2e, 0xc0, 0xb5,
-	0xa4, 0x6b, 0x49, 0xb5, 0x90, 0xd4, 0xd2, 0x48, 0xaa, 0xef, 0xf2, 0x4c, 0x7c, 0xf9, 0xa4, 0x15,
-	0x84, 0xbc, 0x4f, 0xb4, 0xd2, 0x4a, 0x51, 0x90, 0x9e, 0x1f, 0x72, 0xfd, 0xc6, 0x0b, 0x98, 0x65,
-	0x83, 0xa1, 0x26, 0xc0, 0x17, 0xe0, 0xdf, 0xef, 0x2a, 0xb9, 0x2b, 0xf4, 0xbc, 0x29, 0xb7, 0x51,
-	0x9b, 0xdf, 0xe4, 0x37, 0xd4, 0x28, 0x36, 0xd3, 0x70, 0x1b, 0xb5, 0x79, 0xdd, 0xd8, 0x30, 0x3f,
-	0xf6, 0xda, 0xbc, 0x89, 0xf0, 0x12, 0x9e, 0x49, 0xb3, 0x97, 0x86, 0x05, 0xb1, 0xb7, 0x88, 0x56,
-	0xa7, 0xcb, 0x76, 0x61, 0x4b, 0xbb, 0x2d, 0xbe, 0x2f, 0x27, 0xbf, 0x5d, 0x08, 0x8e, 0x6c, 0x23,
-	0xf9, 0xe5, 0x82, 0x7f, 0x55, 0x15, 0x45, 0xae, 0x8e, 0x26, 0x21, 0x85, 0xd0, 0x28, 0x50, 0xb4,
-	0xc1, 0x73, 0xf0, 0x7e, 0xd0, 0x83, 0x1d, 0xdf, 0x3e, 0x47, 0x0b, 0x9d, 0x3c, 0xbe, 0xd0, 0xf7,
-	0x70, 0xf6, 0x99, 0x54, 0x76, 0xdb, 0xd3, 0x3d, 0xee, 0xc9, 0x0e, 0x9b, 0xec, 0x87, 0x25, 0xdf,
-	0x61, 0xce, 0x49, 0xed, 0x64, 0xf9, 0x54, 0x8a, 0xd7, 0x10, 0x66, 0x1d, 0x54, 0x13, 0x45, 0xab,
-	0xb9, 0x91, 0xb7, 0x67, 0xe0, 0x3d, 0x22, 0xf9, 0x3b, 0x81, 0xe8, 0xdb, 0x7a, 0xab, 0xbe, 0x9a,
-	0x2f, 0x0a, 0x2f, 0x21, 0xb0, 0xea, 0x35, 0xf5, 0xa1, 0xb7, 0xd4, 0xe1, 0x5d, 0x1d, 0x57, 0x00,
-	0xb5, 0xa4, 0xda, 0xfc, 0x5e, 0xec, 0xa8, 0x73, 0x83, 0xee, 0x3f, 0x87, 0xd4, 0xe1, 0x03, 0x54,
-	0x4b, 0xdf, 0x35, 0x78, 0x43, 0xfa, 0x1e, 0xdd, 0xd5, 0xf1, 0x15, 0xf8, 0x46, 0xa6, 0xbe, 0x5a,
-	0xb4, 0x3a, 0x19, 0xba, 0x48, 0x1d, 0x6e, 0xab, 0xf8, 0x66, 0x68, 0x78, 0xf6, 0x5f, 0xc3, 0xa9,
-	0x33, 0xb0, 0x8c, 0xef, 0xe0, 0x6c, 0x3b, 0x3a, 0x0a, 0xf3, 0x75, 0xd7, 0x85, 0xe9, 0x1a, 0x1f,
-	0x2c, 0x75, 0xf8, 0x01, 0x1a, 0x3f, 0xc0, 0x5c, 0x8e, 0x4f, 0xc2, 0x02, 0x4d, 0xf0, 0xbc, 0x5b,
-	0xd5, 0xa8, 0x98, 0x3a, 0xfc, 0x10, 0xff, 0x31, 0x84, 0xa0, 0x16, 0x0f, 0x77, 0x95, 0xd8, 0xac,
-	0x7d, 0xfd, 0x1f, 0xf6, 0xf6, 0x5f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x47, 0xc7, 0x42, 0xc5, 0xd4,
-	0x04, 0x00, 0x00,
+	// 477 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x54, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xb6, 0xe3, 0xc4, 0xae, 0xc7, 0x6d, 0x53, 0x8d, 0x0a, 0xda, 0x03, 0x07, 0xcb, 0x07, 0x94,
+	0x1e, 0x08, 0x52, 0xb8, 0x23, 0xa0, 0x08, 0x99, 0x03, 0xa8, 0xda, 0x07, 0x40, 0xda, 0x38, 0xe3,
+	0xd4, 0xa2, 0xfe, 0xe9, 0x7a, 0x2d, 0xd4, 0x1b, 0xbc, 0x07, 0xef, 0xc3, 0x6b, 0x21, 0xef, 0xda,
+	0xb1, 0x5d, 0x50, 0xd5, 0x5b, 0x6f, 0x3b, 0x3f, 0xfb, 0xfd, 0xcc, 0xd8, 0x0b, 0x27, 0x39, 0xd5,
+	0xb5, 0xd8, 0xd3, 0xba, 0x92, 0xa5, 0x2a, 0x71, 0x5e, 0x6c, 0x53, 0x15, 0xfd, 0x00, 0x8f, 0xd3,
+	0x6d, 0x43, 0xb5, 0x42, 0x84, 0xb9, 0xca, 0x72, 0x62, 0x76, 0x68, 0xaf, 0x1c, 0xae, 0xcf, 0x18,
+	0x42, 0xa0, 0xa4, 0x28, 0x6a, 0x91, 0xa8, 0xac, 0x2c, 0xd8, 0x2c, 0xb4, 0x57, 0xc7, 0x7c, 0x9c,
+	0xc2, 0x17, 0xe0, 0xa7, 0xb2, 0xcc, 0x2f, 0xaf, 0x45, 0x56, 0x30, 0x27, 0xb4, 0x57, 0x3e, 0x1f,
+	0x12, 0xc8, 0xc0, 0x53, 0xa5, 0xa9, 0xcd, 0x75, 0xad, 0x0f, 0xa3, 0x3f, 0x36, 0xc0, 0x95, 0xa4,
+	0x2b, 0x49, 0x95, 0x90, 0xd4, 0xc2, 0x48, 0xaa, 0x6e, 0xb2, 0x44, 0x7c, 0xfe, 0xa8, 0x15, 0xf8,
+	0x7c, 0x48, 0xb4, 0xd2, 0x0a, 0x91, 0x93, 0xe6, 0xf7, 0xb9, 0x3e, 0xe3, 0x39, 0x2c, 0x92, 0x11,
+	0xa9, 0x09, 0xf0, 0x39, 0xb8, 0xb7, 0x4d, 0x29, 0x9b, 0x5c, 0xf3, 0xcd, 0x79, 0x17, 0xb5, 0xf9,
+	0x5d, 0xb6, 0xa7, 0x5a, 0xb1, 0x85, 0x6e, 0xef, 0xa2, 0x36, 0xaf, 0x2f, 0xd6, 0xcc, 0x0d, 0x9d,
+	0x36, 0x6f, 0x22, 0xbc, 0x80, 0x23, 0x69, 0xe6, 0x52, 0x33, 0x2f, 0x74, 0x56, 0xc1, 0xe6, 0x64,
+	0xdd, 0x0e, 0x6c, 0xdd, 0x4d, 0x8b, 0x1f, 0xca, 0xd1, 0x2f, 0x1b, 0xbc, 0x27, 0xb6, 0x11, 0xfd,
+	0xb4, 0xc1, 0xbd, 0x2c, 0xf3, 0x3c, 0x53, 0x4f, 0x26, 0x21, 0x06, 0xdf, 0x28, 0x50, 0xb4, 0xc3,
+	0x33, 0x70, 0xbe, 0xd3, 0x5d, 0x47, 0xdf, 0x1e, 0x27, 0x03, 0x9d, 0x3d, 0x3c, 0xd0, 0x77, 0x70,
+	0xfa, 0x89, 0x54, 0x72, 0x3d, 0xc0, 0x3d, 0xec, 0xa9, 0x23, 0x9b, 0x1d, 0xc8, 0xa2, 0x6f, 0xb0,
+	0xe4, 0xa4, 0x1a, 0x59, 0x3c, 0x16, 0xe2, 0x15, 0xf8, 0x49, 0xdf, 0xaa, 0x81, 0x82, 0xcd, 0xd2,
+	0xc8, 0x3b, 0x20, 0xf0, 0xa1, 0x23, 0xfa, 0xed, 0x40, 0xf0, 0x75, 0x9b, 0xaa, 0x2f, 0xe6, 0x8f,
+	0xc2, 0x0b, 0xf0, 0x3a, 0xf5, 0x1a, 0xfa, 0xbe, 0xb7, 0xd8, 0xe2, 0x7d, 0x1d, 0x37, 0x00, 0x95,
+	0xa4, 0xca, 0x7c, 0x2f, 0x1d, 0xd5, 0x99, 0xe9, 0x1e, 0x7e, 0x87, 0xd8, 0xe2, 0xa3, 0xae, 0x16,
+	0xbe, 0xbf, 0xe0, 0x8c, 0xe1, 0x87, 0xee, 0xbe, 0x8e, 0x2f, 0xc1, 0x35, 0x32, 0xf5, 0xd6, 0x82,
+	0xcd, 0xf1, 0xd8, 0x45, 0x6c, 0xf1, 0xae, 0x8a, 0xaf, 0xc7, 0x86, 0x17, 0xff, 0x35, 0x1c, 0x5b,
+	0x23, 0xcb, 0xf8, 0x16, 0x4e, 0xd3, 0xc9, 0x52, 0x98, 0xab, 0x6f, 0x9d, 0x9b, 0x5b, 0xd3, 0x85,
+	0xc5, 0x16, 0xbf, 0xd7, 0x8d, 0xef, 0x61, 0x29, 0xa7, 0x2b, 0x61, 0x9e, 0x06, 0x78, 0xd6, 0x8f,
+	0x6a, 0x52, 0x8c, 0x2d, 0xbe, 0x94, 0xff, 0xae, 0xb0, 0xce, 0xf6, 0x85, 0x50, 0x8d, 0x24, 0x76,
+	0xa4, 0x9f, 0xa2, 0x21, 0xf1, 0xc1, 0x07, 0xaf, 0x12, 0x77, 0x37, 0xa5, 0xd8, 0x6d, 0x5d, 0xfd,
+	0xc2, 0xbd, 0xf9, 0x3b, 0x00, 0xe2, 0x16, 0xa8, 0xef, 0xf2, 0x04, 0x00, 0x00,
 }
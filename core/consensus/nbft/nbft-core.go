@@ -25,7 +25,6 @@ import (
 
 	"strings"
 
-	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils/vote"
 	"github.com/bocheninc/L0/core/consensus"
 )
@@ -64,7 +63,7 @@ type nbftCore struct {
 }
 
 func (instance *nbftCore) sendPrePrepare(reqs []*Request) {
-	log.Debugf("Replica %s send prePrepare for consensus %s (%d transactions)", instance.options.ID, instance.name, len(reqs))
+	logger.Debugf("Replica %s send prePrepare for consensus %s (%d transactions)", instance.options.ID, instance.name, len(reqs))
 
 	instance.reqs = reqs
 	chains := make(map[string]string)
@@ -104,7 +103,7 @@ func (instance *nbftCore) recvPrePrepare(preprep *PrePrepare) {
 	if instance.isPrimaryChain() && preprep.ReplicaID != instance.options.ID {
 		return
 	}
-	log.Debugf("Replica %s received prePrepare from %s for consensus %s, voted %d", instance.options.ID, preprep.ReplicaID, preprep.Name, instance.prePrepareVote.Size())
+	logger.Debugf("Replica %s received prePrepare from %s for consensus %s, voted %d", instance.options.ID, preprep.ReplicaID, preprep.Name, instance.prePrepareVote.Size())
 	instance.prePrepareVote.Add(preprep.ReplicaID, preprep)
 	if instance.isPassPrePrepare == false && instance.maybePrePreparePass() {
 		prep := &Prepare{
@@ -114,14 +113,14 @@ func (instance *nbftCore) recvPrePrepare(preprep *PrePrepare) {
 			Name:      instance.name,
 			Digest:    instance.digest,
 		}
-		log.Debugf("Replica %s send prepare for consensus %s", instance.options.ID, instance.name)
+		logger.Debugf("Replica %s send prepare for consensus %s", instance.options.ID, instance.name)
 		instance.broadcast(&NbftMessage_Prepare{Prepare: prep})
 		instance.recvPrepare(prep)
 	}
 }
 
 func (instance *nbftCore) recvPrepare(prep *Prepare) {
-	log.Debugf("Replica %s received prepare from %s for consensus %s, voted %d", instance.options.ID, prep.ReplicaID, prep.Name, instance.prepareVote.Size())
+	logger.Debugf("Replica %s received prepare from %s for consensus %s, voted %d", instance.options.ID, prep.ReplicaID, prep.Name, instance.prepareVote.Size())
 	instance.prepareVote.Add(prep.ReplicaID, prep)
 	if instance.isPassPrepare == false && instance.maybePreparePass() {
 		commit := &Commit{
@@ -131,17 +130,17 @@ func (instance *nbftCore) recvPrepare(prep *Prepare) {
 			Name:      instance.name,
 			Digest:    instance.digest,
 		}
-		log.Debugf("Replica %s send commit for consensus %s", instance.options.ID, instance.name)
+		logger.Debugf("Replica %s send commit for consensus %s", instance.options.ID, instance.name)
 		instance.broadcast(&NbftMessage_Commit{Commit: commit})
 		instance.recvCommit(commit)
 	}
 }
 
 func (instance *nbftCore) recvCommit(commit *Commit) {
-	log.Debugf("Replica %s received commit from %s for consensus %s, voted %d", instance.options.ID, commit.ReplicaID, commit.Name, instance.commitVote.Size())
+	logger.Debugf("Replica %s received commit from %s for consensus %s, voted %d", instance.options.ID, commit.ReplicaID, commit.Name, instance.commitVote.Size())
 	instance.commitVote.Add(commit.ReplicaID, commit)
 	if instance.isCommit == false && instance.maybeCommitPass() {
-		log.Infof("Replica %s succeed to commit for consensus %s (%d transactions)", instance.options.ID, instance.name, len(instance.reqs))
+		logger.Infof("Replica %s succeed to commit for consensus %s (%d transactions)", instance.options.ID, instance.name, len(instance.reqs))
 		instance.committedReqsChan <- &Committed{Key: instance.name, Requests: instance.reqs}
 	}
 }
@@ -155,7 +154,7 @@ func (instance *nbftCore) maybePrePreparePass() bool {
 		max, ticket := instance.prePrepareVote.Voter()
 		preprep := ticket.(*PrePrepare)
 		quorum := preprep.Quorum
-		log.Debugf("Replica %s preprepare quorum of chain %s for consensus %s: %d >= %d", instance.options.ID, preprep.Chain, instance.name, max, quorum)
+		logger.Debugf("Replica %s preprepare quorum of chain %s for consensus %s: %d >= %d", instance.options.ID, preprep.Chain, instance.name, max, quorum)
 		if max == 0 || quorum < MINQUORUM || uint64(max) < quorum {
 			return false
 		}
@@ -188,8 +187,8 @@ func (instance *nbftCore) maybePreparePass() bool {
 				}
 			})
 		}
-		log.Debugf("Replica %s prepare quorum of chain %s for consensus %s : voter %s", instance.options.ID, chain, instance.name, instance.prepareVote.String())
-		log.Debugf("Replica %s prepare quorum of chain %s for consensus %s : %d >= %d", instance.options.ID, chain, instance.name, max, quorum)
+		logger.Debugf("Replica %s prepare quorum of chain %s for consensus %s : voter %s", instance.options.ID, chain, instance.name, instance.prepareVote.String())
+		logger.Debugf("Replica %s prepare quorum of chain %s for consensus %s : %d >= %d", instance.options.ID, chain, instance.name, max, quorum)
 		if max == 0 || quorum < MINQUORUM || uint64(max) < quorum {
 			return false
 		}
@@ -221,8 +220,8 @@ func (instance *nbftCore) maybeCommitPass() bool {
 				}
 			})
 		}
-		log.Debugf("Replica %s commit quorum of chain %s for consensus %s : vote %s", instance.options.ID, chain, instance.name, instance.commitVote.String())
-		log.Debugf("Replica %s commit quorum of chain %s for consensus %s : %d >= %d", instance.options.ID, chain, instance.name, max, quorum)
+		logger.Debugf("Replica %s commit quorum of chain %s for consensus %s : vote %s", instance.options.ID, chain, instance.name, instance.commitVote.String())
+		logger.Debugf("Replica %s commit quorum of chain %s for consensus %s : %d >= %d", instance.options.ID, chain, instance.name, max, quorum)
 		if max == 0 || quorum < MINQUORUM || uint64(max) < quorum {
 			return false
 		}
@@ -235,7 +234,7 @@ func (instance *nbftCore) broadcast(payload isNbftMessage_Payload) {
 	for _, chain := range instance.chains {
 		instance.broadcastChan <- &Broadcast{
 			to:      chain,
-			payload: &NbftMessage{Payload: payload},
+			payload: signed(instance.options, payload),
 		}
 	}
 }
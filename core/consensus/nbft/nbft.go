@@ -31,6 +31,8 @@ import (
 	"github.com/bocheninc/L0/core/consensus"
 )
 
+var logger = log.Module(log.ModuleConsensus)
+
 // MINQUORUM  Define min quorum
 const MINQUORUM = 3
 
@@ -57,18 +59,22 @@ func NewNbft(options *Options, stack consensus.IStack) *Nbft {
 	nbft.executedCommittedReqs = make(map[string]*Committed)
 	nbft.returnCommittedReqsList = make(map[string][]*ReturnCommitted)
 	nbft.hLastExec = make(map[string]time.Time)
+	nbft.equivocations = consensus.NewEquivocations(maxVotes)
 	if nbft.options.BlockTimeout > nbft.options.BlockInterval {
-		log.Warn("nbft.blockTimeout should is smaller nbft.blockInterval")
+		logger.Warn("nbft.blockTimeout should is smaller nbft.blockInterval")
 		nbft.options.BlockTimeout = 2 * nbft.options.BlockInterval / 3
 	}
 	if nbft.options.BlockDelay < nbft.options.BlockInterval {
-		log.Warn("nbft.BlockDelay should is greater nbft.BlockInterval")
+		logger.Warn("nbft.BlockDelay should is greater nbft.BlockInterval")
 		nbft.options.BlockDelay = nbft.options.BlockInterval
 	}
 	if nbft.options.Q < MINQUORUM {
-		log.Warn("nbft.Q should is not smaller %d", MINQUORUM)
+		logger.Warnf("nbft.Q should is not smaller %d", MINQUORUM)
 		nbft.options.Q = MINQUORUM
 	}
+	if len(nbft.options.Replicas) == 0 {
+		logger.Warnf("Replica %s has no replica set configured, consensus messages aren't authenticated", nbft.options.ID)
+	}
 	return nbft
 }
 
@@ -92,6 +98,7 @@ type Nbft struct {
 	lastExecCommittedReqs   time.Time
 	hLastExec               map[string]time.Time
 	lastRequestTime         int64
+	equivocations           *consensus.Equivocations
 	sync.RWMutex
 }
 
@@ -117,7 +124,7 @@ func (nbft *Nbft) Start() {
 			nbft.exit = nil
 			return
 		case req := <-nbft.commitReqChan:
-			log.Debugf("Replica %s received transaction", nbft.options.ID)
+			logger.Debugf("Replica %s received transaction", nbft.options.ID)
 			if req.Time > nbft.lastRequestTime {
 				nbft.list.Add(req)
 				if nbft.list.Len() == 1 {
@@ -136,7 +143,7 @@ func (nbft *Nbft) Start() {
 func (nbft *Nbft) resetBlockTimer() {
 	t := time.Now()
 	t2 := t.Truncate(nbft.options.BlockInterval)
-	log.Debugf("Replica %s will be start nbft service after %s", nbft.options.ID, nbft.options.BlockInterval-t.Sub(t2))
+	logger.Debugf("Replica %s will be start nbft service after %s", nbft.options.ID, nbft.options.BlockInterval-t.Sub(t2))
 	nbft.blockTimer.Reset(nbft.options.BlockInterval - t.Sub(t2))
 	nbft.blockTimeChan <- t2.Add(-nbft.options.BlockDelay)
 }
@@ -171,18 +178,18 @@ func (nbft *Nbft) processBlock() {
 	if element != nil {
 		elems := nbft.list.RemoveBefore(element)
 		if len(elems) > 0 {
-			log.Debugf("Replica %s start nbft service,  %d transactions", nbft.options.ID, len(elems))
+			logger.Debugf("Replica %s start nbft service,  %d transactions", nbft.options.ID, len(elems))
 			id := nbft.options.Chain + ":" + t.Format(FORMAT)
 			reqs := []*Request{}
 			for index, elem := range elems {
 				reqs = append(reqs, elem.(*Request))
-				log.Debugf("Replica %s consensus %s : transaction %d, %v", nbft.options.ID, id, index, elem.(*Request))
+				logger.Debugf("Replica %s consensus %s : transaction %d, %v", nbft.options.ID, id, index, elem.(*Request))
 			}
 			instance := nbft.getInstance(id)
 			instance.sendPrePrepare(reqs)
 		}
 	} else {
-		log.Debugf("Replica %s start nbft service,  no transactions", nbft.options.ID)
+		logger.Debugf("Replica %s start nbft service,  no transactions", nbft.options.ID)
 	}
 
 	if nbft.list.Len() != 0 {
@@ -209,10 +216,10 @@ func (nbft *Nbft) RecvTransaction(tx consensus.ITransaction) {
 		nbft.commitReqChan <- req
 		nbft.broadcastChan <- &Broadcast{
 			to:      nbft.options.Chain,
-			payload: &NbftMessage{Payload: &NbftMessage_Request{Request: req}},
+			payload: signed(nbft.options, &NbftMessage_Request{Request: req}),
 		}
 	} else {
-		log.Errorf("Replica %s failed to receive transaction, fromchain %s is diff localchain %s", nbft.options.ID, req.FromChain, nbft.options.Chain)
+		logger.Errorf("Replica %s failed to receive transaction, fromchain %s is diff localchain %s", nbft.options.ID, req.FromChain, nbft.options.Chain)
 	}
 }
 
@@ -220,41 +227,46 @@ func (nbft *Nbft) RecvTransaction(tx consensus.ITransaction) {
 func (nbft *Nbft) RecvConsensus(payload []byte) {
 	nbftMessage := &NbftMessage{}
 	nbftMessage.Deserialize(payload)
+	if err := nbft.verify(nbftMessage); err != nil {
+		logger.Warnf("Replica %s received consensus message : reject %v", nbft.options.ID, err)
+		return
+	}
+	nbft.checkEquivocation(nbftMessage, payload)
 	switch tp := nbftMessage.Payload.(type) {
 	case *NbftMessage_Request:
 		req := nbftMessage.GetRequest()
-		log.Debugf("Replica %s received consensus request", nbft.options.ID)
+		logger.Debugf("Replica %s received consensus request", nbft.options.ID)
 		if req.FromChain == nbft.options.Chain {
 			nbft.commitReqChan <- req
 		} else {
-			log.Errorf("Replica %s failed to receive transaction, fromchain %s is not localchain %s", nbft.options.ID, req.FromChain, nbft.options.Chain)
+			logger.Errorf("Replica %s failed to receive transaction, fromchain %s is not localchain %s", nbft.options.ID, req.FromChain, nbft.options.Chain)
 		}
 	case *NbftMessage_Preprepare:
 		preprep := nbftMessage.GetPreprepare()
 		instance := nbft.getInstance(preprep.Name)
 		if instance != nil {
-			log.Debugf("Replica %s received consensus preprepare for consensus %s", nbft.options.ID, preprep.Name)
+			logger.Debugf("Replica %s received consensus preprepare for consensus %s", nbft.options.ID, preprep.Name)
 			instance.recvPrePrepare(preprep)
 		} else {
-			log.Warnf("Replica %s received preprepare timeout for consensus %s", nbft.options.ID, preprep.Name)
+			logger.Warnf("Replica %s received preprepare timeout for consensus %s", nbft.options.ID, preprep.Name)
 		}
 	case *NbftMessage_Prepare:
 		prep := nbftMessage.GetPrepare()
 		instance := nbft.getInstance(prep.Name)
 		if instance != nil {
-			log.Debugf("Replica %s received consensus prepare for consensus %s", nbft.options.ID, prep.Name)
+			logger.Debugf("Replica %s received consensus prepare for consensus %s", nbft.options.ID, prep.Name)
 			instance.recvPrepare(prep)
 		} else {
-			log.Warnf("Replica %s received prepare timeout for consensus %s", nbft.options.ID, prep.Name)
+			logger.Warnf("Replica %s received prepare timeout for consensus %s", nbft.options.ID, prep.Name)
 		}
 	case *NbftMessage_Commit:
 		commit := nbftMessage.GetCommit()
 		instance := nbft.getInstance(commit.Name)
 		if instance != nil {
-			log.Debugf("Replica %s received consensus commit for consensus %s", nbft.options.ID, commit.Name)
+			logger.Debugf("Replica %s received consensus commit for consensus %s", nbft.options.ID, commit.Name)
 			instance.recvCommit(commit)
 		} else {
-			log.Warnf("Replica %s received commit timeout for consensus %s", nbft.options.ID, commit.Name)
+			logger.Warnf("Replica %s received commit timeout for consensus %s", nbft.options.ID, commit.Name)
 		}
 	case *NbftMessage_FetchCommitted:
 		fetchCommitted := nbftMessage.GetFetchCommitted()
@@ -263,7 +275,7 @@ func (nbft *Nbft) RecvConsensus(payload []byte) {
 		returnCommitted := nbftMessage.GetReturnCommitted()
 		nbft.recvReturnCommitted(returnCommitted)
 	default:
-		log.Warnf("unsupport nbft message type %v ", tp)
+		logger.Warnf("unsupport nbft message type %v ", tp)
 	}
 }
 
@@ -306,7 +318,7 @@ func (nbft *Nbft) removeInstance(key string) {
 	if instance, ok := nbft.nbftCores[key]; ok {
 		if !instance.isCommit {
 			instance.committedReqsChan <- &Committed{Key: instance.name, Requests: nil}
-			log.Warnf("Replica %s is failed for consensus %s, timeout (%d transaction)", nbft.options.ID, instance.name, len(instance.reqs))
+			logger.Warnf("Replica %s is failed for consensus %s, timeout (%d transaction)", nbft.options.ID, instance.name, len(instance.reqs))
 		}
 		delete(nbft.nbftCores, key)
 	}
@@ -335,18 +347,18 @@ func (nbft *Nbft) time(key string) int64 {
 	str := strings.Replace(key, nbft.options.Chain+":", "", 1)
 	t, err := time.Parse(FORMAT, str)
 	if err != nil {
-		log.Panic(err)
+		logger.Panic(err)
 	}
 	return t.UnixNano()
 }
 
 func (nbft *Nbft) recvFetchCommitted(fetchCommitted *FetchCommitted) {
-	log.Debugf("Replica %s received fetchCommitted from %s for consensus %s", nbft.options.ID, fetchCommitted.ReplicaID, fetchCommitted.Key)
+	logger.Debugf("Replica %s received fetchCommitted from %s for consensus %s", nbft.options.ID, fetchCommitted.ReplicaID, fetchCommitted.Key)
 
 	if committedReqs, ok := nbft.executedCommittedReqs[fetchCommitted.Key]; ok {
 		nbft.broadcastChan <- &Broadcast{
 			to:      fetchCommitted.ReplicaID,
-			payload: &NbftMessage{Payload: &NbftMessage_ReturnCommitted{ReturnCommitted: &ReturnCommitted{ReplicaID: nbft.options.ID, Committed: committedReqs}}},
+			payload: signed(nbft.options, &NbftMessage_ReturnCommitted{ReturnCommitted: &ReturnCommitted{ReplicaID: nbft.options.ID, Committed: committedReqs}}),
 		}
 		return
 	}
@@ -354,14 +366,14 @@ func (nbft *Nbft) recvFetchCommitted(fetchCommitted *FetchCommitted) {
 	if committedReqs, ok := nbft.unexecuteCommittedReqs[fetchCommitted.Key]; ok {
 		nbft.broadcastChan <- &Broadcast{
 			to:      fetchCommitted.ReplicaID,
-			payload: &NbftMessage{Payload: &NbftMessage_ReturnCommitted{ReturnCommitted: &ReturnCommitted{ReplicaID: nbft.options.ID, Committed: committedReqs}}},
+			payload: signed(nbft.options, &NbftMessage_ReturnCommitted{ReturnCommitted: &ReturnCommitted{ReplicaID: nbft.options.ID, Committed: committedReqs}}),
 		}
 		return
 	}
 }
 
 func (nbft *Nbft) recvReturnCommitted(returnCommitted *ReturnCommitted) {
-	log.Debugf("Replica %s received returnCommitted from %s for consensus %s", nbft.options.ID, returnCommitted.ReplicaID, returnCommitted.Committed.Key)
+	logger.Debugf("Replica %s received returnCommitted from %s for consensus %s", nbft.options.ID, returnCommitted.ReplicaID, returnCommitted.Committed.Key)
 	t := time.Unix(0, nbft.time(returnCommitted.Committed.Key))
 	if !t.After(nbft.lastExecCommittedReqs) {
 		return
@@ -377,7 +389,7 @@ func (nbft *Nbft) recvReturnCommitted(returnCommitted *ReturnCommitted) {
 		vote.Add(returnCommitted.ReplicaID, returnCommitted.Committed)
 		returnCommittedList = append(returnCommittedList, returnCommitted)
 		n, ticket := vote.Voter()
-		log.Debugf("Replica %s received returnCommitted from %s for consensus %s, vote %d >= %d", nbft.options.ID, returnCommitted.ReplicaID, returnCommitted.Committed.Key, n, nbft.options.Q)
+		logger.Debugf("Replica %s received returnCommitted from %s for consensus %s, vote %d >= %d", nbft.options.ID, returnCommitted.ReplicaID, returnCommitted.Committed.Key, n, nbft.options.Q)
 		if n < nbft.options.Q {
 			return
 		}
@@ -399,7 +411,7 @@ func (nbft *Nbft) addUnexecuteCommittedReqs(committed *Committed) {
 }
 
 func (nbft *Nbft) addExecutedCommittdReqs(committed *Committed) {
-	log.Debugf("Replica %s write block for consensus %s", nbft.options.ID, committed.Key)
+	logger.Debugf("Replica %s write block for consensus %s", nbft.options.ID, committed.Key)
 	nbft.committedTxsChan <- nbft.toCommittedTxs(committed)
 	nbft.executedCommittedReqs[committed.Key] = committed
 	delete(nbft.unexecuteCommittedReqs, committed.Key)
@@ -419,7 +431,7 @@ func (nbft *Nbft) executeCommittedReqs() {
 			} else {
 				nbft.broadcastChan <- &Broadcast{
 					to:      nbft.options.Chain,
-					payload: &NbftMessage{Payload: &NbftMessage_FetchCommitted{FetchCommitted: &FetchCommitted{ReplicaID: nbft.options.ID, Key: committed.Key}}},
+					payload: signed(nbft.options, &NbftMessage_FetchCommitted{FetchCommitted: &FetchCommitted{ReplicaID: nbft.options.ID, Key: committed.Key}}),
 				}
 			}
 			break
@@ -434,3 +446,20 @@ func (nbft *Nbft) executeCommittedReqs() {
 		}
 	}
 }
+
+// State returns the snapshot of nbft state
+func (nbft *Nbft) State() *consensus.State {
+	nbft.RLock()
+	instances := len(nbft.nbftCores)
+	nbft.RUnlock()
+
+	return &consensus.State{
+		Plugin:    "nbft",
+		ID:        nbft.options.ID,
+		Running:   nbft.IsRunning(),
+		SeqNo:     nbft.stack.GetLastSeqNo(),
+		ExecSeqNo: nbft.stack.GetLastSeqNo(),
+		LastSeqNo: nbft.stack.GetLastSeqNo(),
+		Instances: instances,
+	}
+}
@@ -22,6 +22,9 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/utils"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/consensus/helper"
 )
 
@@ -29,3 +32,44 @@ func TestNbft(t *testing.T) {
 	nbft := NewNbft(NewDefaultOptions(), helper.NewStack())
 	fmt.Println(nbft)
 }
+
+type testSigner struct {
+	*crypto.PrivateKey
+}
+
+func (s testSigner) SignHash(hash []byte) (*crypto.Signature, error) {
+	return s.Sign(hash)
+}
+
+func TestVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	options := NewDefaultOptions()
+	options.Signer = testSigner{key}
+	options.Replicas = consensus.Replicas{"r0": utils.BytesToHex(key.Public().Bytes())}
+	nbft := NewNbft(options, helper.NewStack())
+	prepare := func(chain, replicaID string) *NbftMessage {
+		return signed(options, &NbftMessage_Prepare{Prepare: &Prepare{Name: "n", Chain: chain, ReplicaID: replicaID, Digest: "d"}})
+	}
+
+	if err := nbft.verify(prepare(options.Chain, "r0")); err != nil {
+		t.Fatalf("signed vote rejected, %v", err)
+	}
+	// the votes of another chain are verified against its replica set
+	if err := nbft.verify(prepare("1", "f0")); err == nil {
+		t.Fatal("vote of a chain without replica set accepted")
+	}
+	options.ChainReplicas = map[string]consensus.Replicas{"1": {"f0": options.Replicas["r0"]}}
+	if err := nbft.verify(prepare("1", "f0")); err != nil {
+		t.Fatalf("vote of another chain rejected, %v", err)
+	}
+	if err := nbft.verify(prepare("1", "f1")); err == nil {
+		t.Fatal("vote from outside the replica set of another chain accepted")
+	}
+	// votes without a sender are not exempt from the signature check
+	if err := nbft.verify(&NbftMessage{Payload: &NbftMessage_Prepare{Prepare: &Prepare{Name: "n", Chain: "1", Digest: "d"}}}); err == nil {
+		t.Fatal("unsigned vote of another chain without replica id accepted")
+	}
+	if err := nbft.verify(prepare(options.Chain, "")); err == nil {
+		t.Fatal("vote without replica id accepted")
+	}
+}
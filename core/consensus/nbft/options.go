@@ -18,7 +18,11 @@
 
 package nbft
 
-import "time"
+import (
+	"time"
+
+	"github.com/bocheninc/L0/core/consensus"
+)
 
 // NewDefaultOptions Create nbft options with default value
 func NewDefaultOptions() *Options {
@@ -50,4 +54,7 @@ type Options struct {
 	CommitTxChanSize     int
 	CommittedTxsChanSize int
 	BroadcastChanSize    int
+	Replicas             consensus.Replicas            // replica id to hex public key, empty accepts unsigned messages
+	ChainReplicas        map[string]consensus.Replicas // replica sets of the other chains, verifying their cross-chain votes
+	Signer               consensus.ISigner             `json:"-"` // replica key signing consensus messages, nil sends them unsigned
 }
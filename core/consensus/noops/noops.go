@@ -19,6 +19,8 @@
 package noops
 
 import (
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"encoding/json"
@@ -27,6 +29,8 @@ import (
 	"github.com/bocheninc/L0/core/consensus"
 )
 
+var logger = log.Module(log.ModuleConsensus)
+
 // NewNoops Create Noops
 func NewNoops(options *Options, stack consensus.IStack) *Noops {
 	noops := &Noops{
@@ -52,6 +56,7 @@ type Noops struct {
 	broadcastChan    chan consensus.IBroadcast
 	blockTimer       *time.Timer
 	seqNo            uint64
+	sync.Mutex       // guards exit
 	exit             chan struct{}
 }
 
@@ -62,20 +67,25 @@ func (noops *Noops) String() string {
 
 // IsRunning Noops consenter serverice already started
 func (noops *Noops) IsRunning() bool {
+	noops.Lock()
+	defer noops.Unlock()
 	return noops.exit != nil
 }
 
 // Start Start consenter serverice of Noops
 func (noops *Noops) Start() {
-	if noops.IsRunning() {
+	noops.Lock()
+	if noops.exit != nil {
+		noops.Unlock()
 		return
 	}
-	noops.exit = make(chan struct{})
+	exit := make(chan struct{})
+	noops.exit = exit
+	noops.Unlock()
 	noops.blockTimer = time.NewTimer(noops.options.BlockInterval)
 	for {
 		select {
-		case <-noops.exit:
-			noops.exit = nil
+		case <-exit:
 			return
 		case <-noops.blockTimer.C:
 			noops.processBlock()
@@ -95,9 +105,9 @@ func (noops *Noops) processBlock() {
 			return false
 		})
 		txs = noops.stack.VerifyTxsInConsensus(txs, true)
-		noops.seqNo++
-		log.Infof("Noops write block (%d transactions)  %d", len(txs), noops.seqNo)
-		seqNos := []uint64{noops.seqNo}
+		seqNo := atomic.AddUint64(&noops.seqNo, 1)
+		logger.Infof("Noops write block (%d transactions)  %d", len(txs), seqNo)
+		seqNos := []uint64{seqNo}
 		noops.committedTxsChan <- &consensus.CommittedTxs{Time: uint32(time.Now().Unix()), Transactions: txs, SeqNos: seqNos}
 		noops.stack.Removes(txs)
 	}
@@ -106,8 +116,11 @@ func (noops *Noops) processBlock() {
 
 // Stop Stop consenter serverice of Noops
 func (noops *Noops) Stop() {
-	if noops.IsRunning() {
+	noops.Lock()
+	defer noops.Unlock()
+	if noops.exit != nil {
 		close(noops.exit)
+		noops.exit = nil
 	}
 }
 
@@ -130,3 +143,16 @@ func (noops *Noops) BroadcastConsensusChannel() <-chan consensus.IBroadcast {
 func (noops *Noops) CommittedTxsChannel() <-chan *consensus.CommittedTxs {
 	return noops.committedTxsChan
 }
+
+// State returns the snapshot of noops state
+func (noops *Noops) State() *consensus.State {
+	seqNo := atomic.LoadUint64(&noops.seqNo)
+	return &consensus.State{
+		Plugin:    "noops",
+		Running:   noops.IsRunning(),
+		IsPrimary: true,
+		SeqNo:     seqNo,
+		ExecSeqNo: seqNo,
+		LastSeqNo: seqNo,
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Replica set change operations
+const (
+	ReplicaAdd    = "add"
+	ReplicaRemove = "remove"
+)
+
+// MinReplicas the least replicas of a bft replica set
+const MinReplicas = 4
+
+// ReplicaChange Governance change of the replica set, Address is the p2p
+// address the nodes connect an added replica at
+type ReplicaChange struct {
+	Op        string `json:"op"`
+	ID        string `json:"id"`
+	PublicKey string `json:"publicKey,omitempty"`
+	Address   string `json:"address,omitempty"`
+}
+
+// Validate checks the change regardless of the replica set
+func (change *ReplicaChange) Validate() error {
+	if change.ID == "" {
+		return fmt.Errorf("replica id is required")
+	}
+	switch change.Op {
+	case ReplicaAdd:
+		if b, err := hex.DecodeString(change.PublicKey); err != nil || len(b) != 65 {
+			return fmt.Errorf("invalid public key %q of replica %s, want the hex node id", change.PublicKey, change.ID)
+		}
+		if _, _, err := net.SplitHostPort(change.Address); change.Address != "" && err != nil {
+			return fmt.Errorf("invalid address %q of replica %s, want host:port", change.Address, change.ID)
+		}
+	case ReplicaRemove:
+	default:
+		return fmt.Errorf("unknown replica change %q, want %s or %s", change.Op, ReplicaAdd, ReplicaRemove)
+	}
+	return nil
+}
+
+// Replicas Replica set, replica id to hex public key
+type Replicas map[string]string
+
+// Apply returns a copy of the replica set with the change applied
+func (rs Replicas) Apply(change *ReplicaChange) (Replicas, error) {
+	if err := change.Validate(); err != nil {
+		return nil, err
+	}
+	_, ok := rs[change.ID]
+	switch {
+	case len(rs) == 0:
+		return nil, fmt.Errorf("no replica set is configured")
+	case change.Op == ReplicaAdd && ok:
+		return nil, fmt.Errorf("replica %s already exists", change.ID)
+	case change.Op == ReplicaRemove && !ok:
+		return nil, fmt.Errorf("replica %s doesn't exist", change.ID)
+	case change.Op == ReplicaRemove && len(rs) <= MinReplicas:
+		return nil, fmt.Errorf("replica %s can't be removed, at least %d replicas are required", change.ID, MinReplicas)
+	}
+
+	next := make(Replicas, len(rs)+1)
+	for id, key := range rs {
+		next[id] = key
+	}
+	if change.Op == ReplicaAdd {
+		next[change.ID] = change.PublicKey
+	} else {
+		delete(next, change.ID)
+	}
+	return next, nil
+}
+
+// IDs returns the sorted replica ids
+func (rs Replicas) IDs() []string {
+	ids := make([]string, 0, len(rs))
+	for id := range rs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ScheduledChange is a replica set change taking effect once SeqNo is
+// executed
+type ScheduledChange struct {
+	SeqNo  uint64         `json:"seqNo"`
+	Change *ReplicaChange `json:"change"`
+}
+
+// ReplicaSet is the replica set in effect and the changes scheduled on it.
+// A change executed at seqNo takes effect Delay seqNos later, which keeps
+// the quorum of the instances in flight.
+type ReplicaSet struct {
+	Replicas Replicas           `json:"replicas"`
+	Changes  []*ScheduledChange `json:"changes,omitempty"`
+	Delay    uint64             `json:"delay"`
+	// Addresses are the p2p addresses of the added replicas
+	Addresses map[string]string `json:"addresses,omitempty"`
+}
+
+// NewReplicaSet returns the genesis replica set
+func NewReplicaSet(replicas Replicas, delay uint64) *ReplicaSet {
+	return &ReplicaSet{Replicas: replicas, Delay: delay}
+}
+
+// Schedule validates the change executed at seqNo against the set with
+// every scheduled change applied and schedules it, the changes executed
+// out of the consensus at seqNo 0 take effect at once
+func (set *ReplicaSet) Schedule(change *ReplicaChange, seqNo uint64) error {
+	if _, err := set.Latest().Apply(change); err != nil {
+		return err
+	}
+	effective := seqNo
+	if seqNo > 0 {
+		effective += set.Delay
+	}
+	set.Changes = append(set.Changes, &ScheduledChange{SeqNo: effective, Change: change})
+	if change.Op == ReplicaAdd && change.Address != "" {
+		addresses := make(map[string]string, len(set.Addresses)+1)
+		for id, address := range set.Addresses {
+			addresses[id] = address
+		}
+		addresses[change.ID] = change.Address
+		set.Addresses = addresses
+	}
+	set.Settle(seqNo)
+	return nil
+}
+
+// Settle applies the changes in effect once seqNo is executed to Replicas
+func (set *ReplicaSet) Settle(seqNo uint64) {
+	set.Replicas = set.At(seqNo)
+	var pending []*ScheduledChange
+	for _, scheduled := range set.Changes {
+		if scheduled.SeqNo > seqNo {
+			pending = append(pending, scheduled)
+		}
+	}
+	set.Changes = pending
+}
+
+// At returns the replica set in effect once seqNo is executed
+func (set *ReplicaSet) At(seqNo uint64) Replicas {
+	replicas := set.Replicas
+	for _, scheduled := range set.Changes {
+		if scheduled.SeqNo > seqNo {
+			continue
+		}
+		// the changes are validated when scheduled
+		if next, err := replicas.Apply(scheduled.Change); err == nil {
+			replicas = next
+		}
+	}
+	return replicas
+}
+
+// Latest returns the replica set with every scheduled change applied
+func (set *ReplicaSet) Latest() Replicas {
+	return set.At(^uint64(0))
+}
+
+// IReplicaStack is implemented by the stacks executing the governance txs
+type IReplicaStack interface {
+	// ReplicaSet returns the replica set written up to the last seqNo
+	ReplicaSet() (*ReplicaSet, error)
+}
+
+// IReplicaNotifier is implemented by the consenters changing the replica set
+type IReplicaNotifier interface {
+	// ReplicaChangeChannel delivers the changes once they take effect, the
+	// public key of a removed replica is set
+	ReplicaChangeChannel() <-chan *ReplicaChange
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package consensus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplicasApply(t *testing.T) {
+	key := "04" + strings.Repeat("ab", 64)
+	rs := Replicas{"r1": key, "r2": key, "r3": key, "r4": key}
+
+	added, err := rs.Apply(&ReplicaChange{Op: ReplicaAdd, ID: "r5", PublicKey: key})
+	if err != nil || len(added) != 5 || len(rs) != 4 {
+		t.Fatalf("add: %v %v, the applied set must be a copy", added, err)
+	}
+	removed, err := added.Apply(&ReplicaChange{Op: ReplicaRemove, ID: "r1"})
+	if err != nil || strings.Join(removed.IDs(), ",") != "r2,r3,r4,r5" {
+		t.Fatalf("remove: %v %v", removed.IDs(), err)
+	}
+
+	for _, c := range []struct {
+		rs     Replicas
+		change *ReplicaChange
+	}{
+		{rs, &ReplicaChange{Op: ReplicaAdd, ID: "r1", PublicKey: key}},
+		{rs, &ReplicaChange{Op: ReplicaAdd, ID: "r5", PublicKey: "04ab"}},
+		{rs, &ReplicaChange{Op: ReplicaRemove, ID: "r5"}},
+		{rs, &ReplicaChange{Op: ReplicaRemove, ID: "r1"}},
+		{rs, &ReplicaChange{Op: "rename", ID: "r1"}},
+		{nil, &ReplicaChange{Op: ReplicaAdd, ID: "r1", PublicKey: key}},
+	} {
+		if _, err := c.rs.Apply(c.change); err == nil {
+			t.Errorf("%v applied to %v", c.change, c.rs.IDs())
+		}
+	}
+}
+
+func TestReplicaSet(t *testing.T) {
+	key := "04" + strings.Repeat("ab", 64)
+	set := NewReplicaSet(Replicas{"r1": key, "r2": key, "r3": key, "r4": key}, 10)
+
+	if err := set.Schedule(&ReplicaChange{Op: ReplicaAdd, ID: "r5", PublicKey: key, Address: "127.0.0.1:20170"}, 5); err != nil {
+		t.Fatal(err)
+	}
+	// validated against the set with the scheduled changes applied
+	if err := set.Schedule(&ReplicaChange{Op: ReplicaAdd, ID: "r5", PublicKey: key}, 6); err == nil {
+		t.Fatal("r5 scheduled twice")
+	}
+	if err := set.Schedule(&ReplicaChange{Op: ReplicaRemove, ID: "r1"}, 8); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.At(14)) != 4 || len(set.At(15)) != 5 || strings.Join(set.At(18).IDs(), ",") != "r2,r3,r4,r5" {
+		t.Fatalf("at 14 %v, at 15 %v, at 18 %v", set.At(14).IDs(), set.At(15).IDs(), set.At(18).IDs())
+	}
+
+	set.Settle(15)
+	if len(set.Replicas) != 5 || len(set.Changes) != 1 || set.Changes[0].SeqNo != 18 {
+		t.Fatalf("settled %v, %d changes", set.Replicas.IDs(), len(set.Changes))
+	}
+	// executed out of the consensus
+	if err := set.Schedule(&ReplicaChange{Op: ReplicaAdd, ID: "r6", PublicKey: key}, 0); err != nil || set.Replicas["r6"] == "" {
+		t.Fatalf("r6 isn't in effect at once, %v", err)
+	}
+
+	if err := (&ReplicaChange{Op: ReplicaAdd, ID: "r7", PublicKey: key, Address: "20170"}).Validate(); err == nil {
+		t.Fatal("address without host validated")
+	}
+}
@@ -19,6 +19,7 @@
 package block_storage
 
 import (
+	"encoding/binary"
 	"errors"
 
 	"github.com/bocheninc/L0/components/db"
@@ -33,6 +34,14 @@ const (
 	acrossChain
 )
 
+// seqNoKey is the consensus seqNo of the last committed batch written,
+// blockSeqNoPrefix prefixes the height of a block to the seqNo of its
+// last batch
+const (
+	seqNoKey         = "consensusLastSeqNo"
+	blockSeqNoPrefix = "consensusSeqNo"
+)
+
 // Blockchain represents block
 type Blockchain struct {
 	dbHandler         *db.BlockchainDB
@@ -128,6 +137,46 @@ func (blockchain *Blockchain) GetBlockchainHeight() (uint32, error) {
 	return height, nil
 }
 
+// SetLastSeqNo returns the write batch recording the consensus seqNo of the
+// last committed batch written
+func (blockchain *Blockchain) SetLastSeqNo(seqNo uint64) *db.WriteBatch {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, seqNo)
+	return db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, []byte(seqNoKey), value)
+}
+
+// GetLastSeqNo returns the consensus seqNo of the last committed batch
+// written, 0 if none is recorded
+func (blockchain *Blockchain) GetLastSeqNo() uint64 {
+	value, _ := blockchain.dbHandler.Get(blockchain.indexColumnFamily, []byte(seqNoKey))
+	if len(value) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+// SetBlockSeqNo returns the write batch recording the consensus seqNo of
+// the last batch of the block at height
+func (blockchain *Blockchain) SetBlockSeqNo(height uint32, seqNo uint64) *db.WriteBatch {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, seqNo)
+	return db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, blockSeqNoKey(height), value)
+}
+
+// GetBlockSeqNo returns the consensus seqNo of the last batch of the block
+// at height, 0 if the block wasn't written by the consensus
+func (blockchain *Blockchain) GetBlockSeqNo(height uint32) uint64 {
+	value, _ := blockchain.dbHandler.Get(blockchain.indexColumnFamily, blockSeqNoKey(height))
+	if len(value) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+func blockSeqNoKey(height uint32) []byte {
+	return append([]byte(blockSeqNoPrefix), utils.Uint32ToBytes(height)...)
+}
+
 // AppendBlock appends a block
 func (blockchain *Blockchain) AppendBlock(block *types.Block) []*db.WriteBatch {
 	blockHashBytes := block.Hash().Bytes()
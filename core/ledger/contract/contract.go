@@ -28,6 +28,8 @@ import (
 	"github.com/bocheninc/L0/core/types"
 )
 
+var logger = log.Module(log.ModuleLedger)
+
 var DeployAddr = []byte("00000000000000000000")
 
 type ILedgerSmartContract interface {
@@ -74,18 +76,18 @@ func NewSmartConstract(db *db.BlockchainDB, ledgerHandler ILedgerSmartContract)
 
 // StartConstract start constract
 func (sctx *SmartConstract) StartConstract(blockHeight uint32) {
-	log.Debugf("startConstract() for blockHeight [%d]", blockHeight)
+	logger.Debugf("startConstract() for blockHeight [%d]", blockHeight)
 	if !sctx.InProgress() {
-		log.Errorf("A tx [%d] is already in progress. Received call for begin of another smartcontract [%d]", sctx.height, blockHeight)
+		logger.Errorf("A tx [%d] is already in progress. Received call for begin of another smartcontract [%d]", sctx.height, blockHeight)
 	}
 	sctx.height = blockHeight
 }
 
 // StopContract start contract
 func (sctx *SmartConstract) StopContract(blockHeight uint32) {
-	log.Debugf("stopConstract() for blockHeight [%d]", blockHeight)
+	logger.Debugf("stopConstract() for blockHeight [%d]", blockHeight)
 	if sctx.height != blockHeight {
-		log.Errorf("Different blockHeight in contract-begin [%s] and contract-finish [%s]", sctx.height, blockHeight)
+		logger.Errorf("Different blockHeight in contract-begin [%d] and contract-finish [%d]", sctx.height, blockHeight)
 	}
 
 	sctx.height = 0
@@ -103,7 +105,7 @@ func (sctx *SmartConstract) ExecTransaction(tx *types.Transaction, scAddr string
 // GetState get value
 func (sctx *SmartConstract) GetState(key string) ([]byte, error) {
 	if !sctx.InProgress() {
-		log.Errorf("State can be changed only in context of a block.")
+		logger.Errorf("State can be changed only in context of a block.")
 	}
 
 	value := sctx.stateExtra.get(sctx.scAddr, key)
@@ -121,9 +123,9 @@ func (sctx *SmartConstract) GetState(key string) ([]byte, error) {
 
 // AddState put key-value into cache
 func (sctx *SmartConstract) AddState(key string, value []byte) {
-	log.Debugf("PutState smartcontract=[%s], key=[%s], value=[%#v]", sctx.scAddr, key, value)
+	logger.Debugf("PutState smartcontract=[%s], key=[%s], value=[%#v]", sctx.scAddr, key, value)
 	if !sctx.InProgress() {
-		log.Errorf("State can be changed only in context of a block.")
+		logger.Errorf("State can be changed only in context of a block.")
 	}
 
 	sctx.stateExtra.set(sctx.scAddr, key, value)
@@ -132,7 +134,7 @@ func (sctx *SmartConstract) AddState(key string, value []byte) {
 // DelState remove key-value
 func (sctx *SmartConstract) DelState(key string) {
 	if !sctx.InProgress() {
-		log.Errorf("State can be changed only in context of a block.")
+		logger.Errorf("State can be changed only in context of a block.")
 	}
 
 	sctx.stateExtra.delete(sctx.scAddr, key)
@@ -147,7 +149,7 @@ func (sctx *SmartConstract) GetBalances(addr string) (*big.Int, error) {
 func (sctx *SmartConstract) CurrentBlockHeight() uint32 {
 	height, err := sctx.ledgerHandler.Height()
 	if err == nil {
-		log.Errorf("can't read blockchain height")
+		logger.Errorf("can't read blockchain height")
 	}
 
 	return height
@@ -156,7 +158,7 @@ func (sctx *SmartConstract) CurrentBlockHeight() uint32 {
 // SmartContractFailed execute smartContract fail
 func (sctx *SmartConstract) SmartContractFailed() {
 	sctx.committed = false
-	log.Errorf("VM can't put state into L0")
+	logger.Errorf("VM can't put state into L0")
 }
 
 // SmartContractCommitted execute smartContract successfully
@@ -194,13 +196,13 @@ func (sctx *SmartConstract) AddChangesForPersistence(writeBatch []*db.WriteBatch
 		updates := smartContract.getUpdatedKVs()
 		for _, value := range updates {
 			if value.optype == db.OperationDelete {
-				log.Debugf("Contract Del: %s", value.key)
+				logger.Debugf("Contract Del: %s", value.key)
 				writeBatch = append(writeBatch, db.NewWriteBatch(sctx.columnFamily, db.OperationDelete, []byte(value.key), value.value))
 			} else if value.optype == db.OperationPut {
-				log.Debugf("Contract Put: %s", value.key)
+				logger.Debugf("Contract Put: %s", value.key)
 				writeBatch = append(writeBatch, db.NewWriteBatch(sctx.columnFamily, db.OperationPut, []byte(value.key), value.value))
 			} else {
-				log.Errorf("invalid method ...")
+				logger.Errorf("invalid method ...")
 			}
 		}
 	}
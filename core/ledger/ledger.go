@@ -33,6 +33,7 @@ import (
 	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/coordinate"
 	"github.com/bocheninc/L0/core/ledger/block_storage"
 	"github.com/bocheninc/L0/core/ledger/contract"
@@ -43,16 +44,22 @@ import (
 	"github.com/bocheninc/L0/vm"
 )
 
+var logger = log.Module(log.ModuleLedger)
+
 var (
 	ledgerInstance *Ledger
 )
 
 // Ledger represents the ledger in blockchain
 type Ledger struct {
-	block    *block_storage.Blockchain
-	state    *state.State
-	storage  *merge.Storage
-	contract *contract.SmartConstract
+	dbHandler *db.BlockchainDB
+	block     *block_storage.Blockchain
+	state     *state.State
+	storage   *merge.Storage
+	contract  *contract.SmartConstract
+	// execSeqNo is the consensus seqNo of the block executed, 0 for the
+	// blocks written out of the consensus
+	execSeqNo uint64
 
 	sync.Mutex
 	atmoicTxsStatistics     int
@@ -64,25 +71,39 @@ type Ledger struct {
 // NewLedger returns the ledger instance
 func NewLedger(db *db.BlockchainDB) *Ledger {
 	if ledgerInstance == nil {
-		ledgerInstance = &Ledger{
-			block:                   block_storage.NewBlockchain(db),
-			state:                   state.NewState(db),
-			storage:                 merge.NewStorage(db),
-			atmoicTxsStatistics:     0,
-			acrossTxsStatistics:     make(map[string]int),
-			blockAtmoicTxStatistics: 0,
-			blockAcrossTxStatistics: make(map[string]int),
-		}
-		_, err := ledgerInstance.Height()
-		if err != nil {
-			ledgerInstance.init()
-		}
+		ledgerInstance = newLedger(db)
+		height, _ := ledgerInstance.Height()
+		heightGauge.Set(float64(height))
 	}
 
 	ledgerInstance.contract = contract.NewSmartConstract(db, ledgerInstance)
 	return ledgerInstance
 }
 
+// Open returns a ledger on db which, unlike NewLedger, is not shared
+func Open(db *db.BlockchainDB) *Ledger {
+	return newLedger(db)
+}
+
+// newLedger returns a ledger on db, generating the genesis block if needed
+func newLedger(db *db.BlockchainDB) *Ledger {
+	ledger := &Ledger{
+		dbHandler:               db,
+		block:                   block_storage.NewBlockchain(db),
+		state:                   state.NewState(db),
+		storage:                 merge.NewStorage(db),
+		atmoicTxsStatistics:     0,
+		acrossTxsStatistics:     make(map[string]int),
+		blockAtmoicTxStatistics: 0,
+		blockAcrossTxStatistics: make(map[string]int),
+	}
+	if _, err := ledger.Height(); err != nil {
+		ledger.init()
+	}
+	ledger.contract = contract.NewSmartConstract(db, ledger)
+	return ledger
+}
+
 // VerifyChain verifys the blockchain data
 func (ledger *Ledger) VerifyChain() {
 	height, err := ledger.Height()
@@ -94,7 +115,7 @@ func (ledger *Ledger) VerifyChain() {
 	for i := height; i >= 1; i-- {
 		previousBlock, err := ledger.GetBlockByNumber(i - 1) // storage
 		if previousBlock != nil && err != nil {
-			log.Debug("get block err")
+			logger.Debug("get block err")
 			panic(err)
 		}
 
@@ -118,25 +139,83 @@ func (ledger *Ledger) GetGenesisBlock() *types.Block {
 
 // AppendBlock appends a new block to the ledger,flag = true pack up block ,flag = false sync block
 func (ledger *Ledger) AppendBlock(block *types.Block, flag bool) error {
+	return ledger.appendBlock(block, flag, nil, 0)
+}
+
+// AppendCommittedBlock appends the block of the batches committed by the
+// consensus up to seqNo, seqNo is written with the block
+func (ledger *Ledger) AppendCommittedBlock(block *types.Block, seqNo uint64) error {
+	return ledger.appendBlock(block, true, nil, seqNo, ledger.block.SetLastSeqNo(seqNo), ledger.block.SetBlockSeqNo(block.Height(), seqNo))
+}
+
+// ImportCommittedBlock appends a committed block fetched from the other
+// replicas like ImportBlock, seqNo is written with the block
+func (ledger *Ledger) ImportCommittedBlock(block *types.Block, seqNo uint64) error {
+	merkle := block.Header.TxsMerkleHash
+	block.Transactions = OriginalTxs(block.Transactions)
+	return ledger.appendBlock(block, true, &merkle, seqNo, ledger.block.SetLastSeqNo(seqNo), ledger.block.SetBlockSeqNo(block.Height(), seqNo))
+}
+
+// BlockSeqNo returns the consensus seqNo of the last batch of the block at
+// height, 0 if the block wasn't written by the consensus
+func (ledger *Ledger) BlockSeqNo(height uint32) uint64 {
+	return ledger.block.GetBlockSeqNo(height)
+}
+
+// SetLastSeqNo records the seqNo of committed batches without txs
+func (ledger *Ledger) SetLastSeqNo(seqNo uint64) error {
+	return ledger.dbHandler.AtomicWrite([]*db.WriteBatch{ledger.block.SetLastSeqNo(seqNo)})
+}
+
+// LastSeqNo returns the consensus seqNo of the last committed batch written
+func (ledger *Ledger) LastSeqNo() uint64 {
+	return ledger.block.GetLastSeqNo()
+}
+
+// ImportBlock appends a block exported from another ledger. It re-executes
+// the original txs of the block and writes nothing unless the executed txs
+// have the merkle hash of the block header.
+func (ledger *Ledger) ImportBlock(block *types.Block) error {
+	merkle := block.Header.TxsMerkleHash
+	block.Transactions = OriginalTxs(block.Transactions)
+	return ledger.appendBlock(block, true, &merkle, 0)
+}
+
+func (ledger *Ledger) appendBlock(block *types.Block, flag bool, merkle *crypto.Hash, seqNo uint64, extra ...*db.WriteBatch) error {
 	var err error
 	var txWriteBatchs []*db.WriteBatch
+	start := time.Now()
+	txs := block.Transactions
 
+	ledger.execSeqNo = seqNo
 	txWriteBatchs, block.Transactions, err = ledger.executeTransaction(block.Transactions)
 	if err != nil {
+		traceAppendFailed(txs, err)
 		return err
 	}
 
 	block.Header.TxsMerkleHash = merkleRootHash(block.Transactions)
+	if merkle != nil && !block.Header.TxsMerkleHash.Equal(*merkle) {
+		err = fmt.Errorf("executed txs have merkle hash %s, block has %s", block.Header.TxsMerkleHash, *merkle)
+		ledger.state.Discard()
+		traceAppendFailed(txs, err)
+		return err
+	}
 	writeBatchs := ledger.block.AppendBlock(block)
 
 	writeBatchs = append(writeBatchs, txWriteBatchs...)
+	writeBatchs = append(writeBatchs, extra...)
 
 	if err := ledger.state.AtomicWrite(writeBatchs); err != nil {
+		traceAppendFailed(txs, err)
 		return nil
 	}
+	commitLatency.ObserveSince(start)
+	observeBlock(block)
+	traceAppendBlock(txs, block)
 
 	if flag {
-		var txs types.Transactions
+		txs = nil
 		for _, tx := range block.Transactions {
 			if (tx.GetType() == types.TypeMerged && !ledger.checkCoordinate(tx)) || tx.GetType() == types.TypeAcrossChain {
 				txs = append(txs, tx)
@@ -145,7 +224,7 @@ func (ledger *Ledger) AppendBlock(block *types.Block, flag bool) error {
 		if err := ledger.storage.ClassifiedTransaction(txs); err != nil {
 			return err
 		}
-		log.Infoln("blockHeight: ", block.Height(), "need merge Txs len : ", len(txs), "all Txs len: ", len(block.Transactions))
+		logger.Infoln("blockHeight: ", block.Height(), "need merge Txs len : ", len(txs), "all Txs len: ", len(block.Transactions))
 	}
 
 	return nil
@@ -216,7 +295,7 @@ func (ledger *Ledger) GetMergedTransaction(duration uint32) (types.Transactions,
 		return nil, err
 	}
 	delay1 := time.Since(t1)
-	log.Debug("getMerge delay :", delay1)
+	logger.Debug("getMerge delay :", delay1)
 	return txs, nil
 }
 
@@ -225,6 +304,21 @@ func (ledger *Ledger) PutTxsHashByMergeTxHash(mergeTxHash crypto.Hash, txsHashs
 	return ledger.storage.PutTxsHashByMergeTxHash(mergeTxHash, txsHashs)
 }
 
+// PutMergeBackupTxs saves the merged transactions not yet acked by the parent chain
+func (ledger *Ledger) PutMergeBackupTxs(txs types.Transactions) error {
+	return ledger.storage.PutBackupTxs(txs)
+}
+
+// GetMergeBackupTxs returns the merged transactions saved by PutMergeBackupTxs
+func (ledger *Ledger) GetMergeBackupTxs() (types.Transactions, error) {
+	return ledger.storage.GetBackupTxs()
+}
+
+// GetTxsHashByMergeTxHash gets the hashes of the transactions merged into mergeTxHash
+func (ledger *Ledger) GetTxsHashByMergeTxHash(mergeTxHash crypto.Hash) ([]crypto.Hash, error) {
+	return ledger.storage.GetTxsByMergeTxHash(mergeTxHash)
+}
+
 //GetTxsByMergeTxHash gets transactions
 func (ledger *Ledger) GetTxsByMergeTxHash(mergeTxHash crypto.Hash) (types.Transactions, error) {
 	txsHashs, err := ledger.storage.GetTxsByMergeTxHash(mergeTxHash)
@@ -290,6 +384,10 @@ func (ledger *Ledger) commitedTranaction(tx *types.Transaction, writeBatchs []*d
 		if writeBatchs, err = ledger.executeDistriTx(writeBatchs, tx); err != nil {
 			return nil, err
 		}
+	case types.TypeGovernance:
+		if writeBatchs, err = ledger.executeGovernanceTx(writeBatchs, tx); err != nil {
+			return nil, err
+		}
 	}
 
 	return writeBatchs, err
@@ -339,6 +437,46 @@ func (ledger *Ledger) executeIssueTx(writeBatchs []*db.WriteBatch, tx *types.Tra
 	return writeBatchs, nil
 }
 
+// executeGovernanceTx schedules the replica set change at the seqNo of the
+// block, a change which can't be applied to the set is skipped
+func (ledger *Ledger) executeGovernanceTx(writeBatchs []*db.WriteBatch, tx *types.Transaction) ([]*db.WriteBatch, error) {
+	sender := tx.Sender()
+	// governance txs are sent by issue accounts, exempt from the balance check
+	atomicTxWriteBatchs, err := ledger.state.Transfer(sender, sender, tx.Fee(), state.NewBalance(big.NewInt(0), tx.Nonce()), types.TypeIssue)
+	if err != nil {
+		return writeBatchs, err
+	}
+	writeBatchs = append(writeBatchs, atomicTxWriteBatchs...)
+
+	change, err := tx.ReplicaChange()
+	if err != nil {
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Warnf("skip replica change of tx %s, %v", tx.Hash(), err)
+		return writeBatchs, nil
+	}
+	replicaWriteBatchs, err := ledger.state.ChangeReplicas(change, ledger.execSeqNo)
+	if err != nil {
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Warnf("skip replica change of tx %s, %v", tx.Hash(), err)
+		return writeBatchs, nil
+	}
+	logger.WithField(log.FieldTxHash, tx.Hash().String()).Infof("replica set change of tx %s : %s %s", tx.Hash(), change.Op, change.ID)
+	return append(writeBatchs, replicaWriteBatchs...), nil
+}
+
+// ReplicaSet returns the replica set changed by the governance txs, nil if
+// no replica set is stored
+func (ledger *Ledger) ReplicaSet() (*consensus.ReplicaSet, error) {
+	ledger.Lock()
+	defer ledger.Unlock()
+	return ledger.state.GetReplicaSet()
+}
+
+// InitReplicas stores the genesis replica set unless a replica set is stored
+func (ledger *Ledger) InitReplicas(set *consensus.ReplicaSet) error {
+	ledger.Lock()
+	defer ledger.Unlock()
+	return ledger.state.InitReplicas(set)
+}
+
 func (ledger *Ledger) executeAtomicTx(writeBatchs []*db.WriteBatch, tx *types.Transaction) ([]*db.WriteBatch, error) {
 	sender := tx.Sender()
 	atomicTxWriteBatchs, err := ledger.state.Transfer(sender, tx.Recipient(), tx.Fee(), state.NewBalance(tx.Amount(), tx.Nonce()), types.TypeAtomic)
@@ -446,13 +584,13 @@ func (ledger *Ledger) executeSmartContractTx(writeBatchs []*db.WriteBatch, tx *t
 	ctx := vm.NewCTX(tx, contractSpec, ledger.contract)
 	_, err := vm.RealExecute(ctx)
 	if err != nil {
-		log.Errorf("contract execute failed ......")
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Errorf("contract execute failed %v", err)
 		return nil, nil, errors.New("contract execute failed ......")
 	}
 
 	smartContractTxs, err := ledger.contract.FinishContractTransaction()
 	if err != nil {
-		log.Error("FinishContractTransaction: ", err)
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Error("FinishContractTransaction: ", err)
 		return nil, nil, err
 	}
 
@@ -478,7 +616,7 @@ func (ledger *Ledger) checkCoordinate(tx *types.Transaction) bool {
 func (ledger *Ledger) GetTmpBalance(addr accounts.Address) (*big.Int, error) {
 	balance, err := ledger.state.GetTmpBalance(addr)
 	if err != nil {
-		log.Error("can't get balance from db")
+		logger.Error("can't get balance from db")
 	}
 
 	return balance.Amount, err
@@ -31,7 +31,8 @@ import (
 )
 
 const (
-	timeKey string = "timeKey"
+	timeKey   string = "timeKey"
+	backupKey string = "backupKey"
 )
 
 // Storage represents Merged transactions
@@ -161,6 +162,27 @@ func (storage *Storage) GetTxsByMergeTxHash(mergeTXHash crypto.Hash) ([]crypto.H
 	return txsHashs, nil
 }
 
+// PutBackupTxs saves the merged transactions not yet acked by the parent chain
+func (storage *Storage) PutBackupTxs(txs types.Transactions) error {
+	if len(txs) == 0 {
+		return storage.dbHandler.Delete(storage.columnFamily, []byte(backupKey))
+	}
+	return storage.dbHandler.Put(storage.columnFamily, []byte(backupKey), utils.Serialize(txs))
+}
+
+// GetBackupTxs returns the merged transactions saved by PutBackupTxs
+func (storage *Storage) GetBackupTxs() (types.Transactions, error) {
+	txsBytes, err := storage.dbHandler.Get(storage.columnFamily, []byte(backupKey))
+	if err != nil || len(txsBytes) == 0 {
+		return nil, err
+	}
+	txs := make(types.Transactions, 0)
+	if err := utils.Deserialize(txsBytes, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
 func (storage *Storage) persistenceTransaction(tx *types.Transaction) error {
 
 	if !utils.Contain(tx.CreateTime(), storage.timeArray) {
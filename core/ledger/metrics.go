@@ -0,0 +1,47 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"github.com/bocheninc/L0/components/metrics"
+	"github.com/bocheninc/L0/core/types"
+)
+
+var (
+	heightGauge   = metrics.NewGauge("l0_ledger_height", "Height of the last block appended to the ledger.")
+	commitLatency = metrics.NewHistogram("l0_ledger_block_commit_seconds", "Time to execute and write a block.", nil)
+	blockTxs      = metrics.NewHistogramVec("l0_ledger_block_txs", "Number of txs of each type in a block.",
+		[]float64{1, 10, 100, 1000, 10000, 100000}, "type")
+	txsTotal = metrics.NewCounterVec("l0_ledger_txs_total", "Number of committed txs of each type.", "type")
+)
+
+// observeBlock records the metrics of an appended block
+func observeBlock(block *types.Block) {
+	heightGauge.Set(float64(block.Height()))
+
+	counts := make(map[uint32]int)
+	for _, tx := range block.Transactions {
+		counts[tx.GetType()]++
+	}
+	for txType, cnt := range counts {
+		name := types.TxTypeName(txType)
+		blockTxs.With(name).Observe(float64(cnt))
+		txsTotal.With(name).Add(float64(cnt))
+	}
+}
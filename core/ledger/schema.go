@@ -0,0 +1,45 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import "github.com/bocheninc/L0/components/db"
+
+// migrations changes the db layout of the ledger, sorted by version.
+// Version 1 is the layout before schema versions were stored: balances
+// under bl_ in the balance column family, the height and hash indexes in
+// the index column family, contract states in scontract and the merged
+// transactions of the storage column family in time buckets
+var migrations = []db.Migration{
+	{
+		Version:     1,
+		Description: "baseline layout",
+		Run:         func(run *db.MigrationRun) error { return nil },
+	},
+}
+
+// SchemaVersion returns the db schema version of this binary
+func SchemaVersion() uint32 {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate migrates the db to the schema version of this binary before a
+// ledger is opened on it, a dry run reports the migrations without writing
+func Migrate(chainDb *db.BlockchainDB, dryRun bool) ([]db.MigrationResult, error) {
+	return chainDb.Migrate(migrations, dryRun)
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+var (
+	replicasColumnFamily = "state"
+	replicasKey          = []byte("replicas")
+)
+
+// GetReplicaSet returns the stored replica set, nil if none is stored
+func (state *State) GetReplicaSet() (*consensus.ReplicaSet, error) {
+	data, err := state.dbHandler.Get(replicasColumnFamily, replicasKey)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	set := &consensus.ReplicaSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// GetTmpReplicaSet returns the replica set including the changes of the txs
+// executed but not written yet
+func (state *State) GetTmpReplicaSet() (*consensus.ReplicaSet, error) {
+	if state.tmpReplicas != nil {
+		return state.tmpReplicas, nil
+	}
+	return state.GetReplicaSet()
+}
+
+// ChangeReplicas schedules the change executed at the consensus seqNo
+func (state *State) ChangeReplicas(change *consensus.ReplicaChange, seqNo uint64) ([]*db.WriteBatch, error) {
+	set, err := state.GetTmpReplicaSet()
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, fmt.Errorf("no replica set is configured")
+	}
+	next := *set
+	if err := next.Schedule(change, seqNo); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(&next)
+	if err != nil {
+		return nil, err
+	}
+	state.tmpReplicas = &next
+	return []*db.WriteBatch{db.NewWriteBatch(replicasColumnFamily, db.OperationPut, replicasKey, data)}, nil
+}
+
+// InitReplicas stores the genesis replica set unless a replica set is stored
+func (state *State) InitReplicas(set *consensus.ReplicaSet) error {
+	if stored, err := state.GetReplicaSet(); err != nil || stored != nil || set == nil || len(set.Replicas) == 0 {
+		return err
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return state.dbHandler.Put(replicasColumnFamily, replicasKey, data)
+}
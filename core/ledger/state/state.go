@@ -26,15 +26,19 @@ import (
 	"github.com/bocheninc/L0/components/db"
 	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/types"
 )
 
+var logger = log.Module(log.ModuleLedger)
+
 // State represents the account state
 type State struct {
 	dbHandler     *db.BlockchainDB
 	balancePrefix []byte
 	columnFamily  string
 	tmpBalance    map[string]*Balance
+	tmpReplicas   *consensus.ReplicaSet
 }
 
 const (
@@ -100,7 +104,7 @@ func (state *State) GetBalance(a accounts.Address) (*big.Int, uint32, error) {
 	}
 	balance := new(Balance)
 	balance.deserialize(balanceBytes)
-	log.Info("balanceBytes: ", balanceBytes, "Amount: ", balance.Amount)
+	logger.Info("balanceBytes: ", balanceBytes, "Amount: ", balance.Amount)
 	return balance.Amount, balance.Nonce, nil
 }
 
@@ -177,9 +181,16 @@ func (state *State) AtomicWrite(writeBatchs []*db.WriteBatch) error {
 	}
 	//clear map
 	state.tmpBalance = make(map[string]*Balance)
+	state.tmpReplicas = nil
 	return nil
 }
 
+// Discard drops the changes not written by AtomicWrite
+func (state *State) Discard() {
+	state.tmpBalance = make(map[string]*Balance)
+	state.tmpReplicas = nil
+}
+
 //checkBalance check negative Balance,flag = 1 add, flag = 2 sub
 func (state *State) checkBalance(balance, change, fee *big.Int, operation uint32) bool {
 	tmpBalance := new(big.Int)
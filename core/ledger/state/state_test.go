@@ -21,19 +21,21 @@ package state
 import (
 	"math/big"
 	"os"
+	"strings"
 	"testing"
 
 	"bytes"
 
 	"github.com/bocheninc/L0/components/db"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/types"
 )
 
 var (
 	testConfig = &db.Config{
 		DbPath:            "/tmp/rocksdb-test/",
-		Columnfamilies:    []string{"balance"},
+		Columnfamilies:    []string{"balance", "state"},
 		KeepLogFileNumber: 10,
 		MaxLogFileSize:    10485760,
 		LogLevel:          "warn",
@@ -132,3 +134,42 @@ func TestTransfer(t *testing.T) {
 
 	os.RemoveAll("/tmp/rocksdb-test")
 }
+
+func TestReplicas(t *testing.T) {
+	testDb := db.NewDB(testConfig)
+	s := NewState(testDb)
+	key := "04" + strings.Repeat("ab", 64)
+	genesis := consensus.Replicas{"r1": key, "r2": key, "r3": key, "r4": key}
+
+	if _, err := s.ChangeReplicas(&consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: "r5", PublicKey: key}, 5); err == nil {
+		t.Fatal("replica added without a genesis replica set")
+	}
+	if err := s.InitReplicas(consensus.NewReplicaSet(genesis, 2)); err != nil {
+		t.Fatal(err)
+	}
+	writeBatchs, err := s.ChangeReplicas(&consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: "r5", PublicKey: key}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ChangeReplicas(&consensus.ReplicaChange{Op: consensus.ReplicaAdd, ID: "r5", PublicKey: key}, 5); err == nil {
+		t.Fatal("the change of the executed tx isn't seen before the write")
+	}
+	if err := s.AtomicWrite(writeBatchs); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stored replica set isn't replaced by the genesis one and keeps
+	// the seqNo the change takes effect
+	s = NewState(testDb)
+	if err := s.InitReplicas(consensus.NewReplicaSet(genesis, 2)); err != nil {
+		t.Fatal(err)
+	}
+	set, err := s.GetReplicaSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.At(6)) != 4 || strings.Join(set.At(7).IDs(), ",") != "r1,r2,r3,r4,r5" {
+		t.Fatalf("replicas at 6 %v, at 7 %v", set.At(6).IDs(), set.At(7).IDs())
+	}
+	os.RemoveAll("/tmp/rocksdb-test")
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/trace"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// TraceTx returns the stages the transaction passed through on this node
+func (ledger *Ledger) TraceTx(txHash crypto.Hash) []trace.Record {
+	return trace.Get(txHash)
+}
+
+func traceAppendBlock(txs types.Transactions, block *types.Block) {
+	appended := make(map[crypto.Hash]bool, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		appended[tx.Hash()] = true
+	}
+	for _, tx := range txs {
+		if appended[tx.Hash()] {
+			trace.Add(tx.Hash(), trace.Record{Stage: trace.AppendBlock, OK: true, Height: block.Height()})
+		} else {
+			trace.Add(tx.Hash(), trace.Record{Stage: trace.AppendBlock, Height: block.Height(), Detail: "execution failed"})
+		}
+	}
+}
+
+func traceAppendFailed(txs types.Transactions, err error) {
+	for _, tx := range txs {
+		trace.Fail(tx.Hash(), trace.AppendBlock, err.Error())
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/consensus"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// Kinds of the problems found by Verify
+const (
+	IssueBlock     = "block"
+	IssueLink      = "link"
+	IssueHash      = "hash"
+	IssueMerkle    = "merkle"
+	IssueSignature = "signature"
+	IssueExecution = "execution"
+)
+
+// VerifyIssue is a problem of a block or transaction found by Verify
+type VerifyIssue struct {
+	Height uint32       `json:"height"`
+	Kind   string       `json:"kind"`
+	Tx     *crypto.Hash `json:"tx,omitempty"`
+	Detail string       `json:"detail"`
+}
+
+// StateDiff is a key whose live value differs from the replayed one, an
+// empty value means the key is missing
+type StateDiff struct {
+	ColumnFamily string `json:"cf"`
+	Key          string `json:"key"`
+	Live         string `json:"live"`
+	Replayed     string `json:"replayed"`
+}
+
+// VerifyReport is the result of Verify
+type VerifyReport struct {
+	Height uint32        `json:"height"`
+	Txs    int           `json:"txs"`
+	Issues []VerifyIssue `json:"issues"`
+	Diffs  []StateDiff   `json:"diffs"`
+}
+
+// OK reports whether the chain verified without any problem
+func (r *VerifyReport) OK() bool {
+	return len(r.Issues) == 0 && len(r.Diffs) == 0
+}
+
+func (r *VerifyReport) addIssue(height uint32, kind string, tx *types.Transaction, format string, args ...interface{}) {
+	issue := VerifyIssue{Height: height, Kind: kind, Detail: fmt.Sprintf(format, args...)}
+	if tx != nil {
+		h := tx.Hash()
+		issue.Tx = &h
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// verifiedColumnFamilies are compared between the live and replayed db
+var verifiedColumnFamilies = []string{"balance", "scontract", "state"}
+
+// Verify replays all blocks from genesis into the empty scratch db seeded
+// with the genesis replica set. It checks the block links and hashes, the
+// merkle hash of the txs and their signatures, re-executes the txs and
+// contracts and compares the resulting balances, contract states and
+// replica set with the ledger.
+func (ledger *Ledger) Verify(scratch *db.BlockchainDB, replicas *consensus.ReplicaSet) (*VerifyReport, error) {
+	height, err := ledger.Height()
+	if err != nil {
+		return nil, err
+	}
+	replay := newLedger(scratch)
+	if err := replay.InitReplicas(replicas); err != nil {
+		return nil, err
+	}
+	report := &VerifyReport{Height: height}
+
+	genesis, err := ledger.GetBlockByNumber(0)
+	if err != nil {
+		return nil, err
+	}
+	if !genesis.Hash().Equal(replay.GetGenesisBlock().Hash()) {
+		report.addIssue(0, IssueBlock, nil, "genesis %s, want %s", genesis.Hash(), replay.GetGenesisBlock().Hash())
+	}
+
+	previous := genesis.Hash()
+	for h := uint32(1); h <= height; h++ {
+		block, err := ledger.GetBlockByNumber(h)
+		if err != nil {
+			report.addIssue(h, IssueBlock, nil, "%v", err)
+			return report, nil
+		}
+		report.Txs += len(block.Transactions)
+		ledger.verifyBlock(report, block, previous)
+		replay.replayBlock(report, block, ledger.BlockSeqNo(h))
+		previous = block.Hash()
+	}
+
+	for _, cf := range verifiedColumnFamilies {
+		diffs, err := diffColumnFamily(ledger.dbHandler, scratch, cf)
+		if err != nil {
+			return nil, err
+		}
+		report.Diffs = append(report.Diffs, diffs...)
+	}
+	return report, nil
+}
+
+func (ledger *Ledger) verifyBlock(report *VerifyReport, block *types.Block, previous crypto.Hash) {
+	h := block.Height()
+	if !block.PreviousHash().Equal(previous) {
+		report.addIssue(h, IssueLink, nil, "previous hash %s, want %s", block.PreviousHash(), previous)
+	}
+	if stored, err := ledger.block.GetBlockByHash(block.Hash().Bytes()); err != nil || stored.Height() != h {
+		report.addIssue(h, IssueHash, nil, "block is not stored under its hash %s", block.Hash())
+	}
+	if merkle := merkleRootHash(block.Transactions); !merkle.Equal(block.Header.TxsMerkleHash) {
+		report.addIssue(h, IssueMerkle, nil, "txs merkle hash %s, header has %s", merkle, block.Header.TxsMerkleHash)
+	}
+
+	for _, tx := range OriginalTxs(block.Transactions) {
+		switch tx.GetType() {
+		case types.TypeMerged, types.TypeSmartContract:
+			continue
+		}
+		signer, err := tx.Verfiy()
+		if err != nil {
+			report.addIssue(h, IssueSignature, tx, "%v", err)
+		} else if signer != tx.Sender() {
+			report.addIssue(h, IssueSignature, tx, "signed by %s, sender is %s", signer, tx.Sender())
+		}
+	}
+}
+
+// replayBlock executes the txs of block like AppendBlock and compares the
+// executed txs with the block
+func (ledger *Ledger) replayBlock(report *VerifyReport, block *types.Block, seqNo uint64) {
+	h := block.Height()
+	ledger.execSeqNo = seqNo
+	header := *block.Header
+	replayed := &types.Block{Header: &header}
+
+	writeBatchs, txs, err := ledger.executeTransaction(OriginalTxs(block.Transactions))
+	if err != nil {
+		report.addIssue(h, IssueExecution, nil, "%v", err)
+		// keep the chain going without the state changes of the block
+		bh, _ := ledger.Height()
+		ledger.contract.StopContract(bh)
+		writeBatchs, txs = nil, block.Transactions
+	}
+	replayed.Transactions = txs
+	replayed.Header.TxsMerkleHash = merkleRootHash(txs)
+	if err == nil && !replayed.Header.TxsMerkleHash.Equal(block.Header.TxsMerkleHash) {
+		report.addIssue(h, IssueExecution, nil, "executed %d txs with merkle hash %s, block has %d txs with %s",
+			len(txs), replayed.Header.TxsMerkleHash, len(block.Transactions), block.Header.TxsMerkleHash)
+	}
+
+	writeBatchs = append(ledger.block.AppendBlock(replayed), writeBatchs...)
+	if err := ledger.state.AtomicWrite(writeBatchs); err != nil {
+		report.addIssue(h, IssueExecution, nil, "write replayed block: %v", err)
+	}
+}
+
+// OriginalTxs strips the txs appended by the contracts executed in the
+// block, they are unsigned and follow the txs of the block
+func OriginalTxs(txs types.Transactions) types.Transactions {
+	hasContract := false
+	for _, tx := range txs {
+		if tx.GetType() == types.TypeSmartContract {
+			hasContract = true
+			break
+		}
+	}
+	if !hasContract {
+		return txs
+	}
+
+	n := len(txs)
+	for n > 0 {
+		tx := txs[n-1]
+		if tx.Data.Signature != nil || tx.GetType() == types.TypeMerged || tx.GetType() == types.TypeSmartContract {
+			break
+		}
+		n--
+	}
+	return txs[:n]
+}
+
+func diffColumnFamily(live, replayed *db.BlockchainDB, cf string) ([]StateDiff, error) {
+	liveIt := live.NewIterator(cf)
+	defer liveIt.Close()
+	replayedIt := replayed.NewIterator(cf)
+	defer replayedIt.Close()
+
+	// both iterators walk the keys in order, a key only one of them is at
+	// is missing in the other db
+	var diffs []StateDiff
+	for liveIt.Valid() || replayedIt.Valid() {
+		cmp := -1
+		if !liveIt.Valid() {
+			cmp = 1
+		} else if replayedIt.Valid() {
+			cmp = bytes.Compare(liveIt.Key(), replayedIt.Key())
+		}
+		switch {
+		case cmp < 0:
+			diffs = append(diffs, StateDiff{ColumnFamily: cf, Key: hex.EncodeToString(liveIt.Key()), Live: hex.EncodeToString(liveIt.Value())})
+			liveIt.Next()
+		case cmp > 0:
+			diffs = append(diffs, StateDiff{ColumnFamily: cf, Key: hex.EncodeToString(replayedIt.Key()), Replayed: hex.EncodeToString(replayedIt.Value())})
+			replayedIt.Next()
+		default:
+			if v, rv := liveIt.Value(), replayedIt.Value(); !bytes.Equal(v, rv) {
+				diffs = append(diffs, StateDiff{ColumnFamily: cf, Key: hex.EncodeToString(liveIt.Key()), Live: hex.EncodeToString(v), Replayed: hex.EncodeToString(rv)})
+			}
+			liveIt.Next()
+			replayedIt.Next()
+		}
+	}
+	if err := liveIt.Err(); err != nil {
+		return nil, err
+	}
+	return diffs, replayedIt.Err()
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/bocheninc/L0/components/log"
 )
 
+var logger = log.Module(log.ModuleMerge)
+
 //PersistItem
 type PersistItem struct {
 	dbHandler    *db.BlockchainDB
@@ -42,7 +44,7 @@ func (pi *PersistItem) Exists(key string) (bool, []byte) {
 	dbKey := []byte(key)
 	valueBytes, err := pi.dbHandler.Get(pi.columnFamily, dbKey)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		return false, nil
 	}
 
@@ -54,7 +56,7 @@ func (pi *PersistItem) Add(key string, value []byte) error {
 	dbKey := []byte(key)
 	err := pi.dbHandler.Put(pi.columnFamily, dbKey, value)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 	}
 
 	return err
@@ -66,7 +68,7 @@ func (pi *PersistItem) Del(key string) error {
 	err := pi.dbHandler.Delete(pi.columnFamily, dbKey)
 
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 	}
 
 	return err
@@ -23,7 +23,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils"
 	cache "github.com/bocheninc/L0/core/merge/cache"
 	"github.com/bocheninc/L0/core/types"
@@ -152,7 +151,7 @@ func (cct *ChainCacheTable) handleTx(chainID, peerID string, peerNum uint32, dur
 		return
 	}
 
-	log.Debugln("===> handleTx TxHash: ", tx.Hash().String(), " Cnt: ", cct.countDot[txHash].getCnt(), " chainID: ", chainID, "  peerId: ", peerID, " peerNum: ", peerNum)
+	logger.Debugln("===> handleTx TxHash: ", tx.Hash().String(), " Cnt: ", cct.countDot[txHash].getCnt(), " chainID: ", chainID, "  peerId: ", peerID, " peerNum: ", peerNum)
 	if cct.countDot[txHash].getCnt() > peerNum/2 && !cct.countDot[txHash].getFlag() {
 		cct.countDot[txHash].setFlag(true)
 		if cct.sendTxBack != nil {
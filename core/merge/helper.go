@@ -20,10 +20,12 @@ package merge
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/core/blockchain"
 	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/notify"
 	"github.com/bocheninc/L0/core/types"
 	"github.com/bocheninc/L0/msgnet"
 )
@@ -68,7 +70,18 @@ func (h *Helper) Start() {
 
 	h.txMerge.start()
 	h.txParser.start()
-	log.Infoln("merge start...:")
+	logger.Infoln("merge start...:")
+}
+
+// Stop stops merging and saves the merged transactions not yet acked
+func (h *Helper) Stop() {
+	h.txMerge.stop()
+	logger.Infoln("merge stop")
+}
+
+// SetMergeDuration changes the interval of merging transactions
+func (h *Helper) SetMergeDuration(d time.Duration) {
+	h.txMerge.setMergeDuration(d)
 }
 
 // HandleNetMsg handle msg from msg_net
@@ -92,20 +105,31 @@ func (h *Helper) HandleLocalMsg(event Event) {
 func (h *Helper) ProcessEvent(event Event) {
 	switch et := event.(type) {
 	case TxEvent:
-		log.Debugln("mergeSendMsgnet: ", " peetID: ", et.peerID, " dstChainID :", et.dstChainID)
+		logger.Debugln("mergeSendMsgnet: ", " peetID: ", et.peerID, " dstChainID :", et.dstChainID)
 		h.pmSender.SendMsgnetMessage(et.peerID, et.dstChainID, et.msg)
+		publishMergeEvent(notify.MergeUpload, et.dstChainID, et.peerID, et.msg.Payload)
 	case AckMergeTxEvent:
 		tx := new(types.Transaction)
 		tx.Deserialize(et.msg.Payload)
-		log.Debugln("AckMergeTxEvent: ", " peerID: ", et.peerID, " TxHash: ", tx.Hash().String())
+		logger.WithFields(log.Fields{log.FieldPeer: et.peerID, log.FieldTxHash: tx.Hash().String()}).Debugln("AckMergeTxEvent")
 		h.pmSender.SendMsgnetMessage(config.PeerID, h.peerAddress(et.chainID, et.peerID), et.msg)
+		publishMergeEvent(notify.MergeAck, et.chainID, et.peerID, et.msg.Payload)
 	case AckMergedTxEvent:
 		h.pmSender.SendMsgnetMessage(config.PeerID, h.peerAddress(et.chainID, et.peerID), et.msg)
+		publishMergeEvent(notify.MergeAcked, et.chainID, et.peerID, et.msg.Payload)
 	case BroadcastAckMergeTxEvent:
 		h.pmSender.Relay(et.tx)
 	}
 }
 
+func publishMergeEvent(stage, chainID, peerID string, payload []byte) {
+	tx := new(types.Transaction)
+	if err := tx.Deserialize(payload); err != nil {
+		return
+	}
+	notify.Publish(notify.MergeTx, &notify.MergeEvent{Stage: stage, ChainID: chainID, PeerID: peerID, TxHash: tx.Hash()})
+}
+
 func (h *Helper) peerAddress(chainID, peerID string) string {
 	return fmt.Sprintf("%s:%s", chainID, peerID)
 }
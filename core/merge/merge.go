@@ -21,6 +21,7 @@ package merge
 import (
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bocheninc/L0/components/crypto"
@@ -28,21 +29,31 @@ import (
 	"github.com/bocheninc/L0/core/accounts"
 	"github.com/bocheninc/L0/core/coordinate"
 	"github.com/bocheninc/L0/core/ledger"
+	"github.com/bocheninc/L0/core/notify"
 	"github.com/bocheninc/L0/core/p2p"
 	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 	"github.com/bocheninc/L0/msgnet"
 )
 
+var logger = log.Module(log.ModuleMerge)
+
 // SEPARATOR separates fromChain from toChain
 const SEPARATOR = "|"
 
 // TxMerge merge transactions
 type TxMerge struct {
-	ledger    *ledger.Ledger
-	receive   Receiver
-	ticker    *time.Ticker
+	ledger   *ledger.Ledger
+	receive  Receiver
+	ticker   *time.Ticker
+	duration chan time.Duration
+	quit     chan chan struct{}
+	// mu guards the upload state, updated by acks from the network
+	mu        sync.Mutex
 	backupTxs map[string]*types.Transaction
+	uploadAt  map[string]time.Time
+	mergedTxs map[string][]crypto.Hash
 }
 
 // NewTxMerge initialization
@@ -50,7 +61,11 @@ func NewTxMerge(ledger *ledger.Ledger) *TxMerge {
 	return &TxMerge{
 		ledger:    ledger,
 		ticker:    time.NewTicker(config.MergeDuration),
+		duration:  make(chan time.Duration, 1),
+		quit:      make(chan chan struct{}),
 		backupTxs: make(map[string]*types.Transaction),
+		uploadAt:  make(map[string]time.Time),
+		mergedTxs: make(map[string][]crypto.Hash),
 	}
 }
 
@@ -59,9 +74,62 @@ func (tm *TxMerge) setReceiver(receiver Receiver) {
 }
 
 func (tm *TxMerge) start() {
+	tm.loadBackupTxs()
 	go tm.eventLoop()
 }
 
+// stop waits for the merge in progress and saves the merged transactions
+// not yet acked, they are uploaded again after restart
+func (tm *TxMerge) stop() {
+	done := make(chan struct{})
+	tm.quit <- done
+	<-done
+}
+
+func (tm *TxMerge) loadBackupTxs() {
+	txs, err := tm.ledger.GetMergeBackupTxs()
+	if err != nil {
+		logger.Errorf("load unacked merge txs error %v", err)
+		return
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	now := time.Now()
+	for _, tx := range txs {
+		key := tx.Hash().String()
+		tm.backupTxs[key] = tx
+		tm.uploadAt[key] = now
+		if txsHash, err := tm.ledger.GetTxsHashByMergeTxHash(tx.Hash()); err == nil {
+			tm.mergedTxs[key] = txsHash
+		}
+	}
+	backlogGauge.Set(float64(len(tm.backupTxs)))
+	if len(txs) > 0 {
+		logger.Infof("loaded %d unacked merge txs", len(txs))
+	}
+}
+
+func (tm *TxMerge) saveBackupTxs() {
+	txs := tm.getBackupTxs()
+	if err := tm.ledger.PutMergeBackupTxs(txs); err != nil {
+		logger.Errorf("save unacked merge txs error %v", err)
+		return
+	}
+	logger.Infof("saved %d unacked merge txs", len(txs))
+}
+
+// setMergeDuration passes the new duration to the event loop, replacing
+// one not yet applied
+func (tm *TxMerge) setMergeDuration(d time.Duration) {
+	for {
+		select {
+		case tm.duration <- d:
+			return
+		case <-tm.duration:
+		}
+	}
+}
+
 func (tm *TxMerge) sendEvent(event Event) {
 
 	if tm.receive != nil {
@@ -75,27 +143,43 @@ func (tm *TxMerge) recvEvent(event Event) {
 		tx := &types.Transaction{}
 		tx.Deserialize(msg.Payload)
 		tm.deleteBackupTx(tx)
+		notify.Publish(notify.MergeTx, &notify.MergeEvent{Stage: notify.MergeAckRecvd, TxHash: tx.Hash()})
 		if msg.Cmd == msgnet.ChainAckMergeTxsMsg {
 			broadcastAckMergeTxEvent := BroadcastAckMergeTxEvent{tx: tx}
 			tm.sendEvent(broadcastAckMergeTxEvent)
 		}
-		log.Debugln("reciveMsgNetAckMerged", tx.Hash())
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Debugln("reciveMsgNetAckMerged")
 	case p2p.Msg:
 		tx := &types.Transaction{}
 		tx.Deserialize(msg.Payload)
 		tm.deleteBackupTx(tx)
-		log.Debugln("reciveP2PAckMerged", tx.Hash())
+		logger.WithField(log.FieldTxHash, tx.Hash().String()).Debugln("reciveP2PAckMerged")
 	}
 }
 
 func (tm *TxMerge) deleteBackupTx(tx *types.Transaction) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	if len(tm.backupTxs) == 0 {
 		return
 	}
-	delete(tm.backupTxs, tx.Hash().String())
+	key := tx.Hash().String()
+	if at, ok := tm.uploadAt[key]; ok {
+		ackLatency.ObserveSince(at)
+		delete(tm.uploadAt, key)
+		trace.OK(tx.Hash(), trace.MergeAck, "")
+		for _, txHash := range tm.mergedTxs[key] {
+			trace.OK(txHash, trace.MergeAck, "merged into "+key)
+		}
+		delete(tm.mergedTxs, key)
+	}
+	delete(tm.backupTxs, key)
+	backlogGauge.Set(float64(len(tm.backupTxs)))
 }
 
 func (tm *TxMerge) getBackupTxs() types.Transactions {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	if len(tm.backupTxs) == 0 {
 		return nil
 	}
@@ -109,15 +193,25 @@ func (tm *TxMerge) getBackupTxs() types.Transactions {
 func (tm *TxMerge) eventLoop() {
 	for {
 		select {
+		case done := <-tm.quit:
+			tm.ticker.Stop()
+			tm.saveBackupTxs()
+			close(done)
+			return
+		case d := <-tm.duration:
+			config.MergeDuration = d
+			tm.ticker.Stop()
+			tm.ticker = time.NewTicker(d)
+			logger.Infof("merge duration changed to %v", d)
 		case <-tm.ticker.C:
 			txs, err := tm.ledger.GetMergedTransaction(uint32(config.MergeDuration / time.Second))
 			if err != nil {
-				log.Error("get MergeTxs err: ", err)
+				logger.Error("get MergeTxs err: ", err)
 			}
-			log.Infoln("getMergetxs len:", len(txs))
+			logger.Infoln("getMergetxs len:", len(txs))
 			if len(txs) != 0 {
 				if err := tm.mergerTx(txs); err != nil {
-					log.Error(err)
+					logger.Error(err)
 				}
 			}
 		}
@@ -127,7 +221,7 @@ func (tm *TxMerge) eventLoop() {
 
 func (tm *TxMerge) mergerTx(txs types.Transactions) error {
 	if len(txs) == 0 {
-		log.Debugln("no merge transaction.")
+		logger.Debugln("no merge transaction.")
 		return nil
 	}
 
@@ -176,10 +270,13 @@ func (tm *TxMerge) mergerTx(txs types.Transactions) error {
 		}
 		transaction := tm.maketransaction(chainCoordinates[0], chainCoordinates[1], v.amount.Abs(v.amount), v.fee, v.txTime)
 
-		log.Infoln("mergeTxData: ", transaction.Data, " mergeTxHash: ", transaction.Hash())
+		logger.Infoln("mergeTxData: ", transaction.Data, " mergeTxHash: ", transaction.Hash())
 		if err := tm.ledger.PutTxsHashByMergeTxHash(transaction.Hash(), v.txsHash); err != nil {
 			return err
 		}
+		tm.mu.Lock()
+		tm.mergedTxs[transaction.Hash().String()] = v.txsHash
+		tm.mu.Unlock()
 		delete(m, k)
 		transactions = append(transactions, transaction)
 
@@ -188,7 +285,7 @@ func (tm *TxMerge) mergerTx(txs types.Transactions) error {
 	uploadPayload := NewUploadPayload(uint32(config.MaxPeers), uint32(config.MergeDuration), tm.getBackupTxs(), transactions)
 
 	dstChainID := coordinate.HexToChainCoordinate(config.ChainID).ParentCoorinate()
-	log.Debugln("uploadPayload: ", *uploadPayload, "dstChainID: ", dstChainID.String(), " maxPeer: ", config.MaxPeers)
+	logger.Debugln("uploadPayload: ", *uploadPayload, "dstChainID: ", dstChainID.String(), " maxPeer: ", config.MaxPeers)
 	mergeTxEvent := TxEvent{
 		msg: msgnet.Message{
 			Cmd:     msgnet.ChainMergeTxsMsg,
@@ -200,9 +297,19 @@ func (tm *TxMerge) mergerTx(txs types.Transactions) error {
 
 	tm.sendEvent(mergeTxEvent)
 
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	now := time.Now()
 	for _, tx := range transactions {
-		tm.backupTxs[tx.Hash().String()] = tx
+		key := tx.Hash().String()
+		tm.backupTxs[key] = tx
+		tm.uploadAt[key] = now
+		trace.OK(tx.Hash(), trace.MergeUpload, "to "+dstChainID.String())
+		for _, txHash := range tm.mergedTxs[key] {
+			trace.OK(txHash, trace.MergeUpload, "merged into "+key+" to "+dstChainID.String())
+		}
 	}
+	backlogGauge.Set(float64(len(tm.backupTxs)))
 
 	return nil
 }
@@ -77,7 +77,7 @@ func (tp *TxParser) recvEvent(chainID, peerID string, event Event) {
 	payload := event.(msgnet.Message).Payload
 	uploadPayload := new(UploadPayload)
 	uploadPayload.Deserialize(payload)
-	log.Debugln("parseChainID: ", chainID, " peerID: ", peerID, " uploadPayload: ", *uploadPayload)
+	logger.Debugln("parseChainID: ", chainID, " peerID: ", peerID, " uploadPayload: ", *uploadPayload)
 	t := SetChainTable(chainID, tp.callback)
 	t.AddMergeTxs(chainID, peerID, uploadPayload)
 }
@@ -86,7 +86,7 @@ func (tp *TxParser) eventLoop() {
 	for {
 		select {
 		case peerTx := <-tp.txMergeChan:
-			log.Debug(" ===> peerTx: ", peerTx, " txHash: ", peerTx.tx.Hash().String(), " chainID: ", peerTx.chainID, " peerrID: ", peerTx.peerID)
+			logger.Debug(" ===> peerTx: ", peerTx, " txHash: ", peerTx.tx.Hash().String(), " chainID: ", peerTx.chainID, " peerrID: ", peerTx.peerID)
 			go tp.handleMergeTx(peerTx)
 		}
 	}
@@ -126,6 +126,6 @@ func (tp *TxParser) isChainCoordinatePoint(fromChain, toChain string) bool {
 }
 
 func (tp *TxParser) processTransaction(tx *types.Transaction) {
-	log.Infoln("===> processTransaction", tx.Hash().String())
+	logger.WithField(log.FieldTxHash, tx.Hash().String()).Infoln("===> processTransaction")
 	tp.bc.ProcessTransaction(tx)
 }
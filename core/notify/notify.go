@@ -0,0 +1,145 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package notify
+
+import (
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+)
+
+// Event kinds published by the node
+const (
+	// NewBlock is published with *types.Block after a block is appended
+	NewBlock = "newBlock"
+	// NewPendingTx is published with *types.Transaction after a tx enters the txpool
+	NewPendingTx = "newPendingTx"
+	// MergeTx is published with *MergeEvent when merged txs are uploaded or acked
+	MergeTx = "mergeTx"
+)
+
+// Merge stages carried by MergeEvent
+const (
+	MergeUpload   = "upload"
+	MergeAck      = "ack"
+	MergeAcked    = "acked"
+	MergeAckRecvd = "ackReceived"
+)
+
+// Event is a notification published to subscribers
+type Event struct {
+	Kind string
+	Data interface{}
+}
+
+// MergeEvent describes a merge upload or ack
+type MergeEvent struct {
+	Stage   string      `json:"stage"`
+	ChainID string      `json:"chainID,omitempty"`
+	PeerID  string      `json:"peerID,omitempty"`
+	TxHash  crypto.Hash `json:"txHash"`
+}
+
+// Subscription receives the events of the subscribed kinds. Events are
+// buffered, a subscription which can't keep up is dropped and C is closed
+type Subscription struct {
+	C <-chan Event
+
+	feed    *Feed
+	ch      chan Event
+	kinds   map[string]bool
+	once    sync.Once
+	dropped bool
+}
+
+// Dropped returns if the subscription was dropped because its buffer was full
+func (s *Subscription) Dropped() bool {
+	s.feed.mu.RLock()
+	defer s.feed.mu.RUnlock()
+	return s.dropped
+}
+
+// Unsubscribe removes the subscription from the feed
+func (s *Subscription) Unsubscribe() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		delete(s.feed.subs, s)
+		close(s.ch)
+	})
+}
+
+// Feed delivers published events to subscriptions
+type Feed struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewFeed returns an empty feed
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe subscribes the kinds with a buffer of bufferSize events
+func (f *Feed) Subscribe(bufferSize int, kinds ...string) *Subscription {
+	ch := make(chan Event, bufferSize)
+	s := &Subscription{C: ch, feed: f, ch: ch, kinds: make(map[string]bool)}
+	for _, kind := range kinds {
+		s.kinds[kind] = true
+	}
+
+	f.mu.Lock()
+	f.subs[s] = struct{}{}
+	f.mu.Unlock()
+	return s
+}
+
+// Publish sends the event to the subscriptions without blocking the publisher
+func (f *Feed) Publish(kind string, data interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s := range f.subs {
+		if !s.kinds[kind] {
+			continue
+		}
+		select {
+		case s.ch <- Event{Kind: kind, Data: data}:
+		default:
+			s.dropped = true
+			s.close()
+		}
+	}
+}
+
+var defaultFeed = NewFeed()
+
+// Subscribe subscribes the kinds on the default feed
+func Subscribe(bufferSize int, kinds ...string) *Subscription {
+	return defaultFeed.Subscribe(bufferSize, kinds...)
+}
+
+// Publish publishes the event on the default feed
+func Publish(kind string, data interface{}) {
+	defaultFeed.Publish(kind, data)
+}
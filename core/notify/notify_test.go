@@ -0,0 +1,73 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package notify
+
+import (
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	f := NewFeed()
+	blocks := f.Subscribe(2, NewBlock)
+	all := f.Subscribe(10, NewBlock, NewPendingTx)
+
+	f.Publish(NewBlock, 1)
+	f.Publish(NewPendingTx, "tx")
+
+	if ev := <-blocks.C; ev.Kind != NewBlock || ev.Data.(int) != 1 {
+		t.Fatalf("unexpected event %v", ev)
+	}
+	select {
+	case ev := <-blocks.C:
+		t.Fatalf("unexpected event %v", ev)
+	default:
+	}
+	if ev := <-all.C; ev.Kind != NewBlock {
+		t.Fatalf("unexpected event %v", ev)
+	}
+	if ev := <-all.C; ev.Kind != NewPendingTx {
+		t.Fatalf("unexpected event %v", ev)
+	}
+
+	all.Unsubscribe()
+	all.Unsubscribe()
+	if _, ok := <-all.C; ok {
+		t.Fatal("channel should be closed after unsubscribe")
+	}
+}
+
+func TestFeedBackpressure(t *testing.T) {
+	f := NewFeed()
+	s := f.Subscribe(2, NewBlock)
+
+	for i := 0; i < 3; i++ {
+		f.Publish(NewBlock, i)
+	}
+	if !s.Dropped() {
+		t.Fatal("slow subscription should be dropped")
+	}
+
+	n := 0
+	for range s.C {
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("buffered events %d, want 2", n)
+	}
+}
@@ -99,6 +99,7 @@ func (m *Msg) read(r io.Reader) (int, error) {
 
 	buf := make([]byte, l)
 	n, err := io.ReadFull(r, buf)
+	bytesIn.Add(float64(n))
 
 	if n != int(l) {
 		return n, err
@@ -111,7 +112,9 @@ func (m *Msg) read(r io.Reader) (int, error) {
 func (m *Msg) write(w io.Writer) (int, error) {
 	data := m.Serialize()
 	data = append(utils.VarInt(uint64(len(data))), data...)
-	return w.Write(data)
+	n, err := w.Write(data)
+	bytesOut.Add(float64(n))
+	return n, err
 }
 
 // NewMsg New Message used by msgType chainId and payload
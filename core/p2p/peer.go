@@ -69,6 +69,19 @@ func (peers *peerMap) contains(pid PeerID) bool {
 	return false
 }
 
+func (peers *peerMap) getByID(pid PeerID) (*Peer, bool) {
+	peers.RLock()
+	defer peers.RUnlock()
+
+	for _, p := range peers.m {
+		if bytes.Equal(p.ID, pid) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
 func (peers *peerMap) get(c net.Conn) (*Peer, bool) {
 	peers.RLock()
 	defer peers.RUnlock()
@@ -144,6 +157,10 @@ type Peer struct {
 	running map[string]*protoRW
 }
 
+func (peer *Peer) logEntry() *log.Entry {
+	return logger.WithField(log.FieldPeer, peer.ID.String())
+}
+
 // NewPeer returns a new Peer with input id
 func NewPeer(id []byte, conn net.Conn, addr string, protocols []Protocol) *Peer {
 	protoMap := make(map[string]*protoRW)
@@ -197,7 +214,7 @@ func (peer *Peer) getProto(cmd uint8) *protoRW {
 			return nil
 		}
 	} else {
-		log.Debugf("peer running not exist error %v", peer)
+		peer.logEntry().Debugf("peer running not exist error %v", peer)
 		return nil
 	}
 	return nil
@@ -210,14 +227,14 @@ func (peer *Peer) run() {
 	for {
 		m, err := readMsg(conn)
 		if m == nil || err != nil {
-			log.Errorf("peer read msg error %s", err)
+			peer.logEntry().Errorf("peer read msg error %s", err)
 			peerManager.delPeer <- conn
 			break
 		}
 		//TODO: refactor this to synchronous
 		// doHandshake -> doHandleshakeAck after this ... allow [ping, pong, peers, getpeers]
 		if msgCmd, ok := msgMap[m.Cmd]; ok {
-			log.Debugf("handle message %s, server address:%s", msgCmd, peer.Address)
+			peer.logEntry().Debugf("handle message %s, server address:%s", msgCmd, peer.Address)
 		}
 		// Update the ActiveTime when message reached
 		peerManager.alivePeer <- conn
@@ -227,7 +244,7 @@ func (peer *Peer) run() {
 			respMsg := NewMsg(pongMsg, nil)
 			respMsg.write(peer.Conn)
 		case pongMsg:
-			log.Debug("Received Pong Message")
+			peer.logEntry().Debug("Received Pong Message")
 		case peersMsg:
 			peer.onPeers(m, peerManager)
 		case getPeersMsg:
@@ -242,7 +259,7 @@ func (peer *Peer) run() {
 					proto.in <- *m
 				}
 			} else {
-				log.Error("unknown message", p)
+				peer.logEntry().Error("unknown message", p)
 				peerManager.delPeer <- conn
 				break
 			}
@@ -264,7 +281,7 @@ func (peer Peer) onPeers(msg *Msg, pm *peerManager) {
 func (peer Peer) onGetPeers(msg *Msg, w io.Writer, pm *peerManager) {
 	peersData, err := pm.peers.getPeersData(msg.Payload)
 	if err != nil {
-		log.Errorf("PeerManager handle getPeersMsg error %v", err)
+		peer.logEntry().Errorf("PeerManager handle getPeersMsg error %v", err)
 	}
 	respMsg := NewMsg(peersMsg, peersData)
 	respMsg.write(w)
@@ -272,14 +289,14 @@ func (peer Peer) onGetPeers(msg *Msg, w io.Writer, pm *peerManager) {
 
 // startProtocols starts all sub-protocols
 func (peer *Peer) startProtocols() {
-	log.Debug("Peer StartProtocols")
+	peer.logEntry().Debug("Peer StartProtocols")
 	go peer.run()
 	for _, proto := range peer.running {
 		// log.Debugf("Peer StartProtocols %v: %v,%v", proto.Name, peer.running, proto.Run)
 		go func(proto *protoRW) {
 			err := proto.Run(peer, proto)
 			if err != nil {
-				log.Errorf("Peer Handle Protocols error %v", err)
+				peer.logEntry().Errorf("Peer Handle Protocols error %v", err)
 				//TODO: quit
 				peerManager := getPeerManager()
 				peerManager.delPeer <- peer.Conn
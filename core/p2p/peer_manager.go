@@ -21,10 +21,11 @@ package p2p
 import (
 	"bytes"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/bocheninc/L0/components/db"
-	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
 	"github.com/bocheninc/L0/core/params"
 )
 
@@ -43,6 +44,7 @@ type peerManager struct {
 	handshakings *peerMap
 	dialings     map[string]bool
 	quit         chan struct{}
+	done         chan struct{}
 	addPeer      chan *Peer
 	delPeer      chan net.Conn
 	alivePeer    chan net.Conn
@@ -52,6 +54,9 @@ type peerManager struct {
 	broadcastCh  chan *Msg
 	clientConn   chan net.Conn
 	dialTask     chan *Peer
+	// removed holds the peers removed by admin, they are not connected again
+	removedMu sync.Mutex
+	removed   map[string]bool
 }
 
 // getPeerManager returns a peerManager
@@ -59,12 +64,13 @@ func getPeerManager() *peerManager {
 	if pm == nil {
 		pm = &peerManager{
 			localPeer: NewPeer(
-				config.PrivateKey.Public().Bytes(),
+				nodePublicKey().Bytes(),
 				nil, config.Address, nil),
 			peers:        newPeerMap(),
 			handshakings: newPeerMap(),
 			dialings:     make(map[string]bool),
 			quit:         make(chan struct{}, 1),
+			done:         make(chan struct{}),
 			addPeer:      make(chan *Peer, 1),
 			delPeer:      make(chan net.Conn, 1),
 			alivePeer:    make(chan net.Conn, 1),
@@ -74,8 +80,12 @@ func getPeerManager() *peerManager {
 			clientConn:   make(chan net.Conn, 8),
 			dialTask:     make(chan *Peer, 8),
 			dialTaskDone: make(chan string),
+			removed:      make(map[string]bool),
 		}
 		// log.Debugf("local peerinfo %s", pm.localPeer)
+		metrics.NewGaugeFunc("l0_p2p_peers", "Number of connected p2p peers.", func() float64 {
+			return float64(pm.peers.count())
+		})
 	}
 	return pm
 }
@@ -98,14 +108,13 @@ func (pm *peerManager) GetLocalPeer() *Peer {
 	return pm.localPeer
 }
 
+// stop saves the connected peers to dial them after restart and disconnects them
 func (pm *peerManager) stop() {
 	pm.savePeers()
-	// close(pm.banPeer)
-	close(pm.addPeer)
-	close(pm.delPeer)
-	close(pm.alivePeer)
-	close(pm.dialTask)
-	dbInstance.Close()
+	for _, peer := range pm.peers.getPeers() {
+		peer.Conn.Close()
+	}
+	logger.Infoln("PeerManager Stop")
 }
 
 func (pm *peerManager) add(peer *Peer) {
@@ -114,31 +123,37 @@ func (pm *peerManager) add(peer *Peer) {
 	// 	peer.Conn.Close()
 	// }
 	if pm.peers.contains(peer.ID) {
-		log.Debugf("Peer [%s] already connected", peer.ID)
+		peer.logEntry().Debugf("Peer [%s] already connected", peer.ID)
+		peer.Conn.Close()
+		return
+	}
+
+	if pm.isRemoved(peer.ID) {
+		peer.logEntry().Debugf("Peer [%s] is removed", peer.ID)
 		peer.Conn.Close()
 		return
 	}
 
 	peer.LastActiveTime = time.Now()
 	pm.peers.set(peer.Conn, peer)
-	log.Infof("Add Peer [%s] Success.", peer)
+	peer.logEntry().Infof("Add Peer [%s] Success.", peer)
 
 	// start all protocols
 	peer.startProtocols()
 
 	err := dbInstance.Put(columnFamily, peer.ID, []byte(peer.Address))
 	if err != nil {
-		log.Error(err.Error())
+		peer.logEntry().Error(err.Error())
 	}
 }
 
 func (pm *peerManager) del(conn net.Conn) {
 	defer conn.Close()
 	if peer, ok := pm.peers.get(conn); ok {
-		log.Infof("Delete Peer [%s] Success.", peer)
+		peer.logEntry().Infof("Delete Peer [%s] Success.", peer)
 		err := dbInstance.Delete(columnFamily, peer.ID)
 		if err != nil {
-			log.Error(err.Error())
+			peer.logEntry().Error(err.Error())
 		}
 		pm.peers.remove(conn)
 	}
@@ -155,8 +170,8 @@ func (pm *peerManager) del(conn net.Conn) {
 // process peers option , usually run as goroutine
 func (pm *peerManager) run() {
 	pm.init()
-	log.Infoln("PeerManager Start ...")
-	log.Debugf("Local PeerInfo %s", pm.localPeer)
+	logger.Infoln("PeerManager Start ...")
+	logger.Debugf("Local PeerInfo %s", pm.localPeer)
 
 	go pm.connectLoop()
 	go pm.broadcastLoop()
@@ -165,7 +180,10 @@ func (pm *peerManager) run() {
 	for {
 		select {
 		case <-pm.quit:
+			ticker.Stop()
 			pm.stop()
+			close(pm.done)
+			return
 		case peer := <-pm.addPeer:
 			pm.add(peer)
 		case conn := <-pm.delPeer:
@@ -184,21 +202,25 @@ func (pm *peerManager) run() {
 // if no peers data, connect bootstrap node
 func (pm *peerManager) init() {
 	if dbInstance == nil {
-		log.Fatalln("Error,the database is not initialized.")
+		logger.Fatalln("Error,the database is not initialized.")
 	}
 	list, err := dbInstance.Get(columnFamily, []byte("peerList"))
 	if err != nil {
-		log.Errorln("Database get peers error :", err.Error())
+		logger.Errorln("Database get peers error :", err.Error())
 	}
 	if len(list) > 0 {
 		peerList := bytes.Split(list, []byte{'&'})
 		for _, peerID := range peerList {
 			peerAddr, err := dbInstance.Get(columnFamily, peerID)
 			if err != nil {
-				log.Errorln(err.Error())
+				logger.Errorln(err.Error())
+				continue
+			}
+			peer, err := ParsePeer(string(peerAddr))
+			if err != nil {
+				logger.Errorf("Database peer %s error %v", peerAddr, err)
 				continue
 			}
-			peer, _ := ParsePeer(string(peerAddr))
 			pm.dialTask <- peer
 		}
 	} else {
@@ -214,6 +236,8 @@ func (pm *peerManager) connectLoop() {
 			pm.connect(peer)
 		case peer := <-pm.dialTaskDone:
 			delete(pm.dialings, peer)
+		case <-pm.quit:
+			return
 		}
 	}
 }
@@ -224,6 +248,8 @@ func (pm *peerManager) broadcastLoop() {
 		select {
 		case msg := <-pm.broadcastCh:
 			pm.broadcast(msg)
+		case <-pm.quit:
+			return
 		}
 	}
 }
@@ -231,7 +257,11 @@ func (pm *peerManager) broadcastLoop() {
 // connect connects peer,if success add to connections
 func (pm *peerManager) connect(peer *Peer) {
 	if bytes.Equal(pm.localPeer.ID, peer.ID) {
-		log.Debugf("can ont connect self[%s]", peer.ID)
+		peer.logEntry().Debugf("can ont connect self[%s]", peer.ID)
+		return
+	}
+
+	if pm.isRemoved(peer.ID) {
 		return
 	}
 
@@ -244,11 +274,11 @@ func (pm *peerManager) connect(peer *Peer) {
 	}
 
 	if pm.peers.count() >= config.MaxPeers {
-		log.Debugf("connected peer more than max peers.")
+		logger.Debugf("connected peer more than max peers.")
 		return
 	}
 
-	log.Debugf("peer manager try connect : %s", peer)
+	peer.logEntry().Debugf("peer manager try connect : %s", peer)
 
 	// prevent connect a peer many times
 	pm.dialings[peer.String()] = true
@@ -265,7 +295,7 @@ func (pm *peerManager) connect(peer *Peer) {
 				return
 			}
 
-			log.Debugf("Reconnect Peer %v", peer.Address)
+			peer.logEntry().Debugf("Reconnect Peer %v", peer.Address)
 			time.Sleep(time.Duration(int64(config.ConnectTimeInterval)))
 			if pm.peers.contains(peer.ID) || pm.handshakings.contains(peer.ID) {
 				return
@@ -278,7 +308,7 @@ func (pm *peerManager) connect(peer *Peer) {
 // keepAlive manages peers, send ping msg or reconnect
 // make sure the minimum peers
 func (pm *peerManager) manage() {
-	log.Debugf("Peer Info [number: %d]", pm.peers.count())
+	logger.Debugf("Peer Info [number: %d]", pm.peers.count())
 
 	// add to test
 	params.ConnNums = pm.peers.count()
@@ -289,7 +319,7 @@ func (pm *peerManager) manage() {
 	for _, peer := range pm.peers.getPeers() {
 		sec := now.Sub(peer.LastActiveTime)
 		if int(sec) > config.KeepAliveInterval*config.KeepAliveTimes {
-			log.Debugf("Peer Keep Alive Timeout %d > %d, lastActiveTime %v", int(sec), config.KeepAliveInterval*config.KeepAliveTimes, peer.LastActiveTime)
+			peer.logEntry().Debugf("Peer Keep Alive Timeout %d > %d, lastActiveTime %v", int(sec), config.KeepAliveInterval*config.KeepAliveTimes, peer.LastActiveTime)
 			pm.delPeer <- peer.Conn
 			pm.dialTask <- peer
 			continue
@@ -297,7 +327,7 @@ func (pm *peerManager) manage() {
 		if int(sec) > config.KeepAliveInterval {
 			msg := NewMsg(pingMsg, nil)
 			if n, err := msg.write(peer.Conn); n == 0 || err != nil {
-				log.Errorf("Send pingMsg error n: %d, err: %v", n, err)
+				peer.logEntry().Errorf("Send pingMsg error n: %d, err: %v", n, err)
 			}
 		}
 	}
@@ -313,7 +343,7 @@ func (pm *peerManager) getPeers() {
 	for _, bNode := range config.BootstrapNodes {
 		peer, err := ParsePeer(bNode)
 		if err != nil {
-			log.Errorln(err.Error())
+			logger.Errorln(err.Error())
 			continue
 		}
 		pm.dialTask <- peer
@@ -323,6 +353,25 @@ func (pm *peerManager) getPeers() {
 	pm.broadcastCh <- msg
 }
 
+// setRemoved marks the peer removed or not, removed peers are not connected
+func (pm *peerManager) setRemoved(id PeerID, removed bool) {
+	pm.removedMu.Lock()
+	defer pm.removedMu.Unlock()
+
+	if removed {
+		pm.removed[id.String()] = true
+	} else {
+		delete(pm.removed, id.String())
+	}
+}
+
+func (pm *peerManager) isRemoved(id PeerID) bool {
+	pm.removedMu.Lock()
+	defer pm.removedMu.Unlock()
+
+	return pm.removed[id.String()]
+}
+
 func (pm *peerManager) updateActiveTime(conn net.Conn) {
 	// peer, ok := pm.peers.get(conn)
 	// log.Debugf("keep alive %s peer %s ok %d", peer.LastActiveTime, peer, ok)
@@ -334,17 +383,16 @@ func (pm *peerManager) updateActiveTime(conn net.Conn) {
 
 // savePeers saves peers to database
 func (pm *peerManager) savePeers() {
-	// TODO: lcnd exit call this
-	log.Debugf("peer manager try to write %d records to database", pm.peers.count())
+	logger.Debugf("peer manager try to write %d records to database", pm.peers.count())
 	if pm.peers.count() == 0 {
-		log.Debugln("savePeerList: There is no peer in connections")
+		logger.Debugln("savePeerList: There is no peer in connections")
 		return
 	}
 
-	peerList := make([][]byte, pm.peers.count())
+	peerList := make([][]byte, 0, pm.peers.count())
 	for _, peer := range pm.peers.getPeers() {
 		if err := dbInstance.Put(columnFamily, peer.ID, []byte(peer.Address)); err != nil {
-			log.Errorf("savePeerList: save peer [%s] to database error %v", peer.ID, err.Error())
+			peer.logEntry().Errorf("savePeerList: save peer [%s] to database error %v", peer.ID, err.Error())
 			continue
 		}
 		peerList = append(peerList, peer.ID)
@@ -354,23 +402,23 @@ func (pm *peerManager) savePeers() {
 
 	err := dbInstance.Put(columnFamily, []byte("peerList"), peers)
 	if err != nil {
-		log.Errorf("savePeerList: save peers to database error %v", err.Error())
+		logger.Errorf("savePeerList: save peers to database error %v", err.Error())
 	}
 }
 
 func (pm *peerManager) broadcast(msg *Msg) {
 	if msg != nil {
 		for _, peer := range pm.peers.getPeers() {
-			log.Debugf("Peer Manager broadcast message %d to peer %s", msg.Cmd, peer.Address)
+			peer.logEntry().Debugf("Peer Manager broadcast message %d to peer %s", msg.Cmd, peer.Address)
 			// if msg.Cmd <= peersMsg || msg.Cmd == 23 || !peer.TestFilter(msg.CheckSum[:]) {
 			if n, err := msg.write(peer.Conn); err != nil {
-				log.Errorf("broadcast message write error %d - %v", n, err)
+				peer.logEntry().Errorf("broadcast message write error %d - %v", n, err)
 			}
 			// } else {
 			// 	log.Errorf("Peer Manager broadcast error %d %s", msg.Cmd, peer.Address)
 			// }
 		}
 	} else {
-		log.Errorf("broadcast message error, msg is nil %v", msg)
+		logger.Errorf("broadcast message error, msg is nil %v", msg)
 	}
 }
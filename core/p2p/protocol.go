@@ -22,7 +22,6 @@ import (
 	"io"
 
 	"github.com/bocheninc/L0/components/crypto"
-	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils"
 )
 
@@ -85,9 +84,9 @@ func GetEncHandshake() *EncHandshake {
 	if encHandshake == nil {
 		// TODO　Generate random string
 		h := crypto.Sha256([]byte("random string"))
-		sign, err := config.PrivateKey.Sign(h[:])
+		sign, err := signHash(h[:])
 		if err != nil {
-			log.Error(err.Error())
+			logger.Error(err.Error())
 		}
 		encHandshake = &EncHandshake{
 			Signature: sign,
@@ -133,9 +132,9 @@ func (enc *EncHandshake) matchProtocol(i interface{}) bool {
 			if err == nil {
 				return true
 			}
-			log.Errorf("enc handshake error %v", err.Error())
+			logger.Errorf("enc handshake error %v", err.Error())
 		}
-		log.Errorf("enc handshake error, decode nil content %v", enc)
+		logger.Errorf("enc handshake error, decode nil content %v", enc)
 	}
 	return false
 }
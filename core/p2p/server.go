@@ -20,6 +20,7 @@ package p2p
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"time"
@@ -27,12 +28,16 @@ import (
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/db"
 	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/core/accounts/signer"
 )
 
+var logger = log.Module(log.ModuleP2P)
+
 // Config is the p2p network configuration
 type Config struct {
 	Address             string
 	PrivateKey          *crypto.PrivateKey
+	Signer              *signer.KeySigner
 	BootstrapNodes      []string
 	MaxPeers            int
 	ReconnectTimes      int
@@ -84,19 +89,19 @@ func NewServer(db *db.BlockchainDB, cfg *Config) *Server {
 	}
 
 	if db == nil || cfg == nil {
-		log.Errorln("NewServer: database instance or config instance is nil.")
+		logger.Errorln("NewServer: database instance or config instance is nil.")
 		return nil
 	}
 
-	log.Debugf("P2P Network Server database instance %v", db)
-	log.Debugf("P2P Network Server config instance %v", cfg)
+	logger.Debugf("P2P Network Server database instance %v", db)
+	logger.Debugf("P2P Network Server config instance %v", cfg)
 
 	return srv
 }
 
 // Start starts a p2p network run as goroutine
 func (srv *Server) Start() {
-	log.Infoln("P2P Network Server Starting ...")
+	logger.Infoln("P2P Network Server Starting ...")
 	srv.init()
 
 	go srv.run()
@@ -104,24 +109,53 @@ func (srv *Server) Start() {
 	go srv.peerManager.run()
 }
 
+// Stop stops accepting connections, saves the connected peers and disconnects them
+func (srv *Server) Stop() {
+	logger.Infoln("P2P Network Server Stopping ...")
+	srv.tcpServer.close()
+	close(srv.peerManager.quit)
+	<-srv.peerManager.done
+}
+
 // Sign signs data with node key
 func (srv *Server) Sign(data []byte) (*crypto.Signature, error) {
 	h := crypto.Sha256(data)
+	return signHash(h[:])
+}
+
+// signHash signs hash with the node key of the server config
+func signHash(hash []byte) (*crypto.Signature, error) {
+	return config.SignHash(hash)
+}
 
-	if config.PrivateKey != nil {
-		return config.PrivateKey.Sign(h[:])
+// SignHash signs hash with the external signer if configured, otherwise with the local node key
+func (c *Config) SignHash(hash []byte) (*crypto.Signature, error) {
+	if c.Signer != nil {
+		return c.Signer.SignHash(hash)
+	}
+
+	if c.PrivateKey != nil {
+		return c.PrivateKey.Sign(hash)
 	}
 
 	return nil, fmt.Errorf("Node private key not config")
 }
 
+// nodePublicKey returns the public key of the node key
+func nodePublicKey() *crypto.PublicKey {
+	if config.Signer != nil {
+		return config.Signer.PublicKey()
+	}
+	return config.PrivateKey.Public()
+}
+
 // Broadcast broadcasts message to remote peers
 func (srv *Server) Broadcast(msg *Msg) {
 	srv.peerManager.broadcastCh <- msg
 }
 
 func (srv *Server) init() {
-	log.Infoln("Net Server initializing")
+	logger.Infoln("Net Server initializing")
 
 	if srv.peerManager == nil {
 		srv.peerManager = getPeerManager()
@@ -135,7 +169,7 @@ func (srv *Server) init() {
 func (srv *Server) onNewPeer(c *Connection) {
 	go func() {
 		if err := srv.doHandshake(c); err != nil {
-			log.Errorf("Handshake error %s", err)
+			logger.Errorf("Handshake error %s", err)
 			srv.onPeerClose(c)
 			return
 		}
@@ -157,6 +191,8 @@ func (srv *Server) run() {
 			c := newConnection(conn, srv.tcpServer)
 			// go c.listen()
 			srv.onNewPeer(c)
+		case <-srv.peerManager.quit:
+			return
 		}
 	}
 }
@@ -171,6 +207,31 @@ func (srv *Server) GetLocalPeer() *Peer {
 	return srv.peerManager.GetLocalPeer()
 }
 
+// AddPeer connects the peer given as url, a peer removed before is allowed again
+func (srv *Server) AddPeer(rawurl string) error {
+	peer, err := ParsePeer(rawurl)
+	if err != nil {
+		return err
+	}
+	srv.peerManager.setRemoved(peer.ID, false)
+	srv.peerManager.dialTask <- peer
+	return nil
+}
+
+// RemovePeer disconnects the peer with the hex id, it won't be connected
+// again until added by AddPeer
+func (srv *Server) RemovePeer(id string) error {
+	pid, err := hex.DecodeString(id)
+	if err != nil || len(pid) == 0 {
+		return fmt.Errorf("invalid peer id %s", id)
+	}
+	srv.peerManager.setRemoved(pid, true)
+	if peer, ok := srv.peerManager.peers.getByID(pid); ok {
+		srv.peerManager.delPeer <- peer.Conn
+	}
+	return nil
+}
+
 func (srv *Server) doHandshake(c *Connection) error {
 	if err := srv.doProtoHandshake(c); err != nil {
 		return err
@@ -218,19 +279,19 @@ func (srv Server) readProtoHandshake(c *Connection) error {
 	proto.deserialize(m.Payload)
 
 	if !proto.matchProtocol(GetProtoHandshake()) {
-		log.Debug("protocol error")
+		logger.Debug("protocol error")
 		srv.onPeerClose(c)
 		return fmt.Errorf("protocol handshake error")
 	}
 
 	if srv.peers.contains(proto.ID) {
-		log.Debugf("peer[%x] is already connected", proto.ID)
+		logger.WithField(log.FieldPeer, PeerID(proto.ID).String()).Debugf("peer[%x] is already connected", proto.ID)
 		srv.onPeerClose(c)
 		return fmt.Errorf("peer[%x] is already connected", proto.ID)
 	}
 	peer := NewPeer(proto.ID, c.conn, proto.SrvAddress, srv.Protocols)
 	if !bytes.Equal(proto.ID, peer.ID) {
-		log.Errorf("PeerID not match %v != %v", proto.ID, peer.ID)
+		peer.logEntry().Errorf("PeerID not match %v != %v", proto.ID, peer.ID)
 		return fmt.Errorf("PeerID not match %v != %v", proto.ID, peer.ID)
 	}
 	srv.handshakings.set(c.conn, peer)
@@ -247,7 +308,7 @@ func (srv Server) readEncHandshake(c *Connection) error {
 	enc := &EncHandshake{}
 	enc.deserialize(m.Payload)
 	if !enc.matchProtocol(GetEncHandshake()) {
-		log.Debugln("encryption verify error")
+		logger.Debugln("encryption verify error")
 		srv.onPeerClose(c)
 		return fmt.Errorf("Encryption Verify Error")
 	}
@@ -267,7 +328,7 @@ func readMsg(r io.Reader) (*Msg, error) {
 	msg := new(Msg)
 	n, err := msg.read(r)
 	if err != nil || n == 0 {
-		log.Errorf("connection error %s", err)
+		logger.Errorf("connection error %s", err)
 		return nil, err
 	}
 
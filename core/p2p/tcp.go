@@ -20,10 +20,9 @@ package p2p
 
 import (
 	"net"
+	"sync"
 
 	"strings"
-
-	"github.com/bocheninc/L0/components/log"
 )
 
 // TCPServer represents a tcp server
@@ -34,6 +33,10 @@ type TCPServer struct {
 	onNewClient   func(c *Connection)
 	onNewMessage  func(c *Connection, msg *Msg)
 	onClientClose func(c *Connection)
+
+	mu        sync.Mutex
+	listeners []*net.TCPListener
+	closed    bool
 }
 
 // Connection represents a tcp client
@@ -67,13 +70,16 @@ func (srv *TCPServer) listen() (err error) {
 
 	for _, bind = range addrs {
 		if addr, err = net.ResolveTCPAddr("tcp4", bind); err != nil {
-			log.Errorf("net.ResolveTCPAddr(\"tcp4\", \"%s\") error(%v)", bind, err)
+			logger.Errorf("net.ResolveTCPAddr(\"tcp4\", \"%s\") error(%v)", bind, err)
 			return
 		}
 		if listener, err = net.ListenTCP("tcp4", addr); err != nil {
-			log.Errorf("net.ListenTCP(\"tcp4\", \"%s\") error(%v)", bind, err)
+			logger.Errorf("net.ListenTCP(\"tcp4\", \"%s\") error(%v)", bind, err)
 			return
 		}
+		srv.mu.Lock()
+		srv.listeners = append(srv.listeners, listener)
+		srv.mu.Unlock()
 		// split N core accept
 		for i := 0; i < srv.core; i++ {
 			go srv.accept(listener)
@@ -123,18 +129,38 @@ func (srv *TCPServer) accept(lis *net.TCPListener) {
 	for {
 		if conn, err = lis.AcceptTCP(); err != nil {
 			// if listener close then return
-			log.Errorf("listener.Accept(\"%s\") error(%v)", lis.Addr().String(), err)
+			if srv.isClosed() {
+				return
+			}
+			logger.Errorf("listener.Accept(\"%s\") error(%v)", lis.Addr().String(), err)
 			return
 		}
 
 		// handle requests
-		log.Debugf("Accept connection %s, %v", conn.RemoteAddr(), conn)
+		logger.Debugf("Accept connection %s, %v", conn.RemoteAddr(), conn)
 		c := newConnection(conn, srv)
 		// go c.listen()
 		srv.onNewClient(c)
 	}
 }
 
+// close closes the listeners, the accepted connections are kept
+func (srv *TCPServer) close() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.closed = true
+	for _, listener := range srv.listeners {
+		listener.Close()
+	}
+	srv.listeners = nil
+}
+
+func (srv *TCPServer) isClosed() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.closed
+}
+
 // OnNewClient called when new client connect
 func (srv *TCPServer) OnNewClient(callback func(c *Connection)) {
 	srv.onNewClient = callback
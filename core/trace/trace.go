@@ -0,0 +1,157 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package trace records the stages a transaction passes through on this
+// node, from rpc broadcast to block and merge, in a bounded store.
+package trace
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/bocheninc/L0/components/crypto"
+)
+
+// Stages of a transaction
+const (
+	// Broadcast is recorded when the tx is received by rpc Transaction.Broadcast
+	Broadcast = "broadcast"
+	// TxPool is recorded by Validator.VerifyTxInTxPool
+	TxPool = "txpool"
+	// Relay is recorded when the tx is received from or relayed to peers
+	Relay = "relay"
+	// RequestBatch is recorded when the tx is included in a consensus request batch
+	RequestBatch = "requestBatch"
+	// Committed is recorded when the tx is output in CommittedTxs
+	Committed = "committed"
+	// AppendBlock is recorded when the block of the tx is appended to the ledger
+	AppendBlock = "appendBlock"
+	// MergeUpload is recorded when the tx is uploaded to the merge peer
+	MergeUpload = "mergeUpload"
+	// MergeAck is recorded when the merge ack of the tx is received
+	MergeAck = "mergeAck"
+)
+
+const (
+	defaultCapacity = 10000
+	maxRecords      = 32
+)
+
+// Record is the outcome of one stage of a transaction
+type Record struct {
+	Stage  string    `json:"stage"`
+	Time   time.Time `json:"time"`
+	OK     bool      `json:"ok"`
+	Detail string    `json:"detail,omitempty"`
+	SeqNo  uint64    `json:"seqNo,omitempty"`
+	Height uint32    `json:"height,omitempty"`
+}
+
+type txTrace struct {
+	hash    crypto.Hash
+	records []Record
+}
+
+// Store keeps the records of the latest capacity transactions
+type Store struct {
+	sync.Mutex
+	capacity int
+	order    *list.List
+	traces   map[crypto.Hash]*list.Element
+}
+
+// NewStore returns a store of capacity transactions
+func NewStore(capacity int) *Store {
+	return &Store{
+		capacity: capacity,
+		order:    list.New(),
+		traces:   make(map[crypto.Hash]*list.Element),
+	}
+}
+
+// Add appends the record to the trace of the transaction
+func (s *Store) Add(hash crypto.Hash, r Record) {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	ele, ok := s.traces[hash]
+	if !ok {
+		ele = s.order.PushBack(&txTrace{hash: hash})
+		s.traces[hash] = ele
+		for s.order.Len() > s.capacity {
+			front := s.order.Front()
+			s.order.Remove(front)
+			delete(s.traces, front.Value.(*txTrace).hash)
+		}
+	} else {
+		s.order.MoveToBack(ele)
+	}
+
+	t := ele.Value.(*txTrace)
+	if len(t.records) >= maxRecords {
+		t.records = append(t.records[:0], t.records[1:]...)
+	}
+	t.records = append(t.records, r)
+}
+
+// Get returns the records of the transaction in the order they were added
+func (s *Store) Get(hash crypto.Hash) []Record {
+	s.Lock()
+	defer s.Unlock()
+
+	ele, ok := s.traces[hash]
+	if !ok {
+		return nil
+	}
+	records := ele.Value.(*txTrace).records
+	return append(make([]Record, 0, len(records)), records...)
+}
+
+// Len returns the number of traced transactions
+func (s *Store) Len() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.order.Len()
+}
+
+var defaultStore = NewStore(defaultCapacity)
+
+// Add appends the record to the default store
+func Add(hash crypto.Hash, r Record) {
+	defaultStore.Add(hash, r)
+}
+
+// OK records a successful stage of the transaction
+func OK(hash crypto.Hash, stage, detail string) {
+	defaultStore.Add(hash, Record{Stage: stage, OK: true, Detail: detail})
+}
+
+// Fail records a failed stage of the transaction
+func Fail(hash crypto.Hash, stage, detail string) {
+	defaultStore.Add(hash, Record{Stage: stage, Detail: detail})
+}
+
+// Get returns the records of the transaction in the default store
+func Get(hash crypto.Hash) []Record {
+	return defaultStore.Get(hash)
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package trace
+
+import (
+	"testing"
+
+	"github.com/bocheninc/L0/components/crypto"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(2)
+	h1 := crypto.DoubleSha256([]byte("tx1"))
+	h2 := crypto.DoubleSha256([]byte("tx2"))
+	h3 := crypto.DoubleSha256([]byte("tx3"))
+
+	s.Add(h1, Record{Stage: Broadcast, OK: true})
+	s.Add(h2, Record{Stage: Broadcast, OK: true})
+	s.Add(h1, Record{Stage: RequestBatch, OK: true, SeqNo: 5})
+	s.Add(h3, Record{Stage: TxPool, Detail: "nonce too low"})
+
+	if s.Len() != 2 {
+		t.Fatalf("len %d, want 2", s.Len())
+	}
+	if records := s.Get(h2); records != nil {
+		t.Errorf("h2 should be evicted, got %v", records)
+	}
+	records := s.Get(h1)
+	if len(records) != 2 || records[0].Stage != Broadcast || records[1].SeqNo != 5 {
+		t.Errorf("unexpected records %v", records)
+	}
+	if records[0].Time.IsZero() {
+		t.Error("record time not set")
+	}
+	if records := s.Get(h3); len(records) != 1 || records[0].OK || records[0].Detail != "nonce too low" {
+		t.Errorf("unexpected records %v", records)
+	}
+}
+
+func TestMaxRecords(t *testing.T) {
+	s := NewStore(1)
+	h := crypto.DoubleSha256([]byte("tx"))
+	for i := 0; i < maxRecords+3; i++ {
+		s.Add(h, Record{Stage: Relay, SeqNo: uint64(i)})
+	}
+	records := s.Get(h)
+	if len(records) != maxRecords || records[0].SeqNo != 3 {
+		t.Errorf("records %d, first seqNo %d", len(records), records[0].SeqNo)
+	}
+}
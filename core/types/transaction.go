@@ -19,13 +19,17 @@
 package types
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 	"sync/atomic"
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/coordinate"
 )
 
@@ -71,8 +75,38 @@ const (
 	TypeDistribut                 // 下发交易
 	TypeIssue                     // 发行交易
 	TypeSmartContract             // contract
+	TypeGovernance                // replica set change
 )
 
+var txTypeNames = map[uint32]string{
+	TypeAtomic:        "atomic",
+	TypeAcrossChain:   "acrossChain",
+	TypeMerged:        "merged",
+	TypeBackfront:     "backfront",
+	TypeDistribut:     "distribut",
+	TypeIssue:         "issue",
+	TypeSmartContract: "smartContract",
+	TypeGovernance:    "governance",
+}
+
+// TxTypeName returns the name of the transaction type
+func TxTypeName(txType uint32) string {
+	if name, ok := txTypeNames[txType]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", txType)
+}
+
+// ParseTxType returns the transaction type by name
+func ParseTxType(name string) (uint32, error) {
+	for txType, n := range txTypeNames {
+		if strings.EqualFold(n, name) {
+			return txType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown transaction type %s", name)
+}
+
 // NewTransaction creates an new transaction with the parameters
 func NewTransaction(
 	fromChain coordinate.ChainCoordinate,
@@ -149,6 +183,8 @@ func (tx *Transaction) Verfiy() (accounts.Address, error) {
 	case TypeAcrossChain:
 		fallthrough
 	case TypeIssue:
+		fallthrough
+	case TypeGovernance:
 		if tx.Data.Signature != nil {
 			if sender := tx.sender.Load(); sender != nil {
 				return sender.(accounts.Address), nil
@@ -219,6 +255,18 @@ func (tx *Transaction) Compare(v interface{}) int {
 // GetType returns transaction type
 func (tx *Transaction) GetType() uint32 { return tx.Data.Type }
 
+// ReplicaChange returns the replica set change of a governance transaction
+func (tx *Transaction) ReplicaChange() (*consensus.ReplicaChange, error) {
+	if tx.GetType() != TypeGovernance {
+		return nil, nil
+	}
+	change := new(consensus.ReplicaChange)
+	if err := json.Unmarshal(tx.Payload, change); err != nil {
+		return nil, fmt.Errorf("invalid governance payload: %v", err)
+	}
+	return change, change.Validate()
+}
+
 // Transactions represents transaction slice type for basic sorting.
 type Transactions []*Transaction
 
@@ -27,6 +27,7 @@ import (
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/consensus"
 	"github.com/bocheninc/L0/core/coordinate"
 )
 
@@ -92,3 +93,32 @@ func TestTxSender(t *testing.T) {
 		t.Errorf("Deserialize error with Signature, %0x != %0x", tx.Serialize(), tx2.Serialize())
 	}
 }
+
+func TestParseTxType(t *testing.T) {
+	for _, txType := range []uint32{TypeAtomic, TypeAcrossChain, TypeMerged, TypeBackfront, TypeDistribut, TypeIssue, TypeSmartContract, TypeGovernance} {
+		parsed, err := ParseTxType(TxTypeName(txType))
+		if err != nil || parsed != txType {
+			t.Errorf("ParseTxType(%s) = %d, %v, want %d", TxTypeName(txType), parsed, err, txType)
+		}
+	}
+	if _, err := ParseTxType("foo"); err == nil {
+		t.Error("ParseTxType should fail with unknown type")
+	}
+}
+
+func TestReplicaChange(t *testing.T) {
+	tx := NewTransaction(coordinate.NewChainCoordinate([]byte{0}), coordinate.NewChainCoordinate([]byte{0}), TypeAtomic, 1, accounts.Address{}, accounts.Address{}, big.NewInt(0), big.NewInt(1), utils.CurrentTimestamp())
+	tx.WithPayload([]byte(`{"op":"remove","id":"r1"}`))
+	if change, err := tx.ReplicaChange(); change != nil || err != nil {
+		t.Fatalf("atomic tx changes replicas: %v %v", change, err)
+	}
+
+	tx.Data.Type = TypeGovernance
+	if change, err := tx.ReplicaChange(); err != nil || change.Op != consensus.ReplicaRemove || change.ID != "r1" {
+		t.Fatalf("replica change %v %v", change, err)
+	}
+	tx.WithPayload([]byte(`{"op":"add","id":"r1"}`))
+	if _, err := tx.ReplicaChange(); err == nil {
+		t.Fatal("replica added without public key")
+	}
+}
@@ -19,6 +19,7 @@
 package lcnd
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"runtime/pprof"
@@ -48,7 +49,8 @@ type Lcnd struct {
 	bc              *blockchain.Blockchain
 	protocolManager *node.ProtocolManager
 	consenter       consensus.Consenter
-	wg              sync.WaitGroup
+	db              *db.BlockchainDB
+	watcher         *config.Watcher
 }
 
 // NewLcnd returns l0 daemon instance
@@ -74,18 +76,50 @@ func NewLcnd(cfgFile string) *Lcnd {
 	lcnd.Config = cfg
 
 	lcnd.initLog()
+	if cfg.TxPoolSize > 0 {
+		blockchain.SetTxPoolSize(cfg.TxPoolSize)
+	}
 
 	netConfig = cfg.NetConfig
 
 	mergeConfig = cfg.MergeConfig
 
 	chainDb = db.NewDB(cfg.DbConfig)
+	if _, err := ledger.Migrate(chainDb, false); err != nil {
+		log.Errorf("db migrate error %v", err)
+		chainDb.Close()
+		return nil
+	}
 
 	newLedger = ledger.NewLedger(chainDb)
 	bc = blockchain.NewBlockchain(newLedger)
-	consenter := consenter.NewConsenter(config.ConsenterOptions(), bc)
+	consenterOptions := config.ConsenterOptions()
+	consenterOptions.Lbft.WAL = cfg.ConsensusWAL
+	// consensus messages are signed with the node key, the node id is the replica public key
+	consenterOptions.Lbft.Signer = netConfig
+	consenterOptions.Nbft.Signer = netConfig
+	// the replica set changed by governance txs replaces the configured one,
+	// the changes executed take effect K seqNos later
+	if err := newLedger.InitReplicas(consensus.NewReplicaSet(consenterOptions.Lbft.Replicas, uint64(consenterOptions.Lbft.K))); err != nil {
+		log.Errorf("init replicas error %v", err)
+		return nil
+	}
+	if set, err := newLedger.ReplicaSet(); err != nil {
+		log.Errorf("read replicas error %v", err)
+		return nil
+	} else if set != nil {
+		consenterOptions.Lbft.Replicas = set.At(newLedger.LastSeqNo())
+	}
+	consenter := consenter.NewConsenter(consenterOptions, bc)
 	ks = keystore.NewPlaintextKeyStore(chainDb, cfg.KeyStoreDir)
+	if accountSigner, err := config.AccountSigner(); err != nil {
+		log.Errorf("account signer error %v", err)
+		return nil
+	} else if accountSigner != nil {
+		ks.SetSigner(accountSigner)
+	}
 	lcnd.protocolManager = node.NewProtocolManager(chainDb, netConfig, bc, consenter, newLedger, ks, mergeConfig, cfg.LogDir)
+	lcnd.protocolManager.SetBackup(cfg.BackupDir, cfg.BackupRetention)
 
 	bc.SetBlockchainConsenter(consenter)
 	bc.SetNetworkStack(lcnd.protocolManager)
@@ -93,47 +127,86 @@ func NewLcnd(cfgFile string) *Lcnd {
 	lcnd.bc = bc
 
 	lcnd.consenter = consenter
-	lcnd.wg = sync.WaitGroup{}
+	lcnd.db = chainDb
 
 	return &lcnd
 }
 
-// Start starts the blockchain service
+// Start runs the blockchain service until SIGINT or SIGTERM, a second
+// signal exits without waiting for the shutdown
 func (l *Lcnd) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		log.Infof("received %v, shutting down", sig)
+		cancel()
+		sig = <-sigs
+		log.Errorf("received %v, exit without shutdown", sig)
+		os.Exit(1)
+	}()
+	if err := l.Run(ctx); err != nil {
+		log.Errorf("shutdown error %v", err)
+		os.Exit(1)
+	}
+}
+
+// Run starts the blockchain service and stops it once ctx is done
+func (l *Lcnd) Run(ctx context.Context) error {
+	var stopPProf func()
 	if l.Config.CPUFile != "" {
-		startPProf(l.Config.CPUFile, l.Config.CPUFile+".mem")
+		stopPProf = startPProf(l.Config.CPUFile, l.Config.CPUFile+".mem")
 	}
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
 	l.bc.Start()
 	l.protocolManager.Start()
+	l.watchConfig()
 
-	// TODO: every service start here, and make waitgroup usefull
-	l.wg.Add(1)
-	l.wg.Wait()
+	<-ctx.Done()
+	err := l.shutdown(l.Config.ShutdownTimeout)
+	if stopPProf != nil {
+		stopPProf()
+	}
+	return err
 }
 
-func startPProf(cpuFile, memFile string) {
+// startPProf starts cpu profiling, the returned func stops it and writes
+// the heap profile
+func startPProf(cpuFile, memFile string) func() {
 	cpuProfile, _ := os.Create(cpuFile)
 
 	pprof.StartCPUProfile(cpuProfile)
 
-	abort := make(chan os.Signal, 1)
-	signal.Notify(abort, os.Interrupt, os.Kill, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGKILL)
-	go func() {
-		<-abort
+	return func() {
 		pprof.StopCPUProfile()
 
 		memProfile, _ := os.Create(memFile)
 		pprof.WriteHeapProfile(memProfile)
 		memProfile.Close()
 		cpuProfile.Close()
-		os.Exit(0)
-	}()
-
+	}
 }
 
 func (l *Lcnd) initLog() {
-	log.New(l.Config.LogFile)
+	if l.Config.LogMaxSize > 0 || l.Config.LogRotateEvery > 0 {
+		f, err := log.NewRotateFile(l.Config.LogFile, int64(l.Config.LogMaxSize)<<20, l.Config.LogRotateEvery, l.Config.LogMaxBackups)
+		if err != nil {
+			log.Errorf("open log file %s error %v", l.Config.LogFile, err)
+		} else {
+			log.SetOutput(f)
+		}
+	} else {
+		log.New(l.Config.LogFile)
+	}
+	if err := log.SetFormat(l.Config.LogFormat); err != nil {
+		log.Errorf("log format error %v", err)
+	}
 	log.SetLevel(l.Config.LogLevel)
+	for module, level := range l.Config.LogModules {
+		if err := log.SetModuleLevel(module, level); err != nil {
+			log.Errorf("log level of module %s error %v", module, err)
+		}
+	}
 }
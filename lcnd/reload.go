@@ -0,0 +1,121 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lcnd
+
+import (
+	"reflect"
+
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/config"
+	"github.com/bocheninc/L0/core/blockchain"
+	"github.com/bocheninc/L0/core/p2p"
+	"github.com/bocheninc/L0/rpc"
+)
+
+// watchConfig applies the reloadable settings whenever the config file changes
+func (l *Lcnd) watchConfig() {
+	if l.Config.ConfigFile == "" {
+		return
+	}
+	watcher, err := config.Watch(l.Config.ConfigFile, l.reload)
+	if err != nil {
+		log.Errorf("watch config file %s error %v", l.Config.ConfigFile, err)
+		return
+	}
+	l.watcher = watcher
+}
+
+func (l *Lcnd) reload(r *config.Reloadable) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.Config.Reloadable
+
+	if r.LogLevel != old.LogLevel {
+		log.SetLevel(r.LogLevel)
+		log.Infof("config reload: log level %s", r.LogLevel)
+	}
+	for module, level := range r.LogModules {
+		if old.LogModules[module] != level {
+			if err := log.SetModuleLevel(module, level); err != nil {
+				log.Errorf("config reload: log level of module %s error %v", module, err)
+				continue
+			}
+			log.Infof("config reload: log level of module %s %s", module, level)
+		}
+	}
+	for module := range old.LogModules {
+		if _, ok := r.LogModules[module]; !ok {
+			log.ResetModuleLevel(module)
+			log.Infof("config reload: log level of module %s follows %s", module, r.LogLevel)
+		}
+	}
+
+	if !reflect.DeepEqual(r.RPCTokens, old.RPCTokens) || !reflect.DeepEqual(r.RPCMethods, old.RPCMethods) {
+		if err := rpc.ReloadAuth(r.RPCTokens, r.RPCMethods); err != nil {
+			log.Errorf("config reload: rpc acl error %v", err)
+		} else {
+			log.Infof("config reload: rpc acl with %d tokens and %d methods", len(r.RPCTokens), len(r.RPCMethods))
+		}
+	}
+
+	l.reloadPeers(old.BootstrapNodes, r.BootstrapNodes)
+
+	if r.TxPoolSize != old.TxPoolSize && r.TxPoolSize > 0 {
+		blockchain.SetTxPoolSize(r.TxPoolSize)
+		log.Infof("config reload: txpool max size %d", r.TxPoolSize)
+	}
+
+	if r.MergeDuration != old.MergeDuration {
+		l.protocolManager.SetMergeDuration(r.MergeDuration)
+		log.Infof("config reload: merge duration %v", r.MergeDuration)
+	}
+
+	l.Config.Reloadable = r
+}
+
+// reloadPeers connects the peers added to the bootstrap list and
+// disconnects the removed ones
+func (l *Lcnd) reloadPeers(old, peers []string) {
+	known := make(map[string]bool)
+	for _, url := range old {
+		known[url] = true
+	}
+	for _, url := range peers {
+		if known[url] {
+			delete(known, url)
+			continue
+		}
+		if err := l.protocolManager.AddPeer(url); err != nil {
+			log.Errorf("config reload: add peer %s error %v", url, err)
+		} else {
+			log.Infof("config reload: add peer %s", url)
+		}
+	}
+	for url := range known {
+		peer, err := p2p.ParsePeer(url)
+		if err != nil {
+			continue
+		}
+		if err := l.protocolManager.RemovePeer(peer.ID.String()); err != nil {
+			log.Errorf("config reload: remove peer %s error %v", url, err)
+		} else {
+			log.Infof("config reload: remove peer %s", url)
+		}
+	}
+}
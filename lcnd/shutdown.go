@@ -0,0 +1,110 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package lcnd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/rpc"
+)
+
+// stopStep stops one component during shutdown
+type stopStep struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// stopSteps returns the components in the order they are stopped: first
+// what brings work in, then what writes to the db, and the db last
+func (l *Lcnd) stopSteps() []stopStep {
+	return []stopStep{
+		{"config watcher", func(ctx context.Context) error {
+			if l.watcher == nil {
+				return nil
+			}
+			return l.watcher.Close()
+		}},
+		{"rpc", rpc.StopServer},
+		{"tx intake", func(ctx context.Context) error {
+			l.bc.StopTxIntake()
+			return nil
+		}},
+		{"consensus", func(ctx context.Context) error {
+			if err := waitStop(ctx, l.consenter.Stop); err != nil {
+				return err
+			}
+			return waitStop(ctx, l.bc.Stop)
+		}},
+		{"merge", func(ctx context.Context) error {
+			return waitStop(ctx, l.protocolManager.StopMerge)
+		}},
+		{"p2p", func(ctx context.Context) error {
+			return waitStop(ctx, l.protocolManager.Server.Stop)
+		}},
+	}
+}
+
+// shutdown stops the components in order, each within timeout. The db is
+// closed only if all of them stopped, otherwise it is left to recover
+// from its log on the next start rather than closed under a writer
+func (l *Lcnd) shutdown(timeout time.Duration) error {
+	var failed []string
+	for _, step := range l.stopSteps() {
+		if err := stopWithTimeout(step, timeout); err != nil {
+			log.Errorf("shutdown: stop %s error %v", step.name, err)
+			failed = append(failed, step.name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s not stopped, db left open", strings.Join(failed, ", "))
+	}
+	l.db.Close()
+	log.Infoln("shutdown: db closed")
+	return nil
+}
+
+func stopWithTimeout(step stopStep, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	if err := step.stop(ctx); err != nil {
+		return err
+	}
+	log.Infof("shutdown: %s stopped in %v", step.name, time.Since(start))
+	return nil
+}
+
+// waitStop runs stop, returning early with the error of ctx if it is done first
+func waitStop(ctx context.Context, stop func()) error {
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
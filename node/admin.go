@@ -0,0 +1,74 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+// ConsensusState returns the state of the consenter
+func (pm *ProtocolManager) ConsensusState() (*consensus.State, error) {
+	stater, ok := pm.consenter.(consensus.IState)
+	if !ok {
+		return nil, errors.New("consenter does not report state")
+	}
+	return stater.State(), nil
+}
+
+// Checkpoint creates an online backup of the db in dir, a relative path in
+// the backup root
+func (pm *ProtocolManager) Checkpoint(dir string) (*db.BackupInfo, error) {
+	if pm.backupRoot == "" {
+		return nil, errors.New("backup dir is not configured")
+	}
+	if filepath.Clean(dir) == "." || filepath.IsAbs(dir) {
+		return nil, errors.New("checkpoint dir must be a relative path in the backup dir")
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(dir), "/") {
+		if elem == ".." {
+			return nil, errors.New("checkpoint dir must not contain ..")
+		}
+	}
+	return pm.db.Checkpoint(filepath.Join(pm.backupRoot, dir))
+}
+
+// SetBackup sets where Backup creates backups and how many are kept
+func (pm *ProtocolManager) SetBackup(root string, retention db.Retention) {
+	pm.backupRoot = root
+	pm.backupRetention = retention
+}
+
+// Backup creates an online backup of the db in the backup root and removes
+// the backups beyond retention
+func (pm *ProtocolManager) Backup() (*db.Backup, []string, error) {
+	if pm.backupRoot == "" {
+		return nil, nil, errors.New("backup dir is not configured")
+	}
+	return pm.db.Backup(pm.backupRoot, pm.backupRetention)
+}
+
+// Backups lists the backups in the backup root
+func (pm *ProtocolManager) Backups() ([]*db.Backup, error) {
+	return db.ListBackups(pm.backupRoot)
+}
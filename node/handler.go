@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/db"
@@ -38,11 +39,15 @@ import (
 	"github.com/bocheninc/L0/core/merge"
 	"github.com/bocheninc/L0/core/p2p"
 	"github.com/bocheninc/L0/core/params"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 	"github.com/bocheninc/L0/msgnet"
 	jrpc "github.com/bocheninc/L0/rpc"
 )
 
+// p2pLogger logs the messages handled for peers
+var p2pLogger = log.Module(log.ModuleP2P)
+
 // ProtocolManager manages the protocol
 type ProtocolManager struct {
 	*blockchain.Blockchain
@@ -51,7 +56,7 @@ type ProtocolManager struct {
 
 	statusData StatusData
 
-	peers []*peer
+	peers peerMap
 	// syncer
 	msgnet msgnet.Stack
 	merger *merge.Helper
@@ -60,7 +65,12 @@ type ProtocolManager struct {
 	*keystore.KeyStore
 	*p2p.Server
 
+	db    *db.BlockchainDB
 	msgCh chan *p2p.Msg
+	quit  chan struct{}
+
+	backupRoot      string
+	backupRetention db.Retention
 }
 
 // NewProtocolManager returns a new sub protocol manager.
@@ -75,7 +85,9 @@ func NewProtocolManager(db *db.BlockchainDB, netConfig *p2p.Config,
 		Server:   p2p.NewServer(db, netConfig),
 		Ledger:   ledger,
 		KeyStore: ks,
+		db:       db,
 		msgCh:    make(chan *p2p.Msg, 100),
+		quit:     make(chan struct{}),
 	}
 
 	manager.Server.Protocols = append(manager.Server.Protocols, p2p.Protocol{
@@ -102,12 +114,28 @@ func (pm *ProtocolManager) Start() {
 
 	go pm.consensusReadLoop()
 	go pm.broadcastLoop()
+	go pm.heightLoop()
+	if notifier, ok := pm.consenter.(consensus.IReplicaNotifier); ok {
+		go pm.replicaLoop(notifier)
+	}
 
 	pm.init()
 }
 
+// StopMerge stops merging and announcing the height, and saves the merged
+// transactions not yet acked
+func (pm *ProtocolManager) StopMerge() {
+	close(pm.quit)
+	pm.merger.Stop()
+}
+
+// SetMergeDuration changes the interval of merging transactions
+func (pm *ProtocolManager) SetMergeDuration(d time.Duration) {
+	pm.merger.SetMergeDuration(d)
+}
+
 // Sign signs data with nodekey
-func (pm ProtocolManager) Sign(data []byte) (*crypto.Signature, error) {
+func (pm *ProtocolManager) Sign(data []byte) (*crypto.Signature, error) {
 	return pm.Server.Sign(data)
 }
 
@@ -130,6 +158,7 @@ func (pm *ProtocolManager) handle(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 
 	if msg.Cmd == statusMsg {
 		pm.OnStatus(msg, p)
+		defer pm.peers.remove(p)
 	} else {
 		return err
 	}
@@ -137,10 +166,15 @@ func (pm *ProtocolManager) handle(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 	return pm.handleMsg(p, rw)
 }
 
+// peerLog returns the log entry for the messages of peer p
+func peerLog(p *p2p.Peer) *log.Entry {
+	return p2pLogger.WithField(log.FieldPeer, p.ID.String())
+}
+
 func (pm *ProtocolManager) handleMsg(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 	for {
 		m, err := rw.ReadMsg()
-		log.Debugf("ProtocolManager handle message %s", msgMap[m.Cmd])
+		peerLog(p).Debugf("ProtocolManager handle message %s", msgMap[m.Cmd])
 		if err != nil {
 			return err
 		}
@@ -161,14 +195,24 @@ func (pm *ProtocolManager) handleMsg(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 			pm.OnConsensus(m, p)
 		case broadcastAckMergeTxsMsg:
 			pm.merger.HandleLocalMsg(m)
+		case heightMsg:
+			pm.OnHeight(m, p)
 		default:
-			log.Error("Unknown message")
+			peerLog(p).Error("Unknown message")
 		}
 	}
 }
 
 func (pm *ProtocolManager) handleShake(rw p2p.MsgReadWriter) {
-	rw.WriteMsg(*p2p.NewMsg(statusMsg, utils.Serialize(pm.statusData)))
+	rw.WriteMsg(*p2p.NewMsg(statusMsg, utils.Serialize(pm.currentStatus())))
+}
+
+// currentStatus returns the status with the current height
+func (pm *ProtocolManager) currentStatus() StatusData {
+	return StatusData{
+		Version:     pm.statusData.Version,
+		StartHeight: pm.Blockchain.CurrentHeight(),
+	}
 }
 
 // Relay relays inventory to remote peers
@@ -193,6 +237,9 @@ func (pm *ProtocolManager) Relay(inv types.IInventory) {
 			// inventory.Type = InvTypeTx
 			// inventory.Hashes = []crypto.Hash{inv.Hash()}
 			msg = p2p.NewMsg(txMsg, inv.Serialize())
+			trace.OK(tx.Hash(), trace.Relay, "relayed to peers")
+		} else {
+			trace.Fail(tx.Hash(), trace.Relay, "not relayed, rejected by txpool")
 		}
 	case *types.Block:
 		if pm.Blockchain.ProcessBlock(inv.(*types.Block)) {
@@ -215,6 +262,10 @@ func (pm *ProtocolManager) consensusReadLoop() {
 		case consensusData := <-pm.consenter.BroadcastConsensusChannel():
 			to := consensusData.To()
 			if bytes.Equal(coordinate.HexToChainCoordinate(to), params.ChainID) {
+				if p := pm.replicaPeer(consensusData); p != nil {
+					p2p.SendMessage(p.Conn, p2p.NewMsg(consensusMsg, consensusData.Payload()))
+					break
+				}
 				log.Debugf("Broadcast Consensus Message from %v to %v", params.ChainID, coordinate.HexToChainCoordinate(to))
 				pm.msgCh <- p2p.NewMsg(consensusMsg, consensusData.Payload())
 			} else {
@@ -232,6 +283,16 @@ func (pm *ProtocolManager) consensusReadLoop() {
 	}
 }
 
+// replicaPeer returns the peer of the replica a unicast is sent to, nil to
+// broadcast it
+func (pm *ProtocolManager) replicaPeer(consensusData consensus.IBroadcast) *p2p.Peer {
+	unicast, ok := consensusData.(consensus.IUnicast)
+	if !ok || unicast.Replica() == "" {
+		return nil
+	}
+	return pm.peers.byReplica(unicast.Replica())
+}
+
 func (pm *ProtocolManager) broadcastLoop() {
 	for {
 		select {
@@ -241,6 +302,58 @@ func (pm *ProtocolManager) broadcastLoop() {
 	}
 }
 
+// heightInterval is how often a changed local height is announced
+const heightInterval = 5 * time.Second
+
+// heightLoop announces the local height to the peers whenever it changed,
+// until StopMerge
+func (pm *ProtocolManager) heightLoop() {
+	var announced uint32
+	ticker := time.NewTicker(heightInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if status := pm.currentStatus(); status.StartHeight != announced {
+				announced = status.StartHeight
+				pm.Broadcast(p2p.NewMsg(heightMsg, utils.Serialize(status)))
+			}
+		case <-pm.quit:
+			return
+		}
+	}
+}
+
+// replicaLoop connects the replicas added to the replica set and drops the
+// removed ones once the change takes effect, until StopMerge
+func (pm *ProtocolManager) replicaLoop(notifier consensus.IReplicaNotifier) {
+	local := pm.GetLocalPeer().ID.String()
+	for {
+		select {
+		case change := <-notifier.ReplicaChangeChannel():
+			if change.PublicKey == local {
+				break
+			}
+			switch change.Op {
+			case consensus.ReplicaAdd:
+				if change.Address == "" {
+					p2pLogger.Warnf("replica %s added without an address, wait for it to connect", change.ID)
+					break
+				}
+				if err := pm.AddPeer(fmt.Sprintf("encode://%s@%s", change.PublicKey, change.Address)); err != nil {
+					p2pLogger.Errorf("connect replica %s at %s error %v", change.ID, change.Address, err)
+				}
+			case consensus.ReplicaRemove:
+				if err := pm.RemovePeer(change.PublicKey); err != nil {
+					p2pLogger.Errorf("disconnect replica %s error %v", change.ID, err)
+				}
+			}
+		case <-pm.quit:
+			return
+		}
+	}
+}
+
 // OnStatus handles statusMsg
 func (pm *ProtocolManager) OnStatus(m p2p.Msg, p *p2p.Peer) {
 	// swich status with remote peer
@@ -249,8 +362,8 @@ func (pm *ProtocolManager) OnStatus(m p2p.Msg, p *p2p.Peer) {
 	statusData := StatusData{}
 	utils.Deserialize(m.Payload, &statusData)
 	peer := newPeer(p, statusData)
-	pm.peers = append(pm.peers, peer)
-	log.Debugf("Status Msg %d %d", pm.statusData.StartHeight, peer.Status.StartHeight)
+	pm.peers.add(peer)
+	peerLog(p).Debugf("Status Msg %d %d", pm.statusData.StartHeight, peer.Status.StartHeight)
 	if pm.statusData.StartHeight < peer.Status.StartHeight {
 		// getBlocks := GetBlocks{
 		// 	Version:       pm.statusData.Version,
@@ -261,13 +374,21 @@ func (pm *ProtocolManager) OnStatus(m p2p.Msg, p *p2p.Peer) {
 	}
 }
 
+// OnHeight records the current height announced by the peer
+func (pm *ProtocolManager) OnHeight(m p2p.Msg, p *p2p.Peer) {
+	statusData := StatusData{}
+	utils.Deserialize(m.Payload, &statusData)
+	pm.peers.setHeight(p, statusData.StartHeight)
+}
+
 // OnTx processes tx message
 func (pm *ProtocolManager) OnTx(m p2p.Msg, p *p2p.Peer) {
 	//TODO: broadcast after validation
 	tx := new(types.Transaction)
 	tx.Deserialize(m.Payload)
 	// p.AddFilter(m.CheckSum[:])
-	log.Debugf("Tx Msg %s", tx.Hash())
+	peerLog(p).WithField(log.FieldTxHash, tx.Hash().String()).Debugf("Tx Msg %s", tx.Hash())
+	trace.OK(tx.Hash(), trace.Relay, "received from peer "+p.ID.String())
 	if pm.Blockchain.ProcessTransaction(tx) {
 		// pm.msgCh <- &m
 	}
@@ -292,7 +413,7 @@ func (pm *ProtocolManager) OnGetBlocks(m p2p.Msg, peer *p2p.Peer) {
 
 	for {
 		hash, err = pm.GetNextBlockHash(hash)
-		log.Debugf("GetNextBlockHash hash %s, error %v", hash, err)
+		peerLog(peer).Debugf("GetNextBlockHash hash %s, error %v", hash, err)
 		if err != nil || hash.Equal(crypto.Hash{}) {
 			break
 		} else {
@@ -313,7 +434,7 @@ func (pm *ProtocolManager) OnBlock(m p2p.Msg, p *p2p.Peer) {
 	//TODO: broadcast after validation
 	blk := new(types.Block)
 	blk.Deserialize(m.Payload)
-	log.Debugf("Block Msg %s", blk.Hash())
+	peerLog(p).Debugf("Block Msg %s", blk.Hash())
 	// p.AddFilter(m.CheckSum[:])
 	if pm.Blockchain.ProcessBlock(blk) {
 		pm.statusData.StartHeight++
@@ -374,14 +495,14 @@ func (pm *ProtocolManager) OnGetData(m p2p.Msg, peer *p2p.Peer) {
 	)
 
 	utils.Deserialize(m.Payload, &getdata)
-	log.Debugf("OnGetData message %v", getdata)
+	peerLog(peer).Debugf("OnGetData message %v", getdata)
 
 	for _, inventory := range getdata.InvList {
 		switch inventory.Type {
 		case InvTypeBlock:
 			for _, h := range inventory.Hashes {
 				if block, _ := pm.GetBlockByHash(h.Bytes()); block != nil {
-					log.Debugf("GetBlock from local, %s", block.Hash())
+					peerLog(peer).Debugf("GetBlock from local, %s", block.Hash())
 					msg := p2p.NewMsg(blockMsg, block.Serialize())
 					p2p.SendMessage(peer.Conn, msg)
 				}
@@ -389,7 +510,7 @@ func (pm *ProtocolManager) OnGetData(m p2p.Msg, peer *p2p.Peer) {
 		case InvTypeTx:
 			for _, h := range inventory.Hashes {
 				if tx, _ := pm.GetTransaction(h); tx != nil {
-					log.Debugf("GetTransaction from local, %s", tx.Hash())
+					peerLog(peer).WithField(log.FieldTxHash, tx.Hash().String()).Debugf("GetTransaction from local, %s", tx.Hash())
 					msg := p2p.NewMsg(txMsg, tx.Serialize())
 					p2p.SendMessage(peer.Conn, msg)
 				}
@@ -400,7 +521,12 @@ func (pm *ProtocolManager) OnGetData(m p2p.Msg, peer *p2p.Peer) {
 
 // OnConsensus processes consensus message
 func (pm *ProtocolManager) OnConsensus(m p2p.Msg, peer *p2p.Peer) {
-	log.Debugf("Req receive consensus message %v", m.Cmd)
+	peerLog(peer).Debugf("Req receive consensus message %v", m.Cmd)
+	if sender, ok := pm.consenter.(consensus.ISender); ok {
+		if replica := sender.Sender(m.Payload); replica != "" {
+			pm.peers.setReplica(peer, replica)
+		}
+	}
 	pm.consenter.RecvConsensus(m.Payload) //(p.ID.String(), []byte(""), m.Payload)
 }
 
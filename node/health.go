@@ -0,0 +1,37 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	msgnetpeer "github.com/bocheninc/msg-net/peer"
+)
+
+// Synced reports whether the local chain reached the current heights of the connected peers
+func (pm *ProtocolManager) Synced() bool {
+	return pm.Blockchain.CurrentHeight() >= pm.peers.maxHeight()
+}
+
+// MsgNetStatus reports whether msg-net is configured and connected to a router
+func (pm *ProtocolManager) MsgNetStatus() (enabled bool, connected bool) {
+	peer, ok := pm.msgnet.(*msgnetpeer.Peer)
+	if !ok || peer == nil {
+		return false, false
+	}
+	return true, peer.IsRunning()
+}
@@ -27,6 +27,8 @@ import (
 type peer struct {
 	*p2p.Peer
 	Status StatusData
+	// replica is the consensus replica of the peer, learnt from its messages
+	replica string
 }
 
 func newPeer(p *p2p.Peer, statusData StatusData) *peer {
@@ -36,7 +38,73 @@ func newPeer(p *p2p.Peer, statusData StatusData) *peer {
 	}
 }
 
+// peerMap holds the connected peers and the heights they announced
 type peerMap struct {
 	sync.RWMutex
-	
+	peers []*peer
+}
+
+func (m *peerMap) add(p *peer) {
+	m.Lock()
+	defer m.Unlock()
+	m.peers = append(m.peers, p)
+}
+
+// remove drops the peer once its connection is closed
+func (m *peerMap) remove(p *p2p.Peer) {
+	m.Lock()
+	defer m.Unlock()
+	for i, peer := range m.peers {
+		if peer.Peer == p {
+			m.peers = append(m.peers[:i], m.peers[i+1:]...)
+			return
+		}
+	}
+}
+
+// setHeight records the current height announced by the peer
+func (m *peerMap) setHeight(p *p2p.Peer, height uint32) {
+	m.Lock()
+	defer m.Unlock()
+	for _, peer := range m.peers {
+		if peer.Peer == p {
+			peer.Status.StartHeight = height
+		}
+	}
+}
+
+// setReplica records the consensus replica of the peer
+func (m *peerMap) setReplica(p *p2p.Peer, replica string) {
+	m.Lock()
+	defer m.Unlock()
+	for _, peer := range m.peers {
+		if peer.Peer == p {
+			peer.replica = replica
+		}
+	}
+}
+
+// byReplica returns the peer of the consensus replica, nil if not known
+func (m *peerMap) byReplica(replica string) *p2p.Peer {
+	m.RLock()
+	defer m.RUnlock()
+	for _, peer := range m.peers {
+		if peer.replica == replica {
+			return peer.Peer
+		}
+	}
+	return nil
+}
+
+// maxHeight returns the highest height announced by the connected peers
+func (m *peerMap) maxHeight() uint32 {
+	m.RLock()
+	defer m.RUnlock()
+	var height uint32
+	for _, peer := range m.peers {
+		if peer.Status.StartHeight > height {
+			height = peer.Status.StartHeight
+		}
+	}
+	return height
 }
@@ -0,0 +1,72 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bocheninc/L0/core/p2p"
+)
+
+func TestPeerMapHeights(t *testing.T) {
+	var m peerMap
+	p1, p2 := &p2p.Peer{}, &p2p.Peer{}
+	m.add(newPeer(p1, StatusData{StartHeight: 5}))
+	m.add(newPeer(p2, StatusData{StartHeight: 3}))
+
+	var wg sync.WaitGroup
+	for i := uint32(0); i < 10; i++ {
+		wg.Add(1)
+		go func(h uint32) {
+			defer wg.Done()
+			m.setHeight(p2, 3+h)
+			m.maxHeight()
+		}(i)
+	}
+	wg.Wait()
+	m.setHeight(p2, 20)
+	if h := m.maxHeight(); h != 20 {
+		t.Fatalf("max height %d, want the announced 20", h)
+	}
+
+	// a disconnected peer no longer counts
+	m.remove(p2)
+	if h := m.maxHeight(); h != 5 || len(m.peers) != 1 {
+		t.Fatalf("max height %d of %d peers", h, len(m.peers))
+	}
+}
+
+func TestPeerMapReplicas(t *testing.T) {
+	var m peerMap
+	p1, p2 := &p2p.Peer{}, &p2p.Peer{}
+	m.add(newPeer(p1, StatusData{}))
+	m.add(newPeer(p2, StatusData{}))
+	m.setReplica(p2, "00:r2")
+	if p := m.byReplica("00:r2"); p != p2 {
+		t.Fatalf("peer of replica %v, want %v", p, p2)
+	}
+	if p := m.byReplica("00:r1"); p != nil {
+		t.Fatalf("unknown replica has peer %v", p)
+	}
+	m.remove(p2)
+	if p := m.byReplica("00:r2"); p != nil {
+		t.Fatal("disconnected peer still routed")
+	}
+}
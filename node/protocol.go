@@ -28,6 +28,7 @@ const (
 	getdataMsg
 	consensusMsg
 	broadcastAckMergeTxsMsg
+	heightMsg
 )
 
 var (
@@ -41,5 +42,6 @@ var (
 		getdataMsg:              "getdata",
 		consensusMsg:            "consensus",
 		broadcastAckMergeTxsMsg: "broadcastAckMerge",
+		heightMsg:               "height",
 	}
 )
@@ -20,6 +20,7 @@ package rpc
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
@@ -32,6 +33,9 @@ type AccountInterface interface {
 	HasAddress(addr accounts.Address) bool
 	Find(addr accounts.Address) *accounts.Account
 	SignTx(a accounts.Account, tx *types.Transaction, pass string) (*types.Transaction, error)
+	SignTxUnlocked(a accounts.Account, tx *types.Transaction) (*types.Transaction, error)
+	TimedUnlock(a accounts.Account, passphrase string, timeout time.Duration) error
+	Lock(addr accounts.Address) error
 }
 
 // account
@@ -98,3 +102,33 @@ func (a *Account) Sign(args *SignTxArgs, reply *string) error {
 	*reply = utils.BytesToHex(signTx.Serialize())
 	return nil
 }
+
+type UnlockArgs struct {
+	Addr     string
+	Pass     string
+	Duration uint32 // seconds, 0 unlocks until the node exits
+}
+
+// Unlock keeps the decrypted key of the account in memory for the duration,
+// so that it can sign without passphrase
+func (a *Account) Unlock(args *UnlockArgs, reply *bool) error {
+	address := accounts.HexToAddress(args.Addr)
+	if !a.ai.HasAddress(address) {
+		return errors.New("address not exists")
+	}
+	account := a.ai.Find(address)
+	if err := a.ai.TimedUnlock(*account, args.Pass, time.Duration(args.Duration)*time.Second); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+// Lock removes the decrypted key of the account from memory
+func (a *Account) Lock(addr string, reply *bool) error {
+	if err := a.ai.Lock(accounts.HexToAddress(addr)); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
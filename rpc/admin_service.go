@@ -0,0 +1,170 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/bocheninc/L0/components/db"
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/core/consensus"
+)
+
+// AdminInterface node management interface
+type AdminInterface interface {
+	AddPeer(rawurl string) error
+	RemovePeer(id string) error
+	StartReceiveTx()
+	StopReceiveTx()
+	Checkpoint(dir string) (*db.BackupInfo, error)
+	Backup() (*db.Backup, []string, error)
+	Backups() ([]*db.Backup, error)
+	ConsensusState() (*consensus.State, error)
+}
+
+// Admin node management rpc api
+type Admin struct {
+	admin AdminInterface
+}
+
+// NewAdmin initialization
+func NewAdmin(admin AdminInterface) *Admin {
+	return &Admin{admin: admin}
+}
+
+// AddPeer connects the peer given as url encode://<id>@<host>:<port>
+func (a *Admin) AddPeer(rawurl string, reply *bool) error {
+	if err := a.admin.AddPeer(rawurl); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+// RemovePeer disconnects the peer with the hex id and stops reconnecting it
+func (a *Admin) RemovePeer(id string, reply *bool) error {
+	if err := a.admin.RemovePeer(id); err != nil {
+		return err
+	}
+	*reply = true
+	return nil
+}
+
+// SetLogLevel changes the log level, one of debug, info, warn, error
+func (a *Admin) SetLogLevel(level string, reply *string) error {
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return err
+	}
+	log.SetLevel(level)
+	*reply = log.GetLevel().String()
+	return nil
+}
+
+// ModuleLogLevelArgs represents the args of SetModuleLogLevel
+type ModuleLogLevelArgs struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+}
+
+// SetModuleLogLevel changes the log level of one module, such as p2p or consensus
+func (a *Admin) SetModuleLogLevel(args ModuleLogLevelArgs, reply *string) error {
+	if args.Module == "" {
+		return errors.New("module is required")
+	}
+	if err := log.SetModuleLevel(args.Module, args.Level); err != nil {
+		return err
+	}
+	*reply = log.ModuleLevels()[args.Module]
+	return nil
+}
+
+// LogLevels returns the log level of every module logger
+func (a *Admin) LogLevels(ignore string, reply *map[string]string) error {
+	levels := log.ModuleLevels()
+	levels["default"] = log.GetLevel().String()
+	*reply = levels
+	return nil
+}
+
+// StartReceiveTx resumes accepting txs into the txpool
+func (a *Admin) StartReceiveTx(ignore string, reply *bool) error {
+	a.admin.StartReceiveTx()
+	*reply = true
+	return nil
+}
+
+// StopReceiveTx pauses accepting txs into the txpool
+func (a *Admin) StopReceiveTx(ignore string, reply *bool) error {
+	a.admin.StopReceiveTx()
+	*reply = true
+	return nil
+}
+
+// Checkpoint creates an online backup of the db in dir, relative to the
+// backup dir of the node
+func (a *Admin) Checkpoint(dir string, reply *db.BackupInfo) error {
+	info, err := a.admin.Checkpoint(dir)
+	if err != nil {
+		return err
+	}
+	*reply = *info
+	return nil
+}
+
+// BackupReply represents the result of Backup
+type BackupReply struct {
+	Backup  *db.Backup `json:"backup"`
+	Removed []string   `json:"removed"`
+}
+
+// Backup creates an online backup in the backup dir of the node and removes
+// the backups beyond its retention policy
+func (a *Admin) Backup(ignore string, reply *BackupReply) error {
+	backup, removed, err := a.admin.Backup()
+	if backup == nil {
+		return err
+	}
+	reply.Backup = backup
+	reply.Removed = removed
+	if err != nil {
+		logger.Errorf("backup %s created, prune error %v", backup.Name, err)
+	}
+	return nil
+}
+
+// Backups lists the backups in the backup dir of the node, oldest first
+func (a *Admin) Backups(ignore string, reply *[]*db.Backup) error {
+	backups, err := a.admin.Backups()
+	if err != nil {
+		return err
+	}
+	*reply = backups
+	return nil
+}
+
+// ConsensusState returns the view, seqNo and instance count of the consenter
+func (a *Admin) ConsensusState(ignore string, reply *consensus.State) error {
+	state, err := a.admin.ConsensusState()
+	if err != nil {
+		return err
+	}
+	*reply = *state
+	return nil
+}
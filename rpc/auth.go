@@ -0,0 +1,249 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// scopes granted to tokens
+const (
+	// ScopeRead allows ledger and network queries
+	ScopeRead = "read"
+	// ScopeTx allows creating and submitting transactions
+	ScopeTx = "tx"
+	// ScopeAdmin allows account and node administration
+	ScopeAdmin = "admin"
+)
+
+// ErrCodeUnauthorized is returned for calls not permitted to the caller
+const ErrCodeUnauthorized = -32001
+
+// serviceScopes maps services to the scope required to call them, services
+// not listed require ScopeAdmin
+var serviceScopes = map[string]string{
+	"ledger":      ScopeRead,
+	"net":         ScopeRead,
+	"transaction": ScopeTx,
+	"account":     ScopeAdmin,
+	"txpool":      ScopeRead,
+	"admin":       ScopeAdmin,
+}
+
+// methodScopes overrides the scope of single methods, SendTransaction spends
+// from the unlocked node accounts
+var methodScopes = map[string]string{
+	"txpool.drop":                 ScopeAdmin,
+	"transaction.sendtransaction": ScopeAdmin,
+	"transaction.build":           ScopeRead,
+}
+
+// Token is a bearer token or API key with the scopes granted to it
+type Token struct {
+	Name   string
+	Token  string
+	Scopes []string
+	// Methods limits the token to the listed methods, Service.* matches a whole service
+	Methods []string
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Name    string
+	Remote  string
+	scopes  map[string]bool
+	methods []string
+}
+
+// HasScope reports whether the principal is granted scope
+func (p *Principal) HasScope(scope string) bool {
+	return p.scopes[scope]
+}
+
+type principalKey struct{}
+
+func withPrincipal(r *http.Request, p *Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+}
+
+func principalFrom(r *http.Request) *Principal {
+	p, _ := r.Context().Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Auth authenticates requests by bearer token, API key or basic auth and
+// authorizes method calls by scope and allow lists
+type Auth struct {
+	mu       sync.RWMutex
+	user     string
+	password string
+	tokens   []Token
+	methods  []string
+}
+
+// NewAuth returns the Auth configured by option
+func NewAuth(option *Option) *Auth {
+	return &Auth{
+		user:     option.User,
+		password: option.PassWord,
+		tokens:   option.Tokens,
+		methods:  option.Methods,
+	}
+}
+
+// Reload replaces the tokens and the method allow list
+func (a *Auth) Reload(tokens []Token, methods []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens = tokens
+	a.methods = methods
+}
+
+// required reports whether requests must carry credentials
+func (a *Auth) required() bool {
+	return len(a.tokens) > 0 || (a.user != "" && a.password != "")
+}
+
+func allScopes() map[string]bool {
+	return map[string]bool{ScopeRead: true, ScopeTx: true, ScopeAdmin: true}
+}
+
+// anonymousScopes are granted when no credentials are configured, admin
+// methods stay refused until a token or basic auth is set
+func anonymousScopes() map[string]bool {
+	return map[string]bool{ScopeRead: true, ScopeTx: true}
+}
+
+// authenticate returns the principal of r, nil if the credentials are invalid
+func (a *Auth) authenticate(r *http.Request) *Principal {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if !a.required() {
+		return &Principal{Name: "anonymous", Remote: r.RemoteAddr, scopes: anonymousScopes()}
+	}
+
+	secret := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		secret = strings.TrimSpace(auth[len("Bearer "):])
+	}
+	if secret != "" {
+		for _, token := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(token.Token)) == 1 {
+				scopes := make(map[string]bool)
+				for _, scope := range token.Scopes {
+					scopes[scope] = true
+				}
+				return &Principal{Name: token.Name, Remote: r.RemoteAddr, scopes: scopes, methods: token.Methods}
+			}
+		}
+		return nil
+	}
+
+	if user, password, ok := r.BasicAuth(); ok && a.user != "" && a.password != "" {
+		if subtle.ConstantTimeCompare([]byte(user), []byte(a.user)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1 {
+			return &Principal{Name: user, Remote: r.RemoteAddr, scopes: allScopes()}
+		}
+	}
+	return nil
+}
+
+// Handler wraps f with authentication, the principal is passed in the request context
+func (a *Auth) Handler(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p := a.authenticate(r)
+		if p == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lcnd"`)
+			http.Error(w, "Error authentication required.", http.StatusUnauthorized)
+			return
+		}
+		f(w, withPrincipal(r, p))
+	}
+}
+
+// Authorize checks that p may call method, given as Service.Method
+func (a *Auth) Authorize(p *Principal, method string) *Error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if len(a.methods) > 0 && !matchMethod(a.methods, method) {
+		return &Error{Code: ErrCodeUnauthorized, Message: "method not allowed: " + method}
+	}
+	if p == nil {
+		return &Error{Code: ErrCodeUnauthorized, Message: "unauthenticated"}
+	}
+	if len(p.methods) > 0 && !matchMethod(p.methods, method) {
+		return &Error{Code: ErrCodeUnauthorized, Message: "method not allowed: " + method}
+	}
+	scope := MethodScope(method)
+	if scope == ScopeAdmin && !a.required() {
+		return &Error{Code: ErrCodeUnauthorized, Message: "no rpc credentials configured, admin method refused: " + method}
+	}
+	if !p.HasScope(scope) {
+		return &Error{Code: ErrCodeUnauthorized, Message: "scope " + scope + " required for " + method}
+	}
+	return nil
+}
+
+// Audit logs privileged calls, that is calls requiring more than ScopeRead
+func (a *Auth) Audit(p *Principal, method string, err error) {
+	if MethodScope(method) == ScopeRead {
+		return
+	}
+	name, remote := "", ""
+	if p != nil {
+		name, remote = p.Name, p.Remote
+	}
+	if err != nil {
+		logger.Warnf("rpc audit: principal=%s remote=%s method=%s error=%q", name, remote, method, err.Error())
+		return
+	}
+	logger.Infof("rpc audit: principal=%s remote=%s method=%s ok", name, remote, method)
+}
+
+// MethodScope returns the scope required to call method
+func MethodScope(method string) string {
+	if scope, ok := methodScopes[methodKey(method)]; ok {
+		return scope
+	}
+	service := strings.SplitN(methodKey(method), ".", 2)[0]
+	if scope, ok := serviceScopes[service]; ok {
+		return scope
+	}
+	return ScopeAdmin
+}
+
+func matchMethod(patterns []string, method string) bool {
+	method = methodKey(method)
+	for _, pattern := range patterns {
+		pattern = methodKey(pattern)
+		if pattern == method || pattern == "*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, ".*") && strings.HasPrefix(method, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
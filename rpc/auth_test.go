@@ -0,0 +1,143 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	// without credentials configured anonymous callers may read and send txs only
+	open := NewAuth(&Option{})
+	p := open.authenticate(httptest.NewRequest(http.MethodPost, "/", nil))
+	if p == nil || !p.HasScope(ScopeRead) || !p.HasScope(ScopeTx) || p.HasScope(ScopeAdmin) {
+		t.Fatalf("principal %v without credentials configured", p)
+	}
+	if err := open.Authorize(p, "Admin.SetLogLevel"); err == nil {
+		t.Fatal("admin method authorized without credentials configured")
+	}
+	if err := open.Authorize(&Principal{Name: "admin", scopes: allScopes()}, "Account.New"); err == nil {
+		t.Fatal("admin method authorized without credentials configured")
+	}
+	if err := open.Authorize(p, "Transaction.Broadcast"); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := NewAuth(&Option{
+		User:     "user",
+		PassWord: "pass",
+		Tokens:   []Token{{Name: "reader", Token: "secret", Scopes: []string{ScopeRead}}},
+	})
+	for _, c := range []struct {
+		header, value string
+		name          string
+	}{
+		{"Authorization", "Bearer secret", "reader"},
+		{"X-API-Key", "secret", "reader"},
+		{"Authorization", "Bearer wrong", ""},
+		{"X-API-Key", "wrong", ""},
+		{"", "", ""},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if c.header != "" {
+			r.Header.Set(c.header, c.value)
+		}
+		p := auth.authenticate(r)
+		if (p == nil && c.name != "") || (p != nil && p.Name != c.name) {
+			t.Errorf("%s %s: principal %v, want %q", c.header, c.value, p, c.name)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.SetBasicAuth("user", "pass")
+	if p := auth.authenticate(r); p == nil || !p.HasScope(ScopeAdmin) {
+		t.Fatalf("basic auth principal %v", p)
+	}
+	r.SetBasicAuth("user", "wrong")
+	if p := auth.authenticate(r); p != nil {
+		t.Fatalf("basic auth with a wrong password: %v", p)
+	}
+
+	w := httptest.NewRecorder()
+	auth.Handler(func(http.ResponseWriter, *http.Request) { t.Fatal("unauthenticated request handled") })(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d", w.Code)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	auth := NewAuth(&Option{Tokens: []Token{{Name: "admin", Token: "secret", Scopes: []string{ScopeAdmin}}}})
+	reader := &Principal{Name: "reader", scopes: map[string]bool{ScopeRead: true}}
+	sender := &Principal{Name: "sender", scopes: map[string]bool{ScopeRead: true, ScopeTx: true}}
+	admin := &Principal{Name: "admin", scopes: allScopes()}
+	for _, c := range []struct {
+		p      *Principal
+		method string
+		ok     bool
+	}{
+		{reader, "Ledger.GetBlockByNumber", true},
+		{reader, "Transaction.Build", true},
+		{reader, "Transaction.Broadcast", false},
+		{sender, "Transaction.Broadcast", true},
+		{sender, "Transaction.SendTransaction", false},
+		{sender, "transaction_sendTransaction", false},
+		{sender, "TxPool.Drop", false},
+		{sender, "Account.New", false},
+		{sender, "Unknown.Method", false},
+		{admin, "Transaction.SendTransaction", true},
+		{admin, "Admin.SetLogLevel", true},
+		{nil, "Ledger.GetBlockByNumber", false},
+	} {
+		if err := auth.Authorize(c.p, c.method); (err == nil) != c.ok {
+			t.Errorf("%v calling %s: %v", c.p, c.method, err)
+		} else if err != nil && err.Code != ErrCodeUnauthorized {
+			t.Errorf("%s: code %d", c.method, err.Code)
+		}
+	}
+}
+
+func TestAuthorizeMethods(t *testing.T) {
+	auth := NewAuth(&Option{Methods: []string{"Ledger.*", "net_getPeers", "Transaction.Broadcast"}})
+	admin := &Principal{Name: "admin", scopes: allScopes()}
+	for method, ok := range map[string]bool{
+		"Ledger.GetBlockByNumber":     true,
+		"ledger_getBlockByNumber":     true,
+		"Net.GetPeers":                true,
+		"Net.GetLocalPeer":            false,
+		"Transaction.Broadcast":       true,
+		"Transaction.SendTransaction": false,
+		"LedgerX.Get":                 false,
+	} {
+		if err := auth.Authorize(admin, method); (err == nil) != ok {
+			t.Errorf("server allow list, %s: %v", method, err)
+		}
+	}
+
+	// the token allow list applies on top of the server one
+	auth.Reload(nil, nil)
+	limited := &Principal{Name: "limited", scopes: allScopes(), methods: []string{"Ledger.GetBlockByNumber"}}
+	if err := auth.Authorize(limited, "Ledger.GetBlockByNumber"); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Authorize(limited, "Ledger.GetBalance"); err == nil {
+		t.Fatal("method outside the token allow list authorized")
+	}
+}
@@ -23,12 +23,23 @@ type Option struct {
 	Port     string
 	User     string
 	PassWord string
+
+	// TLSCert and TLSKey enable https when both set
+	TLSCert string
+	TLSKey  string
+	// Tokens are the accepted bearer tokens and API keys
+	Tokens []Token
+	// Methods is the allow list of callable methods, all methods if empty
+	Methods []string
+	// MinPeers is the number of peers required by /readyz
+	MinPeers int
 }
 
 func NewDefaultOption() *Option {
 	option := &Option{
-		Enabled: true,
-		Port:    "8000",
+		Enabled:  true,
+		Port:     "8000",
+		MinPeers: 1,
 	}
 
 	return option
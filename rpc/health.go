@@ -0,0 +1,125 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bocheninc/L0/core/params"
+)
+
+// HealthInterface node health interface
+type HealthInterface interface {
+	Synced() bool
+	MsgNetStatus() (enabled bool, connected bool)
+}
+
+// Check is the result of a readiness check
+type Check struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Readiness is the response of /readyz
+type Readiness struct {
+	Ready  bool             `json:"ready"`
+	Checks map[string]Check `json:"checks"`
+}
+
+// NodeInfo is the response of /info
+type NodeInfo struct {
+	Chain     string `json:"chain"`
+	Version   string `json:"version"`
+	Consensus string `json:"consensus"`
+	Height    uint32 `json:"height"`
+	Peers     int    `json:"peers"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// serveHealth reports the process is alive and serving
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readiness checks that the node is synced, connected to enough peers,
+// running consensus and connected to msg-net if configured
+func readiness(node pmHandler, minPeers int) *Readiness {
+	ready := &Readiness{Ready: true, Checks: make(map[string]Check)}
+	check := func(name string, ok bool, detail string) {
+		ready.Checks[name] = Check{OK: ok, Detail: detail}
+		ready.Ready = ready.Ready && ok
+	}
+
+	check("synced", node.Synced(), "")
+
+	peers := len(node.GetPeers())
+	check("peers", peers >= minPeers, fmt.Sprintf("%d peers, %d required", peers, minPeers))
+
+	if state, err := node.ConsensusState(); err != nil {
+		check("consensus", false, err.Error())
+	} else if state.Stalled {
+		check("consensus", false, fmt.Sprintf("%s state transfer stalled at seqNo %d", state.Plugin, state.FetchFrom))
+	} else {
+		check("consensus", state.Running, state.Plugin)
+	}
+
+	switch enabled, connected := node.MsgNetStatus(); {
+	case !enabled:
+		check("msgnet", true, "disabled")
+	case !connected:
+		check("msgnet", false, "router unreachable")
+	default:
+		check("msgnet", true, "connected")
+	}
+	return ready
+}
+
+func serveReady(w http.ResponseWriter, r *http.Request, node pmHandler, minPeers int) {
+	ready := readiness(node, minPeers)
+	status := http.StatusOK
+	if !ready.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, ready)
+}
+
+func serveInfo(w http.ResponseWriter, r *http.Request, node pmHandler) {
+	info := &NodeInfo{
+		Chain:   params.ChainID.String(),
+		Version: params.Version,
+		Peers:   len(node.GetPeers()),
+	}
+	if state, err := node.ConsensusState(); err == nil {
+		info.Consensus = state.Plugin
+	}
+	height, err := node.Height()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	info.Height = height
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,377 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+	ErrCodeServer         = -32000
+)
+
+const jsonrpcVersion = "2.0"
+
+// Limits of a request posted to the server
+const (
+	maxRequestSize = 5 * 1024 * 1024
+	maxBatchSize   = 100
+)
+
+var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+
+// Error is a JSON-RPC 2.0 error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type serverRequest struct {
+	Version string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+	ID      *json.RawMessage `json:"id"`
+}
+
+// isNotification reports whether no response is expected, only JSON-RPC 2.0
+// requests without id are notifications
+func (req *serverRequest) isNotification() bool {
+	return req.Version == jsonrpcVersion && req.ID == nil
+}
+
+type serverResponse struct {
+	Version string           `json:"jsonrpc,omitempty"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+	Error   interface{}      `json:"error"`
+}
+
+// MarshalJSON omits result on failure and error on success, as JSON-RPC 2.0
+// requires, while JSON-RPC 1.0 responses carry both fields
+func (resp *serverResponse) MarshalJSON() ([]byte, error) {
+	type response serverResponse
+	if resp.Version != jsonrpcVersion {
+		return json.Marshal((*response)(resp))
+	}
+	m := map[string]interface{}{"jsonrpc": resp.Version, "id": resp.ID}
+	if resp.Error != nil {
+		m["error"] = resp.Error
+	} else {
+		m["result"] = resp.Result
+	}
+	return json.Marshal(m)
+}
+
+type methodType struct {
+	name      string
+	rcvr      reflect.Value
+	method    reflect.Method
+	argType   reflect.Type
+	replyType reflect.Type
+}
+
+// Server is a JSON-RPC server over HTTP which accepts JSON-RPC 2.0 single and
+// batch requests as well as the JSON-RPC 1.0 requests of net/rpc/jsonrpc clients.
+// Methods are addressed as Service.Method or Service_method.
+type Server struct {
+	methods map[string]*methodType
+	names   []string
+	auth    *Auth
+}
+
+// NewServer returns a new Server
+func NewServer() *Server {
+	return &Server{methods: make(map[string]*methodType)}
+}
+
+// SetAuth enables authorization and audit logging of calls
+func (s *Server) SetAuth(auth *Auth) {
+	s.auth = auth
+}
+
+// Register publishes the methods of rcvr which satisfy the net/rpc conventions
+// func (t *T) MethodName(args T1, reply *T2) error
+func (s *Server) Register(rcvr interface{}) error {
+	typ := reflect.TypeOf(rcvr)
+	name := reflect.Indirect(reflect.ValueOf(rcvr)).Type().Name()
+	if name == "" {
+		return errors.New("rpc: no service name for type " + typ.String())
+	}
+
+	registered := 0
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		mtype := method.Type
+		if method.PkgPath != "" || mtype.NumIn() != 3 || mtype.NumOut() != 1 {
+			continue
+		}
+		if !isExportedOrBuiltin(mtype.In(1)) {
+			continue
+		}
+		replyType := mtype.In(2)
+		if replyType.Kind() != reflect.Ptr || !isExportedOrBuiltin(replyType) {
+			continue
+		}
+		if mtype.Out(0) != typeOfError {
+			continue
+		}
+		fullName := name + "." + method.Name
+		s.methods[methodKey(fullName)] = &methodType{
+			name:      fullName,
+			rcvr:      reflect.ValueOf(rcvr),
+			method:    method,
+			argType:   mtype.In(1),
+			replyType: replyType.Elem(),
+		}
+		s.names = append(s.names, fullName)
+		registered++
+	}
+	if registered == 0 {
+		return fmt.Errorf("rpc: type %s has no suitable methods", name)
+	}
+	return nil
+}
+
+// Methods returns the registered method names in Service.Method form
+func (s *Server) Methods() []string {
+	return append([]string(nil), s.names...)
+}
+
+// methodKey normalizes Service.Method and Service_method to one lookup key
+func methodKey(name string) string {
+	return strings.ToLower(strings.Replace(name, "_", ".", 1))
+}
+
+func isExportedOrBuiltin(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	rune, _ := utf8.DecodeRuneInString(t.Name())
+	return unicode.IsUpper(rune) || t.PkgPath() == ""
+}
+
+// ServeHTTP handles a single or a batch request posted in the body
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		if len(body) >= maxRequestSize {
+			http.Error(w, fmt.Sprintf("request exceeds %d bytes", maxRequestSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := s.handle(body, principalFrom(r))
+	w.Header().Set("Content-type", "application/json")
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// Handle serves the raw request and returns the encoded response, nil if
+// the request consisted of notifications only
+func (s *Server) Handle(body []byte) []byte {
+	return s.handle(body, nil)
+}
+
+func (s *Server) handle(body []byte, p *Principal) []byte {
+	body = bytes.TrimSpace(body)
+	var (
+		resp interface{}
+		err  error
+	)
+	if len(body) > 0 && body[0] == '[' {
+		resp, err = s.handleBatch(body, p)
+	} else {
+		resp, err = s.handleSingle(body, p)
+	}
+	if err != nil {
+		resp = &serverResponse{Version: jsonrpcVersion, Error: err}
+	}
+	if resp == nil || reflect.ValueOf(resp).IsNil() {
+		return nil
+	}
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+func (s *Server) handleBatch(body []byte, p *Principal) ([]*serverResponse, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, &Error{Code: ErrCodeParse, Message: err.Error()}
+	}
+	if len(raws) == 0 {
+		return nil, &Error{Code: ErrCodeInvalidRequest, Message: "empty batch"}
+	}
+	if len(raws) > maxBatchSize {
+		return nil, &Error{Code: ErrCodeInvalidRequest, Message: fmt.Sprintf("batch of %d requests exceeds %d", len(raws), maxBatchSize)}
+	}
+
+	var resps []*serverResponse
+	for _, raw := range raws {
+		req := new(serverRequest)
+		if err := json.Unmarshal(raw, req); err != nil {
+			resps = append(resps, &serverResponse{Version: jsonrpcVersion,
+				Error: &Error{Code: ErrCodeInvalidRequest, Message: err.Error()}})
+			continue
+		}
+		if resp := s.call(req, p); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	return resps, nil
+}
+
+func (s *Server) handleSingle(body []byte, p *Principal) (*serverResponse, error) {
+	req := new(serverRequest)
+	if err := json.Unmarshal(body, req); err != nil {
+		return nil, &Error{Code: ErrCodeParse, Message: err.Error()}
+	}
+	return s.call(req, p), nil
+}
+
+// call invokes the method of req, the response is nil for notifications
+func (s *Server) call(req *serverRequest, p *Principal) *serverResponse {
+	result, err := s.invoke(req, p)
+	if req.isNotification() {
+		return nil
+	}
+
+	// JSON-RPC 1.0 requests carry no version, others are answered in 2.0
+	resp := &serverResponse{ID: req.ID, Result: result}
+	if req.Version != "" {
+		resp.Version = jsonrpcVersion
+		if err != nil {
+			resp.Error = err
+		}
+	} else if err != nil {
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+func (s *Server) invoke(req *serverRequest, p *Principal) (interface{}, *Error) {
+	if req.Method == "" || (req.Version != "" && req.Version != jsonrpcVersion) {
+		return nil, &Error{Code: ErrCodeInvalidRequest, Message: "invalid request"}
+	}
+	mtype, ok := s.methods[methodKey(req.Method)]
+	if !ok {
+		return nil, &Error{Code: ErrCodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if s.auth != nil {
+		if err := s.auth.Authorize(p, mtype.name); err != nil {
+			s.auth.Audit(p, mtype.name, err)
+			return nil, err
+		}
+	}
+
+	argv, err := decodeParams(req.Params, mtype.argType)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+	replyv := reflect.New(mtype.replyType)
+
+	var callErr error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				callErr = &Error{Code: ErrCodeInternal, Message: fmt.Sprintf("%v", r)}
+			}
+		}()
+		out := mtype.method.Func.Call([]reflect.Value{mtype.rcvr, argv, replyv})
+		if errInter := out[0].Interface(); errInter != nil {
+			callErr = errInter.(error)
+		}
+	}()
+	if s.auth != nil {
+		s.auth.Audit(p, mtype.name, callErr)
+	}
+	if callErr != nil {
+		if e, ok := callErr.(*Error); ok {
+			return nil, e
+		}
+		return nil, &Error{Code: ErrCodeServer, Message: callErr.Error()}
+	}
+	return replyv.Interface(), nil
+}
+
+// decodeParams decodes params, either a positional array holding the single
+// argument as net/rpc/jsonrpc clients send it, or the argument itself
+func decodeParams(params json.RawMessage, argType reflect.Type) (reflect.Value, error) {
+	isPtr := argType.Kind() == reflect.Ptr
+	var argv reflect.Value
+	if isPtr {
+		argv = reflect.New(argType.Elem())
+	} else {
+		argv = reflect.New(argType)
+	}
+
+	params = bytes.TrimSpace(params)
+	if len(params) > 0 && params[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return argv, err
+		}
+		switch len(positional) {
+		case 0:
+			params = nil
+		case 1:
+			params = positional[0]
+		default:
+			return argv, fmt.Errorf("expected at most 1 param, got %d", len(positional))
+		}
+	}
+	if len(params) > 0 && !bytes.Equal(params, []byte("null")) {
+		if err := json.Unmarshal(params, argv.Interface()); err != nil {
+			return argv, err
+		}
+	}
+
+	if !isPtr {
+		argv = argv.Elem()
+	}
+	return argv, nil
+}
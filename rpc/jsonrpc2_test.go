@@ -0,0 +1,182 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type Arith int
+
+type ArithArgs struct {
+	A, B int
+}
+
+func (t *Arith) Div(args ArithArgs, reply *int) error {
+	if args.B == 0 {
+		return errors.New("divide by zero")
+	}
+	*reply = args.A / args.B
+	return nil
+}
+
+func (t *Arith) Panic(args ArithArgs, reply *int) error {
+	panic("boom")
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer()
+	if err := s.Register(new(Arith)); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func decodeResponse(t *testing.T, data []byte) map[string]json.RawMessage {
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("response %s: %v", data, err)
+	}
+	return resp
+}
+
+func errorCode(t *testing.T, resp map[string]json.RawMessage) int {
+	e := &Error{}
+	if err := json.Unmarshal(resp["error"], e); err != nil {
+		t.Fatalf("error %s: %v", resp["error"], err)
+	}
+	return e.Code
+}
+
+func TestServerCall(t *testing.T) {
+	s := newTestServer(t)
+	resp := decodeResponse(t, s.Handle([]byte(`{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":6,"B":3},"id":1}`)))
+	if string(resp["result"]) != "2" || string(resp["id"]) != "1" || string(resp["jsonrpc"]) != `"2.0"` {
+		t.Fatalf("response %v", resp)
+	}
+	if _, ok := resp["error"]; ok {
+		t.Fatal("error in a successful response")
+	}
+
+	// Service_method and positional params
+	resp = decodeResponse(t, s.Handle([]byte(`{"jsonrpc":"2.0","method":"arith_div","params":[{"A":6,"B":2}],"id":"a"}`)))
+	if string(resp["result"]) != "3" || string(resp["id"]) != `"a"` {
+		t.Fatalf("response %v", resp)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := newTestServer(t)
+	for _, c := range []struct {
+		body string
+		code int
+	}{
+		{`{"jsonrpc":"2.0",`, ErrCodeParse},
+		{`{"jsonrpc":"1.5","method":"Arith.Div","id":1}`, ErrCodeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1}`, ErrCodeInvalidRequest},
+		{`{"jsonrpc":"2.0","method":"Arith.Mul","id":1}`, ErrCodeMethodNotFound},
+		{`{"jsonrpc":"2.0","method":"Arith.Div","params":[1,2],"id":1}`, ErrCodeInvalidParams},
+		{`{"jsonrpc":"2.0","method":"Arith.Panic","params":{},"id":1}`, ErrCodeInternal},
+		{`{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":1},"id":1}`, ErrCodeServer},
+	} {
+		resp := decodeResponse(t, s.Handle([]byte(c.body)))
+		if code := errorCode(t, resp); code != c.code {
+			t.Errorf("%s: code %d, want %d", c.body, code, c.code)
+		}
+		if _, ok := resp["result"]; ok {
+			t.Errorf("%s: result in an error response", c.body)
+		}
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	s := newTestServer(t)
+	data := s.Handle([]byte(`[
+		{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4,"B":2},"id":1},
+		{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4,"B":2}},
+		{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4},"id":2},
+		1
+	]`))
+	var resps []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resps); err != nil || len(resps) != 3 {
+		t.Fatalf("batch response %s, %v", data, err)
+	}
+	if string(resps[0]["result"]) != "2" || errorCode(t, resps[1]) != ErrCodeServer || errorCode(t, resps[2]) != ErrCodeInvalidRequest {
+		t.Fatalf("batch response %s", data)
+	}
+
+	if code := errorCode(t, decodeResponse(t, s.Handle([]byte(`[]`)))); code != ErrCodeInvalidRequest {
+		t.Fatalf("empty batch code %d", code)
+	}
+	big := "[" + strings.TrimSuffix(strings.Repeat(`{"jsonrpc":"2.0","method":"Arith.Div"},`, maxBatchSize+1), ",") + "]"
+	if code := errorCode(t, decodeResponse(t, s.Handle([]byte(big)))); code != ErrCodeInvalidRequest {
+		t.Fatalf("oversized batch code %d", code)
+	}
+}
+
+func TestServerNotification(t *testing.T) {
+	s := newTestServer(t)
+	if data := s.Handle([]byte(`{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4,"B":2}}`)); data != nil {
+		t.Fatalf("response %s to a notification", data)
+	}
+	if data := s.Handle([]byte(`[{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4,"B":0}}]`)); data != nil {
+		t.Fatalf("response %s to a batch of notifications", data)
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"Arith.Div","params":{"A":4,"B":2}}`)))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("notification status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestServerJSONRPC1(t *testing.T) {
+	s := newTestServer(t)
+	resp := decodeResponse(t, s.Handle([]byte(`{"method":"Arith.Div","params":[{"A":4,"B":2}],"id":1}`)))
+	if string(resp["result"]) != "2" || string(resp["error"]) != "null" || resp["jsonrpc"] != nil {
+		t.Fatalf("response %v", resp)
+	}
+
+	// errors are strings and requests without id are answered
+	resp = decodeResponse(t, s.Handle([]byte(`{"method":"Arith.Div","params":[{"A":4}]}`)))
+	if string(resp["error"]) != `"divide by zero"` || string(resp["id"]) != "null" {
+		t.Fatalf("response %v", resp)
+	}
+}
+
+func TestServeHTTPLimits(t *testing.T) {
+	s := newTestServer(t)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, maxRequestSize+1))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized request status %d", w.Code)
+	}
+}
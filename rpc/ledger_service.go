@@ -19,13 +19,14 @@
 package rpc
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/bocheninc/L0/components/crypto"
-	"github.com/bocheninc/L0/components/log"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
 	"github.com/bocheninc/L0/core/ledger/state"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 )
 
@@ -45,6 +46,7 @@ type LedgerInterface interface {
 	GetAcrossTxsStatistics() (int, int)
 	GetBlockAtmoicTxsStatistics() int
 	GetBlockAcrossTxsStatistics() (int, int, int, int)
+	TraceTx(txHash crypto.Hash) []trace.Record
 }
 
 //Ledger ledger rpc api
@@ -93,7 +95,7 @@ func (l *Ledger) GetBalance(addr string, reply *state.Balance) error {
 func (l *Ledger) GetBalanceInTxPool(addr string, reply *state.Balance) error {
 	amount, nonce := l.ledger.GetBalanceNonce(accounts.HexToAddress(addr))
 	nonce = nonce - 1
-	log.Debug("amount: ", amount, " nonce: ", nonce)
+	logger.Debug("amount: ", amount, " nonce: ", nonce)
 	*reply = state.Balance{Amount: amount, Nonce: nonce}
 	return nil
 }
@@ -169,6 +171,16 @@ func (l *Ledger) GetTxsByMergeTxHash(mergeTxHash string, reply *types.Transactio
 	return nil
 }
 
+//TraceTx returns the stages the transaction passed through on this node
+func (l *Ledger) TraceTx(txHashBytes string, reply *[]trace.Record) error {
+	records := l.ledger.TraceTx(crypto.HexToHash(txHashBytes))
+	if records == nil {
+		return errors.New("no trace of the transaction")
+	}
+	*reply = records
+	return nil
+}
+
 //DeserializeTx deserializes transaction by transaction serialize string
 func (l *Ledger) DeserializeTx(hexString string, reply *types.Transaction) error {
 	tx := new(types.Transaction)
@@ -20,31 +20,60 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
-	"io"
-	"log"
+	"errors"
 	"net"
 	"net/http"
-	"net/rpc"
-	"net/rpc/jsonrpc"
 	"strings"
+	"sync"
+
+	"github.com/bocheninc/L0/components/log"
+	"github.com/bocheninc/L0/components/metrics"
 )
 
+var logger = log.Module(log.ModuleRPC)
+
 type pmHandler interface {
 	INetWorkInfo
-	IBroadcast
 	LedgerInterface
-	AccountInterface
+	TransactionInterface
+	TxPoolInterface
+	AdminInterface
+	HealthInterface
 }
 
-type HttpConn struct {
-	in  io.Reader
-	out io.Writer
+// running is the server started by StartServer
+var running struct {
+	sync.Mutex
+	auth   *Auth
+	server *http.Server
 }
 
-func (c *HttpConn) Read(p []byte) (n int, err error)  { return c.in.Read(p) }
-func (c *HttpConn) Write(d []byte) (n int, err error) { return c.out.Write(d) }
-func (c *HttpConn) Close() error                      { return nil }
+// ReloadAuth replaces the tokens and the method allow list of the running server
+func ReloadAuth(tokens []Token, methods []string) error {
+	running.Lock()
+	defer running.Unlock()
+	if running.auth == nil {
+		return errors.New("rpc server is not running")
+	}
+	running.auth.Reload(tokens, methods)
+	return nil
+}
+
+// StopServer stops accepting requests and waits for the ones in progress
+// until ctx is done
+func StopServer(ctx context.Context) error {
+	running.Lock()
+	server := running.server
+	running.server = nil
+	running.auth = nil
+	running.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
 
 // StartServer with Test instance as a service
 func StartServer(option *Option, pmHandler pmHandler) {
@@ -52,32 +81,61 @@ func StartServer(option *Option, pmHandler pmHandler) {
 		return
 	}
 
-	server := rpc.NewServer()
+	server := NewServer()
 	server.Register(NewAccount(pmHandler))
 	server.Register(NewTransaction(pmHandler))
 	server.Register(NewNet(pmHandler))
 	server.Register(NewLedger(pmHandler))
+	server.Register(NewTxPool(pmHandler))
+	server.Register(NewAdmin(pmHandler))
 
+	auth := NewAuth(option)
+	server.SetAuth(auth)
 	listener, err := net.Listen("tcp", ":"+option.Port)
 
 	if err != nil {
-		log.Fatal("listen error:", err)
+		logger.Fatal("listen error:", err)
 	}
 
 	defer listener.Close()
-	http.Serve(listener, http.HandlerFunc(BasicAuth(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/" {
-			serverCodec := jsonrpc.NewServerCodec(&HttpConn{in: r.Body, out: w})
-			w.Header().Set("Content-type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			err := server.ServeRequest(serverCodec)
-			if err != nil {
-				log.Printf("Error while serving JSON request: %v", err)
-				http.Error(w, "Error while serving JSON request, details have been logged.", 500)
+	handler := auth.Handler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			if err := auth.Authorize(principalFrom(r), "Ledger.Subscribe"); err != nil {
+				http.Error(w, err.Message, http.StatusForbidden)
 				return
 			}
+			serveWebsocket(w, r, pmHandler)
+			return
+		}
+		if r.URL.Path == "/" {
+			server.ServeHTTP(w, r)
 		}
-	}, option.User, option.PassWord)))
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", serveHealth)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		serveReady(w, r, pmHandler, option.MinPeers)
+	})
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		serveInfo(w, r, pmHandler)
+	})
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/", handler)
+
+	httpServer := &http.Server{Handler: mux}
+	running.Lock()
+	running.auth = auth
+	running.server = httpServer
+	running.Unlock()
+
+	if option.TLSCert != "" && option.TLSKey != "" {
+		err = httpServer.ServeTLS(listener, option.TLSCert, option.TLSKey)
+	} else {
+		err = httpServer.Serve(listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		logger.Errorf("rpc server stopped: %v", err)
+	}
 }
 
 // ViewFunc defines view method
@@ -0,0 +1,312 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/components/websocket"
+	"github.com/bocheninc/L0/core/notify"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// subscription types
+const (
+	SubNewHeads       = "newHeads"
+	SubPendingTxs     = "pendingTxs"
+	SubTxConfirmation = "txConfirmation"
+	SubMerge          = "merge"
+)
+
+const defaultSubBufferSize = 256
+
+// SubscribeArgs are the params of a subscribe request
+type SubscribeArgs struct {
+	Type string `json:"type"`
+	// TxHash is the transaction to wait for, required by txConfirmation
+	TxHash string `json:"txHash"`
+	// FromHeight replays blocks from the height before streaming new ones,
+	// supported by newHeads and txConfirmation
+	FromHeight *uint32 `json:"fromHeight"`
+	// BufferSize is the number of events buffered before the subscription is dropped
+	BufferSize int `json:"bufferSize"`
+}
+
+type wsRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type wsResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type wsNotification struct {
+	Subscription string      `json:"subscription"`
+	Type         string      `json:"type"`
+	Result       interface{} `json:"result,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	// ResumeHeight is the height to resume from after the subscription was dropped
+	ResumeHeight *uint32 `json:"resumeHeight,omitempty"`
+}
+
+// HeaderResult is pushed to newHeads subscriptions
+type HeaderResult struct {
+	Hash          crypto.Hash `json:"hash"`
+	PreviousHash  crypto.Hash `json:"previousHash"`
+	TxsMerkleHash crypto.Hash `json:"transactionsMerkleHash"`
+	TimeStamp     uint32      `json:"timeStamp"`
+	Height        uint32      `json:"height"`
+	TxCount       int         `json:"txCount"`
+}
+
+// PendingTxResult is pushed to pendingTxs subscriptions
+type PendingTxResult struct {
+	Hash        crypto.Hash        `json:"hash"`
+	Transaction *types.Transaction `json:"transaction"`
+}
+
+// TxConfirmationResult is pushed to txConfirmation subscriptions
+type TxConfirmationResult struct {
+	TxHash      crypto.Hash `json:"txHash"`
+	BlockHash   crypto.Hash `json:"blockHash"`
+	BlockHeight uint32      `json:"blockHeight"`
+}
+
+func newHeaderResult(block *types.Block) *HeaderResult {
+	return &HeaderResult{
+		Hash:          block.Hash(),
+		PreviousHash:  block.Header.PreviousHash,
+		TxsMerkleHash: block.Header.TxsMerkleHash,
+		TimeStamp:     block.Header.TimeStamp,
+		Height:        block.Height(),
+		TxCount:       len(block.Transactions),
+	}
+}
+
+// wsConn serves the subscriptions of one websocket connection
+type wsConn struct {
+	conn   *websocket.Conn
+	ledger LedgerInterface
+
+	mu    sync.Mutex
+	subs  map[string]*notify.Subscription
+	subID uint64
+}
+
+func serveWebsocket(w http.ResponseWriter, r *http.Request, ledger LedgerInterface) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		logger.Debugf("websocket upgrade error %v", err)
+		return
+	}
+
+	wc := &wsConn{conn: conn, ledger: ledger, subs: make(map[string]*notify.Subscription)}
+	defer wc.close()
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		req := new(wsRequest)
+		if err := json.Unmarshal(data, req); err != nil {
+			wc.write(&wsResponse{Error: "invalid request: " + err.Error()})
+			continue
+		}
+		result, err := wc.handle(req)
+		resp := &wsResponse{ID: req.ID, Result: result}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		wc.write(resp)
+	}
+}
+
+func (wc *wsConn) handle(req *wsRequest) (interface{}, error) {
+	switch req.Method {
+	case "subscribe":
+		args := new(SubscribeArgs)
+		if err := json.Unmarshal(req.Params, args); err != nil {
+			return nil, err
+		}
+		return wc.subscribe(args)
+	case "unsubscribe":
+		var id string
+		if err := json.Unmarshal(req.Params, &id); err != nil {
+			return nil, err
+		}
+		return wc.unsubscribe(id), nil
+	}
+	return nil, fmt.Errorf("unknown method %s", req.Method)
+}
+
+func (wc *wsConn) subscribe(args *SubscribeArgs) (string, error) {
+	var kind string
+	switch args.Type {
+	case SubNewHeads:
+		kind = notify.NewBlock
+	case SubPendingTxs:
+		kind = notify.NewPendingTx
+	case SubTxConfirmation:
+		if args.TxHash == "" {
+			return "", errors.New("txHash is required")
+		}
+		kind = notify.NewBlock
+	case SubMerge:
+		kind = notify.MergeTx
+	default:
+		return "", fmt.Errorf("unknown subscription type %s", args.Type)
+	}
+
+	bufferSize := args.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultSubBufferSize
+	}
+
+	// subscribe before replaying, so no block between replay and live events is missed
+	sub := notify.Subscribe(bufferSize, kind)
+
+	wc.mu.Lock()
+	wc.subID++
+	id := fmt.Sprintf("0x%x", wc.subID)
+	wc.subs[id] = sub
+	wc.mu.Unlock()
+
+	go wc.run(id, args, sub)
+	return id, nil
+}
+
+func (wc *wsConn) unsubscribe(id string) bool {
+	wc.mu.Lock()
+	sub, ok := wc.subs[id]
+	delete(wc.subs, id)
+	wc.mu.Unlock()
+	if ok {
+		sub.Unsubscribe()
+	}
+	return ok
+}
+
+func (wc *wsConn) run(id string, args *SubscribeArgs, sub *notify.Subscription) {
+	var (
+		lastHeight uint32
+		txHash     = crypto.HexToHash(args.TxHash)
+	)
+	defer wc.unsubscribe(id)
+
+	// found reports whether a txConfirmation subscription is done
+	found := func(block *types.Block) bool {
+		for _, tx := range block.Transactions {
+			if tx.Hash() == txHash {
+				wc.notify(id, args.Type, &TxConfirmationResult{TxHash: txHash, BlockHash: block.Hash(), BlockHeight: block.Height()})
+				return true
+			}
+		}
+		return false
+	}
+
+	if args.FromHeight != nil && (args.Type == SubNewHeads || args.Type == SubTxConfirmation) {
+		height, err := wc.ledger.Height()
+		if err != nil {
+			wc.notifyError(id, args.Type, err.Error(), nil)
+			return
+		}
+		for h := *args.FromHeight; h <= height; h++ {
+			block, err := wc.ledger.GetBlockByNumber(h)
+			if err != nil || block == nil {
+				break
+			}
+			lastHeight = h
+			if args.Type == SubNewHeads {
+				wc.notify(id, args.Type, newHeaderResult(block))
+			} else if found(block) {
+				return
+			}
+		}
+	}
+
+	for ev := range sub.C {
+		switch args.Type {
+		case SubNewHeads, SubTxConfirmation:
+			block := ev.Data.(*types.Block)
+			if block.Height() <= lastHeight {
+				continue
+			}
+			lastHeight = block.Height()
+			if args.Type == SubNewHeads {
+				wc.notify(id, args.Type, newHeaderResult(block))
+			} else if found(block) {
+				return
+			}
+		case SubPendingTxs:
+			tx := ev.Data.(*types.Transaction)
+			wc.notify(id, args.Type, &PendingTxResult{Hash: tx.Hash(), Transaction: tx})
+		case SubMerge:
+			wc.notify(id, args.Type, ev.Data)
+		}
+	}
+
+	if sub.Dropped() {
+		var resume *uint32
+		if args.Type == SubNewHeads || args.Type == SubTxConfirmation {
+			resumeHeight := lastHeight + 1
+			resume = &resumeHeight
+		}
+		wc.notifyError(id, args.Type, "subscription dropped, client too slow", resume)
+	}
+}
+
+func (wc *wsConn) notify(id, subType string, result interface{}) {
+	wc.write(&wsNotification{Subscription: id, Type: subType, Result: result})
+}
+
+func (wc *wsConn) notifyError(id, subType, msg string, resumeHeight *uint32) {
+	wc.write(&wsNotification{Subscription: id, Type: subType, Error: msg, ResumeHeight: resumeHeight})
+}
+
+func (wc *wsConn) write(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Errorf("websocket marshal error %v", err)
+		return
+	}
+	if err := wc.conn.WriteMessage(data); err != nil {
+		logger.Debugf("websocket write error %v", err)
+	}
+}
+
+func (wc *wsConn) close() {
+	wc.mu.Lock()
+	subs := wc.subs
+	wc.subs = make(map[string]*notify.Subscription)
+	wc.mu.Unlock()
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	wc.conn.Close()
+}
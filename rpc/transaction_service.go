@@ -20,12 +20,15 @@ package rpc
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/bocheninc/L0/components/crypto"
 	"github.com/bocheninc/L0/components/utils"
 	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/blockchain"
 	"github.com/bocheninc/L0/core/coordinate"
+	"github.com/bocheninc/L0/core/trace"
 	"github.com/bocheninc/L0/core/types"
 )
 
@@ -33,8 +36,15 @@ type IBroadcast interface {
 	Relay(inv types.IInventory)
 }
 
+type TransactionInterface interface {
+	IBroadcast
+	AccountInterface
+	GetBalanceNonce(addr accounts.Address) (*big.Int, uint32)
+	SuggestFee() *big.Int
+}
+
 type Transaction struct {
-	pmHander IBroadcast
+	pmHander TransactionInterface
 }
 
 type TransactionCreateArgs struct {
@@ -46,7 +56,7 @@ type TransactionCreateArgs struct {
 	TxType    uint32
 }
 
-func NewTransaction(pmHandler IBroadcast) *Transaction {
+func NewTransaction(pmHandler TransactionInterface) *Transaction {
 	return &Transaction{pmHander: pmHandler}
 }
 
@@ -66,6 +76,83 @@ func (t *Transaction) Create(args *TransactionCreateArgs, reply *string) error {
 	return nil
 }
 
+type TransactionBuildArgs struct {
+	FromChain string
+	ToChain   string
+	Sender    string
+	Recipient string
+	Amount    int64
+	Fee       int64  // 0 uses the suggested fee
+	Nonce     uint32 // 0 uses the pending nonce of the sender
+	TxType    string // atomic, acrossChain, backfront, distribut, issue
+	Payload   string
+}
+
+type BuiltTransaction struct {
+	TxType       string             `json:"txType"`
+	Transaction  *types.Transaction `json:"transaction"`
+	Raw          string             `json:"raw"`
+	SignHash     crypto.Hash        `json:"signHash"`
+	SuggestedFee *big.Int           `json:"suggestedFee"`
+}
+
+// Build builds an unsigned transaction for the sender, the returned sign hash can be signed offline
+// and the signature attached before Broadcast
+func (t *Transaction) Build(args *TransactionBuildArgs, reply *BuiltTransaction) error {
+	txType, err := types.ParseTxType(args.TxType)
+	if err != nil {
+		return err
+	}
+	if txType == types.TypeMerged || txType == types.TypeSmartContract {
+		return fmt.Errorf("Invalid Params: can't build %s transaction", args.TxType)
+	}
+	if args.Sender == "" {
+		return errors.New("Invalid Params: sender is required")
+	}
+	if args.Amount <= 0 {
+		return errors.New("Invalid Amount in Tx, Amount must be >0")
+	}
+
+	sender := accounts.HexToAddress(args.Sender)
+	suggestedFee := t.pmHander.SuggestFee()
+	fee := big.NewInt(args.Fee)
+	if args.Fee <= 0 {
+		fee.Set(suggestedFee)
+	}
+	nonce := args.Nonce
+	if nonce == 0 {
+		_, nonce = t.pmHander.GetBalanceNonce(sender)
+	}
+
+	tx := types.NewTransaction(
+		coordinate.HexToChainCoordinate(args.FromChain),
+		coordinate.HexToChainCoordinate(args.ToChain),
+		txType,
+		nonce,
+		sender,
+		accounts.HexToAddress(args.Recipient),
+		big.NewInt(args.Amount),
+		fee,
+		utils.CurrentTimestamp(),
+	)
+	if args.Payload != "" {
+		tx.WithPayload(utils.HexToBytes(args.Payload))
+	}
+
+	if err := blockchain.CheckTransaction(tx); err != nil {
+		return fmt.Errorf("Invalid chain coordinate for %s transaction: %v", args.TxType, err)
+	}
+
+	*reply = BuiltTransaction{
+		TxType:       types.TxTypeName(txType),
+		Transaction:  tx,
+		Raw:          utils.BytesToHex(tx.Serialize()),
+		SignHash:     tx.SignHash(),
+		SuggestedFee: suggestedFee,
+	}
+	return nil
+}
+
 func (t *Transaction) Broadcast(txHex string, reply *crypto.Hash) error {
 	if len(txHex) < 1 {
 		return errors.New("Invalid Params: len(txSerializeData) must be >0 ")
@@ -74,20 +161,59 @@ func (t *Transaction) Broadcast(txHex string, reply *crypto.Hash) error {
 	tx := new(types.Transaction)
 	tx.Deserialize(utils.HexToBytes(txHex))
 
+	if err := t.relay(tx); err != nil {
+		return err
+	}
+	*reply = tx.Hash()
+	return nil
+}
+
+type SendTxArgs struct {
+	OriginTx string
+	Addr     string
+}
+
+// SendTransaction signs the transaction with an unlocked account and broadcasts it
+func (t *Transaction) SendTransaction(args *SendTxArgs, reply *crypto.Hash) error {
+	address := accounts.HexToAddress(args.Addr)
+	if !t.pmHander.HasAddress(address) {
+		return errors.New("address not exists")
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.Deserialize(utils.HexToBytes(args.OriginTx)); err != nil {
+		return err
+	}
+
+	if _, err := t.pmHander.SignTxUnlocked(*t.pmHander.Find(address), tx); err != nil {
+		return err
+	}
+
+	if err := t.relay(tx); err != nil {
+		return err
+	}
+	*reply = tx.Hash()
+	return nil
+}
+
+func (t *Transaction) relay(tx *types.Transaction) error {
 	if tx.Amount().Sign() <= 0 {
+		trace.Fail(tx.Hash(), trace.Broadcast, "invalid amount")
 		return errors.New("Invalid Amount in Tx, Amount must be >0")
 	}
 
 	if tx.Fee() == nil || tx.Fee().Sign() <= 0 {
+		trace.Fail(tx.Hash(), trace.Broadcast, "invalid fee")
 		return errors.New("Invalid Fee in Tx, Fee must be >0")
 	}
 
 	_, err := tx.Verfiy()
 	if err != nil {
+		trace.Fail(tx.Hash(), trace.Broadcast, "invalid signature")
 		return errors.New("Invalid Tx, varify the signature of Tx failed")
 	}
 
+	trace.OK(tx.Hash(), trace.Broadcast, "")
 	t.pmHander.Relay(tx)
-	*reply = tx.Hash()
 	return nil
 }
@@ -0,0 +1,72 @@
+// Copyright (C) 2017, Beijing Bochen Technology Co.,Ltd.  All rights reserved.
+//
+// This file is part of L0
+//
+// The L0 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The L0 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"github.com/bocheninc/L0/components/crypto"
+	"github.com/bocheninc/L0/core/accounts"
+	"github.com/bocheninc/L0/core/blockchain"
+	"github.com/bocheninc/L0/core/types"
+)
+
+// TxPoolInterface txpool interface
+type TxPoolInterface interface {
+	GetTxPoolStatus() *blockchain.TxPoolStatus
+	GetPendingTxsBySender(addr accounts.Address) types.Transactions
+	LookupTxInTxPool(txHash crypto.Hash) *blockchain.TxPoolTx
+	DropTxInTxPool(txHash crypto.Hash) ([]crypto.Hash, error)
+}
+
+// TxPool txpool rpc api
+type TxPool struct {
+	pool TxPoolInterface
+}
+
+// NewTxPool initialization
+func NewTxPool(pool TxPoolInterface) *TxPool {
+	return &TxPool{pool: pool}
+}
+
+// Status returns the number of pending txs per account and type
+func (p *TxPool) Status(ignore string, reply *blockchain.TxPoolStatus) error {
+	*reply = *p.pool.GetTxPoolStatus()
+	return nil
+}
+
+// Pending returns the pending txs sent by addr
+func (p *TxPool) Pending(addr string, reply *types.Transactions) error {
+	*reply = p.pool.GetPendingTxsBySender(accounts.HexToAddress(addr))
+	return nil
+}
+
+// GetTx returns the state of the tx in the txpool, with the reason if it was rejected
+func (p *TxPool) GetTx(txHash string, reply *blockchain.TxPoolTx) error {
+	*reply = *p.pool.LookupTxInTxPool(crypto.HexToHash(txHash))
+	return nil
+}
+
+// Drop removes the tx and the following txs of its sender from the txpool
+func (p *TxPool) Drop(txHash string, reply *[]crypto.Hash) error {
+	hashes, err := p.pool.DropTxInTxPool(crypto.HexToHash(txHash))
+	if err != nil {
+		return err
+	}
+	*reply = hashes
+	return nil
+}
@@ -27,6 +27,8 @@ import (
 	"github.com/yuin/gopher-lua"
 )
 
+var logger = log.Module(log.ModuleVM)
+
 func exporter(ctx *CTX) map[string]lua.LGFunction {
 	return map[string]lua.LGFunction{
 		"Account":            genAccountFunc(ctx),
@@ -49,7 +51,7 @@ func genAccountFunc(ctx *CTX) lua.LGFunction {
 
 		balances, err := ctx.getBalances(addr)
 		if err != nil {
-			log.Error("get balances error", err)
+			logger.Error("get balances error", err)
 			l.Push(lua.LNil)
 			return 1
 		}
@@ -67,7 +69,7 @@ func genTransferFunc(ctx *CTX) lua.LGFunction {
 	return func(l *lua.LState) int {
 		if l.GetTop() != 2 {
 			l.Push(lua.LBool(false))
-			log.Warnf("param illegality when invoke Transfer payload:\n%s", ctx.payload())
+			logger.Warnf("param illegality when invoke Transfer payload:\n%s", ctx.payload())
 			return 1
 		}
 
@@ -76,7 +78,7 @@ func genTransferFunc(ctx *CTX) lua.LGFunction {
 		txType := uint32(0)
 		err := ctx.transfer(recipientAddr, amout, txType)
 		if err != nil {
-			log.Errorf("contract do transfer error recipientAddr:%s, amout:%g, txType:%d  err:%s", recipientAddr, amout, txType, err)
+			logger.Errorf("contract do transfer error recipientAddr:%s, amout:%d, txType:%d  err:%s", recipientAddr, amout, txType, err)
 			l.Push(lua.LBool(false))
 			return 1
 		}
@@ -97,7 +99,7 @@ func genCurrentBlockHeight(ctx *CTX) lua.LGFunction {
 func genGetState(ctx *CTX) lua.LGFunction {
 	return func(l *lua.LState) int {
 		if l.GetTop() != 1 {
-			log.Warnf("param illegality when invoke GetState payload:\n%s", ctx.payload())
+			logger.Warnf("param illegality when invoke GetState payload:\n%s", ctx.payload())
 			l.Push(lua.LNil)
 			return 1
 		}
@@ -105,7 +107,7 @@ func genGetState(ctx *CTX) lua.LGFunction {
 		key := l.CheckString(1)
 		data, err := ctx.getState(key)
 		if err != nil {
-			log.Error("getState error ", err)
+			logger.Error("getState error ", err)
 			l.Push(lua.LNil)
 			return 1
 		}
@@ -116,7 +118,7 @@ func genGetState(ctx *CTX) lua.LGFunction {
 
 		buf := bytes.NewBuffer(data)
 		if lv, err := byteToLValue(buf); err != nil {
-			log.Error("byteToLValue error")
+			logger.Error("byteToLValue error")
 			l.Push(lua.LNil)
 		} else {
 			l.Push(lv)
@@ -130,7 +132,7 @@ func genPutState(ctx *CTX) lua.LGFunction {
 	return func(l *lua.LState) int {
 		if l.GetTop() != 2 {
 			l.Push(lua.LNil)
-			log.Warnf("param illegality when invoke PutState payload:\n%s", ctx.payload())
+			logger.Warnf("param illegality when invoke PutState payload:\n%s", ctx.payload())
 			return 1
 		}
 
@@ -140,7 +142,7 @@ func genPutState(ctx *CTX) lua.LGFunction {
 		data := lvalueToByte(value)
 		err := ctx.putState(key, data)
 		if err != nil {
-			log.Error("putState error", err)
+			logger.Error("putState error", err)
 			l.Push(lua.LBool(false))
 		} else {
 			l.Push(lua.LBool(true))
@@ -154,14 +156,14 @@ func genDelState(ctx *CTX) lua.LGFunction {
 	return func(l *lua.LState) int {
 		if l.GetTop() != 1 {
 			l.Push(lua.LNil)
-			log.Warnf("param illegality when invoke DelState payload:\n%s", ctx.payload())
+			logger.Warnf("param illegality when invoke DelState payload:\n%s", ctx.payload())
 			return 1
 		}
 
 		key := l.CheckString(1)
 		err := ctx.delState(key)
 		if err != nil {
-			log.Error("delState error", err)
+			logger.Error("delState error", err)
 			l.Push(lua.LBool(false))
 		} else {
 			l.Push(lua.LBool(true))
@@ -23,6 +23,7 @@ import (
 	"errors"
 
 	"bytes"
+	"time"
 
 	"github.com/bocheninc/L0/core/accounts"
 	"github.com/yuin/gopher-lua"
@@ -61,6 +62,7 @@ func execContract(ctx *CTX) (bool, error) {
 		return false, errors.New("contract script code size illegal, max size is:" + string(conf.ExecLimitMaxScriptSize) + " byte")
 	}
 
+	start := time.Now()
 	L := newState()
 	defer L.Close()
 
@@ -71,8 +73,10 @@ func execContract(ctx *CTX) (bool, error) {
 	}
 
 	if bytes.Equal(ctx.Transaction.Recipient().Bytes(), zeroAddr.Bytes()) {
+		defer luaExecSeconds.With("L0Init").ObserveSince(start)
 		return callLuaFunc(L, "L0Init")
 	} else {
+		defer luaExecSeconds.With("L0Invoke").ObserveSince(start)
 		params := ctx.ContractSpec.ContractParams
 		return callLuaFunc(L, "L0Invoke", params...)
 	}